@@ -1,6 +1,10 @@
 package config
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -42,6 +46,95 @@ func TestValidateServerConfig(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "trusted proxies",
+			config: ServerConfig{
+				Port:           8080,
+				TrustedProxies: []string{"10.0.0.1", "172.16.0.0/12", "::1"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid trusted proxy",
+			config: ServerConfig{
+				Port:           8080,
+				TrustedProxies: []string{"proxy.internal"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "tls cert without key",
+			config: ServerConfig{
+				Port: 8080,
+				TLS:  TLSConfig{CertFile: "server.crt"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "mutual tls with redirect",
+			config: ServerConfig{
+				Port: 8443,
+				TLS: TLSConfig{CertFile: "server.crt", KeyFile: "server.key", ClientCAFile: "ca.crt",
+					MinVersion: "1.3", RedirectHTTPPort: 8080},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unsupported tls min version",
+			config: ServerConfig{
+				Port: 8443,
+				TLS:  TLSConfig{CertFile: "server.crt", KeyFile: "server.key", MinVersion: "1.0"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "client ca without server certificate",
+			config: ServerConfig{
+				Port: 8080,
+				TLS:  TLSConfig{ClientCAFile: "ca.crt"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "redirect port equal to server port",
+			config: ServerConfig{
+				Port: 8443,
+				TLS:  TLSConfig{CertFile: "server.crt", KeyFile: "server.key", RedirectHTTPPort: 8443},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative multipart memory",
+			config: ServerConfig{
+				Port:            8080,
+				MultipartMemory: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative ping interval",
+			config: ServerConfig{
+				Port:      8080,
+				WebSocket: WebSocketConfig{PingInterval: -1},
+			},
+			wantErr: true,
+		},
+		{
+			name: "wildcard allowed origin",
+			config: ServerConfig{
+				Port:      8080,
+				WebSocket: WebSocketConfig{AllowedOrigins: []string{"https://*.example.com", "http://localhost:*"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "malformed allowed origin pattern",
+			config: ServerConfig{
+				Port:      8080,
+				WebSocket: WebSocketConfig{AllowedOrigins: []string{"https://[example.com"}},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -102,6 +195,71 @@ func TestValidateVADConfig(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid padding",
+			config: VADConfig{
+				Provider:      "silero_vad",
+				Threshold:     0.5,
+				PrePaddingMs:  200,
+				PostPaddingMs: 200,
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid padding - negative",
+			config: VADConfig{
+				Provider:      "ten_vad",
+				Threshold:     0.5,
+				PostPaddingMs: -200,
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid health checks",
+			config: VADConfig{
+				Provider:            "ten_vad",
+				Threshold:           0.5,
+				HealthCheckInterval: 60,
+				MaxInstanceUses:     1000,
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid max instance uses - negative",
+			config: VADConfig{
+				Provider:        "silero_vad",
+				Threshold:       0.5,
+				MaxInstanceUses: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid max dedicated instances - negative",
+			config: VADConfig{
+				Provider:              "ten_vad",
+				Threshold:             0.5,
+				MaxDedicatedInstances: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid prefilter",
+			config: VADConfig{
+				Provider:  "silero_vad",
+				Threshold: 0.5,
+				Prefilter: PrefilterConf{Enabled: true, ThresholdDB: -50, HangoverMs: 500},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid prefilter - positive level",
+			config: VADConfig{
+				Provider:  "ten_vad",
+				Threshold: 0.5,
+				Prefilter: PrefilterConf{Enabled: true, ThresholdDB: 6},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -156,6 +314,92 @@ func TestValidateLoggingConfig(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "slow operation thresholds",
+			config: LoggingConfig{
+				Level:         "info",
+				Format:        "json",
+				Output:        "console",
+				SlowRequestMs: 5000,
+				SlowVADMs:     100,
+				SlowDecodeMs:  2000,
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative slow decode threshold",
+			config: LoggingConfig{
+				Level:        "info",
+				Format:       "json",
+				Output:       "console",
+				SlowDecodeMs: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "otlp output",
+			config: LoggingConfig{
+				Level:  "info",
+				Format: "json",
+				Output: "otlp",
+				OTLP: OTLPLogConfig{
+					Endpoint:           "http://otel-collector:4318",
+					Headers:            []string{"Authorization=Bearer abc"},
+					ResourceAttributes: []string{"service.name=asr", "deployment.environment=prod"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid otlp endpoint",
+			config: LoggingConfig{
+				Level:  "info",
+				Format: "json",
+				Output: "otlp",
+				OTLP:   OTLPLogConfig{Endpoint: "otel-collector:4318"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid otlp resource attribute",
+			config: LoggingConfig{
+				Level:  "info",
+				Format: "json",
+				Output: "otlp",
+				OTLP:   OTLPLogConfig{ResourceAttributes: []string{"service.name"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "redaction adjustments",
+			config: LoggingConfig{
+				Level:     "info",
+				Format:    "json",
+				Output:    "console",
+				Redaction: RedactionConfig{Keywords: []string{"phone"}, RemoveKeywords: []string{"Cert"}, AllowKeys: []string{"hotkey"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty redaction keyword",
+			config: LoggingConfig{
+				Level:     "info",
+				Format:    "json",
+				Output:    "console",
+				Redaction: RedactionConfig{Keywords: []string{" "}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "removed keyword not built in",
+			config: LoggingConfig{
+				Level:     "info",
+				Format:    "json",
+				Output:    "console",
+				Redaction: RedactionConfig{RemoveKeywords: []string{"phone"}},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -310,6 +554,163 @@ func TestMustLoadPanicsOnError(t *testing.T) {
 	_ = MustLoad("/non/existent/path/config.json")
 }
 
+func TestLoadFormats(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.json": `{"server": {"port": 9001}, "logging": {"level": "debug"}}`,
+		"config.yaml": "server:\n  port: 9001\nlogging:\n  level: debug\n",
+		"config.yml":  "server:\n  port: 9001\nlogging:\n  level: debug\n",
+		"config.toml": "[server]\nport = 9001\n\n[logging]\nlevel = \"debug\"\n",
+		// No recognised extension, the format comes from CONFIG_FORMAT
+		"asr.conf": "server:\n  port: 9001\nlogging:\n  level: debug\n",
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		format  string
+		wantErr bool
+	}{
+		{"json", "config.json", "", false},
+		{"yaml", "config.yaml", "", false},
+		{"yml", "config.yml", "", false},
+		{"toml", "config.toml", "", false},
+		{"format from environment", "asr.conf", "yaml", false},
+		{"format overrides extension", "config.yml", "YAML", false},
+		{"unknown extension", "asr.conf", "", true},
+		{"invalid format", "config.yaml", "xml", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(path, []byte(files[tt.file]), 0644); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("CONFIG_FORMAT", tt.format)
+
+			cfg, err := Load(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if cfg.Server.Port != 9001 || cfg.Logging.Level != "debug" {
+				t.Errorf("Load() port = %d, level = %q, want 9001, debug", cfg.Server.Port, cfg.Logging.Level)
+			}
+		})
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	t.Setenv("CONFIG_SOURCE", "")
+	t.Setenv("CONFIG_FORMAT", "")
+	dir := t.TempDir()
+	base := filepath.Join(dir, "config.json")
+	files := map[string]string{
+		base:                                   `{"server": {"port": 9001}, "logging": {"level": "info"}, "vad": {"silero_vad": {"threshold": 0.6}}}`,
+		filepath.Join(dir, "config.prod.json"): `{"logging": {"level": "warn"}, "vad": {"silero_vad": {"min_silence_duration": 0.3}}}`,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		env         string
+		wantLevel   string
+		wantSilence float32
+		wantErr     bool
+	}{
+		{"", "info", DefaultMinSilenceDur, false},
+		{"prod", "warn", 0.3, false},
+		{"staging", "info", DefaultMinSilenceDur, false}, // No profile file
+		{"../prod", "", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run("APP_ENV="+tt.env, func(t *testing.T) {
+			t.Setenv("APP_ENV", tt.env)
+			cfg, err := Load(base)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			// Keys missing from the profile keep the base values
+			if cfg.Server.Port != 9001 || cfg.VAD.SileroVAD.Threshold != 0.6 {
+				t.Errorf("base values lost: port = %d, threshold = %v", cfg.Server.Port, cfg.VAD.SileroVAD.Threshold)
+			}
+			if cfg.Logging.Level != tt.wantLevel || cfg.VAD.SileroVAD.MinSilenceDuration != tt.wantSilence {
+				t.Errorf("level = %q, min_silence_duration = %v, want %q, %v",
+					cfg.Logging.Level, cfg.VAD.SileroVAD.MinSilenceDuration, tt.wantLevel, tt.wantSilence)
+			}
+		})
+	}
+
+	// Runtime settings are persisted to the profile, which overrides the base file
+	t.Setenv("APP_ENV", "prod")
+	if err := PersistSettings(base, map[string]interface{}{"logging.level": "error"}); err != nil {
+		t.Fatalf("PersistSettings() error = %v", err)
+	}
+	if cfg, err := Load(base); err != nil || cfg.Logging.Level != "error" {
+		t.Errorf("Load() after PersistSettings() = %v, %v", cfg, err)
+	}
+}
+
+func TestCheckFiles(t *testing.T) {
+	dir := t.TempDir()
+	model := filepath.Join(dir, "model.onnx")
+	tokens := filepath.Join(dir, "tokens.txt")
+	for _, path := range []string{model, tokens} {
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	missing := filepath.Join(dir, "missing.onnx")
+
+	tests := []struct {
+		name     string
+		modify   func(cfg *Config)
+		wantErrs int
+	}{
+		{"all files present", func(cfg *Config) {}, 0},
+		{"missing model", func(cfg *Config) { cfg.Recognition.ModelPath = missing }, 1},
+		{"directory instead of file", func(cfg *Config) { cfg.Recognition.TokensPath = dir }, 1},
+		{"missing VAD model", func(cfg *Config) { cfg.VAD.SileroVAD.ModelPath = missing }, 1},
+		{"VAD model unused by ten_vad", func(cfg *Config) {
+			cfg.VAD.Provider = "ten_vad"
+			cfg.VAD.SileroVAD.ModelPath = missing
+		}, 0},
+		{"missing speaker model", func(cfg *Config) {
+			cfg.Speaker.Enabled = true
+			cfg.Speaker.ModelPath = missing
+		}, 1},
+		{"speaker model unused when disabled", func(cfg *Config) { cfg.Speaker.ModelPath = missing }, 0},
+		{"missing extra model files", func(cfg *Config) {
+			cfg.Recognition.Models = map[string]ModelConfig{
+				"whisper": {Type: "whisper", EncoderPath: missing, DecoderPath: missing, TokensPath: tokens},
+			}
+		}, 2},
+		{"missing certificate", func(cfg *Config) { cfg.Server.TLS.CertFile = missing }, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{}
+			cfg.VAD.Provider = "silero_vad"
+			cfg.VAD.SileroVAD.ModelPath = model
+			cfg.Recognition.ModelPath = model
+			cfg.Recognition.TokensPath = tokens
+			tt.modify(cfg)
+
+			if errs := cfg.CheckFiles(); len(errs) != tt.wantErrs {
+				t.Errorf("CheckFiles() = %v, want %d errors", errs, tt.wantErrs)
+			}
+		})
+	}
+}
+
 func TestMask(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -472,3 +873,440 @@ func TestToSafeMap(t *testing.T) {
 		t.Errorf("server.port = %v, want 8080", serverMap["port"])
 	}
 }
+
+func TestValidateTranscriptionConfig(t *testing.T) {
+	if err := validateTranscriptionConfig(&TranscriptionConfig{Enabled: true, MaxFileSize: 1024}); err != nil {
+		t.Errorf("validateTranscriptionConfig() unexpected error: %v", err)
+	}
+	if err := validateTranscriptionConfig(&TranscriptionConfig{MaxFileSize: -1}); err == nil {
+		t.Error("validateTranscriptionConfig() should fail for negative max_file_size")
+	}
+	if err := validateTranscriptionConfig(&TranscriptionConfig{URLFetchTimeout: -1}); err == nil {
+		t.Error("validateTranscriptionConfig() should fail for negative url_fetch_timeout")
+	}
+}
+
+func TestValidateSessionConfig(t *testing.T) {
+	if err := validateSessionConfig(&SessionConfig{SendQueueSize: 500, JitterBufferSize: 16}); err != nil {
+		t.Errorf("validateSessionConfig() unexpected error: %v", err)
+	}
+	if err := validateSessionConfig(&SessionConfig{JitterBufferSize: -1}); err == nil {
+		t.Error("validateSessionConfig() should fail for negative jitter_buffer_size")
+	}
+	if err := validateSessionConfig(&SessionConfig{BackpressureHighWatermark: 0.8, BackpressureLowWatermark: 0.5}); err != nil {
+		t.Errorf("validateSessionConfig() unexpected error: %v", err)
+	}
+	if err := validateSessionConfig(&SessionConfig{BackpressureHighWatermark: 1.5}); err == nil {
+		t.Error("validateSessionConfig() should fail for backpressure_high_watermark above 1")
+	}
+	if err := validateSessionConfig(&SessionConfig{BackpressureHighWatermark: 0.5, BackpressureLowWatermark: 0.8}); err == nil {
+		t.Error("validateSessionConfig() should fail when backpressure_low_watermark exceeds the high watermark")
+	}
+	if err := validateSessionConfig(&SessionConfig{ResumeGracePeriod: -1}); err == nil {
+		t.Error("validateSessionConfig() should fail for negative resume_grace_period")
+	}
+	if err := validateSessionConfig(&SessionConfig{DrainTimeout: -1}); err == nil {
+		t.Error("validateSessionConfig() should fail for negative drain_timeout")
+	}
+	if err := validateSessionConfig(&SessionConfig{Timeout: 300, CleanupInterval: 30, MaxSegmentSeconds: 60}); err != nil {
+		t.Errorf("validateSessionConfig() unexpected error: %v", err)
+	}
+	if err := validateSessionConfig(&SessionConfig{MaxSegmentSeconds: -1}); err == nil {
+		t.Error("validateSessionConfig() should fail for negative max_segment_seconds")
+	}
+	if err := validateSessionConfig(&SessionConfig{SpillBufferSize: -1}); !errors.Is(err, ErrNegativeValue) {
+		t.Errorf("validateSessionConfig() error = %v, want %v", err, ErrNegativeValue)
+	}
+	quotas := SessionConfig{
+		Quota:  SessionQuota{MaxDuration: 600, MaxAudioSeconds: 300},
+		Quotas: []SessionQuota{{APIKey: "demo", MaxDuration: 60}},
+	}
+	if err := validateSessionConfig(&quotas); err != nil {
+		t.Errorf("validateSessionConfig() unexpected error: %v", err)
+	}
+	if err := validateSessionConfig(&SessionConfig{Quota: SessionQuota{MaxAudioBytes: -1}}); !errors.Is(err, ErrNegativeValue) {
+		t.Errorf("validateSessionConfig() error = %v, want %v", err, ErrNegativeValue)
+	}
+	if err := validateSessionConfig(&SessionConfig{Quotas: []SessionQuota{{MaxDuration: 60}}}); err == nil {
+		t.Error("validateSessionConfig() should fail for a quota without api_key")
+	}
+	if got := quotas.QuotaFor("demo"); got.MaxDuration != 60 || got.MaxAudioSeconds != 0 {
+		t.Errorf("QuotaFor(demo) = %+v, want the per-key quota", got)
+	}
+	if got := quotas.QuotaFor("other"); got.MaxDuration != 600 {
+		t.Errorf("QuotaFor(other) = %+v, want the default quota", got)
+	}
+}
+
+func TestValidateRecognitionConfig(t *testing.T) {
+	if err := validateRecognitionConfig(&RecognitionConfig{Mode: "offline"}); err != nil {
+		t.Errorf("validateRecognitionConfig() unexpected error: %v", err)
+	}
+	if err := validateRecognitionConfig(&RecognitionConfig{Mode: "realtime"}); err == nil {
+		t.Error("validateRecognitionConfig() should fail for unknown mode")
+	}
+	if err := validateRecognitionConfig(&RecognitionConfig{HotwordsScore: -1}); !errors.Is(err, ErrNegativeValue) {
+		t.Errorf("validateRecognitionConfig() error = %v, want %v", err, ErrNegativeValue)
+	}
+	if err := validateRecognitionConfig(&RecognitionConfig{BatchTimeoutMs: -1}); !errors.Is(err, ErrNegativeValue) {
+		t.Errorf("validateRecognitionConfig() error = %v, want %v", err, ErrNegativeValue)
+	}
+
+	models := map[string]ModelConfig{
+		"en": {Type: "whisper", EncoderPath: "encoder.onnx", DecoderPath: "decoder.onnx", TokensPath: "tokens.txt"},
+	}
+	if err := validateRecognitionConfig(&RecognitionConfig{Models: models}); err != nil {
+		t.Errorf("validateRecognitionConfig() unexpected error: %v", err)
+	}
+	models["zh"] = ModelConfig{Type: "paraformer", TokensPath: "tokens.txt"}
+	if err := validateRecognitionConfig(&RecognitionConfig{Models: models}); !errors.Is(err, ErrEmptyModelPath) {
+		t.Errorf("validateRecognitionConfig() error = %v, want %v", err, ErrEmptyModelPath)
+	}
+	models["zh"] = ModelConfig{Type: "ctc", ModelPath: "model.onnx", TokensPath: "tokens.txt"}
+	if err := validateRecognitionConfig(&RecognitionConfig{Models: models}); err == nil {
+		t.Error("validateRecognitionConfig() should fail for unknown model type")
+	}
+	models["zh"] = ModelConfig{
+		Type:        "transducer",
+		EncoderPath: "encoder.onnx",
+		DecoderPath: "decoder.onnx",
+		JoinerPath:  "joiner.onnx",
+		TokensPath:  "tokens.txt",
+		LM:          LMConfig{ModelPath: "lm.onnx", Scale: 0.5},
+	}
+	if err := validateRecognitionConfig(&RecognitionConfig{Models: models}); err != nil {
+		t.Errorf("validateRecognitionConfig() unexpected error: %v", err)
+	}
+	models["zh"] = ModelConfig{Type: "paraformer", ModelPath: "model.onnx", TokensPath: "tokens.txt", LM: LMConfig{ModelPath: "lm.onnx"}}
+	if err := validateRecognitionConfig(&RecognitionConfig{Models: models}); err == nil {
+		t.Error("validateRecognitionConfig() should fail for lm on a non-transducer model")
+	}
+
+	translation := TranslationConfig{EncoderPath: "encoder.onnx", DecoderPath: "decoder.onnx", TokensPath: "tokens.txt"}
+	if err := validateRecognitionConfig(&RecognitionConfig{Translation: translation}); err != nil {
+		t.Errorf("validateRecognitionConfig() unexpected error: %v", err)
+	}
+	translation.TokensPath = ""
+	if err := validateRecognitionConfig(&RecognitionConfig{Translation: translation}); !errors.Is(err, ErrEmptyModelPath) {
+		t.Errorf("validateRecognitionConfig() error = %v, want %v", err, ErrEmptyModelPath)
+	}
+
+	streaming := StreamingRecognitionConfig{
+		EncoderPath:    "encoder.onnx",
+		DecoderPath:    "decoder.onnx",
+		JoinerPath:     "joiner.onnx",
+		TokensPath:     "tokens.txt",
+		DecodingMethod: "greedy_search",
+	}
+	if err := validateRecognitionConfig(&RecognitionConfig{Mode: "streaming", Streaming: streaming}); err != nil {
+		t.Errorf("validateRecognitionConfig() unexpected error: %v", err)
+	}
+
+	missing := streaming
+	missing.JoinerPath = ""
+	if err := validateRecognitionConfig(&RecognitionConfig{Mode: "streaming", Streaming: missing}); !errors.Is(err, ErrEmptyModelPath) {
+		t.Errorf("validateRecognitionConfig() error = %v, want %v", err, ErrEmptyModelPath)
+	}
+}
+
+func TestValidateSpeakerConfig(t *testing.T) {
+	if err := validateSpeakerConfig(&SpeakerConfig{GRPC: SpeakerGRPCConfig{ListenAddr: ":9090", MaxAudioSeconds: 60}}); err != nil {
+		t.Errorf("validateSpeakerConfig() unexpected error: %v", err)
+	}
+	if err := validateSpeakerConfig(&SpeakerConfig{GRPC: SpeakerGRPCConfig{MaxAudioSeconds: -1}}); err == nil {
+		t.Error("validateSpeakerConfig() should fail for negative grpc.max_audio_seconds")
+	}
+	if err := validateSpeakerConfig(&SpeakerConfig{Diarization: DiarizationConfig{Enabled: true, Threshold: 1.5}}); !errors.Is(err, ErrInvalidThreshold) {
+		t.Errorf("validateSpeakerConfig() error = %v, want %v", err, ErrInvalidThreshold)
+	}
+	if err := validateSpeakerConfig(&SpeakerConfig{Diarization: DiarizationConfig{MaxSpeakers: -1}}); !errors.Is(err, ErrNegativeValue) {
+		t.Errorf("validateSpeakerConfig() error = %v, want %v", err, ErrNegativeValue)
+	}
+	if err := validateSpeakerConfig(&SpeakerConfig{Storage: "sqlite"}); err != nil {
+		t.Errorf("validateSpeakerConfig() unexpected error: %v", err)
+	}
+	if err := validateSpeakerConfig(&SpeakerConfig{Storage: "mongodb"}); !errors.Is(err, ErrInvalidSpeakerStorage) {
+		t.Errorf("validateSpeakerConfig() error = %v, want %v", err, ErrInvalidSpeakerStorage)
+	}
+	if err := validateSpeakerConfig(&SpeakerConfig{Storage: "postgres"}); err == nil {
+		t.Error("validateSpeakerConfig() should fail for postgres storage without a DSN")
+	}
+	if err := validateSpeakerConfig(&SpeakerConfig{Storage: "redis", Redis: SpeakerRedisConfig{Addr: "localhost:6379"}, SyncInterval: 30}); err != nil {
+		t.Errorf("validateSpeakerConfig() unexpected error: %v", err)
+	}
+	if err := validateSpeakerConfig(&SpeakerConfig{PoolSize: -1}); !errors.Is(err, ErrNegativeValue) {
+		t.Errorf("validateSpeakerConfig() error = %v, want %v", err, ErrNegativeValue)
+	}
+	if err := validateSpeakerConfig(&SpeakerConfig{SyncInterval: -1}); !errors.Is(err, ErrNegativeValue) {
+		t.Errorf("validateSpeakerConfig() error = %v, want %v", err, ErrNegativeValue)
+	}
+	if err := validateSpeakerConfig(&SpeakerConfig{MaxUploadSize: -1}); !errors.Is(err, ErrNegativeValue) {
+		t.Errorf("validateSpeakerConfig() error = %v, want %v", err, ErrNegativeValue)
+	}
+	if err := validateSpeakerConfig(&SpeakerConfig{MinEnrollSeconds: -1}); !errors.Is(err, ErrNegativeValue) {
+		t.Errorf("validateSpeakerConfig() error = %v, want %v", err, ErrNegativeValue)
+	}
+	if err := validateSpeakerConfig(&SpeakerConfig{Tracking: SpeakerTrackingConfig{RetentionDays: -1}}); !errors.Is(err, ErrNegativeValue) {
+		t.Errorf("validateSpeakerConfig() error = %v, want %v", err, ErrNegativeValue)
+	}
+	if err := validateSpeakerConfig(&SpeakerConfig{Audit: SpeakerAuditConfig{Enabled: true, Sink: "syslog"}}); !errors.Is(err, ErrInvalidAuditSink) {
+		t.Errorf("validateSpeakerConfig() error = %v, want %v", err, ErrInvalidAuditSink)
+	}
+	if err := validateSpeakerConfig(&SpeakerConfig{Storage: "json", Audit: SpeakerAuditConfig{Enabled: true, Sink: "database"}}); err == nil {
+		t.Error("validateSpeakerConfig() should fail for the database audit sink with json storage")
+	}
+	if err := validateSpeakerConfig(&SpeakerConfig{Storage: "sqlite", Audit: SpeakerAuditConfig{Enabled: true, Sink: "database"}}); err != nil {
+		t.Errorf("validateSpeakerConfig() unexpected error: %v", err)
+	}
+	if err := validateSpeakerConfig(&SpeakerConfig{MinEnrollSeconds: 3, MaxSpeechSeconds: 2}); err == nil {
+		t.Error("validateSpeakerConfig() should fail when max_speech_seconds is below min_enroll_seconds")
+	}
+	if err := validateSpeakerConfig(&SpeakerConfig{AntiSpoofing: AntiSpoofingConfig{Enabled: true, Threshold: 0.5}}); !errors.Is(err, ErrEmptyModelPath) {
+		t.Errorf("validateSpeakerConfig() error = %v, want %v", err, ErrEmptyModelPath)
+	}
+	if err := validateSpeakerConfig(&SpeakerConfig{AntiSpoofing: AntiSpoofingConfig{Threshold: -0.1}}); !errors.Is(err, ErrInvalidThreshold) {
+		t.Errorf("validateSpeakerConfig() error = %v, want %v", err, ErrInvalidThreshold)
+	}
+}
+
+func TestValidateTelephonyConfig(t *testing.T) {
+	if err := validateTelephonyConfig(&TelephonyConfig{ListenAddr: ":5004", IdleTimeout: 10}); err != nil {
+		t.Errorf("validateTelephonyConfig() unexpected error: %v", err)
+	}
+	if err := validateTelephonyConfig(&TelephonyConfig{IdleTimeout: -1}); err == nil {
+		t.Error("validateTelephonyConfig() should fail for negative idle_timeout")
+	}
+}
+
+func TestValidateTCPConfig(t *testing.T) {
+	if err := validateTCPConfig(&TCPConfig{Enabled: true, Port: 9000, MaxConnections: 100, MaxFrameSize: 65536, IdleTimeout: 60}); err != nil {
+		t.Errorf("validateTCPConfig() unexpected error: %v", err)
+	}
+	if err := validateTCPConfig(&TCPConfig{}); err != nil {
+		t.Errorf("validateTCPConfig() should ignore port when disabled, got: %v", err)
+	}
+	if err := validateTCPConfig(&TCPConfig{Enabled: true, Port: 70000}); !errors.Is(err, ErrInvalidPort) {
+		t.Errorf("validateTCPConfig() error = %v, want ErrInvalidPort", err)
+	}
+	if err := validateTCPConfig(&TCPConfig{MaxFrameSize: -1}); err == nil {
+		t.Error("validateTCPConfig() should fail for negative max_frame_size")
+	}
+}
+
+func TestValidateJobsConfig(t *testing.T) {
+	if err := validateJobsConfig(&JobsConfig{Enabled: true, WorkerCount: 2, QueueSize: 100}); err != nil {
+		t.Errorf("validateJobsConfig() unexpected error: %v", err)
+	}
+	if err := validateJobsConfig(&JobsConfig{WorkerCount: -1}); err == nil {
+		t.Error("validateJobsConfig() should fail for negative worker_count")
+	}
+	if err := validateJobsConfig(&JobsConfig{QueueSize: -1}); err == nil {
+		t.Error("validateJobsConfig() should fail for negative queue_size")
+	}
+	if err := validateJobsConfig(&JobsConfig{RetentionHours: -1}); err == nil {
+		t.Error("validateJobsConfig() should fail for negative retention_hours")
+	}
+	if err := validateJobsConfig(&JobsConfig{MaxFinished: -1}); err == nil {
+		t.Error("validateJobsConfig() should fail for negative max_finished")
+	}
+}
+
+func TestValidateMQTTConfig(t *testing.T) {
+	valid := MQTTConfig{
+		Enabled:     true,
+		BrokerURL:   "tcp://localhost:1883",
+		AudioTopic:  "asr/+/audio",
+		ResultTopic: "asr/{device}/result",
+		KeepAlive:   30,
+	}
+	if err := validateMQTTConfig(&valid); err != nil {
+		t.Errorf("validateMQTTConfig() unexpected error: %v", err)
+	}
+	if err := validateMQTTConfig(&MQTTConfig{}); err != nil {
+		t.Errorf("validateMQTTConfig() should ignore disabled config, got: %v", err)
+	}
+	noWildcard := valid
+	noWildcard.AudioTopic = "asr/audio"
+	if err := validateMQTTConfig(&noWildcard); err == nil {
+		t.Error("validateMQTTConfig() should fail for audio_topic without a device level")
+	}
+	if err := validateMQTTConfig(&MQTTConfig{IdleTimeout: -1}); err == nil {
+		t.Error("validateMQTTConfig() should fail for negative idle_timeout")
+	}
+}
+
+func TestValidateKafkaConfig(t *testing.T) {
+	if err := validateKafkaConfig(&KafkaConfig{}); err != nil {
+		t.Errorf("validateKafkaConfig() should ignore disabled config, got: %v", err)
+	}
+	if err := validateKafkaConfig(&KafkaConfig{Enabled: true, Brokers: []string{"localhost:9092"}, Topic: "asr.results"}); err != nil {
+		t.Errorf("validateKafkaConfig() unexpected error: %v", err)
+	}
+	if err := validateKafkaConfig(&KafkaConfig{Enabled: true, Topic: "asr.results"}); err == nil {
+		t.Error("validateKafkaConfig() should fail without brokers")
+	}
+}
+
+func TestValidateAdminConfig(t *testing.T) {
+	if err := validateAdminConfig(&AdminConfig{}); err != nil {
+		t.Errorf("validateAdminConfig() should ignore disabled config, got: %v", err)
+	}
+	if err := validateAdminConfig(&AdminConfig{Enabled: true, Token: "secret"}); err != nil {
+		t.Errorf("validateAdminConfig() unexpected error: %v", err)
+	}
+	if err := validateAdminConfig(&AdminConfig{Enabled: true}); err == nil {
+		t.Error("validateAdminConfig() should fail without token")
+	}
+	if err := validateAdminConfig(&AdminConfig{Enabled: true, Token: "secret", EventHistory: -1}); !errors.Is(err, ErrNegativeValue) {
+		t.Errorf("validateAdminConfig() error = %v, want %v", err, ErrNegativeValue)
+	}
+}
+
+func TestValidateAuthConfig(t *testing.T) {
+	if err := validateAuthConfig(&AuthConfig{}); err != nil {
+		t.Errorf("validateAuthConfig() should ignore disabled config, got: %v", err)
+	}
+	if err := validateAuthConfig(&AuthConfig{Enabled: true, JWKSURL: "https://idp.example.com/jwks"}); err != nil {
+		t.Errorf("validateAuthConfig() unexpected error: %v", err)
+	}
+	if err := validateAuthConfig(&AuthConfig{Enabled: true}); err == nil {
+		t.Error("validateAuthConfig() should fail without a key source")
+	}
+	if err := validateAuthConfig(&AuthConfig{Leeway: -1}); !errors.Is(err, ErrNegativeValue) {
+		t.Errorf("validateAuthConfig() error = %v, want %v", err, ErrNegativeValue)
+	}
+
+	hmacOnly := &AuthConfig{Enabled: true, HMAC: HMACAuthConfig{Keys: []HMACKey{{KeyID: "billing", Secret: "s3cret"}}, MaxSkew: 300}}
+	if err := validateAuthConfig(hmacOnly); err != nil {
+		t.Errorf("validateAuthConfig() should accept HMAC keys as the only credential, got: %v", err)
+	}
+	if err := validateAuthConfig(&AuthConfig{HMAC: HMACAuthConfig{Keys: []HMACKey{{KeyID: "billing"}}}}); err == nil {
+		t.Error("validateAuthConfig() should fail for an HMAC key without a secret")
+	}
+	duplicate := []HMACKey{{KeyID: "billing", Secret: "a"}, {KeyID: "billing", Secret: "b"}}
+	if err := validateAuthConfig(&AuthConfig{HMAC: HMACAuthConfig{Keys: duplicate}}); err == nil {
+		t.Error("validateAuthConfig() should fail for duplicate HMAC key IDs")
+	}
+	scoped := []HMACKey{{KeyID: "kiosk", Secret: "a", Scopes: []string{"asr:stream", "speaker:read"}}}
+	if err := validateAuthConfig(&AuthConfig{EnforceScopes: true, HMAC: HMACAuthConfig{Keys: scoped}}); err != nil {
+		t.Errorf("validateAuthConfig() unexpected error: %v", err)
+	}
+	blank := []HMACKey{{KeyID: "kiosk", Secret: "a", Scopes: []string{"asr:stream speaker:read"}}}
+	if err := validateAuthConfig(&AuthConfig{HMAC: HMACAuthConfig{Keys: blank}}); err == nil {
+		t.Error("validateAuthConfig() should fail for a scope containing whitespace")
+	}
+	if err := validateAuthConfig(&AuthConfig{HMAC: HMACAuthConfig{MaxSkew: -1}}); !errors.Is(err, ErrNegativeValue) {
+		t.Errorf("validateAuthConfig() error = %v, want %v", err, ErrNegativeValue)
+	}
+}
+
+func TestValidateRateLimitConfig(t *testing.T) {
+	valid := &RateLimitConfig{Enabled: true, WSUpgradesPerMinute: 60, WSUpgradeBurst: 10, MaxWSConnectionsPerIP: 50, AudioBytesPerSecond: 64000}
+	if err := validateRateLimitConfig(valid); err != nil {
+		t.Errorf("validateRateLimitConfig() unexpected error: %v", err)
+	}
+	if err := validateRateLimitConfig(&RateLimitConfig{}); err != nil {
+		t.Errorf("validateRateLimitConfig() should accept zero limits, got: %v", err)
+	}
+	for _, cfg := range []*RateLimitConfig{
+		{WSUpgradesPerMinute: -1},
+		{WSUpgradeBurst: -1},
+		{MaxWSConnectionsPerIP: -1},
+		{AudioBytesPerSecond: -1},
+	} {
+		if err := validateRateLimitConfig(cfg); !errors.Is(err, ErrNegativeValue) {
+			t.Errorf("validateRateLimitConfig(%+v) error = %v, want %v", *cfg, err, ErrNegativeValue)
+		}
+	}
+}
+
+func TestValidateHealthConfig(t *testing.T) {
+	if err := validateHealthConfig(&HealthConfig{DecodeCheck: true, DecodeCheckInterval: 30, DecodeCheckTimeout: 5}); err != nil {
+		t.Errorf("validateHealthConfig() unexpected error: %v", err)
+	}
+	if err := validateHealthConfig(&HealthConfig{}); err != nil {
+		t.Errorf("validateHealthConfig() should accept zero values, got: %v", err)
+	}
+	for _, cfg := range []*HealthConfig{
+		{DecodeCheckInterval: -1},
+		{DecodeCheckTimeout: -1},
+		{MinFreeDiskMB: -1},
+	} {
+		if err := validateHealthConfig(cfg); !errors.Is(err, ErrNegativeValue) {
+			t.Errorf("validateHealthConfig(%+v) error = %v, want %v", *cfg, err, ErrNegativeValue)
+		}
+	}
+	if err := validateHealthConfig(&HealthConfig{QueueDegradedRatio: 0.9, MinFreeDiskMB: 512, DegradedStatusCode: 503}); err != nil {
+		t.Errorf("validateHealthConfig() unexpected error for degradation settings: %v", err)
+	}
+	for _, cfg := range []*HealthConfig{
+		{QueueDegradedRatio: 1.5},
+		{DegradedStatusCode: 42},
+	} {
+		if err := validateHealthConfig(cfg); err == nil {
+			t.Errorf("validateHealthConfig(%+v) should fail", *cfg)
+		}
+	}
+}
+
+func TestValidateStatsDConfig(t *testing.T) {
+	valid := &StatsDConfig{Enabled: true, Addr: "127.0.0.1:8125", Prefix: "asr.", Tags: []string{"env:prod", "region:cn"}, FlushInterval: 10}
+	if err := validateStatsDConfig(valid); err != nil {
+		t.Errorf("validateStatsDConfig() unexpected error: %v", err)
+	}
+	if err := validateStatsDConfig(&StatsDConfig{}); err != nil {
+		t.Errorf("validateStatsDConfig() should ignore disabled config, got: %v", err)
+	}
+	if err := validateStatsDConfig(&StatsDConfig{FlushInterval: -1}); !errors.Is(err, ErrNegativeValue) {
+		t.Errorf("validateStatsDConfig() error = %v, want %v", err, ErrNegativeValue)
+	}
+	if err := validateStatsDConfig(&StatsDConfig{Enabled: true}); err == nil {
+		t.Error("validateStatsDConfig() should fail without addr")
+	}
+	if err := validateStatsDConfig(&StatsDConfig{Prefix: "asr:"}); err == nil {
+		t.Error("validateStatsDConfig() should fail for a prefix containing ':'")
+	}
+	for _, tag := range []string{"", "env:prod,team", "env|prod", "env prod"} {
+		if err := validateStatsDConfig(&StatsDConfig{Tags: []string{tag}}); err == nil {
+			t.Errorf("validateStatsDConfig() should fail for tag %q", tag)
+		}
+	}
+}
+
+func TestChangedKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		update func(c *Config)
+		want   []string
+	}{
+		{"unchanged", func(c *Config) {}, nil},
+		{"top level field", func(c *Config) { c.VAD.PoolSize = 10 }, []string{"vad.pool_size"}},
+		{"nested field", func(c *Config) { c.VAD.SileroVAD.Threshold = 0.7 }, []string{"vad.silero_vad.threshold"}},
+		{"list", func(c *Config) { c.Server.TrustedProxies = []string{"10.0.0.1"} }, []string{"server.trusted_proxies"}},
+		{
+			name: "several sections",
+			update: func(c *Config) {
+				c.Logging.Level = "debug"
+				c.RateLimit.BurstSize = 5
+			},
+			want: []string{"rate_limit.burst_size", "logging.level"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := &Config{Server: ServerConfig{TrustedProxies: []string{"10.0.0.0/8"}}}
+			updated := *old
+			tt.update(&updated)
+			if got := ChangedKeys(old, &updated); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ChangedKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	keys := []string{"server.websocket.ping_interval", "vad.pool_size"}
+	for section, want := range map[string]bool{"vad": true, "server.websocket": true, "server": true, "server.tls": false, "va": false} {
+		if got := sectionChanged(section, keys); got != want {
+			t.Errorf("sectionChanged(%q) = %v, want %v", section, got, want)
+		}
+	}
+}
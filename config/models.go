@@ -0,0 +1,114 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// ModelRefPrefix starts a model path that refers to a file of a downloaded model, e.g.
+// model://sense-voice/model.int8.onnx
+const ModelRefPrefix = "model://"
+
+var ErrUnknownModel = errors.New("unknown model")
+
+// archiveSuffixes are the archive formats that are extracted after download
+var archiveSuffixes = []string{".tar.gz", ".tgz", ".tar.bz2", ".tbz2", ".tar", ".zip"}
+
+// ArchiveSuffix returns the archive suffix of the download URL, or "" for a single file
+func (s ModelSource) ArchiveSuffix() string {
+	name := strings.ToLower(s.FileName())
+	for _, suffix := range archiveSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return suffix
+		}
+	}
+	return ""
+}
+
+// FileName returns the last element of the download URL path
+func (s ModelSource) FileName() string {
+	if u, err := url.Parse(s.URL); err == nil {
+		return path.Base(u.Path)
+	}
+	return path.Base(s.URL)
+}
+
+// Dir returns the directory a model is installed in
+func (c *ModelsConfig) Dir(name string) string {
+	return filepath.Join(c.CacheDir, name)
+}
+
+// ResolveRef returns the local path of a model://<name>/<file> reference. Without a file,
+// it is the downloaded file of a single-file model or the directory of an archive.
+func (c *ModelsConfig) ResolveRef(ref string) (string, error) {
+	name, file, _ := strings.Cut(strings.TrimPrefix(ref, ModelRefPrefix), "/")
+	for _, src := range c.Sources {
+		if src.Name != name {
+			continue
+		}
+		if file == "" {
+			if src.ArchiveSuffix() != "" {
+				return c.Dir(name), nil
+			}
+			return filepath.Join(c.Dir(name), src.FileName()), nil
+		}
+		if !filepath.IsLocal(file) {
+			return "", fmt.Errorf("%s: path escapes the model directory", ref)
+		}
+		return filepath.Join(c.Dir(name), filepath.FromSlash(file)), nil
+	}
+	return "", fmt.Errorf("%s: %w %q", ref, ErrUnknownModel, name)
+}
+
+// resolveModelRefs replaces model:// references anywhere in the configuration with the local
+// paths of the downloaded files
+func resolveModelRefs(cfg *Config) error {
+	return resolveRefs(reflect.ValueOf(cfg).Elem(), "", &cfg.Models)
+}
+
+func resolveRefs(v reflect.Value, key string, models *ModelsConfig) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("mapstructure"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			if err := resolveRefs(v.Field(i), joinKey(key, name), models); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		// Map values are not addressable, so each is resolved in a copy and stored back
+		iter := v.MapRange()
+		for iter.Next() {
+			value := reflect.New(iter.Value().Type()).Elem()
+			value.Set(iter.Value())
+			if err := resolveRefs(value, joinKey(key, fmt.Sprint(iter.Key().Interface())), models); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), value)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := resolveRefs(v.Index(i), fmt.Sprintf("%s[%d]", key, i), models); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		if !strings.HasPrefix(v.String(), ModelRefPrefix) {
+			return nil
+		}
+		resolved, err := models.ResolveRef(v.String())
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		v.SetString(resolved)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testChecksum = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+func TestValidateModelsConfig(t *testing.T) {
+	valid := ModelSource{Name: "sense-voice", URL: "https://example.com/model.tar.bz2", SHA256: testChecksum}
+	tests := []struct {
+		name    string
+		modify  func(*ModelsConfig)
+		wantErr bool
+	}{
+		{"valid", func(c *ModelsConfig) {}, false},
+		{"no sources without cache dir", func(c *ModelsConfig) { c.CacheDir = ""; c.Sources = nil }, false},
+		{"empty cache dir", func(c *ModelsConfig) { c.CacheDir = "" }, true},
+		{"negative timeout", func(c *ModelsConfig) { c.DownloadTimeout = -1 }, true},
+		{"empty name", func(c *ModelsConfig) { c.Sources[0].Name = "" }, true},
+		{"name with separator", func(c *ModelsConfig) { c.Sources[0].Name = "../asr" }, true},
+		{"duplicate name", func(c *ModelsConfig) { c.Sources = append(c.Sources, valid) }, true},
+		{"ftp url", func(c *ModelsConfig) { c.Sources[0].URL = "ftp://example.com/model.onnx" }, true},
+		{"short checksum", func(c *ModelsConfig) { c.Sources[0].SHA256 = "e3b0c442" }, true},
+		{"missing checksum", func(c *ModelsConfig) { c.Sources[0].SHA256 = "" }, true},
+		{"negative strip components", func(c *ModelsConfig) { c.Sources[0].StripComponents = -1 }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := ModelsConfig{CacheDir: "models/cache", Sources: []ModelSource{valid}}
+			tt.modify(&cfg)
+			if err := validateModelsConfig(&cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validateModelsConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveRef(t *testing.T) {
+	cfg := ModelsConfig{CacheDir: "cache", Sources: []ModelSource{
+		{Name: "sense-voice", URL: "https://example.com/sense-voice.tar.bz2?token=x"},
+		{Name: "silero", URL: "https://example.com/v5/silero_vad.onnx"},
+	}}
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{"model://sense-voice/model.int8.onnx", filepath.Join("cache", "sense-voice", "model.int8.onnx"), false},
+		{"model://sense-voice", filepath.Join("cache", "sense-voice"), false},
+		{"model://silero", filepath.Join("cache", "silero", "silero_vad.onnx"), false},
+		{"model://sense-voice/../silero/silero_vad.onnx", "", true},
+		{"model://whisper/tokens.txt", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := cfg.ResolveRef(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveRef() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveRef() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadResolvesModelRefs(t *testing.T) {
+	t.Setenv("CONFIG_SOURCE", "")
+	t.Setenv("CONFIG_FORMAT", "")
+	t.Setenv("APP_ENV", "")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sources := `"models": {"cache_dir": "cache", "sources": [
+		{"name": "sense-voice", "url": "https://example.com/sense-voice.tar.bz2", "sha256": "` + testChecksum + `"}
+	]}`
+	write(`{` + sources + `, "recognition": {
+		"model_path": "model://sense-voice/model.int8.onnx",
+		"tokens_path": "model://sense-voice/tokens.txt",
+		"models": {"alt": {"type": "sense_voice", "model_path": "model://sense-voice/model.onnx", "tokens_path": "models/tokens.txt"}}
+	}}`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	modelDir := filepath.Join("cache", "sense-voice")
+	if cfg.Recognition.ModelPath != filepath.Join(modelDir, "model.int8.onnx") || cfg.Recognition.TokensPath != filepath.Join(modelDir, "tokens.txt") {
+		t.Errorf("recognition paths = %q, %q", cfg.Recognition.ModelPath, cfg.Recognition.TokensPath)
+	}
+	if alt := cfg.Recognition.Models["alt"]; alt.ModelPath != filepath.Join(modelDir, "model.onnx") || alt.TokensPath != "models/tokens.txt" {
+		t.Errorf("models.alt paths = %q, %q", alt.ModelPath, alt.TokensPath)
+	}
+
+	write(`{` + sources + `, "recognition": {"model_path": "model://whisper/model.onnx", "tokens_path": "tokens.txt"}}`)
+	_, err = Load(path)
+	if !errors.Is(err, ErrUnknownModel) || !strings.Contains(err.Error(), "recognition.model_path") {
+		t.Errorf("Load() error = %v, want %v naming the key", err, ErrUnknownModel)
+	}
+}
@@ -0,0 +1,306 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Remote configuration sources, selected with the CONFIG_SOURCE environment variable
+const (
+	SourceFile   = "file"
+	SourceEtcd   = "etcd"
+	SourceConsul = "consul"
+
+	DefaultEtcdEndpoint   = "http://127.0.0.1:2379"
+	DefaultConsulEndpoint = "http://127.0.0.1:8500"
+
+	remoteRequestTimeout = 10 * time.Second
+	remoteRetryInterval  = 5 * time.Second
+	consulWaitTime       = 5 * time.Minute
+)
+
+var (
+	ValidConfigSources = []string{SourceFile, SourceEtcd, SourceConsul}
+
+	ErrInvalidConfigSource = errors.New("invalid config source")
+	ErrRemoteKeyNotFound   = errors.New("remote config key not found")
+)
+
+// RemoteSource is a configuration document stored under a key of etcd (v3 JSON gateway)
+// or Consul KV. The document has the same format as a configuration file.
+type RemoteSource struct {
+	Provider string // etcd or consul
+	Endpoint string // Base URL of the etcd or Consul HTTP API
+	Key      string
+	Token    string // etcd auth token or Consul ACL token, optional
+	Format   string // json, yaml or toml
+
+	client *http.Client
+}
+
+// RemoteSourceFromEnv returns the remote source configured by CONFIG_SOURCE,
+// CONFIG_REMOTE_ENDPOINT, CONFIG_REMOTE_KEY and CONFIG_REMOTE_TOKEN, or nil when the
+// configuration is read from a file. The format is taken from CONFIG_FORMAT, or from the
+// extension of the key, and defaults to json.
+func RemoteSourceFromEnv() (*RemoteSource, error) {
+	provider := strings.ToLower(os.Getenv("CONFIG_SOURCE"))
+	if provider == "" || provider == SourceFile {
+		return nil, nil
+	}
+	if !containsString(ValidConfigSources, provider) {
+		return nil, fmt.Errorf("%w: got %q, expected one of %v", ErrInvalidConfigSource, provider, ValidConfigSources)
+	}
+
+	src := &RemoteSource{
+		Provider: provider,
+		Endpoint: strings.TrimSuffix(os.Getenv("CONFIG_REMOTE_ENDPOINT"), "/"),
+		Key:      os.Getenv("CONFIG_REMOTE_KEY"),
+		Token:    os.Getenv("CONFIG_REMOTE_TOKEN"),
+		Format:   strings.ToLower(os.Getenv("CONFIG_FORMAT")),
+		client:   &http.Client{},
+	}
+	if src.Key == "" {
+		return nil, fmt.Errorf("CONFIG_REMOTE_KEY is required when CONFIG_SOURCE is %s", provider)
+	}
+	if src.Endpoint == "" {
+		src.Endpoint = DefaultEtcdEndpoint
+		if provider == SourceConsul {
+			src.Endpoint = DefaultConsulEndpoint
+		}
+	}
+	if src.Format == "" {
+		src.Format = strings.TrimPrefix(path.Ext(src.Key), ".")
+		if !containsString(ValidConfigFormats, src.Format) {
+			src.Format = "json"
+		}
+	}
+	if !containsString(ValidConfigFormats, src.Format) {
+		return nil, fmt.Errorf("%w: got %q, expected one of %v", ErrInvalidConfigFormat, src.Format, ValidConfigFormats)
+	}
+	return src, nil
+}
+
+// String describes the source for logs
+func (s *RemoteSource) String() string {
+	return fmt.Sprintf("%s %s%s", s.Provider, s.Endpoint, path.Join("/", s.Key))
+}
+
+// Fetch returns the configuration document and the revision (etcd) or index (Consul) it
+// was read at
+func (s *RemoteSource) Fetch(ctx context.Context) ([]byte, uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, remoteRequestTimeout)
+	defer cancel()
+	if s.Provider == SourceConsul {
+		return s.consulGet(ctx, 0)
+	}
+	return s.etcdRange(ctx)
+}
+
+// Watch calls onChange whenever the key changes after revision, until ctx is canceled.
+// Connection errors are retried.
+func (s *RemoteSource) Watch(ctx context.Context, revision uint64, onChange func()) {
+	for ctx.Err() == nil {
+		var err error
+		if s.Provider == SourceConsul {
+			revision, err = s.consulWatch(ctx, revision, onChange)
+		} else {
+			revision, err = s.etcdWatch(ctx, revision, onChange)
+		}
+		if err != nil && ctx.Err() == nil {
+			fmt.Printf("[WARN] Watching remote config %s failed, retrying: %v\n", s, err)
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(remoteRetryInterval):
+		}
+	}
+}
+
+// ============================================================================
+// etcd v3 JSON gateway
+// ============================================================================
+
+type etcdKeyValue struct {
+	Value       string `json:"value"`
+	ModRevision string `json:"mod_revision"`
+}
+
+type etcdRangeResponse struct {
+	Header struct {
+		Revision string `json:"revision"`
+	} `json:"header"`
+	Kvs []etcdKeyValue `json:"kvs"`
+}
+
+type etcdWatchResponse struct {
+	Result struct {
+		Header struct {
+			Revision string `json:"revision"`
+		} `json:"header"`
+		Canceled bool `json:"canceled"`
+		Events   []struct {
+			Kv etcdKeyValue `json:"kv"`
+		} `json:"events"`
+	} `json:"result"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// etcdPost sends a JSON request to the gateway
+func (s *RemoteSource) etcdPost(ctx context.Context, endpoint string, body interface{}) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.Endpoint+endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		req.Header.Set("Authorization", s.Token)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("etcd %s returned %s: %s", endpoint, resp.Status, bytes.TrimSpace(message))
+	}
+	return resp, nil
+}
+
+func (s *RemoteSource) etcdRange(ctx context.Context) ([]byte, uint64, error) {
+	resp, err := s.etcdPost(ctx, "/v3/kv/range", map[string]string{
+		"key": base64.StdEncoding.EncodeToString([]byte(s.Key)),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	var result etcdRangeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, 0, fmt.Errorf("invalid etcd response: %w", err)
+	}
+	if len(result.Kvs) == 0 {
+		return nil, 0, fmt.Errorf("%w: %s", ErrRemoteKeyNotFound, s)
+	}
+	value, err := base64.StdEncoding.DecodeString(result.Kvs[0].Value)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid etcd value: %w", err)
+	}
+	revision, _ := strconv.ParseUint(result.Header.Revision, 10, 64)
+	return value, revision, nil
+}
+
+// etcdWatch streams the changes of the key after revision and returns the last revision seen
+func (s *RemoteSource) etcdWatch(ctx context.Context, revision uint64, onChange func()) (uint64, error) {
+	resp, err := s.etcdPost(ctx, "/v3/watch", map[string]interface{}{
+		"create_request": map[string]interface{}{
+			"key":            base64.StdEncoding.EncodeToString([]byte(s.Key)),
+			"start_revision": strconv.FormatUint(revision+1, 10),
+		},
+	})
+	if err != nil {
+		return revision, err
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var message etcdWatchResponse
+		if err := decoder.Decode(&message); err != nil {
+			return revision, err
+		}
+		if message.Error != nil {
+			return revision, errors.New(message.Error.Message)
+		}
+		if message.Result.Canceled {
+			return revision, errors.New("etcd canceled the watch")
+		}
+		for _, event := range message.Result.Events {
+			if r, err := strconv.ParseUint(event.Kv.ModRevision, 10, 64); err == nil && r > revision {
+				revision = r
+			}
+		}
+		if len(message.Result.Events) > 0 {
+			onChange()
+		}
+	}
+}
+
+// ============================================================================
+// Consul KV
+// ============================================================================
+
+// consulGet reads the key, blocking until its index exceeds index when index is not zero
+func (s *RemoteSource) consulGet(ctx context.Context, index uint64) ([]byte, uint64, error) {
+	query := url.Values{"raw": {""}}
+	if index > 0 {
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", consulWaitTime.String())
+	}
+	endpoint := s.Endpoint + "/v1/kv/" + strings.TrimPrefix(s.Key, "/") + "?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if s.Token != "" {
+		req.Header.Set("X-Consul-Token", s.Token)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, 0, fmt.Errorf("%w: %s", ErrRemoteKeyNotFound, s)
+	default:
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, 0, fmt.Errorf("consul returned %s: %s", resp.Status, bytes.TrimSpace(message))
+	}
+	value, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	newIndex, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	return value, newIndex, nil
+}
+
+// consulWatch runs blocking queries until the index moves past index. Consul can also
+// return early without a change, or reset the index, which is not reported as a change.
+func (s *RemoteSource) consulWatch(ctx context.Context, index uint64, onChange func()) (uint64, error) {
+	for {
+		queryCtx, cancel := context.WithTimeout(ctx, consulWaitTime+remoteRequestTimeout)
+		_, newIndex, err := s.consulGet(queryCtx, max(index, 1))
+		cancel()
+		if err != nil {
+			return index, err
+		}
+		if newIndex > index {
+			index = newIndex
+			onChange()
+		} else if newIndex < index {
+			index = newIndex
+		}
+	}
+}
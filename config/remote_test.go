@@ -0,0 +1,184 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const remoteDocument = "server:\n  port: 9002\nlogging:\n  level: debug\n"
+
+// etcdServer serves the key /asr/config.yaml at revision 7 and streams one change on watch
+func etcdServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		key := base64.StdEncoding.EncodeToString([]byte("/asr/config.yaml"))
+
+		switch r.URL.Path {
+		case "/v3/kv/range":
+			if body["key"] != key {
+				fmt.Fprint(w, `{"header":{"revision":"7"}}`)
+				return
+			}
+			fmt.Fprintf(w, `{"header":{"revision":"7"},"kvs":[{"value":%q,"mod_revision":"7"}]}`,
+				base64.StdEncoding.EncodeToString([]byte(remoteDocument)))
+		case "/v3/watch":
+			create := body["create_request"].(map[string]interface{})
+			if create["start_revision"] != "8" {
+				t.Errorf("watch start_revision = %v, want 8", create["start_revision"])
+			}
+			fmt.Fprint(w, `{"result":{"header":{"revision":"7"},"created":true}}`)
+			fmt.Fprint(w, `{"result":{"header":{"revision":"8"},"events":[{"kv":{"mod_revision":"8"}}]}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+// consulServer serves the key asr/config.yaml at index 5, which moves to 6 on the first
+// blocking query
+func consulServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/asr/config.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		if _, raw := r.URL.Query()["raw"]; !raw || r.Header.Get("X-Consul-Token") != "secret" {
+			t.Errorf("unexpected query %q with token %q", r.URL.RawQuery, r.Header.Get("X-Consul-Token"))
+		}
+		index := "5"
+		if r.URL.Query().Get("index") == "5" {
+			index = "6"
+		}
+		w.Header().Set("X-Consul-Index", index)
+		fmt.Fprint(w, remoteDocument)
+	}))
+}
+
+func TestLoadRemote(t *testing.T) {
+	etcd := etcdServer(t)
+	defer etcd.Close()
+	consul := consulServer(t)
+	defer consul.Close()
+
+	tests := []struct {
+		name     string
+		source   string
+		endpoint string
+		key      string
+		wantErr  error
+	}{
+		{"etcd", SourceEtcd, etcd.URL, "/asr/config.yaml", nil},
+		{"consul", SourceConsul, consul.URL, "asr/config.yaml", nil},
+		{"etcd missing key", SourceEtcd, etcd.URL, "/asr/other.yaml", ErrRemoteKeyNotFound},
+		{"consul missing key", SourceConsul, consul.URL, "asr/other.yaml", ErrRemoteKeyNotFound},
+		{"invalid source", "zookeeper", etcd.URL, "/asr/config.yaml", ErrInvalidConfigSource},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_SOURCE", tt.source)
+			t.Setenv("CONFIG_REMOTE_ENDPOINT", tt.endpoint)
+			t.Setenv("CONFIG_REMOTE_KEY", tt.key)
+			t.Setenv("CONFIG_REMOTE_TOKEN", "secret")
+			t.Setenv("CONFIG_FORMAT", "")
+
+			// The file path is ignored for remote sources
+			cfg, err := Load("missing.json")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.Server.Port != 9002 || cfg.Logging.Level != "debug" {
+				t.Errorf("Load() port = %d, level = %q", cfg.Server.Port, cfg.Logging.Level)
+			}
+		})
+	}
+}
+
+func TestRemoteSourceWatch(t *testing.T) {
+	etcd := etcdServer(t)
+	defer etcd.Close()
+	consul := consulServer(t)
+	defer consul.Close()
+
+	for _, src := range []*RemoteSource{
+		{Provider: SourceEtcd, Endpoint: etcd.URL, Key: "/asr/config.yaml", client: &http.Client{}},
+		{Provider: SourceConsul, Endpoint: consul.URL, Key: "asr/config.yaml", Token: "secret", client: &http.Client{}},
+	} {
+		t.Run(src.Provider, func(t *testing.T) {
+			_, revision, err := src.Fetch(context.Background())
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			changed := make(chan struct{}, 1)
+			go src.Watch(ctx, revision, func() {
+				select {
+				case changed <- struct{}{}:
+				default:
+				}
+			})
+
+			select {
+			case <-changed:
+			case <-time.After(5 * time.Second):
+				t.Fatal("no change reported")
+			}
+		})
+	}
+}
+
+func TestRemoteSourceFromEnv(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        map[string]string
+		wantNil    bool
+		wantFormat string
+		wantURL    string
+		wantErr    bool
+	}{
+		{"file", map[string]string{"CONFIG_SOURCE": "file"}, true, "", "", false},
+		{"unset", map[string]string{}, true, "", "", false},
+		{"format from key", map[string]string{"CONFIG_SOURCE": "etcd", "CONFIG_REMOTE_KEY": "/asr/config.toml"}, false, "toml", DefaultEtcdEndpoint, false},
+		{"default format", map[string]string{"CONFIG_SOURCE": "consul", "CONFIG_REMOTE_KEY": "asr/config"}, false, "json", DefaultConsulEndpoint, false},
+		{"format from environment", map[string]string{"CONFIG_SOURCE": "Consul", "CONFIG_REMOTE_KEY": "asr/config", "CONFIG_FORMAT": "yaml"}, false, "yaml", DefaultConsulEndpoint, false},
+		{"missing key", map[string]string{"CONFIG_SOURCE": "etcd"}, false, "", "", true},
+		{"invalid format", map[string]string{"CONFIG_SOURCE": "etcd", "CONFIG_REMOTE_KEY": "k", "CONFIG_FORMAT": "xml"}, false, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"CONFIG_SOURCE", "CONFIG_REMOTE_ENDPOINT", "CONFIG_REMOTE_KEY", "CONFIG_FORMAT"} {
+				t.Setenv(name, tt.env[name])
+			}
+			src, err := RemoteSourceFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RemoteSourceFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if (src == nil) != tt.wantNil {
+				t.Fatalf("RemoteSourceFromEnv() = %v, want nil %v", src, tt.wantNil)
+			}
+			if src != nil && (src.Format != tt.wantFormat || src.Endpoint != tt.wantURL) {
+				t.Errorf("RemoteSourceFromEnv() format = %q, endpoint = %q", src.Format, src.Endpoint)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+var ErrUnknownConfigKey = errors.New("unknown configuration key")
+
+// schemaEnums are the allowed values of settings checked against a list, by dotted key;
+// "*" stands for any map key or list element
+var schemaEnums = map[string][]string{
+	"server.tls.min_version":                append([]string{""}, ValidTLSVersions...),
+	"vad.provider":                          ValidVADTypes,
+	"recognition.mode":                      append([]string{""}, ValidRecognitionModes...),
+	"recognition.streaming.decoding_method": ValidDecodingMethods,
+	"recognition.models.*.type":             ValidModelTypes,
+	"response.send_mode":                    ValidSendModes,
+	"speaker.storage":                       append([]string{""}, ValidSpeakerStorages...),
+	"speaker.audit.sink":                    append([]string{""}, ValidAuditSinks...),
+	"logging.level":                         ValidLogLevels,
+	"logging.format":                        ValidLogFormats,
+	"logging.output":                        ValidLogOutputs,
+}
+
+// ============================================================================
+// JSON Schema
+// ============================================================================
+
+// Schema returns a JSON Schema (draft 2020-12) of the configuration file with the default
+// values. Unknown keys are rejected, so editors flag typos while the file is written.
+func Schema() map[string]interface{} {
+	defaults := viper.New()
+	setDefaults(defaults)
+
+	schema := typeSchema(reflect.TypeOf(Config{}), "", defaults)
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "asr_server configuration"
+	return schema
+}
+
+func typeSchema(t reflect.Type, key string, defaults *viper.Viper) map[string]interface{} {
+	schema := make(map[string]interface{})
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := make(map[string]interface{}, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("mapstructure"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			properties[name] = typeSchema(t.Field(i).Type, joinKey(key, name), defaults)
+		}
+		schema["type"] = "object"
+		schema["properties"] = properties
+		schema["additionalProperties"] = false
+		return schema
+	case reflect.Map:
+		schema["type"] = "object"
+		schema["additionalProperties"] = typeSchema(t.Elem(), joinKey(key, "*"), defaults)
+	case reflect.Slice, reflect.Array:
+		schema["type"] = "array"
+		schema["items"] = typeSchema(t.Elem(), joinKey(key, "*"), defaults)
+	case reflect.Bool:
+		schema["type"] = "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		schema["type"] = "integer"
+	case reflect.Float32, reflect.Float64:
+		schema["type"] = "number"
+	case reflect.String:
+		schema["type"] = "string"
+	}
+
+	if enum, ok := schemaEnums[key]; ok {
+		schema["enum"] = enum
+	}
+	if !strings.Contains(key, "*") {
+		if value := defaults.Get(key); value != nil {
+			schema["default"] = value
+		}
+	}
+	return schema
+}
+
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// ============================================================================
+// Unknown Keys
+// ============================================================================
+
+// unknownKeys returns the keys read into v that are not settings, each with the closest
+// setting name when one is likely meant, e.g. "vad.silero_vad.theshold (did you mean threshold?)"
+func unknownKeys(v *viper.Viper) []string {
+	var unknown []string
+	for _, key := range v.AllKeys() {
+		unknown = append(unknown, checkKey(reflect.TypeOf(Config{}), strings.Split(key, "."), v.Get(key), "")...)
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// checkKey checks the remaining parts of a key against type t. Lists are read as a single
+// value, so the keys of their elements are checked from value.
+func checkKey(t reflect.Type, parts []string, value interface{}, prefix string) []string {
+	if len(parts) == 0 {
+		items, ok := value.([]interface{})
+		if !ok || t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Struct {
+			return nil
+		}
+		var unknown []string
+		for i, item := range items {
+			fields, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for name, fieldValue := range fields {
+				unknown = append(unknown, checkKey(t.Elem(), []string{strings.ToLower(name)}, fieldValue, fmt.Sprintf("%s[%d]", prefix, i))...)
+			}
+		}
+		return unknown
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		var names []string
+		for i := 0; i < t.NumField(); i++ {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("mapstructure"), ",")
+			if name == parts[0] {
+				return checkKey(t.Field(i).Type, parts[1:], value, joinKey(prefix, name))
+			}
+			names = append(names, name)
+		}
+		key := joinKey(prefix, parts[0])
+		if suggestion := closestName(parts[0], names); suggestion != "" {
+			key += fmt.Sprintf(" (did you mean %s?)", suggestion)
+		}
+		return []string{key}
+	case reflect.Map:
+		return checkKey(t.Elem(), parts[1:], value, joinKey(prefix, parts[0]))
+	default:
+		return []string{joinKey(prefix, strings.Join(parts, "."))}
+	}
+}
+
+// closestName returns the name within edit distance 2 of s, if any
+func closestName(s string, names []string) string {
+	best, bestDistance := "", 3
+	for _, name := range names {
+		if d := editDistance(s, name); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// checkUnknownKeys reports keys that are not settings. They are ignored with a warning, or
+// fail loading when the CONFIG_STRICT environment variable is true.
+func checkUnknownKeys(v *viper.Viper) error {
+	unknown := unknownKeys(v)
+	if len(unknown) == 0 {
+		return nil
+	}
+	if strict, _ := strconv.ParseBool(os.Getenv("CONFIG_STRICT")); strict {
+		return fmt.Errorf("%w: %s", ErrUnknownConfigKey, strings.Join(unknown, ", "))
+	}
+	for _, key := range unknown {
+		fmt.Printf("[WARN] Unknown configuration key ignored: %s\n", key)
+	}
+	return nil
+}
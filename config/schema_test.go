@@ -0,0 +1,97 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want []string
+	}{
+		{"known keys", "vad:\n  silero_vad:\n    threshold: 0.5\nrecognition:\n  models:\n    en:\n      type: whisper\n", nil},
+		{"typo", "vad:\n  silero_vad:\n    theshold: 0.5\n", []string{"vad.silero_vad.theshold (did you mean threshold?)"}},
+		{"unknown section", "metrics:\n  enabled: true\n", []string{"metrics"}},
+		{"key below a setting", "logging:\n  level:\n    root: debug\n", []string{"logging.level.root"}},
+		{"map entry", "recognition:\n  models:\n    en:\n      modle_path: a.onnx\n", []string{"recognition.models.en.modle_path (did you mean model_path?)"}},
+		{"list element", "session:\n  quotas:\n    - api_key: k\n      max_duraton: 10\n", []string{"session.quotas[0].max_duraton (did you mean max_duration?)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := viper.New()
+			v.SetConfigType("yaml")
+			if err := v.ReadConfig(strings.NewReader(tt.yaml)); err != nil {
+				t.Fatal(err)
+			}
+			if got := unknownKeys(v); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unknownKeys() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadStrict(t *testing.T) {
+	t.Setenv("CONFIG_SOURCE", "")
+	t.Setenv("CONFIG_FORMAT", "")
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"vad": {"theshold": 0.7}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("CONFIG_STRICT", "")
+	if _, err := Load(path); err != nil {
+		t.Errorf("Load() without CONFIG_STRICT error = %v", err)
+	}
+	t.Setenv("CONFIG_STRICT", "true")
+	if _, err := Load(path); !errors.Is(err, ErrUnknownConfigKey) {
+		t.Errorf("Load() with CONFIG_STRICT error = %v, want %v", err, ErrUnknownConfigKey)
+	}
+
+	// The shipped configuration uses known keys only
+	t.Setenv("CONFIG_STRICT", "")
+	v := viper.New()
+	v.SetConfigFile(filepath.Join("..", "config.json"))
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if unknown := unknownKeys(v); len(unknown) > 0 {
+		t.Errorf("config.json has unknown keys: %v", unknown)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	schema := Schema()
+	property := func(path ...string) map[string]interface{} {
+		node := schema
+		for _, name := range path {
+			node = node["properties"].(map[string]interface{})[name].(map[string]interface{})
+		}
+		return node
+	}
+
+	if schema["additionalProperties"] != false || property("vad")["additionalProperties"] != false {
+		t.Error("objects should reject unknown keys")
+	}
+	threshold := property("vad", "silero_vad", "threshold")
+	if threshold["type"] != "number" || threshold["default"] == nil {
+		t.Errorf("vad.silero_vad.threshold = %v", threshold)
+	}
+	if provider := property("vad", "provider"); !reflect.DeepEqual(provider["enum"], ValidVADTypes) {
+		t.Errorf("vad.provider enum = %v", provider["enum"])
+	}
+	if port := property("server", "port"); port["type"] != "integer" || port["default"] != DefaultServerPort {
+		t.Errorf("server.port = %v", port)
+	}
+	models := property("recognition", "models")["additionalProperties"].(map[string]interface{})
+	if modelType := models["properties"].(map[string]interface{})["type"].(map[string]interface{}); !reflect.DeepEqual(modelType["enum"], ValidModelTypes) {
+		t.Errorf("recognition.models.*.type enum = %v", modelType["enum"])
+	}
+}
@@ -0,0 +1,158 @@
+// Package adminevents streams structured server events to admin dashboards
+package adminevents
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"asr_server/internal/logger"
+	"asr_server/internal/session"
+)
+
+// Event types
+const (
+	TypeSessionOpened   = "session_opened"
+	TypeSessionClosed   = "session_closed"
+	TypePoolExhausted   = "pool_exhausted"
+	TypeConfigReloaded  = "config_reloaded"
+	TypeModelReloaded   = "model_reloaded"
+	TypeVADPoolReloaded = "vad_pool_reloaded"
+	TypeError           = "error"
+)
+
+// subscriberBuffer is the number of events queued for a subscriber before it is dropped
+const subscriberBuffer = 256
+
+// exhaustionMessages are the warnings logged when a pool or queue runs out of capacity
+var exhaustionMessages = map[string]string{
+	"silero_vad_pool_timeout":              "vad",
+	"silero_vad_pool_max_retries_exceeded": "vad",
+	"ten_vad_pool_timeout":                 "vad",
+	"recognition_queue_full":               "recognition_queue",
+}
+
+// Event is a server event sent to admin dashboards
+type Event struct {
+	ID        uint64                 `json:"id"` // Increases by one per event, for resuming with since
+	Type      string                 `json:"type"`
+	Timestamp int64                  `json:"timestamp"` // Unix milliseconds
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// Hub fans events out to subscribers and keeps the latest ones in a ring buffer, so a
+// dashboard connecting late still sees recent history. A nil *Hub discards events.
+type Hub struct {
+	mu          sync.Mutex
+	nextID      uint64
+	history     []Event // Ring buffer of the latest events
+	start       int     // Index of the oldest event in history
+	size        int
+	subscribers map[chan Event]struct{}
+}
+
+// NewHub creates a hub keeping up to historySize events for late joiners
+func NewHub(historySize int) *Hub {
+	return &Hub{
+		nextID:      1,
+		history:     make([]Event, historySize),
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Publish records an event and sends it to every subscriber. A subscriber too slow to keep
+// up is dropped, closing its channel, rather than blocking the caller.
+func (h *Hub) Publish(eventType string, data map[string]interface{}) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	event := Event{ID: h.nextID, Type: eventType, Timestamp: time.Now().UnixMilli(), Data: data}
+	h.nextID++
+	if len(h.history) > 0 {
+		if h.size < len(h.history) {
+			h.history[(h.start+h.size)%len(h.history)] = event
+			h.size++
+		} else {
+			h.history[h.start] = event
+			h.start = (h.start + 1) % len(h.history)
+		}
+	}
+
+	for ch := range h.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(h.subscribers, ch)
+			close(ch)
+			// Debug only: the hub observes warnings, so logging one here would recurse
+			logger.Debug("admin_event_subscriber_dropped", "event_id", event.ID)
+		}
+	}
+}
+
+// Subscribe returns the buffered events with an ID above since, and a channel receiving every
+// later event. The channel is closed when the subscriber falls behind; call unsubscribe once
+// done reading.
+func (h *Hub) Subscribe(since uint64) (history []Event, events <-chan Event, unsubscribe func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i := 0; i < h.size; i++ {
+		if event := h.history[(h.start+i)%len(h.history)]; event.ID > since {
+			history = append(history, event)
+		}
+	}
+	ch := make(chan Event, subscriberBuffer)
+	h.subscribers[ch] = struct{}{}
+	return history, ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, subscribed := h.subscribers[ch]; subscribed {
+			delete(h.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// ObserveLog turns logged warnings and errors into events: pool and queue exhaustion warnings
+// become pool_exhausted events and every error an error event. It is installed with
+// logger.SetObserver.
+func (h *Hub) ObserveLog(level slog.Level, msg string, attrs map[string]interface{}) {
+	if resource, exhausted := exhaustionMessages[msg]; exhausted {
+		attrs["resource"] = resource
+		attrs["message"] = msg
+		h.Publish(TypePoolExhausted, attrs)
+		return
+	}
+	if level >= slog.LevelError {
+		attrs["message"] = msg
+		h.Publish(TypeError, attrs)
+	}
+}
+
+// OnSessionStart publishes session_opened
+func (h *Hub) OnSessionStart(sessionID string) {
+	h.Publish(TypeSessionOpened, map[string]interface{}{"session_id": sessionID})
+}
+
+// OnSegment is not published; segments are too frequent for a dashboard feed
+func (h *Hub) OnSegment(session.SegmentEvent) {}
+
+// OnResult is not published; results go to the Kafka and NATS publishers
+func (h *Hub) OnResult(*session.ResultEvent) {}
+
+// OnSessionEnd publishes session_closed with the session's final statistics
+func (h *Hub) OnSessionEnd(info session.SessionInfo, reason session.CloseReason) {
+	h.Publish(TypeSessionClosed, map[string]interface{}{
+		"session_id":     info.ID,
+		"reason":         reason.String(),
+		"remote_addr":    info.RemoteAddr,
+		"uptime_seconds": info.UptimeSeconds,
+		"bytes_received": info.BytesReceived,
+		"segments":       info.Segments,
+		"results":        info.Results,
+	})
+}
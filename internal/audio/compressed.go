@@ -0,0 +1,29 @@
+package audio
+
+import (
+	"errors"
+	"io"
+	"path"
+	"slices"
+	"strings"
+)
+
+// ErrCompressedUnsupported is returned for MP3, FLAC and Ogg Vorbis files when the decoders are not compiled in
+var ErrCompressedUnsupported = errors.New("MP3/FLAC/Ogg decoding not compiled in (rebuild with -tags codecs)")
+
+// CompressedExtensions lists the file extensions decoded by DecodeCompressed
+var CompressedExtensions = []string{".mp3", ".flac", ".ogg", ".oga"}
+
+// IsCompressedFile reports whether filename has an MP3, FLAC or Ogg Vorbis extension
+func IsCompressedFile(filename string) bool {
+	return slices.Contains(CompressedExtensions, strings.ToLower(path.Ext(filename)))
+}
+
+// DecodeFile decodes an audio file, choosing the decoder by the extension of filename.
+// MP3, FLAC and Ogg Vorbis files are decoded with DecodeCompressed; anything else as WAV.
+func DecodeFile(r io.ReadSeeker, filename string) (*Audio, error) {
+	if IsCompressedFile(filename) {
+		return DecodeCompressed(r, filename)
+	}
+	return DecodeWAV(r)
+}
@@ -0,0 +1,10 @@
+//go:build !codecs
+
+package audio
+
+import "io"
+
+// DecodeCompressed always fails without the "codecs" build tag
+func DecodeCompressed(r io.Reader, filename string) (*Audio, error) {
+	return nil, ErrCompressedUnsupported
+}
@@ -0,0 +1,60 @@
+package audio
+
+// DecodeMuLaw decodes G.711 μ-law bytes into samples in [-1, 1]
+func DecodeMuLaw(data []byte) []float32 {
+	out := make([]float32, len(data))
+	DecodeMuLawInto(out, data)
+	return out
+}
+
+// DecodeMuLawInto decodes G.711 μ-law bytes into dst, which must hold len(data) samples
+func DecodeMuLawInto(dst []float32, data []byte) {
+	for i, b := range data {
+		dst[i] = float32(muLawToLinear(b)) / 32768
+	}
+}
+
+// DecodeALaw decodes G.711 A-law bytes into samples in [-1, 1]
+func DecodeALaw(data []byte) []float32 {
+	out := make([]float32, len(data))
+	DecodeALawInto(out, data)
+	return out
+}
+
+// DecodeALawInto decodes G.711 A-law bytes into dst, which must hold len(data) samples
+func DecodeALawInto(dst []float32, data []byte) {
+	for i, b := range data {
+		dst[i] = float32(aLawToLinear(b)) / 32768
+	}
+}
+
+// muLawToLinear expands a μ-law byte to 16-bit linear PCM (ITU-T G.711)
+func muLawToLinear(b byte) int16 {
+	b = ^b
+	t := (int32(b&0x0f) << 3) + 0x84
+	t <<= (b & 0x70) >> 4
+	if b&0x80 != 0 {
+		return int16(0x84 - t)
+	}
+	return int16(t - 0x84)
+}
+
+// aLawToLinear expands an A-law byte to 16-bit linear PCM (ITU-T G.711)
+func aLawToLinear(b byte) int16 {
+	b ^= 0x55
+	t := int32(b&0x0f) << 4
+	seg := (b & 0x70) >> 4
+	switch seg {
+	case 0:
+		t += 8
+	case 1:
+		t += 0x108
+	default:
+		t += 0x108
+		t <<= seg - 1
+	}
+	if b&0x80 != 0 {
+		return int16(t)
+	}
+	return int16(-t)
+}
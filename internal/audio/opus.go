@@ -0,0 +1,6 @@
+package audio
+
+import "errors"
+
+// ErrOpusUnsupported is returned when the binary was built without the "opus" build tag
+var ErrOpusUnsupported = errors.New("opus decoding not compiled in (rebuild with -tags opus)")
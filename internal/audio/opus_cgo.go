@@ -0,0 +1,66 @@
+//go:build opus
+
+package audio
+
+/*
+#cgo pkg-config: opus
+#include <opus.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// maxOpusFrameSamples is the largest frame Opus can produce per channel (120ms at 48kHz)
+const maxOpusFrameSamples = 5760
+
+// OpusDecoder decodes Opus packets into float32 PCM using libopus
+type OpusDecoder struct {
+	decoder  *C.OpusDecoder
+	channels int
+	buf      []float32
+}
+
+// NewOpusDecoder creates a decoder producing samples at sampleRate with the given channel count
+func NewOpusDecoder(sampleRate, channels int) (*OpusDecoder, error) {
+	var errCode C.int
+	decoder := C.opus_decoder_create(C.opus_int32(sampleRate), C.int(channels), &errCode)
+	if errCode != C.OPUS_OK || decoder == nil {
+		return nil, fmt.Errorf("failed to create opus decoder: %s", C.GoString(C.opus_strerror(errCode)))
+	}
+	return &OpusDecoder{
+		decoder:  decoder,
+		channels: channels,
+		buf:      make([]float32, maxOpusFrameSamples*channels),
+	}, nil
+}
+
+// Decode decodes a single Opus packet and returns interleaved samples.
+// The returned slice is reused by the next call.
+func (d *OpusDecoder) Decode(packet []byte) ([]float32, error) {
+	if len(packet) == 0 {
+		return nil, nil
+	}
+	n := C.opus_decode_float(
+		d.decoder,
+		(*C.uchar)(unsafe.Pointer(&packet[0])),
+		C.opus_int32(len(packet)),
+		(*C.float)(unsafe.Pointer(&d.buf[0])),
+		C.int(maxOpusFrameSamples),
+		0,
+	)
+	if n < 0 {
+		return nil, fmt.Errorf("opus decode failed: %s", C.GoString(C.opus_strerror(n)))
+	}
+	return d.buf[:int(n)*d.channels], nil
+}
+
+// Close releases the native decoder
+func (d *OpusDecoder) Close() {
+	if d.decoder != nil {
+		C.opus_decoder_destroy(d.decoder)
+		d.decoder = nil
+	}
+}
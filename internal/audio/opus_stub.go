@@ -0,0 +1,19 @@
+//go:build !opus
+
+package audio
+
+// OpusDecoder is unavailable without the "opus" build tag
+type OpusDecoder struct{}
+
+// NewOpusDecoder always fails without the "opus" build tag
+func NewOpusDecoder(sampleRate, channels int) (*OpusDecoder, error) {
+	return nil, ErrOpusUnsupported
+}
+
+// Decode always fails without the "opus" build tag
+func (d *OpusDecoder) Decode(packet []byte) ([]float32, error) {
+	return nil, ErrOpusUnsupported
+}
+
+// Close is a no-op without the "opus" build tag
+func (d *OpusDecoder) Close() {}
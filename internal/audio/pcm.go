@@ -0,0 +1,28 @@
+package audio
+
+import "math"
+
+// Float32ToPCM16 encodes samples in [-1, 1] as 16-bit little-endian PCM, clipping out-of-range values
+func Float32ToPCM16(samples []float32) []byte {
+	out := make([]byte, len(samples)*2)
+	for i, s := range samples {
+		v := int32(math.Round(float64(s) * 32767))
+		if v > math.MaxInt16 {
+			v = math.MaxInt16
+		} else if v < math.MinInt16 {
+			v = math.MinInt16
+		}
+		out[i*2] = byte(v)
+		out[i*2+1] = byte(v >> 8)
+	}
+	return out
+}
+
+// PCM16ToFloat32 decodes 16-bit little-endian PCM into samples in [-1, 1]
+func PCM16ToFloat32(data []byte) []float32 {
+	samples := make([]float32, len(data)/2)
+	for i := range samples {
+		samples[i] = float32(int16(data[i*2])|int16(data[i*2+1])<<8) / 32768
+	}
+	return samples
+}
@@ -0,0 +1,41 @@
+package audio
+
+// Resample converts mono samples from one sample rate to another using linear interpolation.
+// The input slice is returned unchanged when the rates already match.
+func Resample(samples []float32, fromRate, toRate int) []float32 {
+	if fromRate == toRate || fromRate <= 0 || toRate <= 0 || len(samples) == 0 {
+		return samples
+	}
+	out := make([]float32, ResampledLen(len(samples), fromRate, toRate))
+	ResampleInto(out, samples, fromRate, toRate)
+	return out
+}
+
+// ResampledLen returns the number of samples Resample produces from n samples
+func ResampledLen(n, fromRate, toRate int) int {
+	if fromRate == toRate || fromRate <= 0 || toRate <= 0 {
+		return n
+	}
+	return int(int64(n) * int64(toRate) / int64(fromRate))
+}
+
+// ResampleInto resamples into dst, which must hold ResampledLen(len(samples), fromRate, toRate)
+// samples, so callers can reuse output buffers. The rates must differ and be positive.
+func ResampleInto(dst, samples []float32, fromRate, toRate int) {
+	if len(samples) == 0 {
+		return
+	}
+	step := float64(fromRate) / float64(toRate)
+	last := len(samples) - 1
+
+	for i := range dst {
+		pos := float64(i) * step
+		idx := int(pos)
+		if idx >= last {
+			dst[i] = samples[last]
+			continue
+		}
+		frac := float32(pos - float64(idx))
+		dst[i] = samples[idx] + (samples[idx+1]-samples[idx])*frac
+	}
+}
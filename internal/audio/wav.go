@@ -0,0 +1,111 @@
+package audio
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/go-audio/wav"
+)
+
+// Audio holds decoded PCM audio normalized to [-1, 1].
+type Audio struct {
+	SampleRate  int
+	NumChannels int
+	// Samples holds interleaved samples for all channels
+	Samples []float32
+}
+
+// Duration returns the audio duration in seconds
+func (a *Audio) Duration() float64 {
+	if a.SampleRate <= 0 || a.NumChannels <= 0 {
+		return 0
+	}
+	return float64(len(a.Samples)/a.NumChannels) / float64(a.SampleRate)
+}
+
+// Mono returns the audio downmixed to a single channel
+func (a *Audio) Mono() []float32 {
+	if a.NumChannels <= 1 {
+		return a.Samples
+	}
+
+	frames := len(a.Samples) / a.NumChannels
+	mono := make([]float32, frames)
+	for i := 0; i < frames; i++ {
+		var sum float32
+		for ch := 0; ch < a.NumChannels; ch++ {
+			sum += a.Samples[i*a.NumChannels+ch]
+		}
+		mono[i] = sum / float32(a.NumChannels)
+	}
+	return mono
+}
+
+// Channel returns the samples of a single channel
+func (a *Audio) Channel(ch int) []float32 {
+	if a.NumChannels <= 1 {
+		return a.Samples
+	}
+
+	frames := len(a.Samples) / a.NumChannels
+	out := make([]float32, frames)
+	for i := 0; i < frames; i++ {
+		out[i] = a.Samples[i*a.NumChannels+ch]
+	}
+	return out
+}
+
+// DecodeWAV decodes a WAV stream into normalized float32 samples
+func DecodeWAV(r io.ReadSeeker) (*Audio, error) {
+	decoder := wav.NewDecoder(r)
+	if !decoder.IsValidFile() {
+		return nil, fmt.Errorf("invalid WAV file")
+	}
+
+	buffer, err := decoder.FullPCMBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode audio: %v", err)
+	}
+
+	numChannels := int(decoder.NumChans)
+	if numChannels <= 0 {
+		return nil, fmt.Errorf("invalid number of channels: %d", numChannels)
+	}
+
+	bitDepth := int(decoder.BitDepth)
+	if bitDepth != 8 && bitDepth != 16 && bitDepth != 24 && bitDepth != 32 {
+		return nil, fmt.Errorf("unsupported bit depth: %d", bitDepth)
+	}
+
+	samples := make([]float32, len(buffer.Data))
+	switch format := decoder.WavAudioFormat; {
+	case format == WAVFormatMuLaw || format == WAVFormatALaw:
+		if bitDepth != 8 {
+			return nil, fmt.Errorf("unsupported G.711 bit depth: %d", bitDepth)
+		}
+		// The 8-bit samples are companded bytes rather than unsigned PCM
+		expand := muLawToLinear
+		if format == WAVFormatALaw {
+			expand = aLawToLinear
+		}
+		for i, sample := range buffer.Data {
+			samples[i] = float32(expand(byte(sample))) / 32768
+		}
+	case bitDepth == 8:
+		// 8-bit WAV samples are unsigned
+		for i, sample := range buffer.Data {
+			samples[i] = float32(sample-128) / 128.0
+		}
+	default:
+		normalizeFactor := float32(int64(1) << (bitDepth - 1))
+		for i, sample := range buffer.Data {
+			samples[i] = float32(sample) / normalizeFactor
+		}
+	}
+
+	return &Audio{
+		SampleRate:  int(decoder.SampleRate),
+		NumChannels: numChannels,
+		Samples:     samples,
+	}, nil
+}
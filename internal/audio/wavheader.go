@@ -0,0 +1,107 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// WAV format tags
+const (
+	WAVFormatPCM        = 1
+	WAVFormatFloat      = 3
+	WAVFormatALaw       = 6
+	WAVFormatMuLaw      = 7
+	wavFormatExtensible = 0xFFFE
+)
+
+// WAVHeader describes the audio in a WAV stream
+type WAVHeader struct {
+	// Format is the WAV format tag, with WAVE_FORMAT_EXTENSIBLE resolved to its sub-format
+	Format      int
+	SampleRate  int
+	NumChannels int
+	BitDepth    int
+}
+
+// IsWAVHeader reports whether data starts with a RIFF/WAVE header
+func IsWAVHeader(data []byte) bool {
+	return len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WAVE"
+}
+
+// ParseWAVHeader parses the chunks of a WAV stream up to the start of the data chunk and
+// returns the header and the offset of the first audio byte. The fmt and data chunk headers
+// must be contained in data.
+func ParseWAVHeader(data []byte) (*WAVHeader, int, error) {
+	if !IsWAVHeader(data) {
+		return nil, 0, fmt.Errorf("missing RIFF/WAVE header")
+	}
+
+	var header *WAVHeader
+	offset := 12
+	for offset+8 <= len(data) {
+		id := string(data[offset : offset+4])
+		size := int(binary.LittleEndian.Uint32(data[offset+4 : offset+8]))
+		body := offset + 8
+
+		switch id {
+		case "fmt ":
+			if size < 16 || body+size > len(data) {
+				return nil, 0, fmt.Errorf("truncated fmt chunk")
+			}
+			chunk := data[body : body+size]
+			header = &WAVHeader{
+				Format:      int(binary.LittleEndian.Uint16(chunk[0:2])),
+				NumChannels: int(binary.LittleEndian.Uint16(chunk[2:4])),
+				SampleRate:  int(binary.LittleEndian.Uint32(chunk[4:8])),
+				BitDepth:    int(binary.LittleEndian.Uint16(chunk[14:16])),
+			}
+			// The sub-format GUID of WAVE_FORMAT_EXTENSIBLE starts with the actual format tag
+			if header.Format == wavFormatExtensible && size >= 26 {
+				header.Format = int(binary.LittleEndian.Uint16(chunk[24:26]))
+			}
+		case "data":
+			if header == nil {
+				return nil, 0, fmt.Errorf("data chunk before fmt chunk")
+			}
+			return header, body, nil
+		}
+
+		// Chunks are padded to an even size
+		offset = body + size + size%2
+	}
+	return nil, 0, fmt.Errorf("incomplete WAV header: data chunk not found")
+}
+
+// DecodePCM converts linear PCM in the given byte order to interleaved samples in [-1, 1].
+// 8-bit samples are unsigned; 32-bit samples are IEEE floats when float is set.
+// A trailing partial sample is ignored.
+func DecodePCM(data []byte, bitDepth int, float bool, order binary.ByteOrder) []float32 {
+	width := bitDepth / 8
+	if width <= 0 {
+		return nil
+	}
+
+	out := make([]float32, len(data)/width)
+	for i := range out {
+		b := data[i*width:]
+		switch {
+		case width == 1:
+			out[i] = float32(int(b[0])-128) / 128
+		case width == 2:
+			out[i] = float32(int16(order.Uint16(b))) / 32768
+		case width == 3:
+			lo, hi := b[0], b[2]
+			if order == binary.BigEndian {
+				lo, hi = hi, lo
+			}
+			v := int32(lo) | int32(b[1])<<8 | int32(int8(hi))<<16
+			out[i] = float32(v) / 8388608
+		case float:
+			out[i] = math.Float32frombits(order.Uint32(b))
+		default:
+			out[i] = float32(int32(order.Uint32(b))) / 2147483648
+		}
+	}
+	return out
+}
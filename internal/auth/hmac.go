@@ -0,0 +1,249 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"asr_server/config"
+)
+
+// Headers of an HMAC-signed request
+const (
+	HeaderKeyID     = "X-Signature-Key-Id"
+	HeaderTimestamp = "X-Signature-Timestamp" // Unix seconds
+	HeaderNonce     = "X-Signature-Nonce"
+	HeaderSignature = "X-Signature" // Hex HMAC-SHA256 of the string to sign
+)
+
+// Request signing errors
+var (
+	ErrMissingSignature = errors.New("missing signature headers")
+	ErrUnknownKeyID     = errors.New("unknown signing key")
+	ErrStaleTimestamp   = errors.New("signature timestamp is outside the allowed skew")
+	ErrReplayedNonce    = errors.New("nonce has already been used")
+	ErrNonceCacheFull   = errors.New("too many signed requests within the allowed skew")
+	ErrBodyTooLarge     = errors.New("signed request body is too large")
+)
+
+const (
+	// maxNonceLength bounds the nonces kept in the replay cache
+	maxNonceLength = 128
+	// spoolMemory is the part of a signed body kept in memory; the rest goes to a temporary file
+	spoolMemory = 1 << 20
+)
+
+// HMACVerifier authenticates requests signed with a shared secret. The signature covers
+//
+//	timestamp + "\n" + nonce + "\n" + method + "\n" + request URI + "\n" + hex(SHA-256(body))
+//
+// Each nonce is accepted once within the allowed timestamp skew, so captured requests cannot
+// be replayed.
+type HMACVerifier struct {
+	keys        map[string]config.HMACKey
+	maxSkew     time.Duration
+	maxBodySize int64
+	nonces      *nonceCache
+	now         func() time.Time
+}
+
+// NewHMACVerifier creates a verifier for the configured signing keys
+func NewHMACVerifier(cfg config.HMACAuthConfig) *HMACVerifier {
+	maxSkew := time.Duration(cfg.MaxSkew) * time.Second
+	if maxSkew <= 0 {
+		maxSkew = time.Duration(config.DefaultHMACMaxSkew) * time.Second
+	}
+	cacheSize := cfg.NonceCacheSize
+	if cacheSize <= 0 {
+		cacheSize = config.DefaultHMACNonceCacheSize
+	}
+
+	keys := make(map[string]config.HMACKey, len(cfg.Keys))
+	for _, key := range cfg.Keys {
+		keys[key.KeyID] = key
+	}
+	return &HMACVerifier{
+		keys:        keys,
+		maxSkew:     maxSkew,
+		maxBodySize: cfg.MaxBodySize,
+		// A nonce must be remembered for as long as its timestamp is accepted
+		nonces: newNonceCache(cacheSize, 2*maxSkew),
+		now:    time.Now,
+	}
+}
+
+// IsSigned reports whether the request carries a signature, as opposed to a bearer token
+func IsSigned(r *http.Request) bool {
+	return r.Header.Get(HeaderSignature) != ""
+}
+
+// Verify checks the request's signature and returns the signing key ID. The body is consumed
+// to compute its digest; Verify returns a copy for the caller to install as the request body
+// and close once the request is handled, which removes any temporary file.
+func (v *HMACVerifier) Verify(r *http.Request) (string, io.ReadCloser, error) {
+	keyID := r.Header.Get(HeaderKeyID)
+	timestamp := r.Header.Get(HeaderTimestamp)
+	nonce := r.Header.Get(HeaderNonce)
+	signature, err := hex.DecodeString(r.Header.Get(HeaderSignature))
+	if keyID == "" || timestamp == "" || nonce == "" || err != nil || len(signature) == 0 {
+		return "", nil, ErrMissingSignature
+	}
+	if len(nonce) > maxNonceLength {
+		return "", nil, fmt.Errorf("nonce longer than %d bytes", maxNonceLength)
+	}
+	key, ok := v.keys[keyID]
+	if !ok {
+		return "", nil, ErrUnknownKeyID
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid %s header", HeaderTimestamp)
+	}
+	if skew := v.now().Sub(time.Unix(seconds, 0)); skew > v.maxSkew || skew < -v.maxSkew {
+		return "", nil, ErrStaleTimestamp
+	}
+
+	body, digest, err := spoolBody(r.Body, v.maxBodySize)
+	if err != nil {
+		return "", nil, err
+	}
+
+	mac := hmac.New(sha256.New, []byte(key.Secret))
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s\n%s", timestamp, nonce, r.Method, r.URL.RequestURI(), hex.EncodeToString(digest))
+	if !hmac.Equal(mac.Sum(nil), signature) {
+		body.Close()
+		return "", nil, ErrInvalidSignature
+	}
+	// Only remember nonces of valid signatures, so forged requests cannot fill the cache
+	if err := v.nonces.add(keyID+":"+nonce, v.now()); err != nil {
+		body.Close()
+		return "", nil, err
+	}
+	return keyID, body, nil
+}
+
+// Scopes returns the scopes granted to a signing key
+func (v *HMACVerifier) Scopes(keyID string) []string {
+	return v.keys[keyID].Scopes
+}
+
+// spoolBody reads body, returning a replayable copy and its SHA-256 digest. Up to
+// spoolMemory bytes are kept in memory and the rest is written to a temporary file.
+func spoolBody(body io.Reader, maxSize int64) (io.ReadCloser, []byte, error) {
+	if body == nil || body == http.NoBody {
+		sum := sha256.Sum256(nil)
+		return http.NoBody, sum[:], nil
+	}
+	if maxSize > 0 {
+		body = io.LimitReader(body, maxSize+1)
+	}
+
+	hash := sha256.New()
+	var head bytes.Buffer
+	n, err := io.CopyN(io.MultiWriter(&head, hash), body, spoolMemory)
+	if err == io.EOF {
+		if maxSize > 0 && n > maxSize {
+			return nil, nil, ErrBodyTooLarge
+		}
+		return io.NopCloser(&head), hash.Sum(nil), nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read request body: %v", err)
+	}
+
+	file, err := os.CreateTemp("", "asr-signed-body-*")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to buffer request body: %v", err)
+	}
+	spooled := &tempFileBody{File: file}
+	rest, err := io.Copy(io.MultiWriter(file, hash), body)
+	if err == nil && maxSize > 0 && n+rest > maxSize {
+		err = ErrBodyTooLarge
+	}
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		spooled.Close()
+		if errors.Is(err, ErrBodyTooLarge) {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("failed to buffer request body: %v", err)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(&head, file), spooled}, hash.Sum(nil), nil
+}
+
+// tempFileBody is a spooled request body; closing it removes the file
+type tempFileBody struct {
+	*os.File
+}
+
+func (b *tempFileBody) Close() error {
+	b.File.Close()
+	return os.Remove(b.File.Name())
+}
+
+// nonceCache remembers used nonces until they expire. When full, expired entries are
+// evicted; if none have expired, new nonces are refused rather than forgetting live ones.
+type nonceCache struct {
+	mu       sync.Mutex
+	seen     map[string]time.Time // Nonce to expiry
+	capacity int
+	ttl      time.Duration
+}
+
+func newNonceCache(capacity int, ttl time.Duration) *nonceCache {
+	return &nonceCache{
+		seen:     make(map[string]time.Time),
+		capacity: capacity,
+		ttl:      ttl,
+	}
+}
+
+// add records nonce, failing if it was already used
+func (c *nonceCache) add(nonce string, now time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if expiry, found := c.seen[nonce]; found && now.Before(expiry) {
+		return ErrReplayedNonce
+	}
+	if len(c.seen) >= c.capacity {
+		for n, expiry := range c.seen {
+			if !now.Before(expiry) {
+				delete(c.seen, n)
+			}
+		}
+		if len(c.seen) >= c.capacity {
+			return ErrNonceCacheFull
+		}
+	}
+	c.seen[nonce] = now.Add(c.ttl)
+	return nil
+}
+
+type keyIDKey struct{}
+
+// WithKeyID returns a context carrying the key ID that signed the request
+func WithKeyID(ctx context.Context, keyID string) context.Context {
+	return context.WithValue(ctx, keyIDKey{}, keyID)
+}
+
+// KeyIDFromContext returns the key ID attached by WithKeyID
+func KeyIDFromContext(ctx context.Context) (string, bool) {
+	keyID, ok := ctx.Value(keyIDKey{}).(string)
+	return keyID, ok
+}
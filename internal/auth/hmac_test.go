@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"asr_server/config"
+)
+
+// signRequest sets the signature headers of r as a client holding secret would
+func signRequest(r *http.Request, keyID, secret, nonce string, timestamp time.Time, body string) {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	digest := sha256.Sum256([]byte(body))
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s\n%s", ts, nonce, r.Method, r.URL.RequestURI(), hex.EncodeToString(digest[:]))
+
+	r.Header.Set(HeaderKeyID, keyID)
+	r.Header.Set(HeaderTimestamp, ts)
+	r.Header.Set(HeaderNonce, nonce)
+	r.Header.Set(HeaderSignature, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func TestHMACVerify(t *testing.T) {
+	v := NewHMACVerifier(config.HMACAuthConfig{
+		Keys:        []config.HMACKey{{KeyID: "svc", Secret: "svc-secret", Scopes: []string{"asr:*"}}},
+		MaxSkew:     300,
+		MaxBodySize: 16,
+	})
+	v.now = func() time.Time { return testNow }
+
+	tests := []struct {
+		name    string
+		body    string
+		sign    func(r *http.Request)
+		tamper  func(r *http.Request)
+		wantErr error
+	}{
+		{
+			name: "valid",
+			body: `{"a":1}`,
+			sign: func(r *http.Request) { signRequest(r, "svc", "svc-secret", "n-valid", testNow, `{"a":1}`) },
+		},
+		{
+			name: "within skew",
+			sign: func(r *http.Request) { signRequest(r, "svc", "svc-secret", "n-skew", testNow.Add(-4*time.Minute), "") },
+		},
+		{
+			name:    "unsigned",
+			sign:    func(r *http.Request) {},
+			wantErr: ErrMissingSignature,
+		},
+		{
+			name:    "unknown key",
+			sign:    func(r *http.Request) { signRequest(r, "other", "svc-secret", "n-key", testNow, "") },
+			wantErr: ErrUnknownKeyID,
+		},
+		{
+			name:    "wrong secret",
+			sign:    func(r *http.Request) { signRequest(r, "svc", "guessed", "n-secret", testNow, "") },
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "stale timestamp",
+			sign: func(r *http.Request) {
+				signRequest(r, "svc", "svc-secret", "n-stale", testNow.Add(-10*time.Minute), "")
+			},
+			wantErr: ErrStaleTimestamp,
+		},
+		{
+			name: "future timestamp",
+			sign: func(r *http.Request) {
+				signRequest(r, "svc", "svc-secret", "n-future", testNow.Add(10*time.Minute), "")
+			},
+			wantErr: ErrStaleTimestamp,
+		},
+		{
+			name:    "tampered body",
+			body:    `{"a":2}`,
+			sign:    func(r *http.Request) { signRequest(r, "svc", "svc-secret", "n-body", testNow, `{"a":1}`) },
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "tampered nonce",
+			sign:    func(r *http.Request) { signRequest(r, "svc", "svc-secret", "n-nonce", testNow, "") },
+			tamper:  func(r *http.Request) { r.Header.Set(HeaderNonce, "n-other") },
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "body too large",
+			body: strings.Repeat("x", 17),
+			sign: func(r *http.Request) {
+				signRequest(r, "svc", "svc-secret", "n-large", testNow, strings.Repeat("x", 17))
+			},
+			wantErr: ErrBodyTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/asr/transcribe?lang=zh", strings.NewReader(tt.body))
+			tt.sign(r)
+			if tt.tamper != nil {
+				tt.tamper(r)
+			}
+
+			keyID, body, err := v.Verify(r)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			defer body.Close()
+			if keyID != "svc" {
+				t.Errorf("Verify() key ID = %q, want svc", keyID)
+			}
+			if got, _ := io.ReadAll(body); string(got) != tt.body {
+				t.Errorf("Verify() body = %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestHMACVerifyReplay(t *testing.T) {
+	v := NewHMACVerifier(config.HMACAuthConfig{
+		Keys: []config.HMACKey{{KeyID: "svc", Secret: "svc-secret"}},
+	})
+	v.now = func() time.Time { return testNow }
+
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/speaker/list", nil)
+		signRequest(r, "svc", "svc-secret", "n-1", testNow, "")
+		return r
+	}
+	if _, _, err := v.Verify(newRequest()); err != nil {
+		t.Fatalf("first Verify() error = %v", err)
+	}
+	if _, _, err := v.Verify(newRequest()); !errors.Is(err, ErrReplayedNonce) {
+		t.Fatalf("replayed Verify() error = %v, want %v", err, ErrReplayedNonce)
+	}
+}
+
+func TestNonceCache(t *testing.T) {
+	const ttl = time.Minute
+
+	tests := []struct {
+		name    string
+		adds    []string // Nonces added at testNow before the checked one
+		nonce   string   // Nonce added at testNow + after
+		after   time.Duration
+		wantErr error
+	}{
+		{"new nonce", []string{"a"}, "b", 0, nil},
+		{"replayed nonce", []string{"a"}, "a", 0, ErrReplayedNonce},
+		{"replayed after expiry", []string{"a"}, "a", ttl, nil},
+		{"full with live nonces", []string{"a", "b"}, "c", time.Second, ErrNonceCacheFull},
+		{"full with expired nonces", []string{"a", "b"}, "c", ttl, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := newNonceCache(2, ttl)
+			for _, nonce := range tt.adds {
+				if err := cache.add(nonce, testNow); err != nil {
+					t.Fatalf("add(%q) error = %v", nonce, err)
+				}
+			}
+			if err := cache.add(tt.nonce, testNow.Add(tt.after)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("add(%q) error = %v, want %v", tt.nonce, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"asr_server/internal/logger"
+)
+
+// ErrUnknownKey is returned when no published key matches a token's key ID
+var ErrUnknownKey = errors.New("no signing key matches the token")
+
+const (
+	// jwksFetchTimeout bounds fetching the key set or the OpenID configuration
+	jwksFetchTimeout = 10 * time.Second
+	// jwksMinRefetch spaces out fetches triggered by unknown key IDs, so tokens with made-up
+	// key IDs cannot flood the identity provider
+	jwksMinRefetch = 10 * time.Second
+	// jwksMaxBody bounds the size of key set and OpenID configuration documents
+	jwksMaxBody = 1 << 20
+)
+
+// jwksCache holds the signing keys published by the identity provider. Keys are refetched
+// after the refresh interval, and early when a token names a key ID not seen yet, as
+// happens after the provider rotates its keys. A single fetch runs at a time, without the
+// lock held, so requests with cached keys never wait for the identity provider.
+type jwksCache struct {
+	issuer  string
+	refresh time.Duration
+	client  *http.Client
+
+	mu          sync.Mutex
+	url         string // Empty until discovered from the issuer
+	keys        map[string]crypto.PublicKey
+	fetched     time.Time
+	lastAttempt time.Time
+	fetching    chan struct{} // Closed when the fetch in flight completes, nil when idle
+}
+
+func newJWKSCache(url, issuer string, refresh time.Duration) *jwksCache {
+	return &jwksCache{
+		url:     url,
+		issuer:  strings.TrimSuffix(issuer, "/"),
+		refresh: refresh,
+		client:  &http.Client{Timeout: jwksFetchTimeout},
+	}
+}
+
+// key returns the public key for kid. A token without a key ID is accepted when the
+// provider publishes a single key. A cached key is returned right away, starting a refresh
+// in the background when the keys are stale; an unknown key ID waits for a refresh.
+func (c *jwksCache) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	c.mu.Lock()
+	key, found := c.lookup(kid)
+	stale := time.Since(c.fetched) > c.refresh
+	var done chan struct{}
+	if stale || !found {
+		done = c.startFetch(ctx)
+	}
+	c.mu.Unlock()
+
+	if found {
+		return key, nil
+	}
+	if done == nil {
+		return nil, ErrUnknownKey
+	}
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	c.mu.Lock()
+	key, found = c.lookup(kid)
+	c.mu.Unlock()
+	if !found {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+// startFetch returns a channel closed when the fetch in flight completes, starting one if
+// none is running and the last attempt is old enough, or nil. The lock must be held.
+func (c *jwksCache) startFetch(ctx context.Context) chan struct{} {
+	if c.fetching != nil {
+		return c.fetching
+	}
+	if time.Since(c.lastAttempt) <= jwksMinRefetch {
+		return nil
+	}
+	c.lastAttempt = time.Now()
+	done := make(chan struct{})
+	c.fetching = done
+	url := c.url
+
+	// The fetch is shared, so it is not cancelled with the request that started it
+	go func() {
+		keys, url, err := c.fetch(context.WithoutCancel(ctx), url)
+
+		c.mu.Lock()
+		if err != nil {
+			logger.Warn("jwks_fetch_failed", "url", url, "issuer", c.issuer, "error", err)
+			// Keep validating with the keys fetched before
+		} else {
+			c.url = url
+			c.keys = keys
+			c.fetched = time.Now()
+		}
+		c.fetching = nil
+		c.mu.Unlock()
+		close(done)
+	}()
+	return done
+}
+
+// lookup finds a cached key; the lock must be held
+func (c *jwksCache) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(c.keys) == 1 {
+		for _, key := range c.keys {
+			return key, true
+		}
+	}
+	key, found := c.keys[kid]
+	return key, found
+}
+
+// jsonWebKey is a key of a JWKS document. Only signature keys of type RSA and EC are used.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetch reads the published key set from url, discovering the URL from the issuer when it
+// is empty. It returns the keys and the URL they were read from.
+func (c *jwksCache) fetch(ctx context.Context, url string) (map[string]crypto.PublicKey, string, error) {
+	if url == "" {
+		discovered, err := c.discover(ctx)
+		if err != nil {
+			return nil, url, err
+		}
+		url = discovered
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := c.getJSON(ctx, url, &set); err != nil {
+		return nil, url, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			logger.Warn("jwks_key_skipped", "kid", jwk.Kid, "error", err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, url, fmt.Errorf("no usable signing keys at %s", url)
+	}
+
+	logger.Info("jwks_fetched", "url", url, "keys", len(keys))
+	return keys, url, nil
+}
+
+// discover reads the JWKS URL from the issuer's OpenID Connect discovery document
+func (c *jwksCache) discover(ctx context.Context) (string, error) {
+	var doc struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := c.getJSON(ctx, c.issuer+"/.well-known/openid-configuration", &doc); err != nil {
+		return "", err
+	}
+	if doc.JWKSURI == "" {
+		return "", fmt.Errorf("openid configuration of %s has no jwks_uri", c.issuer)
+	}
+	return doc.JWKSURI, nil
+}
+
+// getJSON fetches a JSON document
+func (c *jwksCache) getJSON(ctx context.Context, url string, v interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, jwksFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: unexpected status %s", url, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, jwksMaxBody)).Decode(v)
+}
+
+// publicKey converts the key to an RSA or ECDSA public key
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %v", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, errX := decodeBigInt(k.X)
+		y, errY := decodeBigInt(k.Y)
+		if errX != nil || errY != nil || !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("invalid %s point", k.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// decodeBigInt decodes a base64url-encoded unsigned big-endian integer
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,261 @@
+// Package auth validates JSON Web Tokens issued by an identity provider, signed either with
+// a shared secret or with keys published at a JWKS URL.
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256" // Registers SHA-256 for crypto.Hash
+	_ "crypto/sha512" // Registers SHA-384 and SHA-512 for crypto.Hash
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"asr_server/config"
+)
+
+// Token validation errors
+var (
+	ErrMalformedToken   = errors.New("malformed token")
+	ErrUnsupportedAlg   = errors.New("unsupported signing algorithm")
+	ErrInvalidSignature = errors.New("invalid token signature")
+	ErrTokenExpired     = errors.New("token is expired")
+	ErrTokenNotYetValid = errors.New("token is not valid yet")
+	ErrInvalidIssuer    = errors.New("invalid token issuer")
+	ErrInvalidAudience  = errors.New("invalid token audience")
+)
+
+// Claims are the claims of a validated token
+type Claims struct {
+	Subject   string
+	Issuer    string
+	Audience  []string
+	ExpiresAt time.Time
+	Scopes    []string // From the scope or scp claim
+	// All claims as decoded from the payload, numbers as json.Number
+	Raw map[string]interface{}
+}
+
+// Verifier validates tokens against the configured key source, issuer and audience
+type Verifier struct {
+	cfg    config.AuthConfig
+	secret []byte
+	jwks   *jwksCache
+	now    func() time.Time
+}
+
+// NewVerifier creates a verifier from the auth configuration. Tokens are checked with the
+// shared secret when one is set, otherwise with the keys at jwks_url, which is discovered
+// from the issuer's OpenID configuration when left empty.
+func NewVerifier(cfg config.AuthConfig) (*Verifier, error) {
+	v := &Verifier{cfg: cfg, now: time.Now}
+	switch {
+	case cfg.Secret != "":
+		v.secret = []byte(cfg.Secret)
+	case cfg.JWKSURL != "" || cfg.Issuer != "":
+		refresh := time.Duration(cfg.JWKSRefreshInterval) * time.Second
+		if refresh <= 0 {
+			refresh = time.Duration(config.DefaultJWKSRefreshInterval) * time.Second
+		}
+		v.jwks = newJWKSCache(cfg.JWKSURL, cfg.Issuer, refresh)
+	default:
+		return nil, fmt.Errorf("auth requires a secret, jwks_url or issuer")
+	}
+	return v, nil
+}
+
+// tokenHeader is the JOSE header of a token
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify checks the token's signature and registered claims and returns its claims
+func (v *Verifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	var header tokenHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	if err := v.verifySignature(ctx, header, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	claims, err := parseClaims(payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// verifySignature checks signature over signed with the key selected by the header
+func (v *Verifier) verifySignature(ctx context.Context, header tokenHeader, signed, signature []byte) error {
+	hash, ok := algHashes[header.Alg]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnsupportedAlg, header.Alg)
+	}
+
+	// A secret only verifies HMAC tokens, and JWKS keys only asymmetric ones, so a public
+	// key can never be used as an HMAC secret
+	if v.secret != nil {
+		if !strings.HasPrefix(header.Alg, "HS") {
+			return fmt.Errorf("%w: %q with a shared secret", ErrUnsupportedAlg, header.Alg)
+		}
+		mac := hmac.New(hash.New, v.secret)
+		mac.Write(signed)
+		if subtle.ConstantTimeCompare(mac.Sum(nil), signature) != 1 {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+	if strings.HasPrefix(header.Alg, "HS") {
+		return fmt.Errorf("%w: %q with JWKS keys", ErrUnsupportedAlg, header.Alg)
+	}
+
+	key, err := v.jwks.key(ctx, header.Kid)
+	if err != nil {
+		return err
+	}
+	digest := hash.New()
+	digest.Write(signed)
+	sum := digest.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		switch header.Alg[:2] {
+		case "RS":
+			err = rsa.VerifyPKCS1v15(key, hash, sum, signature)
+		case "PS":
+			err = rsa.VerifyPSS(key, hash, sum, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		default:
+			err = fmt.Errorf("%w: %q with an RSA key", ErrUnsupportedAlg, header.Alg)
+		}
+		if err != nil {
+			return ErrInvalidSignature
+		}
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if header.Alg[:2] != "ES" || len(signature) != 2*size {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, sum, r, s) {
+			return ErrInvalidSignature
+		}
+	default:
+		return fmt.Errorf("%w: unsupported key type %T", ErrInvalidSignature, key)
+	}
+	return nil
+}
+
+// algHashes maps the supported signing algorithms to their hash functions
+var algHashes = map[string]crypto.Hash{
+	"HS256": crypto.SHA256, "HS384": crypto.SHA384, "HS512": crypto.SHA512,
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"PS256": crypto.SHA256, "PS384": crypto.SHA384, "PS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+}
+
+// parseClaims decodes the token payload and its registered claims
+func parseClaims(payload []byte) (*Claims, error) {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var raw map[string]interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	claims := &Claims{Raw: raw}
+	claims.Subject, _ = raw["sub"].(string)
+	claims.Issuer, _ = raw["iss"].(string)
+	switch aud := raw["aud"].(type) {
+	case string:
+		claims.Audience = []string{aud}
+	case []interface{}:
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				claims.Audience = append(claims.Audience, s)
+			}
+		}
+	}
+	if exp, ok := numericDate(raw["exp"]); ok {
+		claims.ExpiresAt = exp
+	}
+	claims.Scopes = parseScopes(raw)
+	return claims, nil
+}
+
+// checkClaims enforces expiry, not-before, issuer and audience. Tokens must expire.
+func (v *Verifier) checkClaims(claims *Claims) error {
+	now := v.now()
+	leeway := time.Duration(v.cfg.Leeway) * time.Second
+	if claims.ExpiresAt.IsZero() || now.After(claims.ExpiresAt.Add(leeway)) {
+		return ErrTokenExpired
+	}
+	if nbf, ok := numericDate(claims.Raw["nbf"]); ok && now.Add(leeway).Before(nbf) {
+		return ErrTokenNotYetValid
+	}
+	if v.cfg.Issuer != "" && claims.Issuer != v.cfg.Issuer {
+		return ErrInvalidIssuer
+	}
+	if v.cfg.Audience != "" && !slices.Contains(claims.Audience, v.cfg.Audience) {
+		return ErrInvalidAudience
+	}
+	return nil
+}
+
+// numericDate converts a NumericDate claim, seconds since the epoch, to a time
+func numericDate(v interface{}) (time.Time, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))), true
+}
+
+type claimsKey struct{}
+
+// WithClaims returns a context carrying the claims of the request's token
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims attached by WithClaims
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok
+}
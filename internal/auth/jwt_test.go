@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"asr_server/config"
+)
+
+var testNow = time.Unix(1700000000, 0)
+
+// signToken builds a token with the given header and claims, signed by sign
+func signToken(t *testing.T, header, claims map[string]interface{}, sign func(signed []byte) []byte) string {
+	t.Helper()
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signed)))
+}
+
+func hmacSigner(secret string, hash crypto.Hash) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		mac := hmac.New(hash.New, []byte(secret))
+		mac.Write(signed)
+		return mac.Sum(nil)
+	}
+}
+
+func rsaSigner(t *testing.T, key *rsa.PrivateKey) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		sum := crypto.SHA256.New()
+		sum.Write(signed)
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum.Sum(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signature
+	}
+}
+
+func TestVerifySecret(t *testing.T) {
+	const secret = "test-secret"
+	v, err := NewVerifier(config.AuthConfig{Secret: secret, Issuer: "https://idp.example.com", Audience: "asr", Leeway: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.now = func() time.Time { return testNow }
+
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"sub":   "user-1",
+			"iss":   "https://idp.example.com",
+			"aud":   "asr",
+			"exp":   testNow.Add(time.Hour).Unix(),
+			"scope": "asr:stream speaker:read",
+		}
+		for k, value := range overrides {
+			if value == nil {
+				delete(c, k)
+			} else {
+				c[k] = value
+			}
+		}
+		return c
+	}
+	hs256 := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"valid", signToken(t, hs256, claims(nil), hmacSigner(secret, crypto.SHA256)), nil},
+		{"valid HS512", signToken(t, map[string]interface{}{"alg": "HS512"}, claims(nil), hmacSigner(secret, crypto.SHA512)), nil},
+		{"audience array", signToken(t, hs256, claims(map[string]interface{}{"aud": []string{"other", "asr"}}), hmacSigner(secret, crypto.SHA256)), nil},
+		{"expired within leeway", signToken(t, hs256, claims(map[string]interface{}{"exp": testNow.Add(-10 * time.Second).Unix()}), hmacSigner(secret, crypto.SHA256)), nil},
+		{"malformed", "not-a-token", ErrMalformedToken},
+		{"malformed payload", "e30.!!!.e30", ErrMalformedToken},
+		{"alg none", signToken(t, map[string]interface{}{"alg": "none"}, claims(nil), func([]byte) []byte { return nil }), ErrUnsupportedAlg},
+		{"asymmetric alg with secret", signToken(t, map[string]interface{}{"alg": "RS256"}, claims(nil), hmacSigner(secret, crypto.SHA256)), ErrUnsupportedAlg},
+		{"wrong secret", signToken(t, hs256, claims(nil), hmacSigner("other-secret", crypto.SHA256)), ErrInvalidSignature},
+		{"hash mismatch", signToken(t, map[string]interface{}{"alg": "HS384"}, claims(nil), hmacSigner(secret, crypto.SHA256)), ErrInvalidSignature},
+		{"expired", signToken(t, hs256, claims(map[string]interface{}{"exp": testNow.Add(-time.Minute).Unix()}), hmacSigner(secret, crypto.SHA256)), ErrTokenExpired},
+		{"no expiry", signToken(t, hs256, claims(map[string]interface{}{"exp": nil}), hmacSigner(secret, crypto.SHA256)), ErrTokenExpired},
+		{"not yet valid", signToken(t, hs256, claims(map[string]interface{}{"nbf": testNow.Add(time.Minute).Unix()}), hmacSigner(secret, crypto.SHA256)), ErrTokenNotYetValid},
+		{"wrong issuer", signToken(t, hs256, claims(map[string]interface{}{"iss": "https://evil.example.com"}), hmacSigner(secret, crypto.SHA256)), ErrInvalidIssuer},
+		{"missing issuer", signToken(t, hs256, claims(map[string]interface{}{"iss": nil}), hmacSigner(secret, crypto.SHA256)), ErrInvalidIssuer},
+		{"wrong audience", signToken(t, hs256, claims(map[string]interface{}{"aud": "other"}), hmacSigner(secret, crypto.SHA256)), ErrInvalidAudience},
+		{"missing audience", signToken(t, hs256, claims(map[string]interface{}{"aud": nil}), hmacSigner(secret, crypto.SHA256)), ErrInvalidAudience},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.Verify(context.Background(), tt.token)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if got.Subject != "user-1" || len(got.Scopes) != 2 {
+				t.Errorf("Verify() claims = %+v", got)
+			}
+		})
+	}
+}
+
+func TestVerifyJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "key-1",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	defer server.Close()
+
+	v, err := NewVerifier(config.AuthConfig{JWKSURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.now = func() time.Time { return testNow }
+	claims := map[string]interface{}{"sub": "user-1", "exp": testNow.Add(time.Hour).Unix()}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"valid", signToken(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, claims, rsaSigner(t, key)), nil},
+		{"no key ID with a single key", signToken(t, map[string]interface{}{"alg": "RS256"}, claims, rsaSigner(t, key)), nil},
+		{"unknown key ID", signToken(t, map[string]interface{}{"alg": "RS256", "kid": "key-2"}, claims, rsaSigner(t, otherKey)), ErrUnknownKey},
+		{"signed by another key", signToken(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, claims, rsaSigner(t, otherKey)), ErrInvalidSignature},
+		{"alg mismatch", signToken(t, map[string]interface{}{"alg": "ES256", "kid": "key-1"}, claims, rsaSigner(t, key)), ErrInvalidSignature},
+		// A public key must never be usable as an HMAC secret
+		{"HMAC alg with JWKS", signToken(t, map[string]interface{}{"alg": "HS256", "kid": "key-1"}, claims, hmacSigner("key-1", crypto.SHA256)), ErrUnsupportedAlg},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Verify(context.Background(), tt.token)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Unknown key IDs refetch at most once per jwksMinRefetch
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", n)
+	}
+}
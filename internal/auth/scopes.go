@@ -0,0 +1,46 @@
+package auth
+
+import "strings"
+
+// Scopes required by the API routes. A granted scope may end in a wildcard that covers a
+// family of scopes, such as "speaker:*" or "*".
+const (
+	ScopeASRStream     = "asr:stream"     // Real-time recognition: WebSocket, long-poll and WebRTC
+	ScopeASRTranscribe = "asr:transcribe" // File transcription and batch jobs
+	ScopeSpeakerRead   = "speaker:read"   // Speaker identification, search, verification and listing
+	ScopeSpeakerWrite  = "speaker:write"  // Speaker registration, updates, deletion, import and export
+)
+
+// HasScope reports whether the granted scopes include required
+func HasScope(granted []string, required string) bool {
+	for _, scope := range granted {
+		if scope == required || scope == "*" {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(scope, "*"); ok && strings.HasPrefix(required, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseScopes reads the OAuth 2.0 "scope" claim, a space-separated string, or the "scp"
+// claim used by some providers, a string or an array
+func parseScopes(raw map[string]interface{}) []string {
+	if scope, ok := raw["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+	switch scp := raw["scp"].(type) {
+	case string:
+		return strings.Fields(scp)
+	case []interface{}:
+		scopes := make([]string, 0, len(scp))
+		for _, s := range scp {
+			if s, ok := s.(string); ok {
+				scopes = append(scopes, s)
+			}
+		}
+		return scopes
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHasScope(t *testing.T) {
+	tests := []struct {
+		name     string
+		granted  []string
+		required string
+		want     bool
+	}{
+		{"exact", []string{"asr:stream"}, "asr:stream", true},
+		{"one of several", []string{"speaker:read", "asr:transcribe"}, "asr:transcribe", true},
+		{"wildcard", []string{"*"}, "speaker:write", true},
+		{"family wildcard", []string{"speaker:*"}, "speaker:write", true},
+		{"none granted", nil, "asr:stream", false},
+		{"other scope", []string{"speaker:read"}, "speaker:write", false},
+		{"other family", []string{"speaker:*"}, "asr:stream", false},
+		{"prefix without wildcard", []string{"speaker"}, "speaker:read", false},
+		{"case sensitive", []string{"ASR:STREAM"}, "asr:stream", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasScope(tt.granted, tt.required); got != tt.want {
+				t.Errorf("HasScope(%v, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims string
+		want   []string
+	}{
+		{"scope claim", `{"scope": "asr:stream  speaker:read"}`, []string{"asr:stream", "speaker:read"}},
+		{"scp string", `{"scp": "asr:stream"}`, []string{"asr:stream"}},
+		{"scp array", `{"scp": ["asr:stream", 1, "speaker:read"]}`, []string{"asr:stream", "speaker:read"}},
+		{"scope takes precedence", `{"scope": "asr:stream", "scp": ["speaker:read"]}`, []string{"asr:stream"}},
+		{"no scopes", `{"sub": "user-1"}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var raw map[string]interface{}
+			if err := json.NewDecoder(strings.NewReader(tt.claims)).Decode(&raw); err != nil {
+				t.Fatal(err)
+			}
+			if got := parseScopes(raw); !slices.Equal(got, tt.want) {
+				t.Errorf("parseScopes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package bootstrap
+
+import (
+	"fmt"
+
+	"asr_server/config"
+	"asr_server/internal/logger"
+	"asr_server/internal/session"
+)
+
+// HookFactory creates a session lifecycle hook from the configuration. It returns a nil hook
+// to stay out of the way, e.g. when the feature it implements is disabled.
+type HookFactory func(cfg *config.Config) (session.Hook, error)
+
+// registeredHook is a hook factory added by RegisterHook
+type registeredHook struct {
+	name    string
+	factory HookFactory
+}
+
+var registeredHooks []registeredHook
+
+// RegisterHook adds a session lifecycle hook to applications initialized afterwards, letting
+// custom builds inject behaviour such as billing without patching the session manager. It is
+// meant to be called from an init function in a file added to the main package:
+//
+//	func init() {
+//		bootstrap.RegisterHook("billing", newBillingHook)
+//	}
+func RegisterHook(name string, factory HookFactory) {
+	registeredHooks = append(registeredHooks, registeredHook{name: name, factory: factory})
+}
+
+// installHooks creates the registered hooks and adds them to the session manager
+func installHooks(cfg *config.Config, sessionManager *session.Manager) error {
+	for _, registered := range registeredHooks {
+		hook, err := registered.factory(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to initialize session hook %s: %v", registered.name, err)
+		}
+		if hook == nil {
+			continue
+		}
+		sessionManager.AddHook(hook)
+		logger.Info("session_hook_installed", "name", registered.name)
+	}
+	return nil
+}
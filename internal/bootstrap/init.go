@@ -2,7 +2,7 @@
 //     │
 //     ├─ 1. 创建热重载管理器 ─────────────────────────────┐
 //     │                                                  │
-//     ├─ 2. 创建语音识别引擎 ── 失败? ──────→ return nil, err
+//     ├─ 2. [可选] 下载缺失的模型 / 创建语音识别引擎 ── 失败? → return nil, err
 //     │                                                  │
 //     ├─ 3. 检查 VAD 模型文件 ── 不存在? ──→ return nil, err
 //     │                                                  │
@@ -10,26 +10,55 @@
 //     │                                                  │
 //     ├─ 5. 初始化 VAD 池 ────── 失败? ────→ return nil, err
 //     │                                                  │
-//     ├─ 6. 创建会话管理器                               │
+//     ├─ 6. 创建会话管理器 / [可选] 加载附加模型、流式识别模型、文本替换规则 ── 失败? → return nil, err
 //     │                                                  │
-//     ├─ 7. 创建限流器                                   │
+//     ├─ 7. [可选] 注册 Kafka / NATS 事件发布 ── 失败? → return nil, err
 //     │                                                  │
-//     ├─ 8. [可选] 创建说话人识别模块                     │
+//     ├─ 8. 创建限流器 / [可选] JWT 认证、HMAC 签名 ── 失败? → return nil, err
 //     │                                                  │
-//     └─ 9. 打包返回 AppDependencies ────────────────────┘
+//     ├─ 9. [可选] 创建说话人识别模块 / 启动 gRPC 服务 ── 失败? → return nil, err
+//     │                                                  │
+//     ├─ 10. [可选] 创建文件转写服务 / 批量转写任务 ── 失败? → return nil, err
+//     │                                                  │
+//     ├─ 11. [可选] 创建 WebRTC 接入                     │
+//     │                                                  │
+//     ├─ 12. [可选] 启动 RTP 电话接入 ── 失败? ─→ return nil, err
+//     │                                                  │
+//     ├─ 13. [可选] 启动 TCP 接入 ──── 失败? ─→ return nil, err
+//     │                                                  │
+//     ├─ 14. [可选] 启动 MQTT 桥接 ─── 失败? ─→ return nil, err
+//     │                                                  │
+//     └─ 15. 打包返回 AppDependencies，注册模型热替换回调 ┘
 
 package bootstrap
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"slices"
+	"sync"
+	"time"
 
 	"asr_server/config"
+	"asr_server/internal/adminevents"
+	"asr_server/internal/auth"
+	"asr_server/internal/events"
+	"asr_server/internal/jobs"
 	"asr_server/internal/logger"
 	"asr_server/internal/middleware"
+	"asr_server/internal/models"
+	"asr_server/internal/mqtt"
 	"asr_server/internal/pool"
+	"asr_server/internal/postprocess"
+	"asr_server/internal/redact"
+	"asr_server/internal/rtc"
 	"asr_server/internal/session"
 	"asr_server/internal/speaker"
+	"asr_server/internal/statsd"
+	"asr_server/internal/tcp"
+	"asr_server/internal/telephony"
+	"asr_server/internal/transcribe"
 
 	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
 )
@@ -37,24 +66,92 @@ import (
 // AppDependencies holds all application dependencies.
 // This is the root dependency container for the application.
 type AppDependencies struct {
-	Config           *config.Config
-	SessionManager   *session.Manager
-	VADPool          pool.VADPoolInterface
-	RateLimiter      *middleware.RateLimiter
-	SpeakerManager   *speaker.Manager
-	SpeakerHandler   *speaker.Handler
-	GlobalRecognizer *sherpa.OfflineRecognizer
-	HotReloadMgr     *config.HotReloadManager
+	Config            *config.Config
+	SessionManager    *session.Manager
+	VADPool           pool.VADPoolInterface
+	RateLimiter       *middleware.RateLimiter
+	AuthVerifier      *auth.Verifier     // nil when JWT authentication is disabled
+	HMACVerifier      *auth.HMACVerifier // nil when HMAC request signing is disabled
+	AdminEvents       *adminevents.Hub   // nil when the admin API is disabled
+	StatsD            *statsd.Client     // nil when the StatsD exporter is disabled
+	KafkaPublisher    *events.KafkaPublisher
+	NATSPublisher     *events.NATSPublisher
+	SpeakerManager    *speaker.Manager
+	SpeakerHandler    *speaker.Handler
+	SpeakerGRPCServer *speaker.GRPCServer
+	SpeakerDetections *speaker.DetectionLog
+	SpeakerAudit      *speaker.AuditLog
+	TranscribeHandler *transcribe.Handler
+	JobsManager       *jobs.Manager
+	JobsHandler       *jobs.Handler
+	RTCHandler        *rtc.Handler
+	TelephonyServer   *telephony.Server
+	TCPServer         *tcp.Server
+	MQTTBridge        *mqtt.Bridge
+	GlobalRecognizer  *sherpa.OfflineRecognizer
+	HotReloadMgr      *config.HotReloadManager
+	ModelManager      *models.Manager
+	TextRules         *postprocess.Rules
+
+	// Serializes recognizer reloads and records the paths of the loaded default model
+	reloadMu         sync.Mutex
+	loadedModelPath  string
+	loadedTokensPath string
+
+	// Serializes VAD pool rebuilds and records the settings of the loaded pools
+	vadReloadMu sync.Mutex
+	vadPool     *pool.ReloadablePool
+	vadFactory  *pool.VADFactory
+	loadedVAD   config.VADConfig
 }
 
-// createRecognizer initializes the sherpa offline recognizer
-func createRecognizer(cfg *config.Config) (*sherpa.OfflineRecognizer, error) {
+// createRecognizer initializes the sherpa offline recognizer for a model, language and hotword list
+func createRecognizer(cfg *config.Config, spec session.RecognizerSpec) (*sherpa.OfflineRecognizer, error) {
 	c := sherpa.OfflineRecognizerConfig{}
 	c.FeatConfig.SampleRate = cfg.Audio.SampleRate
 	c.FeatConfig.FeatureDim = cfg.Audio.FeatureDim
 
-	c.ModelConfig.SenseVoice.Model = cfg.Recognition.ModelPath
-	c.ModelConfig.Tokens = cfg.Recognition.TokensPath
+	if spec.Model == "" {
+		c.ModelConfig.SenseVoice.Model = cfg.Recognition.ModelPath
+		c.ModelConfig.SenseVoice.Language = spec.Language
+		c.ModelConfig.Tokens = cfg.Recognition.TokensPath
+	} else {
+		model, exists := cfg.Recognition.Models[spec.Model]
+		if !exists {
+			return nil, fmt.Errorf("model %s is not configured", spec.Model)
+		}
+		switch model.Type {
+		case "sense_voice":
+			c.ModelConfig.SenseVoice.Model = model.ModelPath
+			c.ModelConfig.SenseVoice.Language = model.Language
+		case "whisper":
+			c.ModelConfig.Whisper.Encoder = model.EncoderPath
+			c.ModelConfig.Whisper.Decoder = model.DecoderPath
+			c.ModelConfig.Whisper.Language = model.Language
+			c.ModelConfig.Whisper.Task = "transcribe"
+		case "paraformer":
+			c.ModelConfig.Paraformer.Model = model.ModelPath
+		case "transducer":
+			c.ModelConfig.Transducer.Encoder = model.EncoderPath
+			c.ModelConfig.Transducer.Decoder = model.DecoderPath
+			c.ModelConfig.Transducer.Joiner = model.JoinerPath
+		}
+		c.ModelConfig.Tokens = model.TokensPath
+
+		// The language model rescores the hypotheses kept by modified beam search
+		if model.LM.ModelPath != "" {
+			c.LmConfig.Model = model.LM.ModelPath
+			c.LmConfig.Scale = model.LM.Scale
+			if c.LmConfig.Scale == 0 {
+				c.LmConfig.Scale = config.DefaultLMScale
+			}
+			c.MaxActivePaths = model.LM.NumPaths
+			if c.MaxActivePaths == 0 {
+				c.MaxActivePaths = config.DefaultLMNumPaths
+			}
+			c.DecodingMethod = "modified_beam_search"
+		}
+	}
 	c.ModelConfig.NumThreads = cfg.Recognition.NumThreads
 	c.ModelConfig.Debug = 0
 	if cfg.Recognition.Debug {
@@ -62,6 +159,29 @@ func createRecognizer(cfg *config.Config) (*sherpa.OfflineRecognizer, error) {
 	}
 	c.ModelConfig.Provider = cfg.Recognition.Provider
 
+	// Sherpa reads hotwords from a file while creating the recognizer and only applies
+	// them with modified beam search
+	if spec.Hotwords != "" {
+		f, err := os.CreateTemp("", "hotwords-*.txt")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create hotwords file: %v", err)
+		}
+		defer os.Remove(f.Name())
+		_, err = f.WriteString(spec.Hotwords + "\n")
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to write hotwords file: %v", err)
+		}
+		c.HotwordsFile = f.Name()
+		c.HotwordsScore = spec.HotwordsScore
+		c.DecodingMethod = "modified_beam_search"
+		if c.MaxActivePaths == 0 {
+			c.MaxActivePaths = 4
+		}
+	}
+
 	recognizer := sherpa.NewOfflineRecognizer(&c)
 	if recognizer == nil {
 		return nil, fmt.Errorf("failed to create offline recognizer")
@@ -70,6 +190,77 @@ func createRecognizer(cfg *config.Config) (*sherpa.OfflineRecognizer, error) {
 	return recognizer, nil
 }
 
+// createTranslator initializes a multilingual Whisper recognizer running the translate task,
+// which outputs English text for speech in any supported language
+func createTranslator(cfg *config.Config) (*sherpa.OfflineRecognizer, error) {
+	c := sherpa.OfflineRecognizerConfig{}
+	c.FeatConfig.SampleRate = cfg.Audio.SampleRate
+	c.FeatConfig.FeatureDim = cfg.Audio.FeatureDim
+
+	c.ModelConfig.Whisper.Encoder = cfg.Recognition.Translation.EncoderPath
+	c.ModelConfig.Whisper.Decoder = cfg.Recognition.Translation.DecoderPath
+	c.ModelConfig.Whisper.Task = "translate"
+	c.ModelConfig.Tokens = cfg.Recognition.Translation.TokensPath
+	c.ModelConfig.NumThreads = cfg.Recognition.NumThreads
+	c.ModelConfig.Debug = 0
+	if cfg.Recognition.Debug {
+		c.ModelConfig.Debug = 1
+	}
+	c.ModelConfig.Provider = cfg.Recognition.Provider
+
+	recognizer := sherpa.NewOfflineRecognizer(&c)
+	if recognizer == nil {
+		return nil, fmt.Errorf("failed to create translation recognizer")
+	}
+
+	return recognizer, nil
+}
+
+// newSpeakerTrackerFactory creates a speaker tracker per audio stream, clustering unregistered
+// speakers when diarization is enabled
+func newSpeakerTrackerFactory(cfg *config.Config, manager *speaker.Manager) session.SpeakerTrackerFactory {
+	maxSpeakers := 0
+	if cfg.Speaker.Diarization.Enabled {
+		maxSpeakers = cfg.Speaker.Diarization.MaxSpeakers
+	}
+	return func() session.SpeakerTracker {
+		return manager.NewTracker(cfg.Speaker.Diarization.Threshold, maxSpeakers)
+	}
+}
+
+// createOnlineRecognizer initializes the sherpa online recognizer used in streaming mode
+func createOnlineRecognizer(cfg *config.Config) (*sherpa.OnlineRecognizer, error) {
+	streaming := cfg.Recognition.Streaming
+
+	c := sherpa.OnlineRecognizerConfig{}
+	c.FeatConfig.SampleRate = cfg.Audio.SampleRate
+	c.FeatConfig.FeatureDim = cfg.Audio.FeatureDim
+
+	c.ModelConfig.Transducer.Encoder = streaming.EncoderPath
+	c.ModelConfig.Transducer.Decoder = streaming.DecoderPath
+	c.ModelConfig.Transducer.Joiner = streaming.JoinerPath
+	c.ModelConfig.Tokens = streaming.TokensPath
+	c.ModelConfig.NumThreads = cfg.Recognition.NumThreads
+	c.ModelConfig.Debug = 0
+	if cfg.Recognition.Debug {
+		c.ModelConfig.Debug = 1
+	}
+	c.ModelConfig.Provider = cfg.Recognition.Provider
+
+	c.DecodingMethod = streaming.DecodingMethod
+	c.EnableEndpoint = 1
+	c.Rule1MinTrailingSilence = streaming.Rule1MinTrailingSilence
+	c.Rule2MinTrailingSilence = streaming.Rule2MinTrailingSilence
+	c.Rule3MinUtteranceLength = streaming.Rule3MinUtteranceLength
+
+	recognizer := sherpa.NewOnlineRecognizer(&c)
+	if recognizer == nil {
+		return nil, fmt.Errorf("failed to create online recognizer")
+	}
+
+	return recognizer, nil
+}
+
 // InitApp initializes all core components and returns the dependency container.
 // All dependencies are explicitly created with the provided configuration.
 func InitApp(cfg *config.Config, configPath string) (*AppDependencies, error) {
@@ -79,15 +270,26 @@ func InitApp(cfg *config.Config, configPath string) (*AppDependencies, error) {
 	logger.Info("initializing_hot_reload_manager")
 	hotReloadMgr := config.NewHotReloadManager(cfg, configPath)
 
-	// Register configuration change callback
-	hotReloadMgr.OnChange(func(newCfg *config.Config) {
-		// Update log level dynamically
+	// Collect server events for the admin dashboard stream
+	var adminEvents *adminevents.Hub
+	if cfg.Admin.Enabled {
+		adminEvents = adminevents.NewHub(cfg.Admin.EventHistory)
+		logger.SetObserver(adminEvents.ObserveLog)
+		hotReloadMgr.OnChange(func(newCfg *config.Config) {
+			adminEvents.Publish(adminevents.TypeConfigReloaded, map[string]interface{}{
+				"config_path": configPath,
+			})
+		})
+	}
+
+	// Update log level dynamically
+	hotReloadMgr.OnSectionChange("logging.level", func(newCfg *config.Config) {
 		logger.SetLevel(newCfg.Logging.Level)
-		logger.Info("configuration_reloaded",
-			"log_level", newCfg.Logging.Level,
-			"vad_provider", newCfg.VAD.Provider,
-			"rate_limit_enabled", newCfg.RateLimit.Enabled,
-		)
+		logger.Info("log_level_applied", "log_level", newCfg.Logging.Level)
+	})
+	hotReloadMgr.OnSectionChange("logging.redaction", func(newCfg *config.Config) {
+		redact.Configure(newCfg.Logging.Redaction.Rules())
+		logger.Info("redaction_rules_applied")
 	})
 
 	// Start watching config file
@@ -95,16 +297,22 @@ func InitApp(cfg *config.Config, configPath string) (*AppDependencies, error) {
 		logger.Warn("failed_to_start_config_file_watching", "error", err)
 	}
 
+	// Download the models referenced as model:// that are not in the cache yet
+	modelManager := models.NewManager(cfg.Models)
+	if err := modelManager.EnsureAll(context.Background()); err != nil {
+		logger.Error("failed_to_download_models", "error", err)
+		return nil, fmt.Errorf("failed to download models: %v", err)
+	}
+
 	// Initialize global recognizer
 	logger.Info("initializing_global_recognizer")
-	globalRecognizer, err := createRecognizer(cfg)
+	globalRecognizer, err := createRecognizer(cfg, session.RecognizerSpec{Language: cfg.Recognition.Language})
 	if err != nil {
 		logger.Error("failed_to_initialize_global_recognizer", "error", err)
 		return nil, fmt.Errorf("failed to initialize global recognizer: %v", err)
 	}
 
 	// Create VAD pool using factory with explicit config
-	var vadPool pool.VADPoolInterface
 	vadFactory := pool.NewVADFactory(cfg)
 
 	if cfg.VAD.Provider == pool.SILERO_TYPE {
@@ -116,7 +324,7 @@ func InitApp(cfg *config.Config, configPath string) (*AppDependencies, error) {
 	}
 
 	// Use factory to create VAD pool
-	vadPool, err = vadFactory.CreateVADPool()
+	initialVADPool, err := vadFactory.CreateVADPool()
 	if err != nil {
 		logger.Error("failed_to_create_vad_pool", "error", err)
 		return nil, fmt.Errorf("failed to create VAD pool: %v", err)
@@ -124,30 +332,136 @@ func InitApp(cfg *config.Config, configPath string) (*AppDependencies, error) {
 
 	// Initialize VAD pool
 	logger.Info("initializing_vad_pool", "pool_size", cfg.VAD.PoolSize)
-	if err := vadPool.Initialize(); err != nil {
+	if err := initialVADPool.Initialize(); err != nil {
 		logger.Error("failed_to_initialize_vad_pool", "error", err)
 		return nil, fmt.Errorf("failed to initialize VAD pool: %v", err)
 	}
+	// Rebuilt in place when a configuration reload changes the VAD settings
+	vadPool := pool.NewReloadablePool(initialVADPool)
 
 	// Initialize session manager with explicit dependencies
 	logger.Info("initializing_session_manager")
 	sessionManager := session.NewManager(cfg, globalRecognizer, vadPool)
+	sessionManager.SetRecognizerFactory(func(spec session.RecognizerSpec) (*sherpa.OfflineRecognizer, error) {
+		return createRecognizer(cfg, spec)
+	})
+
+	// Load additional models so that selecting one never stalls a session
+	modelNames := make([]string, 0, len(cfg.Recognition.Models))
+	for name := range cfg.Recognition.Models {
+		modelNames = append(modelNames, name)
+	}
+	slices.Sort(modelNames)
+	for _, name := range modelNames {
+		logger.Info("initializing_model", "name", name, "type", cfg.Recognition.Models[name].Type)
+		recognizer, err := createRecognizer(cfg, session.RecognizerSpec{Model: name})
+		if err != nil {
+			logger.Error("failed_to_initialize_model", "name", name, "error", err)
+			return nil, fmt.Errorf("failed to initialize model %s: %v", name, err)
+		}
+		sessionManager.RegisterModel(name, recognizer)
+	}
+
+	// Initialize speech translation
+	if cfg.Recognition.Translation.EncoderPath != "" {
+		logger.Info("initializing_translator", "encoder", cfg.Recognition.Translation.EncoderPath)
+		translator, err := createTranslator(cfg)
+		if err != nil {
+			logger.Error("failed_to_initialize_translator", "error", err)
+			return nil, fmt.Errorf("failed to initialize translator: %v", err)
+		}
+		sessionManager.SetTranslator(translator)
+	}
+	sessionManager.SetVADPoolFactory(func(vadType string) (pool.VADPoolInterface, error) {
+		return createVADPool(cfg, vadFactory, vadType)
+	})
+	sessionManager.SetVADInstanceFactory(vadFactory.CreateInstance)
+
+	// Load transcript replacement rules
+	var textRules *postprocess.Rules
+	if cfg.PostProcess.RulesFile != "" {
+		logger.Info("initializing_text_rules", "path", cfg.PostProcess.RulesFile)
+		textRules, err = postprocess.NewRules(cfg.PostProcess.RulesFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load text rules: %v", err)
+		}
+		if err := textRules.Watch(); err != nil {
+			logger.Warn("failed_to_watch_text_rules", "path", cfg.PostProcess.RulesFile, "error", err)
+		}
+		sessionManager.SetRules(textRules)
+	}
+
+	// Initialize streaming recognizer
+	if cfg.Recognition.Mode == "streaming" {
+		logger.Info("initializing_online_recognizer", "encoder", cfg.Recognition.Streaming.EncoderPath, "decoding_method", cfg.Recognition.Streaming.DecodingMethod)
+		onlineRecognizer, err := createOnlineRecognizer(cfg)
+		if err != nil {
+			logger.Error("failed_to_initialize_online_recognizer", "error", err)
+			return nil, fmt.Errorf("failed to initialize online recognizer: %v", err)
+		}
+		sessionManager.SetOnlineRecognizer(onlineRecognizer)
+	}
+
+	// Initialize Kafka result publisher
+	var kafkaPublisher *events.KafkaPublisher
+	if cfg.Kafka.Enabled {
+		logger.Info("initializing_kafka_publisher", "brokers", cfg.Kafka.Brokers, "topic", cfg.Kafka.Topic)
+		kafkaPublisher, err = events.NewKafkaPublisher(&cfg.Kafka)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize Kafka publisher: %v", err)
+		}
+		sessionManager.AddPublisher(kafkaPublisher)
+	}
+
+	// Initialize NATS event publisher
+	var natsPublisher *events.NATSPublisher
+	if cfg.NATS.Enabled {
+		logger.Info("initializing_nats_publisher", "subjects", cfg.NATS.Subjects)
+		natsPublisher, err = events.NewNATSPublisher(&cfg.NATS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize NATS publisher: %v", err)
+		}
+		sessionManager.AddPublisher(natsPublisher)
+	}
+
+	// Install session hooks registered by custom builds
+	if err := installHooks(cfg, sessionManager); err != nil {
+		return nil, err
+	}
+	if adminEvents != nil {
+		sessionManager.AddHook(adminEvents)
+	}
 
 	// Initialize rate limiter
 	logger.Info("initializing_rate_limiter",
 		"requests_per_second", cfg.RateLimit.RequestsPerSecond,
 		"max_connections", cfg.RateLimit.MaxConnections,
+		"ws_upgrades_per_minute", cfg.RateLimit.WSUpgradesPerMinute,
+		"max_ws_connections_per_ip", cfg.RateLimit.MaxWSConnectionsPerIP,
+		"audio_bytes_per_second", cfg.RateLimit.AudioBytesPerSecond,
 	)
-	rateLimiter := middleware.NewRateLimiter(
-		cfg.RateLimit.Enabled,
-		cfg.RateLimit.RequestsPerSecond,
-		cfg.RateLimit.BurstSize,
-		cfg.RateLimit.MaxConnections,
-	)
+	rateLimiter := middleware.NewRateLimiter(cfg.RateLimit, cfg.Server.TrustedProxies)
+
+	// Initialize JWT authentication and HMAC request signing
+	var authVerifier *auth.Verifier
+	var hmacVerifier *auth.HMACVerifier
+	if cfg.Auth.Enabled && cfg.Auth.HMAC.Enabled() {
+		logger.Info("initializing_hmac_auth", "keys", len(cfg.Auth.HMAC.Keys), "max_skew", cfg.Auth.HMAC.MaxSkew)
+		hmacVerifier = auth.NewHMACVerifier(cfg.Auth.HMAC)
+	}
+	if cfg.Auth.Enabled && cfg.Auth.JWTEnabled() {
+		logger.Info("initializing_jwt_auth", "jwks_url", cfg.Auth.JWKSURL, "issuer", cfg.Auth.Issuer, "audience", cfg.Auth.Audience, "shared_secret", cfg.Auth.Secret != "")
+		authVerifier, err = auth.NewVerifier(cfg.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize JWT authentication: %v", err)
+		}
+	}
 
 	// Initialize speaker recognition module
 	var speakerManager *speaker.Manager
 	var speakerHandler *speaker.Handler
+	var speakerDetections *speaker.DetectionLog
+	var speakerAudit *speaker.AuditLog
 	if cfg.Speaker.Enabled {
 		if _, statErr := os.Stat(cfg.Speaker.ModelPath); !os.IsNotExist(statErr) {
 			speakerConfig := &speaker.Config{
@@ -156,11 +470,59 @@ func InitApp(cfg *config.Config, configPath string) (*AppDependencies, error) {
 				Provider:   cfg.Speaker.Provider,
 				Threshold:  cfg.Speaker.Threshold,
 				DataDir:    cfg.Speaker.DataDir,
+				PoolSize:   cfg.Speaker.PoolSize,
+				Storage:    cfg.Speaker.Storage,
+
+				PostgresDSN:    cfg.Speaker.Postgres.DSN,
+				RedisAddr:      cfg.Speaker.Redis.Addr,
+				RedisPassword:  cfg.Speaker.Redis.Password,
+				RedisDB:        cfg.Speaker.Redis.DB,
+				RedisKeyPrefix: cfg.Speaker.Redis.KeyPrefix,
+				SyncInterval:   time.Duration(cfg.Speaker.SyncInterval) * time.Second,
+
+				MinEnrollSeconds:   cfg.Speaker.MinEnrollSeconds,
+				MinIdentifySeconds: cfg.Speaker.MinIdentifySeconds,
+				MaxSpeechSeconds:   cfg.Speaker.MaxSpeechSeconds,
 			}
 			mgr, err := speaker.NewManager(speakerConfig)
 			if err == nil {
 				speakerManager = mgr
-				speakerHandler = speaker.NewHandler(speakerManager, cfg)
+				if cfg.Speaker.Tracking.Enabled {
+					// Persist the enrolled speakers recognized in live sessions
+					retention := time.Duration(cfg.Speaker.Tracking.RetentionDays) * 24 * time.Hour
+					detections, err := speaker.NewDetectionLog(cfg.Speaker.DataDir, retention)
+					if err != nil {
+						logger.Warn("failed_to_initialize_speaker_tracking", "error", err)
+					} else {
+						speakerDetections = detections
+						sessionManager.AddPublisher(detections)
+					}
+				}
+				if cfg.Speaker.Audit.Enabled {
+					// Biometric data changes must not go unrecorded, so a broken audit sink is fatal
+					speakerAudit, err = mgr.NewAuditLog(&speaker.AuditConfig{
+						Sink: cfg.Speaker.Audit.Sink,
+						Path: cfg.Speaker.Audit.Path,
+					})
+					if err != nil {
+						return nil, fmt.Errorf("failed to initialize speaker audit log: %v", err)
+					}
+				}
+				speakerHandler = speaker.NewHandler(speakerManager, speakerDetections, speakerAudit, cfg)
+				if cfg.Speaker.AntiSpoofing.Enabled {
+					detector, err := speaker.NewSpoofDetector(&speaker.SpoofConfig{
+						ModelPath:   cfg.Speaker.AntiSpoofing.ModelPath,
+						LibraryPath: cfg.Speaker.AntiSpoofing.LibraryPath,
+						NumThreads:  cfg.Speaker.AntiSpoofing.NumThreads,
+					})
+					if err != nil {
+						logger.Warn("failed_to_initialize_anti_spoofing", "error", err)
+					} else {
+						mgr.SetSpoofDetector(detector, cfg.Speaker.AntiSpoofing.Threshold)
+					}
+				}
+				// Attribute live transcripts to speakers
+				sessionManager.SetSpeakerTrackerFactory(newSpeakerTrackerFactory(cfg, mgr))
 			} else {
 				logger.Warn("failed_to_initialize_speaker_recognition_module", "error", err)
 			}
@@ -169,15 +531,154 @@ func InitApp(cfg *config.Config, configPath string) (*AppDependencies, error) {
 		}
 	}
 
+	// Initialize file transcription service
+	transcribeService := transcribe.NewService(cfg, sessionManager, vadPool)
+	transcribeService.SetRules(textRules)
+	if speakerManager != nil {
+		// Speaker duration limits apply to VAD-detected speech, not silence
+		speakerManager.SetSpeechTrimmer(transcribeService)
+		transcribeService.SetSpeakerTrackerFactory(newSpeakerTrackerFactory(cfg, speakerManager))
+	}
+	var transcribeHandler *transcribe.Handler
+	if cfg.Transcription.Enabled {
+		logger.Info("initializing_transcription_service", "max_file_size", cfg.Transcription.MaxFileSize)
+		transcribeHandler = transcribe.NewHandler(transcribeService, cfg)
+	}
+
+	// Initialize speaker gRPC service
+	var speakerGRPCServer *speaker.GRPCServer
+	if cfg.Speaker.GRPC.Enabled && speakerManager != nil {
+		logger.Info("initializing_speaker_grpc_server", "listen_addr", cfg.Speaker.GRPC.ListenAddr)
+		speakerGRPCServer, err = speaker.NewGRPCServer(cfg, speakerManager, speakerAudit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize speaker gRPC server: %v", err)
+		}
+		if err := speakerGRPCServer.Start(); err != nil {
+			return nil, fmt.Errorf("failed to start speaker gRPC server: %v", err)
+		}
+	}
+
+	// Initialize async batch transcription jobs
+	var jobsManager *jobs.Manager
+	var jobsHandler *jobs.Handler
+	if cfg.Jobs.Enabled {
+		logger.Info("initializing_job_manager", "data_dir", cfg.Jobs.DataDir, "worker_count", cfg.Jobs.WorkerCount)
+		jobsManager, err = jobs.NewManager(cfg, transcribeService)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize job manager: %v", err)
+		}
+		jobsHandler = jobs.NewHandler(jobsManager, cfg)
+	}
+
+	// Initialize WebRTC ingest
+	var rtcHandler *rtc.Handler
+	if cfg.WebRTC.Enabled {
+		logger.Info("initializing_webrtc_ingest", "ice_servers", cfg.WebRTC.ICEServers)
+		rtcHandler = rtc.NewHandler(cfg, sessionManager)
+	}
+
+	// Initialize RTP telephony ingest
+	var telephonyServer *telephony.Server
+	if cfg.Telephony.Enabled {
+		logger.Info("initializing_telephony_ingest", "listen_addr", cfg.Telephony.ListenAddr)
+		telephonyServer = telephony.NewServer(cfg, sessionManager)
+		if err := telephonyServer.Start(); err != nil {
+			return nil, fmt.Errorf("failed to start telephony server: %v", err)
+		}
+	}
+
+	// Initialize raw TCP ingest for embedded clients
+	var tcpServer *tcp.Server
+	if cfg.TCP.Enabled {
+		logger.Info("initializing_tcp_ingest", "port", cfg.TCP.Port)
+		tcpServer = tcp.NewServer(cfg, sessionManager)
+		if err := tcpServer.Start(); err != nil {
+			return nil, fmt.Errorf("failed to start tcp server: %v", err)
+		}
+	}
+
+	// Initialize MQTT bridge for IoT devices
+	var mqttBridge *mqtt.Bridge
+	if cfg.MQTT.Enabled {
+		logger.Info("initializing_mqtt_bridge", "audio_topic", cfg.MQTT.AudioTopic)
+		mqttBridge, err = mqtt.NewBridge(cfg, sessionManager)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize MQTT bridge: %v", err)
+		}
+		mqttBridge.Start()
+	}
+
+	// Push recognition timings and sampled statistics to StatsD
+	var statsdClient *statsd.Client
+	if cfg.StatsD.Enabled {
+		logger.Info("initializing_statsd_exporter", "addr", cfg.StatsD.Addr, "prefix", cfg.StatsD.Prefix, "flush_interval", cfg.StatsD.FlushInterval)
+		statsdClient, err = statsd.NewClient(cfg.StatsD)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize StatsD exporter: %v", err)
+		}
+		sessionManager.SetLatencySink(statsdClient)
+		statsdClient.OnFlush(func() {
+			sessionStats := sessionManager.GetStats()
+			// Latencies are sent as timings, and the pool is reported on its own
+			delete(sessionStats, "recognition_latency")
+			delete(sessionStats, "pool_stats")
+			statsdClient.GaugeStats("sessions", sessionStats)
+			statsdClient.GaugeStats("vad_pool", vadPool.GetStats())
+			statsdClient.GaugeStats("rate_limit", rateLimiter.GetStats())
+			if jobsManager != nil {
+				statsdClient.GaugeStats("jobs", jobsManager.GetStats())
+			}
+		})
+	}
+
 	logger.Info("all_components_initialized_successfully")
-	return &AppDependencies{
-		Config:           cfg,
-		SessionManager:   sessionManager,
-		VADPool:          vadPool,
-		RateLimiter:      rateLimiter,
-		SpeakerManager:   speakerManager,
-		SpeakerHandler:   speakerHandler,
-		GlobalRecognizer: globalRecognizer,
-		HotReloadMgr:     hotReloadMgr,
-	}, nil
+	deps := &AppDependencies{
+		Config:            cfg,
+		SessionManager:    sessionManager,
+		VADPool:           vadPool,
+		RateLimiter:       rateLimiter,
+		AuthVerifier:      authVerifier,
+		HMACVerifier:      hmacVerifier,
+		AdminEvents:       adminEvents,
+		StatsD:            statsdClient,
+		KafkaPublisher:    kafkaPublisher,
+		NATSPublisher:     natsPublisher,
+		SpeakerManager:    speakerManager,
+		SpeakerHandler:    speakerHandler,
+		SpeakerGRPCServer: speakerGRPCServer,
+		SpeakerDetections: speakerDetections,
+		SpeakerAudit:      speakerAudit,
+		TranscribeHandler: transcribeHandler,
+		JobsManager:       jobsManager,
+		JobsHandler:       jobsHandler,
+		RTCHandler:        rtcHandler,
+		TelephonyServer:   telephonyServer,
+		TCPServer:         tcpServer,
+		MQTTBridge:        mqttBridge,
+		GlobalRecognizer:  globalRecognizer,
+		HotReloadMgr:      hotReloadMgr,
+		ModelManager:      modelManager,
+		TextRules:         textRules,
+		loadedModelPath:   cfg.Recognition.ModelPath,
+		loadedTokensPath:  cfg.Recognition.TokensPath,
+		vadPool:           vadPool,
+		vadFactory:        vadFactory,
+		loadedVAD:         cfg.VAD,
+	}
+
+	// Swap in the new model when the config file changes its paths
+	hotReloadMgr.OnChange(deps.reloadOnModelChange)
+	// Apply session timeouts and recognition worker limits without a restart
+	hotReloadMgr.OnSectionChange("session", sessionManager.ApplyLimits)
+	hotReloadMgr.OnSectionChange("pool", sessionManager.ApplyLimits)
+	// Resize the buckets of tracked clients and the connection limits
+	hotReloadMgr.OnSectionChange("rate_limit", func(newCfg *config.Config) {
+		rateLimiter.ApplyConfig(newCfg.RateLimit)
+		logger.Info("rate_limit_applied", "enabled", newCfg.RateLimit.Enabled,
+			"requests_per_second", newCfg.RateLimit.RequestsPerSecond, "burst_size", newCfg.RateLimit.BurstSize)
+	})
+	// Rebuild the VAD pools with the new provider, size and thresholds
+	hotReloadMgr.OnSectionChange("vad", deps.reloadVADPools)
+
+	return deps, nil
 }
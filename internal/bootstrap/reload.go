@@ -0,0 +1,138 @@
+package bootstrap
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"asr_server/config"
+	"asr_server/internal/adminevents"
+	"asr_server/internal/logger"
+	"asr_server/internal/pool"
+	"asr_server/internal/session"
+)
+
+// ReloadRecognizer loads the default recognition model from new paths and swaps it into the
+// session manager without interrupting sessions. Empty paths keep the current ones, so the
+// same files can be reloaded after being replaced on disk. Concurrent reloads are serialized.
+func (d *AppDependencies) ReloadRecognizer(modelPath, tokensPath string) error {
+	d.reloadMu.Lock()
+	defer d.reloadMu.Unlock()
+
+	if modelPath == "" {
+		modelPath = d.Config.Recognition.ModelPath
+	}
+	if tokensPath == "" {
+		tokensPath = d.Config.Recognition.TokensPath
+	}
+	for _, path := range []string{modelPath, tokensPath} {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("model file not found: %s", path)
+		}
+	}
+
+	// Build from a copy so the shared configuration only changes once the swap happens
+	cfg := *d.Config
+	cfg.Recognition.ModelPath = modelPath
+	cfg.Recognition.TokensPath = tokensPath
+
+	logger.Info("reloading_recognizer", "model_path", modelPath, "tokens_path", tokensPath)
+	recognizer, err := createRecognizer(&cfg, session.RecognizerSpec{Language: cfg.Recognition.Language})
+	if err != nil {
+		logger.Error("failed_to_reload_recognizer", "model_path", modelPath, "error", err)
+		return err
+	}
+
+	d.SessionManager.ReplaceRecognizer(recognizer, modelPath, tokensPath)
+	d.loadedModelPath, d.loadedTokensPath = modelPath, tokensPath
+	d.AdminEvents.Publish(adminevents.TypeModelReloaded, map[string]interface{}{
+		"model_path":  modelPath,
+		"tokens_path": tokensPath,
+	})
+	return nil
+}
+
+// reloadOnModelChange reloads the recognizer when a configuration file change updates the
+// model paths. The reloaded configuration is applied in place, so it is compared with the
+// paths of the loaded model rather than with the shared configuration.
+func (d *AppDependencies) reloadOnModelChange(newCfg *config.Config) {
+	d.reloadMu.Lock()
+	changed := newCfg.Recognition.ModelPath != d.loadedModelPath ||
+		newCfg.Recognition.TokensPath != d.loadedTokensPath
+	d.reloadMu.Unlock()
+	if !changed {
+		return
+	}
+
+	if err := d.ReloadRecognizer(newCfg.Recognition.ModelPath, newCfg.Recognition.TokensPath); err != nil {
+		logger.Error("model_hot_reload_failed", "error", err)
+	}
+}
+
+// createVADPool creates and initializes a VAD pool of a provider type with the current settings
+func createVADPool(cfg *config.Config, vadFactory *pool.VADFactory, vadType string) (pool.VADPoolInterface, error) {
+	if vadType == pool.SILERO_TYPE {
+		if _, err := os.Stat(cfg.VAD.SileroVAD.ModelPath); os.IsNotExist(err) {
+			return nil, fmt.Errorf("VAD model file not found: %s", cfg.VAD.SileroVAD.ModelPath)
+		}
+	}
+	vadPool, err := vadFactory.CreateVADPoolForType(vadType)
+	if err != nil {
+		return nil, err
+	}
+	if err := vadPool.Initialize(); err != nil {
+		// Release the instances created before the failure
+		vadPool.Shutdown()
+		return nil, err
+	}
+	return vadPool, nil
+}
+
+// vadPoolChanged reports whether a VAD pool of vadType has to be rebuilt for new settings.
+// Instances are created with the pool size, thresholds and window settings, and pools with
+// their health check settings; the TEN-VAD frame counts are read from the configuration on use.
+func vadPoolChanged(old, updated config.VADConfig, vadType string) bool {
+	if old.PoolSize != updated.PoolSize || old.HealthCheckInterval != updated.HealthCheckInterval ||
+		old.MaxInstanceUses != updated.MaxInstanceUses {
+		return true
+	}
+	switch vadType {
+	case pool.SILERO_TYPE:
+		return old.SileroVAD != updated.SileroVAD
+	case pool.TEN_VAD_TYPE:
+		return old.Threshold != updated.Threshold || old.TenVAD.HopSize != updated.TenVAD.HopSize
+	}
+	return false
+}
+
+// reloadVADPools rebuilds the VAD pools when a configuration change updates the VAD provider,
+// pool size or the settings instances are created with. The new pool is built while the old
+// one keeps serving; then new sessions and transcriptions switch to it, running ones keep
+// their instances, and the old pool is destroyed once they are all returned. When the new
+// pool cannot be created the old one keeps serving.
+func (d *AppDependencies) reloadVADPools(newCfg *config.Config) {
+	d.vadReloadMu.Lock()
+	defer d.vadReloadMu.Unlock()
+
+	old, updated := d.loadedVAD, newCfg.VAD
+	if old.Provider != updated.Provider || vadPoolChanged(old, updated, updated.Provider) {
+		logger.Info("reloading_vad_pool", "provider", updated.Provider, "pool_size", updated.PoolSize)
+		start := time.Now()
+		vadPool, err := createVADPool(newCfg, d.vadFactory, updated.Provider)
+		if err != nil {
+			logger.Error("vad_hot_reload_failed", "provider", updated.Provider, "error", err)
+			return
+		}
+		d.vadPool.Replace(vadPool)
+		d.AdminEvents.Publish(adminevents.TypeVADPoolReloaded, map[string]interface{}{
+			"provider":    updated.Provider,
+			"pool_size":   updated.PoolSize,
+			"duration_ms": time.Since(start).Milliseconds(),
+		})
+	}
+
+	d.SessionManager.ReloadVADPools(updated.Provider, func(vadType string) bool {
+		return vadPoolChanged(old, updated, vadType)
+	})
+	d.loadedVAD = updated
+}
@@ -0,0 +1,65 @@
+//go:build kafka
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"asr_server/config"
+	"asr_server/internal/logger"
+	"asr_server/internal/session"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaPublisher publishes final recognition results to a Kafka topic
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaPublisher creates a publisher writing to the configured brokers and topic
+func NewKafkaPublisher(cfg *config.KafkaConfig) (*KafkaPublisher, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, fmt.Errorf("no Kafka brokers configured")
+	}
+
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Topic:        cfg.Topic,
+		Balancer:     &kafka.Hash{},
+		BatchTimeout: 100 * time.Millisecond,
+		Async:        true,
+		Completion: func(messages []kafka.Message, err error) {
+			if err != nil {
+				logger.Error("kafka_publish_failed", "topic", cfg.Topic, "messages", len(messages), "error", err)
+			}
+		},
+	}
+
+	return &KafkaPublisher{writer: writer}, nil
+}
+
+// Publish implements session.ResultPublisher.
+// Messages are keyed by session ID so results of one session stay ordered within a partition.
+func (p *KafkaPublisher) Publish(event session.ResultEvent) {
+	value, err := json.Marshal(event)
+	if err != nil {
+		logger.Error("kafka_event_marshal_failed", "session_id", event.SessionID, "error", err)
+		return
+	}
+
+	if err := p.writer.WriteMessages(context.Background(), kafka.Message{
+		Key:   []byte(event.SessionID),
+		Value: value,
+	}); err != nil {
+		logger.Error("kafka_publish_failed", "session_id", event.SessionID, "error", err)
+	}
+}
+
+// Close flushes pending messages and closes the writer
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,26 @@
+//go:build !kafka
+
+package events
+
+import (
+	"fmt"
+
+	"asr_server/config"
+	"asr_server/internal/session"
+)
+
+// KafkaPublisher is unavailable without the "kafka" build tag
+type KafkaPublisher struct{}
+
+// NewKafkaPublisher always fails without the "kafka" build tag
+func NewKafkaPublisher(cfg *config.KafkaConfig) (*KafkaPublisher, error) {
+	return nil, fmt.Errorf("Kafka support not compiled in (rebuild with -tags kafka)")
+}
+
+// Publish implements session.ResultPublisher
+func (p *KafkaPublisher) Publish(event session.ResultEvent) {}
+
+// Close is a no-op without the "kafka" build tag
+func (p *KafkaPublisher) Close() error {
+	return nil
+}
@@ -0,0 +1,261 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"asr_server/config"
+	"asr_server/internal/logger"
+	"asr_server/internal/session"
+)
+
+const (
+	// natsQueueSize bounds messages buffered while the connection is down
+	natsQueueSize = 10000
+	// natsReconnectDelay is the wait between reconnect attempts
+	natsReconnectDelay = 2 * time.Second
+	// natsDialTimeout bounds connection establishment
+	natsDialTimeout = 5 * time.Second
+)
+
+// natsMessage is a message waiting to be published
+type natsMessage struct {
+	subject string
+	payload []byte
+}
+
+// NATSPublisher publishes recognition results and session lifecycle events to NATS subjects
+// using the core NATS text protocol. Subjects bound to a JetStream stream are persisted by the server.
+type NATSPublisher struct {
+	cfg  *config.NATSConfig
+	addr string
+	opts map[string]interface{}
+
+	msgs chan natsMessage
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewNATSPublisher creates a publisher and starts connecting in the background
+func NewNATSPublisher(cfg *config.NATSConfig) (*NATSPublisher, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid NATS URL: %s", cfg.URL)
+	}
+
+	opts := map[string]interface{}{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     "asr_server",
+		"lang":     "go",
+	}
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			opts["user"] = u.User.Username()
+			opts["pass"] = password
+		} else {
+			opts["auth_token"] = u.User.Username()
+		}
+	}
+
+	p := &NATSPublisher{
+		cfg:  cfg,
+		addr: u.Host,
+		opts: opts,
+		msgs: make(chan natsMessage, natsQueueSize),
+		done: make(chan struct{}),
+	}
+
+	p.wg.Add(1)
+	go p.run()
+
+	return p, nil
+}
+
+// Publish implements session.ResultPublisher
+func (p *NATSPublisher) Publish(event session.ResultEvent) {
+	p.enqueue(p.cfg.Subjects.Results, event)
+}
+
+// PublishSessionEvent implements session.SessionEventPublisher
+func (p *NATSPublisher) PublishSessionEvent(event session.SessionEvent) {
+	switch event.Type {
+	case session.EventSessionStarted:
+		p.enqueue(p.cfg.Subjects.SessionStarted, event)
+	case session.EventSessionEnded:
+		p.enqueue(p.cfg.Subjects.SessionEnded, event)
+	}
+}
+
+// Close flushes queued messages if connected and stops the publisher
+func (p *NATSPublisher) Close() error {
+	close(p.done)
+	p.wg.Wait()
+	return nil
+}
+
+// enqueue marshals v and queues it for subject; an empty subject disables the event type
+func (p *NATSPublisher) enqueue(subject string, v interface{}) {
+	if subject == "" {
+		return
+	}
+
+	payload, err := json.Marshal(v)
+	if err != nil {
+		logger.Error("nats_event_marshal_failed", "subject", subject, "error", err)
+		return
+	}
+
+	select {
+	case p.msgs <- natsMessage{subject: subject, payload: payload}:
+	default:
+		logger.Warn("nats_queue_full", "subject", subject, "action", "dropped_message")
+	}
+}
+
+// run keeps a connection open and reconnects after failures
+func (p *NATSPublisher) run() {
+	defer p.wg.Done()
+
+	for {
+		conn, err := p.connect()
+		if err != nil {
+			logger.Warn("nats_connect_failed", "addr", p.addr, "error", err)
+		} else {
+			logger.Info("nats_connected", "addr", p.addr)
+			err = p.serve(conn)
+			conn.Close()
+			if err == nil {
+				return
+			}
+			logger.Warn("nats_connection_lost", "addr", p.addr, "error", err)
+		}
+
+		select {
+		case <-p.done:
+			return
+		case <-time.After(natsReconnectDelay):
+		}
+	}
+}
+
+// connect dials the server and performs the INFO/CONNECT handshake
+func (p *NATSPublisher) connect() (*bufferedConn, error) {
+	conn, err := net.DialTimeout("tcp", p.addr, natsDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.SetDeadline(time.Now().Add(natsDialTimeout))
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected greeting: %q", strings.TrimSpace(line))
+	}
+
+	opts, _ := json.Marshal(p.opts)
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", opts); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	line, err = reader.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if strings.HasPrefix(line, "-ERR") {
+		conn.Close()
+		return nil, fmt.Errorf("server rejected connection: %s", strings.TrimSpace(line))
+	}
+	conn.SetDeadline(time.Time{})
+
+	return &bufferedConn{Conn: conn, reader: reader}, nil
+}
+
+// serve publishes queued messages and answers server pings until the connection fails.
+// It returns nil when the publisher is closed.
+func (p *NATSPublisher) serve(conn *bufferedConn) error {
+	var writeMu sync.Mutex
+	writer := bufio.NewWriter(conn)
+
+	readErr := make(chan error, 1)
+	go func() {
+		reader := conn.reader
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				readErr <- err
+				return
+			}
+			switch {
+			case strings.HasPrefix(line, "PING"):
+				writeMu.Lock()
+				writer.WriteString("PONG\r\n")
+				err = writer.Flush()
+				writeMu.Unlock()
+				if err != nil {
+					readErr <- err
+					return
+				}
+			case strings.HasPrefix(line, "-ERR"):
+				logger.Warn("nats_server_error", "message", strings.TrimSpace(line))
+			}
+		}
+	}()
+
+	write := func(msg natsMessage) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+
+		fmt.Fprintf(writer, "PUB %s %d\r\n", msg.subject, len(msg.payload))
+		writer.Write(msg.payload)
+		writer.WriteString("\r\n")
+		// Batch consecutive messages into a single flush
+		if len(p.msgs) == 0 {
+			return writer.Flush()
+		}
+		return nil
+	}
+
+	for {
+		select {
+		case err := <-readErr:
+			return err
+		case msg := <-p.msgs:
+			if err := write(msg); err != nil {
+				return err
+			}
+		case <-p.done:
+			for {
+				select {
+				case msg := <-p.msgs:
+					if err := write(msg); err != nil {
+						return nil
+					}
+				default:
+					writeMu.Lock()
+					writer.Flush()
+					writeMu.Unlock()
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// bufferedConn keeps the handshake reader so buffered server data is not lost
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"asr_server/config"
+	"asr_server/internal/adminevents"
+	"asr_server/internal/bootstrap"
+	"asr_server/internal/logger"
+	"asr_server/internal/speaker"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// adminEventsPingInterval keeps idle event streams alive through proxies
+	adminEventsPingInterval = 30 * time.Second
+	// adminEventsWriteWait bounds writing an event to a dashboard
+	adminEventsWriteWait = 10 * time.Second
+)
+
+// adminEventsUpgrader accepts any origin: every connection must present the admin token, so
+// a cross-site page cannot open the stream with credentials the browser holds
+var adminEventsUpgrader = websocket.Upgrader{
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// reloadModelRequest optionally points the default model at new files
+type reloadModelRequest struct {
+	ModelPath  string `json:"model_path"`
+	TokensPath string `json:"tokens_path"`
+}
+
+// ReloadModelHandler 热替换默认识别模型（依赖注入）
+func ReloadModelHandler(deps *bootstrap.AppDependencies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req reloadModelRequest
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+				return
+			}
+		}
+
+		start := time.Now()
+		if err := deps.ReloadRecognizer(req.ModelPath, req.TokensPath); err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status":      "reloaded",
+			"model_path":  deps.Config.Recognition.ModelPath,
+			"tokens_path": deps.Config.Recognition.TokensPath,
+			"duration_ms": time.Since(start).Milliseconds(),
+		})
+	}
+}
+
+// ConfigHandler 返回当前生效的配置，密码、令牌等敏感字段已脱敏，并列出可在运行时修改的配置项（依赖注入）
+func ConfigHandler(deps *bootstrap.AppDependencies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"config":           deps.Config.MaskedSettings(),
+			"runtime_settings": config.RuntimeSettings,
+		})
+	}
+}
+
+// UpdateConfigHandler 在运行时修改部分配置（日志级别、限流、VAD 阈值）并立即生效（依赖注入）。
+// 请求体以点分键名为键，如 {"logging.level": "debug"}；查询参数 persist=true 时同时写回配置文件
+func UpdateConfigHandler(deps *bootstrap.AppDependencies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var settings map[string]json.RawMessage
+		if err := c.ShouldBindJSON(&settings); err != nil || len(settings) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "request body must be an object of settings"})
+			return
+		}
+		persist, err := strconv.ParseBool(c.DefaultQuery("persist", "false"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "persist must be a boolean"})
+			return
+		}
+		if remote, _ := config.RemoteSourceFromEnv(); persist && remote != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": config.ErrPersistUnsupported.Error()})
+			return
+		}
+
+		values := make(map[string]interface{}, len(settings))
+		changed, err := deps.HotReloadMgr.Update(func(cfg *config.Config) error {
+			if err := cfg.ApplySettings(settings); err != nil {
+				return err
+			}
+			for key := range settings {
+				values[key], _ = cfg.Setting(key)
+			}
+			return nil
+		})
+		if err != nil {
+			response := gin.H{"error": err.Error()}
+			if errors.Is(err, config.ErrNotRuntimeSetting) {
+				response["runtime_settings"] = config.RuntimeSettings
+			}
+			c.JSON(http.StatusBadRequest, response)
+			return
+		}
+		if changed == nil {
+			changed = []string{}
+		}
+		logger.Info("config_updated_by_admin", "changed", changed, "persist", persist, "remote_addr", c.ClientIP())
+
+		if persist {
+			if err := config.PersistSettings(deps.HotReloadMgr.GetConfigPath(), values); err != nil {
+				logger.Error("failed_to_persist_config", "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "changed": changed, "persisted": false})
+				return
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"changed": changed, "persisted": persist})
+	}
+}
+
+// SpeakerAuditHandler 查询声纹库审计日志（依赖注入）。查询参数：from、to（RFC 3339）、
+// action、speaker_id、actor、page（从 1 开始）与 page_size，结果按时间倒序
+func SpeakerAuditHandler(deps *bootstrap.AppDependencies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := speaker.AuditQuery{
+			Action:    c.Query("action"),
+			SpeakerID: c.Query("speaker_id"),
+			Actor:     c.Query("actor"),
+		}
+		for _, param := range []struct {
+			name string
+			dst  *time.Time
+		}{{"from", &query.From}, {"to", &query.To}} {
+			value := c.Query(param.name)
+			if value == "" {
+				continue
+			}
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s must be an RFC 3339 time", param.name)})
+				return
+			}
+			*param.dst = t
+		}
+
+		var err error
+		if query.Page, err = strconv.Atoi(c.DefaultQuery("page", "1")); err != nil || query.Page < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "page must be a positive integer"})
+			return
+		}
+		query.PageSize, err = strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(speaker.DefaultListPageSize)))
+		if err != nil || query.PageSize < 1 || query.PageSize > speaker.MaxListPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("page_size must be between 1 and %d", speaker.MaxListPageSize)})
+			return
+		}
+
+		entries, total, err := deps.SpeakerAudit.Query(&query)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"entries":   entries,
+			"total":     total,
+			"page":      query.Page,
+			"page_size": query.PageSize,
+		})
+	}
+}
+
+// ListSessionsHandler 列出所有活动会话及其收包、识别与队列状态（依赖注入）
+func ListSessionsHandler(deps *bootstrap.AppDependencies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessions := deps.SessionManager.ListSessions()
+		c.JSON(http.StatusOK, gin.H{
+			"sessions": sessions,
+			"total":    len(sessions),
+		})
+	}
+}
+
+// CloseSessionHandler 强制关闭指定会话并断开其连接（依赖注入）
+func CloseSessionHandler(deps *bootstrap.AppDependencies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionID := c.Param("id")
+		if !deps.SessionManager.CloseSession(sessionID) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+			return
+		}
+		logger.Info("session_closed_by_admin", "session_id", sessionID, "remote_addr", c.ClientIP())
+		c.JSON(http.StatusOK, gin.H{"status": "closed", "session_id": sessionID})
+	}
+}
+
+// AdminEventsHandler 通过 WebSocket 实时推送服务器事件（依赖注入）。连接后先发送缓存的历史事件，
+// 查询参数 since 为上次收到的事件 id 时只补发其后的事件；跟不上推送的连接会被关闭，重连时带上 since 即可
+func AdminEventsHandler(deps *bootstrap.AppDependencies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var since uint64
+		if value := c.Query("since"); value != "" {
+			var err error
+			if since, err = strconv.ParseUint(value, 10, 64); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "since must be an event id"})
+				return
+			}
+		}
+
+		conn, err := adminEventsUpgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			logger.Warn("admin_events_upgrade_failed", "remote_addr", c.ClientIP(), "error", err)
+			return
+		}
+		defer conn.Close()
+
+		history, events, unsubscribe := deps.AdminEvents.Subscribe(since)
+		defer unsubscribe()
+		logger.Info("admin_events_connected", "remote_addr", c.ClientIP(), "since", since, "history", len(history))
+
+		// Dashboards only listen; reading handles control frames and notices a disconnect
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+
+		write := func(event adminevents.Event) bool {
+			conn.SetWriteDeadline(time.Now().Add(adminEventsWriteWait))
+			return conn.WriteJSON(event) == nil
+		}
+		for _, event := range history {
+			if !write(event) {
+				return
+			}
+		}
+
+		ticker := time.NewTicker(adminEventsPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-closed:
+				return
+			case event, ok := <-events:
+				if !ok {
+					logger.Info("admin_events_subscriber_too_slow", "remote_addr", c.ClientIP())
+					conn.WriteControl(websocket.CloseMessage,
+						websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow, reconnect with since"),
+						time.Now().Add(adminEventsWriteWait))
+					return
+				}
+				if !write(event) {
+					return
+				}
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(adminEventsWriteWait)); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
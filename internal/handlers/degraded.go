@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"asr_server/internal/bootstrap"
+)
+
+// degradedReason 降级原因：服务仍可处理请求，但某项资源已接近耗尽
+type degradedReason struct {
+	Component string `json:"component"`
+	Reason    string `json:"reason"`
+}
+
+// degradedReasons 检查 VAD 池、识别任务队列与磁盘剩余空间，返回所有降级原因。
+// 阈值每次读取配置，热更新生效
+func degradedReasons(deps *bootstrap.AppDependencies) []degradedReason {
+	cfg := deps.Config.Health
+	var reasons []degradedReason
+
+	if err := vadPoolReady(deps); err != nil {
+		reasons = append(reasons, degradedReason{"vad_pool", err.Error()})
+	}
+
+	if cfg.QueueDegradedRatio > 0 && deps.SessionManager != nil {
+		queued, maxQueued := deps.SessionManager.RecognitionQueueLoad()
+		if maxQueued > 0 && float64(queued) >= cfg.QueueDegradedRatio*float64(maxQueued) {
+			reasons = append(reasons, degradedReason{"recognition_queue", fmt.Sprintf("%d of %d queued recognition tasks", queued, maxQueued)})
+		}
+	}
+
+	if cfg.MinFreeDiskMB > 0 {
+		for _, dir := range dataDirs(deps) {
+			free, err := freeDiskBytes(dir.path)
+			if err != nil {
+				// 不支持的平台或目录尚未创建时跳过
+				continue
+			}
+			if freeMB := free >> 20; freeMB < uint64(cfg.MinFreeDiskMB) {
+				reasons = append(reasons, degradedReason{"disk", fmt.Sprintf("%s directory %s has %d MB free", dir.name, dir.path, freeMB)})
+			}
+		}
+	}
+	return reasons
+}
+
+// dataDir 服务写入数据的目录
+type dataDir struct {
+	name string
+	path string
+}
+
+// dataDirs 返回服务写入数据的目录：日志文件、批量任务音频与声纹数据
+func dataDirs(deps *bootstrap.AppDependencies) []dataDir {
+	cfg := deps.Config
+	var dirs []dataDir
+	if cfg.Logging.Output == "file" || cfg.Logging.Output == "both" {
+		dirs = append(dirs, dataDir{"logs", filepath.Dir(cfg.Logging.FilePath)})
+	}
+	if cfg.Jobs.Enabled {
+		dirs = append(dirs, dataDir{"jobs", cfg.Jobs.DataDir})
+	}
+	if cfg.Speaker.Enabled {
+		dirs = append(dirs, dataDir{"speaker", cfg.Speaker.DataDir})
+	}
+	return dirs
+}
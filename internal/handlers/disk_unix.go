@@ -0,0 +1,14 @@
+//go:build !windows
+
+package handlers
+
+import "syscall"
+
+// freeDiskBytes 返回 dir 所在文件系统对非特权用户可用的剩余空间
+func freeDiskBytes(dir string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package handlers
+
+import "errors"
+
+// freeDiskBytes Windows 下不检查磁盘剩余空间
+func freeDiskBytes(dir string) (uint64, error) {
+	return 0, errors.New("free disk space check is not supported on windows")
+}
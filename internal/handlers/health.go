@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"asr_server/config"
 	"asr_server/internal/bootstrap"
+	"context"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -33,17 +37,121 @@ func HealthHandler(deps *bootstrap.AppDependencies) gin.HandlerFunc {
 			components["speaker"] = map[string]interface{}{"status": "disabled"}
 		}
 
-		status := "healthy"
-		if deps.VADPool == nil || deps.SessionManager == nil || deps.RateLimiter == nil {
-			status = "initializing"
-			c.Status(503)
-		}
-
 		health := map[string]interface{}{
-			"status":     status,
+			"status":     "healthy",
 			"timestamp":  time.Now().Format(time.RFC3339),
 			"components": components,
 		}
-		c.JSON(200, health)
+		code := 200
+		if deps.VADPool == nil || deps.SessionManager == nil || deps.RateLimiter == nil {
+			health["status"] = "initializing"
+			code = 503
+		} else if reasons := degradedReasons(deps); len(reasons) > 0 {
+			health["status"] = "degraded"
+			health["reasons"] = reasons
+			if deps.Config.Health.DegradedStatusCode != 0 {
+				code = deps.Config.Health.DegradedStatusCode
+			}
+		}
+		c.JSON(code, health)
+	}
+}
+
+// LiveHandler 存活探针：进程能处理请求即返回 200，不检查依赖，避免依赖故障导致容器被反复重启
+func LiveHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"status":    "alive",
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+	}
+}
+
+// ReadyHandler 就绪探针：识别器已加载、VAD 池有空闲实例且未在关闭时返回 200，否则返回 503。
+// 启用 health.decode_check 时额外解码一段静音，结果按 decode_check_interval 缓存
+func ReadyHandler(deps *bootstrap.AppDependencies) gin.HandlerFunc {
+	var (
+		decodeMu      sync.Mutex
+		decodeChecked time.Time
+		decodeErr     error
+	)
+	probeDecode := func(ctx context.Context) error {
+		decodeMu.Lock()
+		defer decodeMu.Unlock()
+		cfg := deps.Config.Health
+		if !decodeChecked.IsZero() && time.Since(decodeChecked) < time.Duration(cfg.DecodeCheckInterval)*time.Second {
+			return decodeErr
+		}
+		timeout := time.Duration(cfg.DecodeCheckTimeout) * time.Second
+		if timeout <= 0 {
+			timeout = time.Duration(config.DefaultHealthDecodeCheckTimeout) * time.Second
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		decodeErr = deps.SessionManager.ProbeDecode(ctx)
+		decodeChecked = time.Now()
+		return decodeErr
+	}
+
+	return func(c *gin.Context) {
+		checks := make(map[string]interface{})
+		ready := true
+		check := func(name string, err error) {
+			if err != nil {
+				ready = false
+				checks[name] = gin.H{"status": "fail", "error": err.Error()}
+				return
+			}
+			checks[name] = gin.H{"status": "ok"}
+		}
+
+		check("recognizer", recognizerReady(deps))
+		check("vad_pool", vadPoolReady(deps))
+		if deps.SessionManager == nil {
+			check("sessions", fmt.Errorf("session manager is not initialized"))
+		} else if deps.SessionManager.Draining() {
+			check("sessions", fmt.Errorf("server is shutting down"))
+		} else {
+			check("sessions", nil)
+		}
+		if deps.Config.Health.DecodeCheck && ready {
+			check("decode", probeDecode(c.Request.Context()))
+		}
+
+		status, code := "ready", 200
+		if !ready {
+			status, code = "not_ready", 503
+		}
+		c.JSON(code, gin.H{
+			"status":    status,
+			"timestamp": time.Now().Format(time.RFC3339),
+			"checks":    checks,
+		})
+	}
+}
+
+// recognizerReady 检查离线识别器已加载；流式模式下还需在线识别器
+func recognizerReady(deps *bootstrap.AppDependencies) error {
+	if deps.GlobalRecognizer == nil {
+		return fmt.Errorf("recognizer is not loaded")
+	}
+	if deps.Config.Recognition.Mode == "streaming" && (deps.SessionManager == nil || !deps.SessionManager.Streaming()) {
+		return fmt.Errorf("online recognizer is not loaded")
+	}
+	return nil
+}
+
+// vadPoolReady 检查 VAD 池已初始化且有空闲实例
+func vadPoolReady(deps *bootstrap.AppDependencies) error {
+	if deps.VADPool == nil {
+		return fmt.Errorf("VAD pool is not initialized")
+	}
+	stats := deps.VADPool.GetStats()
+	if total, _ := stats["total_instances"].(int); total == 0 {
+		return fmt.Errorf("VAD pool has no instances")
+	}
+	if available, _ := stats["available_count"].(int); available == 0 {
+		return fmt.Errorf("no VAD instance is available")
 	}
+	return nil
 }
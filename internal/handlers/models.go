@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	"asr_server/internal/bootstrap"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ModelsHandler 列出已安装的模型及其版本与校验值（依赖注入）
+func ModelsHandler(deps *bootstrap.AppDependencies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"cache_dir": deps.ModelManager.CacheDir(),
+			"models":    deps.ModelManager.Installed(),
+		})
+	}
+}
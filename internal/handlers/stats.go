@@ -22,6 +22,20 @@ func StatsHandler(deps *bootstrap.AppDependencies) gin.HandlerFunc {
 		if deps.RateLimiter != nil {
 			stats["rate_limit"] = deps.RateLimiter.GetStats()
 		}
+		if deps.JobsManager != nil {
+			stats["jobs"] = deps.JobsManager.GetStats()
+		}
 		c.JSON(200, stats)
 	}
 }
+
+// MetricsHandler Prometheus 指标接口：按模型与阶段输出识别延迟直方图，以及识别队列深度与丢弃的语音段
+func MetricsHandler(deps *bootstrap.AppDependencies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		c.Status(200)
+		if deps.SessionManager != nil {
+			deps.SessionManager.WriteMetrics(c.Writer)
+		}
+	}
+}
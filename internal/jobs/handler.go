@@ -0,0 +1,112 @@
+package jobs
+
+import (
+	"errors"
+	"net/http"
+
+	"asr_server/config"
+	"asr_server/internal/logger"
+	"asr_server/internal/middleware"
+	"asr_server/internal/pool"
+	"asr_server/internal/transcribe"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler handles batch transcription job HTTP requests.
+// All dependencies are explicitly injected via constructor.
+type Handler struct {
+	manager *Manager
+	cfg     *config.Config
+}
+
+// NewHandler creates a new handler with explicit dependencies
+func NewHandler(manager *Manager, cfg *config.Config) *Handler {
+	return &Handler{
+		manager: manager,
+		cfg:     cfg,
+	}
+}
+
+// RegisterRoutes registers routes
+func (h *Handler) RegisterRoutes(router gin.IRouter) {
+	apiGroup := router.Group("/api/v1")
+	{
+		apiGroup.POST("/jobs", middleware.UploadLimit(h.cfg.Transcription.MaxFileSize, h.cfg.Server.MultipartMemory), h.Submit)
+		apiGroup.GET("/jobs/:id", h.Get)
+		apiGroup.DELETE("/jobs/:id", h.Cancel)
+	}
+}
+
+// Submit enqueues an uploaded WAV file for transcription
+func (h *Handler) Submit(c *gin.Context) {
+	// The form is parsed under the size limit by UploadLimit
+	file, header, err := c.Request.FormFile("audio")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "audio file is required",
+		})
+		return
+	}
+	defer file.Close()
+
+	if !transcribe.IsSupportedFile(header.Filename) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": transcribe.ErrUnsupportedFile.Error(),
+		})
+		return
+	}
+
+	job, err := h.manager.Submit(header.Filename, file, transcribe.RequestSplitChannels(c))
+	if err != nil {
+		if errors.Is(err, pool.ErrQueueFull) || errors.Is(err, pool.ErrPoolShutdown) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		logger.Error("job_submit_failed", "request_id", c.GetString("request_id"), "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Location", "/api/v1/jobs/"+job.ID)
+	c.JSON(http.StatusAccepted, job)
+}
+
+// Get returns a job's status, and its result once completed.
+// With format=text|srt|vtt only the result of a completed job is returned in that format.
+func (h *Handler) Get(c *gin.Context) {
+	format, err := transcribe.RequestFormat(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := h.manager.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if format == transcribe.FormatJSON {
+		c.JSON(http.StatusOK, job)
+		return
+	}
+	if job.Status != StatusCompleted || job.Result == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "job has not completed", "status": job.Status})
+		return
+	}
+	transcribe.WriteResult(c, format, job.Result)
+}
+
+// Cancel cancels a queued or running job
+func (h *Handler) Cancel(c *gin.Context) {
+	job, err := h.manager.Cancel(c.Param("id"))
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrJobFinished):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "status": job.Status})
+	default:
+		c.JSON(http.StatusOK, job)
+	}
+}
@@ -0,0 +1,328 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"asr_server/config"
+	"asr_server/internal/logger"
+	"asr_server/internal/pool"
+	"asr_server/internal/session"
+	"asr_server/internal/transcribe"
+)
+
+// pruneInterval is how often finished jobs beyond the configured retention are deleted
+const pruneInterval = 10 * time.Minute
+
+// Job errors
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobFinished = errors.New("job already finished")
+)
+
+// Manager queues batch transcription jobs and runs them on a fixed set of workers.
+// All dependencies are explicitly injected via constructor.
+type Manager struct {
+	cfg     *config.Config
+	service *transcribe.Service
+	store   *store
+
+	workerCount int
+	queue       chan string
+	wg          sync.WaitGroup
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	running map[string]context.CancelFunc
+}
+
+// NewManager creates a job manager, restores unfinished jobs from disk and starts the workers
+func NewManager(cfg *config.Config, service *transcribe.Service) (*Manager, error) {
+	st, err := newStore(cfg.Jobs.DataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	workerCount := cfg.Jobs.WorkerCount
+	if workerCount <= 0 {
+		workerCount = config.DefaultJobsWorkerCount
+	}
+	queueSize := cfg.Jobs.QueueSize
+	if queueSize <= 0 {
+		queueSize = config.DefaultJobsQueueSize
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Manager{
+		cfg:         cfg,
+		service:     service,
+		store:       st,
+		workerCount: workerCount,
+		queue:       make(chan string, queueSize),
+		ctx:         ctx,
+		cancel:      cancel,
+		running:     make(map[string]context.CancelFunc),
+	}
+
+	for i := 0; i < workerCount; i++ {
+		m.wg.Add(1)
+		go m.worker()
+	}
+	m.prune()
+	m.wg.Add(1)
+	go m.pruneLoop()
+
+	// Requeue jobs interrupted by a previous shutdown. Enqueue in the background
+	// since there may be more pending jobs than queue capacity.
+	pending := st.pending()
+	if len(pending) > 0 {
+		logger.Info("jobs_restored", "count", len(pending))
+		go func() {
+			for _, job := range pending {
+				if job.Status == StatusRunning {
+					m.store.update(job.ID, func(j *Job) { j.Status = StatusQueued; j.StartedAt = nil })
+				}
+				select {
+				case m.queue <- job.ID:
+				case <-m.ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	return m, nil
+}
+
+// Submit stores the uploaded audio and enqueues a new job
+func (m *Manager) Submit(filename string, r io.Reader, splitChannels bool) (Job, error) {
+	select {
+	case <-m.ctx.Done():
+		return Job{}, pool.ErrPoolShutdown
+	default:
+	}
+
+	job := Job{
+		ID:            newJobID(),
+		Status:        StatusQueued,
+		Filename:      filename,
+		SplitChannels: splitChannels,
+		CreatedAt:     time.Now(),
+	}
+
+	f, err := os.Create(m.store.audioPath(job.ID))
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to store audio: %v", err)
+	}
+	_, err = io.Copy(f, r)
+	f.Close()
+	if err != nil {
+		m.store.removeAudio(job.ID)
+		return Job{}, fmt.Errorf("failed to store audio: %v", err)
+	}
+
+	if err := m.store.save(job); err != nil {
+		m.store.removeAudio(job.ID)
+		return Job{}, fmt.Errorf("failed to save job: %v", err)
+	}
+
+	select {
+	case m.queue <- job.ID:
+	default:
+		m.finish(job.ID, StatusFailed, nil, pool.ErrQueueFull)
+		return Job{}, pool.ErrQueueFull
+	}
+
+	logger.Info("job_submitted", "job_id", job.ID, "filename", filename)
+	return job, nil
+}
+
+// Get returns a job by ID
+func (m *Manager) Get(id string) (Job, error) {
+	job, exists := m.store.get(id)
+	if !exists {
+		return Job{}, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// Cancel cancels a queued or running job
+func (m *Manager) Cancel(id string) (Job, error) {
+	job, exists := m.store.get(id)
+	if !exists {
+		return Job{}, ErrJobNotFound
+	}
+	if job.isFinished() {
+		return job, ErrJobFinished
+	}
+
+	m.mu.Lock()
+	cancel, running := m.running[id]
+	m.mu.Unlock()
+	if running {
+		// The worker records the cancelled status once Transcribe returns
+		cancel()
+	}
+
+	job = m.finish(id, StatusCancelled, nil, nil)
+	logger.Info("job_cancelled", "job_id", id)
+	return job, nil
+}
+
+// Shutdown stops the workers; unfinished jobs are resumed on the next start
+func (m *Manager) Shutdown() {
+	m.cancel()
+	m.wg.Wait()
+	logger.Info("job_manager_shutdown")
+}
+
+// GetStats returns job statistics
+func (m *Manager) GetStats() map[string]interface{} {
+	m.mu.Lock()
+	running := len(m.running)
+	m.mu.Unlock()
+
+	return map[string]interface{}{
+		"queued":  len(m.queue),
+		"running": running,
+		"workers": m.workerCount,
+	}
+}
+
+// worker processes queued jobs until shutdown
+func (m *Manager) worker() {
+	defer m.wg.Done()
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case id := <-m.queue:
+			m.run(id)
+		}
+	}
+}
+
+// pruneLoop periodically deletes finished jobs until shutdown
+func (m *Manager) pruneLoop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+			m.prune()
+		}
+	}
+}
+
+// prune deletes finished jobs older than jobs.retention_hours and the oldest beyond
+// jobs.max_finished. The configuration is read on every sweep, so hot reloads apply.
+func (m *Manager) prune() {
+	var cutoff time.Time
+	if hours := m.cfg.Jobs.RetentionHours; hours > 0 {
+		cutoff = time.Now().Add(-time.Duration(hours) * time.Hour)
+	}
+	if removed := m.store.prune(cutoff, m.cfg.Jobs.MaxFinished); removed > 0 {
+		logger.Info("jobs_pruned", "removed", removed, "retention_hours", m.cfg.Jobs.RetentionHours, "max_finished", m.cfg.Jobs.MaxFinished)
+	}
+}
+
+// run transcribes a single job
+func (m *Manager) run(id string) {
+	job, exists := m.store.get(id)
+	if !exists || job.Status != StatusQueued {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	defer cancel()
+
+	m.mu.Lock()
+	m.running[id] = cancel
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		delete(m.running, id)
+		m.mu.Unlock()
+	}()
+
+	now := time.Now()
+	if _, err := m.store.update(id, func(j *Job) { j.Status = StatusRunning; j.StartedAt = &now }); err != nil {
+		logger.Error("job_update_failed", "job_id", id, "error", err)
+		return
+	}
+	logger.Info("job_started", "job_id", id)
+
+	result, err := m.transcribe(ctx, job)
+	if ctx.Err() != nil {
+		// Cancelled jobs are already finished by Cancel; on shutdown the job
+		// stays running and is requeued on the next start
+		return
+	}
+	if err != nil {
+		logger.Error("job_failed", "job_id", id, "error", err)
+		m.finish(id, StatusFailed, nil, err)
+		return
+	}
+
+	m.finish(id, StatusCompleted, result, nil)
+	logger.Info("job_completed", "job_id", id, "duration", result.Duration, "segments", len(result.Segments))
+}
+
+// transcribe decodes the job's stored audio and runs the transcription service
+func (m *Manager) transcribe(ctx context.Context, job Job) (*transcribe.Result, error) {
+	f, err := os.Open(m.store.audioPath(job.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audio: %v", err)
+	}
+	defer f.Close()
+
+	channels, err := transcribe.DecodeAudio(f, job.Filename, m.cfg.Audio.SampleRate, job.SplitChannels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse audio file: %v", err)
+	}
+	// Jobs yield recognition workers to interactive sessions
+	return m.service.TranscribeAudio(transcribe.WithPriority(ctx, session.PriorityBatch), channels, nil)
+}
+
+// finish moves a job to a terminal status and releases its audio
+func (m *Manager) finish(id, status string, result *transcribe.Result, jobErr error) Job {
+	now := time.Now()
+	job, err := m.store.update(id, func(j *Job) {
+		if j.isFinished() {
+			return
+		}
+		j.Status = status
+		j.FinishedAt = &now
+		j.Result = result
+		if jobErr != nil {
+			j.Error = jobErr.Error()
+		}
+	})
+	if err != nil {
+		logger.Error("job_update_failed", "job_id", id, "error", err)
+	}
+	m.store.removeAudio(id)
+	return job
+}
+
+// newJobID generates a unique job ID
+func newJobID() string {
+	bytes := make([]byte, 16)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
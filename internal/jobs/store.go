@@ -0,0 +1,199 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"asr_server/internal/logger"
+	"asr_server/internal/transcribe"
+)
+
+// Job status values
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// Job is a batch transcription job
+type Job struct {
+	ID            string             `json:"id"`
+	Status        string             `json:"status"`
+	Filename      string             `json:"filename"`
+	SplitChannels bool               `json:"split_channels,omitempty"` // Transcribe each channel separately
+	CreatedAt     time.Time          `json:"created_at"`
+	StartedAt     *time.Time         `json:"started_at,omitempty"`
+	FinishedAt    *time.Time         `json:"finished_at,omitempty"`
+	Error         string             `json:"error,omitempty"`
+	Result        *transcribe.Result `json:"result,omitempty"`
+}
+
+// isFinished reports whether the job reached a terminal status
+func (j *Job) isFinished() bool {
+	return j.Status == StatusCompleted || j.Status == StatusFailed || j.Status == StatusCancelled
+}
+
+// store persists each job as <id>.json and its uploaded audio as <id>.wav in a directory
+type store struct {
+	dir string
+	mu  sync.RWMutex
+	// In-memory copy of all jobs, keyed by ID
+	jobs map[string]*Job
+}
+
+// newStore opens the job directory and loads existing jobs
+func newStore(dir string) (*store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create job directory: %v", err)
+	}
+
+	s := &store{dir: dir, jobs: make(map[string]*Job)}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job directory: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read job file %s: %v", entry.Name(), err)
+		}
+		var job Job
+		if err := json.Unmarshal(data, &job); err != nil {
+			return nil, fmt.Errorf("failed to parse job file %s: %v", entry.Name(), err)
+		}
+		s.jobs[job.ID] = &job
+		// Audio outlives its job when the server stopped before finish removed it
+		if job.isFinished() {
+			s.removeAudio(job.ID)
+		}
+	}
+	return s, nil
+}
+
+// audioPath returns the path of a job's uploaded audio file
+func (s *store) audioPath(id string) string {
+	return filepath.Join(s.dir, id+".wav")
+}
+
+// get returns a copy of the job
+func (s *store) get(id string) (Job, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	job, exists := s.jobs[id]
+	if !exists {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// pending returns unfinished jobs ordered by creation time
+func (s *store) pending() []Job {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jobs := make([]Job, 0)
+	for _, job := range s.jobs {
+		if !job.isFinished() {
+			jobs = append(jobs, *job)
+		}
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].CreatedAt.Before(jobs[k].CreatedAt) })
+	return jobs
+}
+
+// save writes the job to memory and disk
+func (s *store) save(job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.writeLocked(job)
+}
+
+// update applies fn to the stored job and persists the result atomically with respect to other updates
+func (s *store) update(id string, fn func(job *Job)) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, exists := s.jobs[id]
+	if !exists {
+		return Job{}, fmt.Errorf("job %s not found", id)
+	}
+	job := *stored
+	fn(&job)
+	return job, s.writeLocked(job)
+}
+
+// writeLocked persists the job via a temporary file and rename; s.mu must be held
+func (s *store) writeLocked(job Job) error {
+	data, err := json.MarshalIndent(&job, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := filepath.Join(s.dir, job.ID+".json.tmp")
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, filepath.Join(s.dir, job.ID+".json")); err != nil {
+		return err
+	}
+
+	s.jobs[job.ID] = &job
+	return nil
+}
+
+// removeAudio deletes a job's uploaded audio once it is no longer needed
+func (s *store) removeAudio(id string) {
+	os.Remove(s.audioPath(id))
+}
+
+// prune deletes finished jobs that finished before cutoff, unless cutoff is zero, and the
+// oldest finished jobs beyond maxFinished, unless it is 0. It returns the number deleted.
+func (s *store) prune(cutoff time.Time, maxFinished int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	finished := make([]*Job, 0)
+	for _, job := range s.jobs {
+		if job.isFinished() {
+			finished = append(finished, job)
+		}
+	}
+	// Newest first, so the jobs kept by maxFinished come first
+	sort.Slice(finished, func(i, k int) bool { return finishedAt(finished[k]).Before(finishedAt(finished[i])) })
+
+	removed := 0
+	for i, job := range finished {
+		expired := !cutoff.IsZero() && finishedAt(job).Before(cutoff)
+		if !expired && (maxFinished <= 0 || i < maxFinished) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, job.ID+".json")); err != nil && !os.IsNotExist(err) {
+			logger.Warn("job_remove_failed", "job_id", job.ID, "error", err)
+			continue
+		}
+		s.removeAudio(job.ID)
+		delete(s.jobs, job.ID)
+		removed++
+	}
+	return removed
+}
+
+// finishedAt returns when a finished job finished, or its creation time if not recorded
+func finishedAt(job *Job) time.Time {
+	if job.FinishedAt != nil {
+		return *job.FinishedAt
+	}
+	return job.CreatedAt
+}
@@ -7,6 +7,10 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"sync/atomic"
+	"time"
+
+	"asr_server/internal/redact"
 
 	"gopkg.in/natefinch/lumberjack.v2"
 )
@@ -15,23 +19,59 @@ var (
 	Logger       *slog.Logger
 	levelVar     *slog.LevelVar // For dynamic log level changes
 	outputCloser io.Closer      // To handle graceful shutdown of log files
+	observer     atomic.Pointer[Observer]
 )
 
-// Sensitive keywords for automatic redaction
-var sensitiveKeywords = []string{
-	"password", "passwd", "pwd",
-	"secret", "private", "privatekey",
-	"key", "apikey", "api_key",
-	"token", "auth", "authorization",
-	"credential", "cred",
+// Observer receives warnings and errors as they are logged, with their key-value pairs in a
+// map and sensitive values redacted. It runs on the logging goroutine and must not block or
+// log warnings or errors itself.
+type Observer func(level slog.Level, msg string, attrs map[string]any)
+
+// SetObserver installs fn to receive warnings and errors; nil removes it
+func SetObserver(fn Observer) {
+	if fn == nil {
+		observer.Store(nil)
+		return
+	}
+	observer.Store(&fn)
 }
 
-// InitLogger initializes the logging system with rotation and multiple outputs.
-func InitLogger(level slog.Level, format, output, filePath string, maxSize, maxBackups, maxAge int, compress bool) {
+// notify passes a warning or error to the observer
+func notify(level slog.Level, msg string, args []any) {
+	fn := observer.Load()
+	if fn == nil {
+		return
+	}
+	attrs := make(map[string]any, len(args)/2)
+	record := slog.NewRecord(time.Time{}, level, msg, 0)
+	record.Add(args...)
+	record.Attrs(func(a slog.Attr) bool {
+		a = sanitizeAttr(a)
+		if err, ok := a.Value.Any().(error); ok {
+			attrs[a.Key] = err.Error()
+		} else {
+			attrs[a.Key] = a.Value.Any()
+		}
+		return true
+	})
+	(*fn)(level, msg, attrs)
+}
+
+// InitLogger initializes the logging system with rotation and multiple outputs. The "otlp"
+// output exports records to an OpenTelemetry collector as configured by otlp, in place of
+// the format.
+func InitLogger(level slog.Level, format, output, filePath string, maxSize, maxBackups, maxAge int, compress bool, otlp OTLPOptions) {
 	// Initialize dynamic level
 	levelVar = &slog.LevelVar{}
 	levelVar.Set(level)
 
+	if output == "otlp" {
+		exporter := newOTLPExporter(otlp)
+		outputCloser = exporter
+		Logger = slog.New(&otlpHandler{exporter: exporter, level: levelVar})
+		return
+	}
+
 	var writers []io.Writer
 	if output == "console" || output == "both" {
 		writers = append(writers, os.Stdout)
@@ -91,7 +131,7 @@ func Close() error {
 }
 
 // InitFromConfig initializes the logger using individual parameters to avoid package cycles.
-func InitFromConfig(level, format, output, filePath string, maxSize, maxBackups, maxAge int, compress bool) {
+func InitFromConfig(level, format, output, filePath string, maxSize, maxBackups, maxAge int, compress bool, otlp OTLPOptions) {
 	InitLogger(
 		parseSlogLevel(level),
 		format,
@@ -101,6 +141,7 @@ func InitFromConfig(level, format, output, filePath string, maxSize, maxBackups,
 		maxBackups,
 		maxAge,
 		compress,
+		otlp,
 	)
 }
 
@@ -133,12 +174,14 @@ func Error(msg string, args ...any) {
 	if Logger != nil {
 		Logger.Error(msg, args...)
 	}
+	notify(slog.LevelError, msg, args)
 }
 
 func Warn(msg string, args ...any) {
 	if Logger != nil {
 		Logger.Warn(msg, args...)
 	}
+	notify(slog.LevelWarn, msg, args)
 }
 
 func Debug(msg string, args ...any) {
@@ -155,6 +198,7 @@ func ErrorWithStack(msg string, err error, args ...any) {
 		allArgs := append(args, "error", err, "stack", captureStack(3))
 		Logger.Error(msg, allArgs...)
 	}
+	notify(slog.LevelError, msg, append(args, "error", err))
 }
 
 // WarnWithContext logs a warning with context information.
@@ -162,6 +206,7 @@ func WarnWithContext(ctx context.Context, msg string, args ...any) {
 	if Logger != nil {
 		Logger.WarnContext(ctx, msg, args...)
 	}
+	notify(slog.LevelWarn, msg, args)
 }
 
 // InfoWithContext logs info with context information.
@@ -186,14 +231,10 @@ func captureStack(skip int) string {
 }
 
 // sanitizeAttr checks if an attribute contains sensitive information and redacts it.
+// Keys are checked with the rules configured in logging.redaction.
 func sanitizeAttr(a slog.Attr) slog.Attr {
-	keyLower := strings.ToLower(a.Key)
-
-	// Check if key contains sensitive keywords
-	for _, keyword := range sensitiveKeywords {
-		if strings.Contains(keyLower, keyword) {
-			return slog.String(a.Key, "[REDACTED]")
-		}
+	if redact.IsSensitiveKey(a.Key) {
+		return slog.String(a.Key, "[REDACTED]")
 	}
 
 	// Handle nested groups
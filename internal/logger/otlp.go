@@ -0,0 +1,408 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// otlpQueueSize bounds records waiting to be exported; more are dropped and counted
+	otlpQueueSize = 8192
+	// otlpRequestTimeout bounds one export request
+	otlpRequestTimeout = 10 * time.Second
+	// otlpRetryDelay is the wait before the first retry, doubled for each further one
+	otlpRetryDelay = 500 * time.Millisecond
+	// otlpDefaultEndpoint is the collector's OTLP/HTTP address when none is configured
+	otlpDefaultEndpoint = "http://localhost:4318"
+	// otlpScopeName is the instrumentation scope of exported records
+	otlpScopeName = "asr_server"
+)
+
+// OTLPOptions configures exporting logs to an OpenTelemetry collector over OTLP/HTTP
+type OTLPOptions struct {
+	// Collector base URL; records are posted to <endpoint>/v1/logs. Empty uses
+	// OTEL_EXPORTER_OTLP_ENDPOINT, or http://localhost:4318.
+	Endpoint string
+	// Extra request headers as key=value, e.g. for collector authentication. Added to
+	// OTEL_EXPORTER_OTLP_HEADERS.
+	Headers []string
+	// Resource attributes as key=value, added to OTEL_RESOURCE_ATTRIBUTES and
+	// OTEL_SERVICE_NAME, so logs share the resource of traces and metrics exported by
+	// other components configured through the same variables
+	ResourceAttributes []string
+	BatchSize          int           // Records per export request
+	FlushInterval      time.Duration // Longest wait before a partial batch is exported
+	MaxRetries         int           // Retries of a failed export before its batch is dropped
+}
+
+// otlpKeyValue is an attribute in the OTLP JSON encoding
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+// otlpValue is an AnyValue in the OTLP JSON encoding. 64-bit integers are strings.
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+// otlpLogRecord is a LogRecord in the OTLP JSON encoding
+type otlpLogRecord struct {
+	TimeUnixNano         string         `json:"timeUnixNano"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+	SeverityNumber       int            `json:"severityNumber"`
+	SeverityText         string         `json:"severityText"`
+	Body                 otlpValue      `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+// otlpHandler is a slog.Handler that queues records for an otlpExporter. Attributes of
+// groups are flattened to dotted keys, e.g. "request.id".
+type otlpHandler struct {
+	exporter *otlpExporter
+	level    slog.Leveler
+	attrs    []otlpKeyValue
+	prefix   string // Dotted path of the open groups
+}
+
+func (h *otlpHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *otlpHandler) Handle(_ context.Context, r slog.Record) error {
+	record := otlpLogRecord{
+		TimeUnixNano:         strconv.FormatInt(r.Time.UnixNano(), 10),
+		ObservedTimeUnixNano: strconv.FormatInt(time.Now().UnixNano(), 10),
+		SeverityNumber:       otlpSeverity(r.Level),
+		SeverityText:         r.Level.String(),
+		Body:                 stringValue(r.Message),
+		Attributes:           append([]otlpKeyValue(nil), h.attrs...),
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		record.Attributes = appendAttr(record.Attributes, h.prefix, a)
+		return true
+	})
+	h.exporter.enqueue(record)
+	return nil
+}
+
+func (h *otlpHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append([]otlpKeyValue(nil), h.attrs...)
+	for _, a := range attrs {
+		clone.attrs = appendAttr(clone.attrs, h.prefix, a)
+	}
+	return &clone
+}
+
+func (h *otlpHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+// appendAttr converts a redacted attribute, flattening groups
+func appendAttr(kvs []otlpKeyValue, prefix string, a slog.Attr) []otlpKeyValue {
+	a = sanitizeAttr(a)
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return kvs
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, attr := range a.Value.Group() {
+			kvs = appendAttr(kvs, prefix, attr)
+		}
+		return kvs
+	}
+	return append(kvs, otlpKeyValue{Key: prefix + a.Key, Value: toOTLPValue(a.Value)})
+}
+
+// toOTLPValue converts a resolved slog value; values without an OTLP counterpart are
+// encoded as JSON strings
+func toOTLPValue(v slog.Value) otlpValue {
+	switch v.Kind() {
+	case slog.KindString:
+		return stringValue(v.String())
+	case slog.KindBool:
+		b := v.Bool()
+		return otlpValue{BoolValue: &b}
+	case slog.KindInt64:
+		i := strconv.FormatInt(v.Int64(), 10)
+		return otlpValue{IntValue: &i}
+	case slog.KindUint64:
+		i := strconv.FormatUint(v.Uint64(), 10)
+		return otlpValue{IntValue: &i}
+	case slog.KindFloat64:
+		f := v.Float64()
+		return otlpValue{DoubleValue: &f}
+	case slog.KindTime:
+		return stringValue(v.Time().Format(time.RFC3339Nano))
+	case slog.KindDuration:
+		return stringValue(v.Duration().String())
+	}
+	switch value := v.Any().(type) {
+	case error:
+		return stringValue(value.Error())
+	case fmt.Stringer:
+		return stringValue(value.String())
+	}
+	data, err := json.Marshal(v.Any())
+	if err != nil {
+		return stringValue(fmt.Sprint(v.Any()))
+	}
+	return stringValue(string(data))
+}
+
+func stringValue(s string) otlpValue {
+	return otlpValue{StringValue: &s}
+}
+
+// otlpSeverity maps a slog level to the OTLP severity number, e.g. INFO to 9
+func otlpSeverity(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError:
+		return 17
+	case level >= slog.LevelWarn:
+		return 13
+	case level >= slog.LevelInfo:
+		return 9
+	default:
+		return 5
+	}
+}
+
+// otlpExporter posts queued records in batches to the collector's /v1/logs endpoint,
+// retrying failed requests with backoff. Logging never blocks on the collector: when the
+// queue is full records are dropped, and the drops are reported on stderr.
+type otlpExporter struct {
+	endpoint  string // URL of the /v1/logs endpoint
+	headers   map[string]string
+	resource  []otlpKeyValue
+	batchSize int
+	interval  time.Duration
+	retries   int
+	client    *http.Client
+
+	queue   chan otlpLogRecord
+	dropped atomic.Int64
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// newOTLPExporter starts an exporter; defaults and OTEL_* environment variables fill
+// in what the options leave unset
+func newOTLPExporter(opts OTLPOptions) *otlpExporter {
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+	if endpoint == "" {
+		endpoint = otlpDefaultEndpoint
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = 512
+	}
+	interval := opts.FlushInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	headers := parseKeyValues(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	for k, v := range parseKeyValues(strings.Join(opts.Headers, ",")) {
+		headers[k] = v
+	}
+
+	// service.name defaults to the scope name; OTEL_SERVICE_NAME overrides the one in
+	// OTEL_RESOURCE_ATTRIBUTES, and the configured attributes override both
+	attributes := map[string]string{"service.name": otlpScopeName}
+	for k, v := range parseKeyValues(os.Getenv("OTEL_RESOURCE_ATTRIBUTES")) {
+		attributes[k] = v
+	}
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		attributes["service.name"] = name
+	}
+	for k, v := range parseKeyValues(strings.Join(opts.ResourceAttributes, ",")) {
+		attributes[k] = v
+	}
+	resource := make([]otlpKeyValue, 0, len(attributes))
+	for k, v := range attributes {
+		resource = append(resource, otlpKeyValue{Key: k, Value: stringValue(v)})
+	}
+
+	e := &otlpExporter{
+		endpoint:  strings.TrimSuffix(endpoint, "/") + "/v1/logs",
+		headers:   headers,
+		resource:  resource,
+		batchSize: batchSize,
+		interval:  interval,
+		retries:   opts.MaxRetries,
+		client:    &http.Client{Timeout: otlpRequestTimeout},
+		queue:     make(chan otlpLogRecord, otlpQueueSize),
+		done:      make(chan struct{}),
+	}
+	e.wg.Add(1)
+	go e.run()
+	return e
+}
+
+// parseKeyValues parses comma-separated key=value pairs, the format of the OTEL_*
+// environment variables; values may be URL-encoded
+func parseKeyValues(s string) map[string]string {
+	pairs := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if unescaped, err := url.PathUnescape(value); err == nil {
+			value = unescaped
+		}
+		pairs[key] = value
+	}
+	return pairs
+}
+
+// enqueue queues a record without blocking
+func (e *otlpExporter) enqueue(record otlpLogRecord) {
+	select {
+	case e.queue <- record:
+	default:
+		e.dropped.Add(1)
+	}
+}
+
+// run batches queued records until Close, then exports what is left
+func (e *otlpExporter) run() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	batch := make([]otlpLogRecord, 0, e.batchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			e.export(batch)
+			batch = make([]otlpLogRecord, 0, e.batchSize)
+		}
+		if dropped := e.dropped.Swap(0); dropped > 0 {
+			fmt.Fprintf(os.Stderr, "otlp log export: dropped %d records, queue full\n", dropped)
+		}
+	}
+
+	for {
+		select {
+		case record := <-e.queue:
+			batch = append(batch, record)
+			if len(batch) >= e.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-e.done:
+			for {
+				select {
+				case record := <-e.queue:
+					batch = append(batch, record)
+					if len(batch) >= e.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// export posts a batch, retrying network errors, 429 and 5xx responses with backoff.
+// Errors go to stderr since logging them would feed back into the exporter.
+func (e *otlpExporter) export(batch []otlpLogRecord) {
+	body, err := json.Marshal(map[string]interface{}{
+		"resourceLogs": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{"attributes": e.resource},
+			"scopeLogs": []interface{}{map[string]interface{}{
+				"scope":      map[string]string{"name": otlpScopeName},
+				"logRecords": batch,
+			}},
+		}},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "otlp log export: failed to encode %d records: %v\n", len(batch), err)
+		return
+	}
+
+	delay := otlpRetryDelay
+	for attempt := 0; ; attempt++ {
+		retry, err := e.post(body)
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= e.retries {
+			fmt.Fprintf(os.Stderr, "otlp log export: dropped %d records: %v\n", len(batch), err)
+			return
+		}
+		select {
+		case <-time.After(delay):
+		case <-e.done:
+			// Shutting down: one last attempt without waiting
+			if _, err := e.post(body); err != nil {
+				fmt.Fprintf(os.Stderr, "otlp log export: dropped %d records: %v\n", len(batch), err)
+			}
+			return
+		}
+		delay *= 2
+	}
+}
+
+// post sends one export request and reports whether a failure is worth retrying
+func (e *otlpExporter) post(body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("POST %s: unexpected status %s", e.endpoint, resp.Status)
+}
+
+// Close exports the queued records and stops the exporter
+func (e *otlpExporter) Close() error {
+	close(e.done)
+	e.wg.Wait()
+	return nil
+}
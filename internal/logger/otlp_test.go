@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOTLPExport(t *testing.T) {
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "deployment.environment=staging,host.name=node-1")
+	t.Setenv("OTEL_SERVICE_NAME", "")
+
+	var requests atomic.Int32
+	received := make(chan map[string]interface{}, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first attempt fails, so the batch is retried
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path != "/v1/logs" || r.Header.Get("Authorization") != "Bearer abc" {
+			t.Errorf("unexpected request %s with Authorization %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		received <- body
+	}))
+	defer server.Close()
+
+	exporter := newOTLPExporter(OTLPOptions{
+		Endpoint:           server.URL,
+		Headers:            []string{"Authorization=Bearer abc"},
+		ResourceAttributes: []string{"deployment.environment=prod"},
+		FlushInterval:      time.Hour,
+		MaxRetries:         1,
+	})
+	log := slog.New(&otlpHandler{exporter: exporter, level: slog.LevelInfo})
+	log.With("session_id", "s1").WithGroup("request").Warn("slow_decode", "ms", 2500, "api_key", "k-123")
+	log.Debug("ignored")
+	exporter.Close()
+
+	var body map[string]interface{}
+	select {
+	case body = <-received:
+	default:
+		t.Fatalf("no logs exported after %d requests", requests.Load())
+	}
+
+	resourceLogs := body["resourceLogs"].([]interface{})[0].(map[string]interface{})
+	resource := attributes(resourceLogs["resource"].(map[string]interface{}))
+	want := map[string]string{"service.name": "asr_server", "deployment.environment": "prod", "host.name": "node-1"}
+	for k, v := range want {
+		if resource[k] != v {
+			t.Errorf("resource attribute %s = %v, want %s", k, resource[k], v)
+		}
+	}
+
+	records := resourceLogs["scopeLogs"].([]interface{})[0].(map[string]interface{})["logRecords"].([]interface{})
+	if len(records) != 1 {
+		t.Fatalf("exported %d records, want 1", len(records))
+	}
+	record := records[0].(map[string]interface{})
+	if record["severityNumber"] != float64(13) || record["body"].(map[string]interface{})["stringValue"] != "slow_decode" {
+		t.Errorf("record = %v", record)
+	}
+	attrs := attributes(record)
+	if attrs["session_id"] != "s1" || attrs["request.ms"] != "2500" || attrs["request.api_key"] != "[REDACTED]" {
+		t.Errorf("record attributes = %v", attrs)
+	}
+}
+
+// attributes returns the string and integer attributes of an OTLP JSON object by key
+func attributes(object map[string]interface{}) map[string]interface{} {
+	attrs := make(map[string]interface{})
+	list, _ := object["attributes"].([]interface{})
+	for _, a := range list {
+		kv := a.(map[string]interface{})
+		value := kv["value"].(map[string]interface{})
+		if s, ok := value["stringValue"]; ok {
+			attrs[kv["key"].(string)] = s
+		} else {
+			attrs[kv["key"].(string)] = value["intValue"]
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminPath is the prefix of the admin API. Its routes are authenticated by AdminAuth alone:
+// Authenticate leaves them alone, since both read the Authorization header.
+const AdminPath = "/admin"
+
+// AdminAuth is a middleware that rejects requests without the admin bearer token, the only
+// credential of the admin API. Browser WebSocket clients cannot set headers, so WebSocket
+// upgrades may pass it in the token query parameter instead.
+//
+// Usage:
+//
+//	admin := router.Group(middleware.AdminPath, middleware.AdminAuth(cfg.Admin.Token))
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok && isWebSocketUpgrade(c.Request) {
+			provided, ok = c.GetQuery("token")
+		}
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"asr_server/config"
+	"asr_server/internal/auth"
+	"asr_server/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authenticate is a middleware that rejects unauthenticated requests, except on the configured
+// exclude_paths and their subpaths, and on the admin API, which AdminAuth authenticates with
+// the admin token. A request is authenticated by either:
+//   - a JWT in the Authorization: Bearer header, or in the access_token query parameter for
+//     browser WebSocket clients, which cannot set headers (requires jwtVerifier)
+//   - an HMAC signature in the X-Signature headers, for server-to-server callers (requires
+//     hmacVerifier)
+//
+// Either verifier may be nil to disable that method. JWT claims are stored in the gin context
+// under "jwt_claims" and attached to the request context, for handlers that only see the
+// *http.Request; the key ID of a signed request is attached the same way:
+//
+//	claims, ok := auth.ClaimsFromContext(r.Context())
+//	keyID, ok := auth.KeyIDFromContext(r.Context())
+//
+// When enforce_scopes is set, the caller's scopes are stored under "auth_scopes" for
+// RequireScope. The configuration is read per request, so hot reloads apply.
+//
+// Usage:
+//
+//	router.Use(middleware.Authenticate(jwtVerifier, hmacVerifier, &cfg.Auth))
+func Authenticate(jwtVerifier *auth.Verifier, hmacVerifier *auth.HMACVerifier, cfg *config.AuthConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if isExcludedPath(path, cfg.ExcludePaths) || isExcludedPath(path, []string{AdminPath}) {
+			c.Next()
+			return
+		}
+
+		if hmacVerifier != nil && auth.IsSigned(c.Request) {
+			keyID, body, err := hmacVerifier.Verify(c.Request)
+			if err != nil {
+				rejectUnauthenticated(c, "signature", err)
+				return
+			}
+			defer body.Close()
+			c.Request.Body = body
+			c.Set("auth_subject", "key:"+keyID)
+			if cfg.EnforceScopes {
+				c.Set("auth_scopes", hmacVerifier.Scopes(keyID))
+			}
+			c.Request = c.Request.WithContext(auth.WithKeyID(c.Request.Context(), keyID))
+			logger.Debug("hmac_authenticated", "request_id", c.GetString("request_id"), "key_id", keyID)
+			c.Next()
+			return
+		}
+
+		if jwtVerifier == nil {
+			rejectUnauthenticated(c, "signature", auth.ErrMissingSignature)
+			return
+		}
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok {
+			token = c.Query("access_token")
+		}
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+
+		claims, err := jwtVerifier.Verify(c.Request.Context(), token)
+		if err != nil {
+			rejectUnauthenticated(c, "token", err)
+			return
+		}
+
+		c.Set("jwt_claims", claims)
+		c.Set("auth_subject", claims.Subject)
+		if cfg.EnforceScopes {
+			c.Set("auth_scopes", claims.Scopes)
+		}
+		c.Request = c.Request.WithContext(auth.WithClaims(c.Request.Context(), claims))
+		logger.Debug("jwt_authenticated", "request_id", c.GetString("request_id"), "subject", claims.Subject, "issuer", claims.Issuer)
+		c.Next()
+	}
+}
+
+// RequireScope is a route middleware that rejects callers whose scopes do not include scope
+// with 403. It only applies when Authenticate stored the caller's scopes, that is when
+// enforce_scopes is set and the path is not excluded from authentication.
+//
+// Usage:
+//
+//	group := router.Group("/api/v1/speaker", middleware.RequireScope(auth.ScopeSpeakerRead))
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, enforced := c.Get("auth_scopes")
+		if !enforced {
+			c.Next()
+			return
+		}
+		granted, _ := value.([]string)
+		if !auth.HasScope(granted, scope) {
+			logger.Warn("auth_scope_denied", "request_id", c.GetString("request_id"), "path", c.Request.URL.Path, "subject", c.GetString("auth_subject"), "required_scope", scope)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "missing required scope " + scope})
+			return
+		}
+		c.Next()
+	}
+}
+
+// rejectUnauthenticated logs why a credential was refused and aborts with 401, or 413 when
+// a signed body exceeds the limit
+func rejectUnauthenticated(c *gin.Context, credential string, err error) {
+	logger.Warn("auth_rejected", "request_id", c.GetString("request_id"), "path", c.Request.URL.Path, "credential", credential, "error", err)
+	status := http.StatusUnauthorized
+	if errors.Is(err, auth.ErrBodyTooLarge) {
+		status = http.StatusRequestEntityTooLarge
+	}
+	c.AbortWithStatusJSON(status, gin.H{"error": "invalid " + credential + ": " + err.Error()})
+}
+
+// isExcludedPath reports whether path is one of excludePaths or below one of them.
+// "/" only matches the root page itself.
+func isExcludedPath(path string, excludePaths []string) bool {
+	for _, excluded := range excludePaths {
+		if path == excluded {
+			return true
+		}
+		if excluded != "/" && strings.HasPrefix(path, strings.TrimSuffix(excluded, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
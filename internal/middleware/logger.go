@@ -2,8 +2,11 @@ package middleware
 
 import (
 	"log/slog"
+	"net/url"
+	"strings"
 	"time"
 
+	"asr_server/config"
 	"asr_server/internal/logger"
 
 	"github.com/gin-gonic/gin"
@@ -14,11 +17,17 @@ import (
 //
 // This middleware should be used AFTER the RequestID() middleware to ensure
 // request_id is available.
-func Logger() gin.HandlerFunc {
+//
+// Credentials passed in the query string or as the long-poll stream token are redacted.
+//
+// Requests slower than logging.slow_request_ms are also logged as slow_http_request
+// warnings. WebSocket connections last as long as the session and are not considered.
+func Logger(cfg *config.LoggingConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
 		raw := c.Request.URL.RawQuery
+		upgrade := isWebSocketUpgrade(c.Request)
 
 		// Process request
 		c.Next()
@@ -30,8 +39,11 @@ func Logger() gin.HandlerFunc {
 		method := c.Request.Method
 		requestID := c.GetString("request_id")
 
+		if token := c.Param("token"); token != "" {
+			path = strings.Replace(path, token, redacted, 1)
+		}
 		if raw != "" {
-			path = path + "?" + raw
+			path = path + "?" + redactQuery(raw)
 		}
 
 		// Choose log level based on status code
@@ -46,7 +58,7 @@ func Logger() gin.HandlerFunc {
 		}
 
 		// Log with request_id for traceability
-		logFn("http_request",
+		attrs := []any{
 			slog.String("request_id", requestID),
 			slog.Int("status", statusCode),
 			slog.String("method", method),
@@ -54,6 +66,50 @@ func Logger() gin.HandlerFunc {
 			slog.String("ip", clientIP),
 			slog.Duration("latency", latency),
 			slog.String("user_agent", c.Request.UserAgent()),
-		)
+		}
+		// JWT subject or signing key, set by Authenticate
+		if subject := c.GetString("auth_subject"); subject != "" {
+			attrs = append(attrs, slog.String("subject", subject))
+		}
+		logFn("http_request", attrs...)
+
+		if threshold := time.Duration(cfg.SlowRequestMs) * time.Millisecond; threshold > 0 && latency > threshold && !upgrade {
+			logger.Warn("slow_http_request", append(attrs,
+				slog.String("route", c.FullPath()),
+				slog.String("handler", c.HandlerName()),
+				slog.Int64("request_bytes", c.Request.ContentLength),
+				slog.Int("response_bytes", c.Writer.Size()),
+				slog.Duration("threshold", threshold),
+			)...)
+		}
+	}
+}
+
+// redacted replaces credentials in logged URLs
+const redacted = "REDACTED"
+
+// sensitiveParams are query parameters carrying credentials: JWTs, the admin token, session
+// resume tokens and API keys
+var sensitiveParams = map[string]bool{
+	"access_token": true,
+	"token":        true,
+	"resume_token": true,
+	"api_key":      true,
+}
+
+// redactQuery replaces the values of sensitive parameters in a raw query string, keeping
+// the order of the parameters
+func redactQuery(raw string) string {
+	params := strings.Split(raw, "&")
+	for i, param := range params {
+		key, _, hasValue := strings.Cut(param, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+		if hasValue && sensitiveParams[strings.ToLower(name)] {
+			params[i] = key + "=" + redacted
+		}
 	}
+	return strings.Join(params, "&")
 }
@@ -0,0 +1,27 @@
+package middleware
+
+import "testing"
+
+func TestRedactQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"no credentials", "format=json&page=2", "format=json&page=2"},
+		{"access token", "access_token=eyJhbGci&format=json", "access_token=REDACTED&format=json"},
+		{"admin token", "since=10&token=secret", "since=10&token=REDACTED"},
+		{"resume token and api key", "resume_token=abc&api_key=k1&lang=zh", "resume_token=REDACTED&api_key=REDACTED&lang=zh"},
+		{"case insensitive", "API_KEY=k1", "API_KEY=REDACTED"},
+		{"escaped name", "api%5Fkey=k1", "api%5Fkey=REDACTED"},
+		{"empty value", "token=", "token=REDACTED"},
+		{"flag without value", "token", "token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactQuery(tt.raw); got != tt.want {
+				t.Errorf("redactQuery(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
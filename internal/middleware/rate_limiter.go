@@ -1,12 +1,15 @@
 package middleware
 
 import (
+	"net"
 	"net/http"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"asr_server/config"
+
 	"golang.org/x/time/rate"
 )
 
@@ -19,16 +22,33 @@ const (
 	IdleThreshold = 0.99
 )
 
-// RateLimiter implements a per-IP token bucket rate limiter with connection limits
+// RateLimiter implements per-IP token bucket rate limiting with connection limits.
+// WebSocket upgrades are limited separately from plain HTTP requests: a long-lived
+// connection is a single request, so it is limited by its upgrade rate and by the number
+// of connections an IP holds open rather than by the request rate.
 type RateLimiter struct {
-	enabled        bool
-	limiters       map[string]*limiterEntry
-	mu             sync.RWMutex
-	r              rate.Limit
-	b              int
+	enabled        atomic.Bool
+	requests       *limiterSet
+	upgrades       atomic.Pointer[limiterSet] // nil when upgrades are not rate limited
 	maxConns       int32
 	connCount      int32
 	cleanupStarted int32 // atomic flag to prevent multiple cleanup goroutines
+
+	maxWSPerIP int
+	wsMu       sync.Mutex
+	wsConns    map[string]int // Open WebSocket connections per IP
+	wsTotal    int32
+
+	// Reverse proxies whose forwarding headers identify the client, see clientIP
+	trustedProxies []*net.IPNet
+}
+
+// limiterSet holds a token bucket per client IP
+type limiterSet struct {
+	limiters map[string]*limiterEntry
+	mu       sync.RWMutex
+	r        rate.Limit
+	b        int
 }
 
 // limiterEntry wraps a rate.Limiter with last access time for cleanup
@@ -37,23 +57,59 @@ type limiterEntry struct {
 	lastAccess time.Time
 }
 
-// NewRateLimiter creates a new rate limiter instance
-func NewRateLimiter(enabled bool, requestsPerSecond int, burstSize int, maxConnections int) *RateLimiter {
-	return &RateLimiter{
-		enabled:  enabled,
+// NewRateLimiter creates a new rate limiter instance. Clients are identified by their
+// address, or by the forwarding headers of requests from trustedProxies (IPs or CIDRs).
+func NewRateLimiter(cfg config.RateLimitConfig, trustedProxies []string) *RateLimiter {
+	rl := &RateLimiter{
+		requests:       newLimiterSet(rate.Limit(cfg.RequestsPerSecond), cfg.BurstSize),
+		wsConns:        make(map[string]int),
+		trustedProxies: parseTrustedProxies(trustedProxies),
+	}
+	rl.ApplyConfig(cfg)
+	return rl
+}
+
+// ApplyConfig updates the limits from a reloaded configuration. Tracked IPs keep their
+// buckets, which are resized to the new rate and burst, and open connections stay counted.
+func (rl *RateLimiter) ApplyConfig(cfg config.RateLimitConfig) {
+	rl.enabled.Store(cfg.Enabled)
+	rl.requests.setLimit(rate.Limit(cfg.RequestsPerSecond), cfg.BurstSize)
+	atomic.StoreInt32(&rl.maxConns, int32(cfg.MaxConnections))
+
+	rl.wsMu.Lock()
+	rl.maxWSPerIP = cfg.MaxWSConnectionsPerIP
+	rl.wsMu.Unlock()
+
+	if cfg.WSUpgradesPerMinute <= 0 {
+		rl.upgrades.Store(nil)
+		return
+	}
+	burst := cfg.WSUpgradeBurst
+	if burst <= 0 {
+		burst = 1
+	}
+	limit := rate.Limit(float64(cfg.WSUpgradesPerMinute) / 60)
+	if upgrades := rl.upgrades.Load(); upgrades != nil {
+		upgrades.setLimit(limit, burst)
+		return
+	}
+	rl.upgrades.Store(newLimiterSet(limit, burst))
+}
+
+func newLimiterSet(r rate.Limit, b int) *limiterSet {
+	return &limiterSet{
 		limiters: make(map[string]*limiterEntry),
-		r:        rate.Limit(requestsPerSecond),
-		b:        burstSize,
-		maxConns: int32(maxConnections),
+		r:        r,
+		b:        b,
 	}
 }
 
-// getLimiter returns or creates a rate limiter for the given IP
-func (rl *RateLimiter) getLimiter(ip string) *rate.Limiter {
+// get returns or creates a rate limiter for the given IP
+func (ls *limiterSet) get(ip string) *rate.Limiter {
 	// Fast path: check if limiter exists with read lock
-	rl.mu.RLock()
-	entry, exists := rl.limiters[ip]
-	rl.mu.RUnlock()
+	ls.mu.RLock()
+	entry, exists := ls.limiters[ip]
+	ls.mu.RUnlock()
 
 	if exists {
 		// Update last access time (safe to do without lock for timestamp)
@@ -62,23 +118,23 @@ func (rl *RateLimiter) getLimiter(ip string) *rate.Limiter {
 	}
 
 	// Slow path: create new limiter with write lock
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
 
 	// Double-check after acquiring write lock
-	if entry, exists := rl.limiters[ip]; exists {
+	if entry, exists := ls.limiters[ip]; exists {
 		entry.lastAccess = time.Now()
 		return entry.limiter
 	}
 
 	// Check map capacity to prevent memory exhaustion attacks
-	if len(rl.limiters) >= MaxLimitersPerInstance {
+	if len(ls.limiters) >= MaxLimitersPerInstance {
 		// Return a restrictive limiter for new IPs when at capacity
 		return rate.NewLimiter(rate.Limit(1), 1)
 	}
 
-	limiter := rate.NewLimiter(rl.r, rl.b)
-	rl.limiters[ip] = &limiterEntry{
+	limiter := rate.NewLimiter(ls.r, ls.b)
+	ls.limiters[ip] = &limiterEntry{
 		limiter:    limiter,
 		lastAccess: time.Now(),
 	}
@@ -86,6 +142,34 @@ func (rl *RateLimiter) getLimiter(ip string) *rate.Limiter {
 	return limiter
 }
 
+// setLimit changes the rate and burst of new and tracked limiters
+func (ls *limiterSet) setLimit(r rate.Limit, b int) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if ls.r == r && ls.b == b {
+		return
+	}
+	ls.r, ls.b = r, b
+	for _, entry := range ls.limiters {
+		entry.limiter.SetLimit(r)
+		entry.limiter.SetBurst(b)
+	}
+}
+
+// limit returns the rate and burst of the set
+func (ls *limiterSet) limit() (rate.Limit, int) {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	return ls.r, ls.b
+}
+
+// size returns the number of tracked IPs
+func (ls *limiterSet) size() int {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	return len(ls.limiters)
+}
+
 // cleanupLimiters removes idle limiters to prevent memory leaks
 func (rl *RateLimiter) cleanupLimiters() {
 	// Use atomic CAS to ensure only one cleanup goroutine runs
@@ -96,81 +180,151 @@ func (rl *RateLimiter) cleanupLimiters() {
 	ticker := time.NewTicker(CleanupInterval)
 	go func() {
 		for range ticker.C {
-			rl.performCleanup()
+			rl.requests.performCleanup()
+			if upgrades := rl.upgrades.Load(); upgrades != nil {
+				upgrades.performCleanup()
+			}
 		}
 	}()
 }
 
 // performCleanup removes limiters that have been idle (tokens fully replenished)
-func (rl *RateLimiter) performCleanup() {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
+func (ls *limiterSet) performCleanup() {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
 
 	now := time.Now()
-	threshold := float64(rl.b) * IdleThreshold
+	threshold := float64(ls.b) * IdleThreshold
 
-	for ip, entry := range rl.limiters {
+	for ip, entry := range ls.limiters {
 		// Check if limiter has been idle for at least 1 minute
 		// and tokens are nearly full (without consuming any)
 		if now.Sub(entry.lastAccess) > CleanupInterval {
 			// Use Tokens() to check token count without consuming
 			if entry.limiter.Tokens() >= threshold {
-				delete(rl.limiters, ip)
+				delete(ls.limiters, ip)
 			}
 		}
 	}
 }
 
-// extractClientIP safely extracts the client IP from the request
-func extractClientIP(r *http.Request) string {
-	// Check X-Forwarded-For header (set by reverse proxies)
+// parseTrustedProxies parses IP addresses and CIDRs, skipping invalid entries, which the
+// configuration rejects
+func parseTrustedProxies(proxies []string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, proxy := range proxies {
+		if ip := net.ParseIP(proxy); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		if _, ipNet, err := net.ParseCIDR(proxy); err == nil {
+			nets = append(nets, ipNet)
+		}
+	}
+	return nets
+}
+
+// trusted reports whether ip belongs to a trusted proxy
+func (rl *RateLimiter) trusted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range rl.trustedProxies {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the IP a request is limited by. Forwarding headers can be set by any
+// client, so they are only used when the request comes from a trusted proxy. X-Forwarded-For
+// is read from the right, skipping trusted proxies, so addresses prepended by the client are
+// ignored; X-Real-IP is used when it is absent.
+func (rl *RateLimiter) clientIP(r *http.Request) string {
+	remote := remoteIP(r.RemoteAddr)
+	if !rl.trusted(remote) {
+		return remote
+	}
+
 	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
-		// X-Forwarded-For can contain multiple IPs: "client, proxy1, proxy2"
-		// The first IP is the original client
 		ips := strings.Split(forwarded, ",")
-		if len(ips) > 0 {
-			clientIP := strings.TrimSpace(ips[0])
-			if clientIP != "" {
-				return clientIP
+		for i := len(ips) - 1; i >= 0; i-- {
+			ip := strings.TrimSpace(ips[i])
+			if ip == "" {
+				continue
+			}
+			if i == 0 || !rl.trusted(ip) {
+				return ip
 			}
 		}
 	}
 
-	// Check X-Real-IP header (set by some proxies like nginx)
-	if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
-		return strings.TrimSpace(realIP)
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+		return realIP
 	}
+	return remote
+}
 
-	// Fall back to RemoteAddr, removing port if present
-	ip := r.RemoteAddr
-	if colonIdx := strings.LastIndex(ip, ":"); colonIdx != -1 {
-		// Check if it's IPv6 with brackets [::1]:port
-		if bracketIdx := strings.LastIndex(ip, "]"); bracketIdx != -1 && bracketIdx < colonIdx {
-			ip = ip[:colonIdx]
-		} else if strings.Count(ip, ":") == 1 {
-			// IPv4 with port
-			ip = ip[:colonIdx]
-		}
+// remoteIP strips the port from a RemoteAddr
+func remoteIP(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
 	}
+	return addr
+}
 
-	return ip
+// isWebSocketUpgrade reports whether the request asks to upgrade to a WebSocket
+func isWebSocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket") &&
+		strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade")
 }
 
-// Middleware returns an HTTP middleware that enforces rate limiting
-func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
-	// If rate limiting is disabled, pass through directly
-	if !rl.enabled {
-		return next
+// acquireWebSocket counts a WebSocket connection for ip, failing when the IP already holds
+// the maximum number of connections
+func (rl *RateLimiter) acquireWebSocket(ip string) bool {
+	rl.wsMu.Lock()
+	defer rl.wsMu.Unlock()
+	if rl.maxWSPerIP > 0 && rl.wsConns[ip] >= rl.maxWSPerIP {
+		return false
 	}
+	rl.wsConns[ip]++
+	atomic.AddInt32(&rl.wsTotal, 1)
+	return true
+}
 
+// releaseWebSocket uncounts a WebSocket connection once its handler returns
+func (rl *RateLimiter) releaseWebSocket(ip string) {
+	rl.wsMu.Lock()
+	defer rl.wsMu.Unlock()
+	if rl.wsConns[ip]--; rl.wsConns[ip] <= 0 {
+		delete(rl.wsConns, ip)
+	}
+	atomic.AddInt32(&rl.wsTotal, -1)
+}
+
+// Middleware returns an HTTP middleware that enforces rate limiting. Whether it is enabled
+// is checked per request, so a reload can turn it on or off.
+func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
 	// Start cleanup goroutine (idempotent due to atomic flag)
 	rl.cleanupLimiters()
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// If rate limiting is disabled, pass through directly
+		if !rl.enabled.Load() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		// Check connection limit using atomic operations
 		for {
 			current := atomic.LoadInt32(&rl.connCount)
-			if current >= rl.maxConns {
+			if current >= atomic.LoadInt32(&rl.maxConns) {
 				http.Error(w, "Too many connections", http.StatusTooManyRequests)
 				return
 			}
@@ -183,10 +337,26 @@ func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
 		defer atomic.AddInt32(&rl.connCount, -1)
 
 		// Extract client IP safely
-		ip := extractClientIP(r)
+		ip := rl.clientIP(r)
+
+		// WebSocket handlers run for the lifetime of the connection, so the upgrade is
+		// checked against the upgrade rate and the IP's open connections instead
+		if isWebSocketUpgrade(r) {
+			if upgrades := rl.upgrades.Load(); upgrades != nil && !upgrades.get(ip).Allow() {
+				http.Error(w, "WebSocket upgrade rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+			if !rl.acquireWebSocket(ip) {
+				http.Error(w, "Too many WebSocket connections", http.StatusTooManyRequests)
+				return
+			}
+			defer rl.releaseWebSocket(ip)
+			next.ServeHTTP(w, r)
+			return
+		}
 
 		// Check rate limit
-		limiter := rl.getLimiter(ip)
+		limiter := rl.requests.get(ip)
 		if !limiter.Allow() {
 			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
 			return
@@ -199,18 +369,26 @@ func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
 // GetStats returns current rate limiter statistics
 func (rl *RateLimiter) GetStats() map[string]interface{} {
 	currentConns := atomic.LoadInt32(&rl.connCount)
-
-	rl.mu.RLock()
-	activeLimiters := len(rl.limiters)
-	rl.mu.RUnlock()
-
-	return map[string]interface{}{
-		"enabled":             rl.enabled,
-		"active_limiters":     activeLimiters,
-		"max_limiters":        MaxLimitersPerInstance,
-		"current_connections": currentConns,
-		"max_connections":     rl.maxConns,
-		"requests_per_second": float64(rl.r),
-		"burst_size":          rl.b,
+	requestRate, burst := rl.requests.limit()
+	rl.wsMu.Lock()
+	maxWSPerIP := rl.maxWSPerIP
+	rl.wsMu.Unlock()
+
+	stats := map[string]interface{}{
+		"enabled":                   rl.enabled.Load(),
+		"active_limiters":           rl.requests.size(),
+		"max_limiters":              MaxLimitersPerInstance,
+		"current_connections":       currentConns,
+		"max_connections":           atomic.LoadInt32(&rl.maxConns),
+		"requests_per_second":       float64(requestRate),
+		"burst_size":                burst,
+		"websocket_connections":     atomic.LoadInt32(&rl.wsTotal),
+		"max_ws_connections_per_ip": maxWSPerIP,
+	}
+	if upgrades := rl.upgrades.Load(); upgrades != nil {
+		upgradeRate, upgradeBurst := upgrades.limit()
+		stats["ws_upgrades_per_minute"] = float64(upgradeRate) * 60
+		stats["ws_upgrade_burst"] = upgradeBurst
 	}
+	return stats
 }
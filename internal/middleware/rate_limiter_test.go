@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"asr_server/config"
+)
+
+func TestClientIP(t *testing.T) {
+	rl := NewRateLimiter(config.RateLimitConfig{}, []string{"10.0.0.0/8", "192.168.1.1"})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		want       string
+	}{
+		{"direct client", "203.0.113.7:5000", "", "", "203.0.113.7"},
+		{"spoofed forwarded header from untrusted client", "203.0.113.7:5000", "198.51.100.1", "", "203.0.113.7"},
+		{"spoofed real ip from untrusted client", "203.0.113.7:5000", "", "198.51.100.1", "203.0.113.7"},
+		{"trusted proxy", "10.1.2.3:5000", "198.51.100.1", "", "198.51.100.1"},
+		{"trusted single proxy address", "192.168.1.1:5000", "198.51.100.1", "", "198.51.100.1"},
+		{"address prepended by client is ignored", "10.1.2.3:5000", "1.2.3.4, 198.51.100.1", "", "198.51.100.1"},
+		{"chain of trusted proxies", "10.1.2.3:5000", "198.51.100.1, 10.9.9.9", "", "198.51.100.1"},
+		{"only trusted proxies", "10.1.2.3:5000", "10.4.4.4, 10.9.9.9", "", "10.4.4.4"},
+		{"real ip from trusted proxy", "10.1.2.3:5000", "", "198.51.100.1", "198.51.100.1"},
+		{"trusted proxy without headers", "10.1.2.3:5000", "", "", "10.1.2.3"},
+		{"ipv6 client", "[2001:db8::1]:5000", "198.51.100.1", "", "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := rl.clientIP(r); got != tt.want {
+				t.Errorf("clientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimiterMiddleware(t *testing.T) {
+	cfg := config.RateLimitConfig{
+		Enabled:               true,
+		RequestsPerSecond:     1,
+		BurstSize:             2,
+		MaxConnections:        100,
+		WSUpgradesPerMinute:   1,
+		WSUpgradeBurst:        1,
+		MaxWSConnectionsPerIP: 1,
+	}
+
+	request := func(h http.Handler, remoteAddr, forwarded string, upgrade bool) int {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = remoteAddr
+		if forwarded != "" {
+			r.Header.Set("X-Forwarded-For", forwarded)
+		}
+		if upgrade {
+			r.Header.Set("Upgrade", "websocket")
+			r.Header.Set("Connection", "Upgrade")
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	t.Run("request rate per ip", func(t *testing.T) {
+		h := NewRateLimiter(cfg, nil).Middleware(ok)
+		for i := 0; i < cfg.BurstSize; i++ {
+			if code := request(h, "203.0.113.7:1", "", false); code != http.StatusOK {
+				t.Fatalf("request %d: status %d, want 200", i, code)
+			}
+		}
+		if code := request(h, "203.0.113.7:1", "", false); code != http.StatusTooManyRequests {
+			t.Errorf("request over burst: status %d, want 429", code)
+		}
+		if code := request(h, "203.0.113.8:1", "", false); code != http.StatusOK {
+			t.Errorf("other ip: status %d, want 200", code)
+		}
+	})
+
+	t.Run("spoofed forwarded header does not reset the limit", func(t *testing.T) {
+		h := NewRateLimiter(cfg, nil).Middleware(ok)
+		if code := request(h, "203.0.113.7:1", "198.51.100.1", true); code != http.StatusOK {
+			t.Fatalf("first upgrade: status %d, want 200", code)
+		}
+		if code := request(h, "203.0.113.7:1", "198.51.100.2", true); code != http.StatusTooManyRequests {
+			t.Errorf("upgrade with another forwarded address: status %d, want 429", code)
+		}
+	})
+
+	t.Run("websocket connections per ip", func(t *testing.T) {
+		rl := NewRateLimiter(cfg, nil)
+		if !rl.acquireWebSocket("203.0.113.7") {
+			t.Fatal("first connection rejected")
+		}
+		if rl.acquireWebSocket("203.0.113.7") {
+			t.Error("connection over max_ws_connections_per_ip accepted")
+		}
+		if !rl.acquireWebSocket("203.0.113.8") {
+			t.Error("connection from other ip rejected")
+		}
+		rl.releaseWebSocket("203.0.113.7")
+		if !rl.acquireWebSocket("203.0.113.7") {
+			t.Error("connection rejected after release")
+		}
+	})
+}
+
+func TestRateLimiterApplyConfig(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	request := func(h http.Handler) int {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = "203.0.113.7:1"
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	rl := NewRateLimiter(config.RateLimitConfig{}, nil)
+	h := rl.Middleware(ok)
+	for i := 0; i < 3; i++ {
+		if code := request(h); code != http.StatusOK {
+			t.Fatalf("disabled: status %d, want 200", code)
+		}
+	}
+
+	cfg := config.RateLimitConfig{Enabled: true, RequestsPerSecond: 1, BurstSize: 1, MaxConnections: 10}
+	rl.ApplyConfig(cfg)
+	if code := request(h); code != http.StatusOK {
+		t.Fatalf("enabled: status %d, want 200", code)
+	}
+	if code := request(h); code != http.StatusTooManyRequests {
+		t.Fatalf("enabled over burst: status %d, want 429", code)
+	}
+
+	// The tracked client's bucket refills at the new rate
+	cfg.RequestsPerSecond, cfg.BurstSize, cfg.WSUpgradesPerMinute = 1000, 100, 6
+	rl.ApplyConfig(cfg)
+	time.Sleep(10 * time.Millisecond)
+	if code := request(h); code != http.StatusOK {
+		t.Errorf("after raising the limit: status %d, want 200", code)
+	}
+	stats := rl.GetStats()
+	if stats["burst_size"] != 100 || stats["ws_upgrades_per_minute"] != float64(6) {
+		t.Errorf("stats = %v", stats)
+	}
+}
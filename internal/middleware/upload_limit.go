@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"asr_server/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UploadLimit is a middleware for upload routes. It rejects request bodies larger than
+// maxBytes with 413 (0 disables the limit), and parses multipart forms keeping at most
+// maxMemory bytes in memory; larger file parts are written to temporary files, which are
+// removed once the handler returns. Handlers read the parsed form as usual:
+//
+//	file, header, err := c.Request.FormFile("audio")
+//
+// Usage:
+//
+//	group.POST("/register", middleware.UploadLimit(cfg.Speaker.MaxUploadSize, cfg.Server.MultipartMemory), h.RegisterSpeaker)
+func UploadLimit(maxBytes, maxMemory int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxBytes > 0 {
+			if c.Request.ContentLength > maxBytes {
+				abortTooLarge(c, maxBytes)
+				return
+			}
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		}
+
+		mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		if mediaType == "multipart/form-data" {
+			if err := c.Request.ParseMultipartForm(maxMemory); err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					abortTooLarge(c, maxBytes)
+					return
+				}
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid multipart form: %v", err)})
+				return
+			}
+			// The server only cleans up forms of the request it created; c.Request may
+			// have been replaced by an earlier middleware
+			form := c.Request.MultipartForm
+			defer func() {
+				if err := form.RemoveAll(); err != nil {
+					logger.Warn("multipart_cleanup_failed", "request_id", c.GetString("request_id"), "error", err)
+				}
+			}()
+		}
+
+		c.Next()
+	}
+}
+
+// abortTooLarge rejects a request whose body exceeds maxBytes
+func abortTooLarge(c *gin.Context, maxBytes int64) {
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error": fmt.Sprintf("request body exceeds maximum size of %d bytes", maxBytes),
+	})
+}
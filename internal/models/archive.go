@@ -0,0 +1,138 @@
+package models
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// extract unpacks an archive into dir, dropping the first strip directories of each entry.
+// Only regular files and directories are extracted; links and special files are skipped.
+func extract(archive, suffix, dir string, strip int) error {
+	if suffix == ".zip" {
+		return extractZip(archive, dir, strip)
+	}
+
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	switch suffix {
+	case ".tar.gz", ".tgz":
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	case ".tar.bz2", ".tbz2":
+		r = bzip2.NewReader(f)
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeDir {
+			continue
+		}
+		target, ok, err := entryPath(dir, header.Name, strip)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if header.Typeflag == tar.TypeDir {
+			err = os.MkdirAll(target, 0755)
+		} else {
+			err = writeFile(target, tr, header.FileInfo().Mode())
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func extractZip(archive, dir string, strip int) error {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	for _, file := range zr.File {
+		mode := file.Mode()
+		if !mode.IsRegular() && !mode.IsDir() {
+			continue
+		}
+		target, ok, err := entryPath(dir, file.Name, strip)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if mode.IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return err
+		}
+		err = writeFile(target, rc, mode)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entryPath returns where an archive entry is extracted to, and false for entries removed
+// entirely by strip. Entries that would be written outside dir are rejected.
+func entryPath(dir, name string, strip int) (string, bool, error) {
+	parts := strings.Split(strings.Trim(path.Clean(name), "/"), "/")
+	if len(parts) <= strip {
+		return "", false, nil
+	}
+	rel := filepath.FromSlash(strings.Join(parts[strip:], "/"))
+	if !filepath.IsLocal(rel) {
+		return "", false, fmt.Errorf("archive entry %s is outside the model directory", name)
+	}
+	return filepath.Join(dir, rel), true, nil
+}
+
+// writeFile writes an extracted file, keeping its executable bits
+func writeFile(target string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644|mode.Perm()&0111)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,211 @@
+package models
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"asr_server/config"
+	"asr_server/internal/logger"
+)
+
+// manifestFile records the installed version inside a model directory
+const manifestFile = ".model.json"
+
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// InstalledModel describes a model in the cache, as recorded when it was installed
+type InstalledModel struct {
+	Name        string    `json:"name"`
+	Version     string    `json:"version,omitempty"`
+	URL         string    `json:"url"`
+	SHA256      string    `json:"sha256"`
+	Size        int64     `json:"size"` // Bytes downloaded
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// Manager downloads the configured models into the cache directory and reports the installed
+// versions. A model is downloaded again only when its checksum in the configuration changes.
+type Manager struct {
+	cfg    config.ModelsConfig
+	client *http.Client
+}
+
+// NewManager creates a model manager
+func NewManager(cfg config.ModelsConfig) *Manager {
+	return &Manager{cfg: cfg, client: &http.Client{}}
+}
+
+// EnsureAll downloads the models that are not installed, one at a time
+func (m *Manager) EnsureAll(ctx context.Context) error {
+	for _, src := range m.cfg.Sources {
+		if err := m.Ensure(ctx, src); err != nil {
+			return fmt.Errorf("model %s: %w", src.Name, err)
+		}
+	}
+	return nil
+}
+
+// Ensure installs a model unless the installed version has the configured checksum. The
+// download is verified and unpacked beside the cache entry, which is only replaced once
+// complete, so an interrupted download never leaves a partial model behind.
+func (m *Manager) Ensure(ctx context.Context, src config.ModelSource) error {
+	if installed, err := readManifest(m.cfg.Dir(src.Name)); err == nil && strings.EqualFold(installed.SHA256, src.SHA256) {
+		logger.Debug("model_cached", "model", src.Name, "version", installed.Version)
+		return nil
+	}
+	if err := os.MkdirAll(m.cfg.CacheDir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	if m.cfg.DownloadTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(m.cfg.DownloadTimeout)*time.Second)
+		defer cancel()
+	}
+	logger.Info("model_download_started", "model", src.Name, "version", src.Version, "url", redactURL(src.URL))
+	start := time.Now()
+	download, size, err := m.download(ctx, src)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(download)
+
+	staging, err := os.MkdirTemp(m.cfg.CacheDir, "."+src.Name+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	if suffix := src.ArchiveSuffix(); suffix != "" {
+		err = extract(download, suffix, staging, src.StripComponents)
+	} else {
+		err = os.Rename(download, filepath.Join(staging, src.FileName()))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to install: %w", err)
+	}
+
+	installed := InstalledModel{
+		Name:        src.Name,
+		Version:     src.Version,
+		URL:         redactURL(src.URL),
+		SHA256:      strings.ToLower(src.SHA256),
+		Size:        size,
+		InstalledAt: time.Now().UTC(),
+	}
+	if err := writeManifest(staging, installed); err != nil {
+		return err
+	}
+	dir := m.cfg.Dir(src.Name)
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to remove previous version: %w", err)
+	}
+	if err := os.Rename(staging, dir); err != nil {
+		return fmt.Errorf("failed to install: %w", err)
+	}
+
+	logger.Info("model_installed",
+		"model", src.Name,
+		"version", src.Version,
+		"size", size,
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+	return nil
+}
+
+// download fetches the model into a temporary file in the cache directory and verifies its
+// checksum, returning the file path and size
+func (m *Manager) download(ctx context.Context, src config.ModelSource) (string, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src.URL, nil)
+	if err != nil {
+		return "", 0, err
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("download failed: unexpected status %s", resp.Status)
+	}
+
+	f, err := os.CreateTemp(m.cfg.CacheDir, "."+src.Name+"-*.download")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create download file: %w", err)
+	}
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, hash), resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", 0, fmt.Errorf("download failed: %w", err)
+	}
+
+	if sum := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(sum, src.SHA256) {
+		os.Remove(f.Name())
+		return "", 0, fmt.Errorf("%w: got %s, want %s", ErrChecksumMismatch, sum, strings.ToLower(src.SHA256))
+	}
+	return f.Name(), size, nil
+}
+
+// Installed returns the configured models that are installed in the cache
+func (m *Manager) Installed() []InstalledModel {
+	installed := make([]InstalledModel, 0, len(m.cfg.Sources))
+	for _, src := range m.cfg.Sources {
+		if model, err := readManifest(m.cfg.Dir(src.Name)); err == nil {
+			installed = append(installed, model)
+		}
+	}
+	return installed
+}
+
+// CacheDir returns the directory models are installed in
+func (m *Manager) CacheDir() string {
+	return m.cfg.CacheDir
+}
+
+func readManifest(dir string) (InstalledModel, error) {
+	var installed InstalledModel
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return installed, err
+	}
+	err = json.Unmarshal(data, &installed)
+	return installed, err
+}
+
+func writeManifest(dir string, installed InstalledModel) error {
+	data, err := json.MarshalIndent(installed, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, manifestFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// redactURL removes credentials and query parameters, which may carry access tokens
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return ""
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
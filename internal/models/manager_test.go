@@ -0,0 +1,143 @@
+package models
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"asr_server/config"
+)
+
+// tarGz builds a .tar.gz archive of files
+func tarGz(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestManagerEnsure(t *testing.T) {
+	archive := tarGz(t, map[string]string{
+		"sense-voice-2024/model.onnx": "model",
+		"sense-voice-2024/tokens.txt": "tokens",
+	})
+	single := []byte("silero")
+	unsafe := tarGz(t, map[string]string{"../escape.txt": "x"})
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/sense-voice.tar.gz":
+			w.Write(archive)
+		case "/silero_vad.onnx":
+			w.Write(single)
+		case "/unsafe.tar.gz":
+			w.Write(unsafe)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	cfg := config.ModelsConfig{CacheDir: cacheDir, Sources: []config.ModelSource{
+		{Name: "sense-voice", Version: "2024-07-17", URL: server.URL + "/sense-voice.tar.gz?token=secret", SHA256: checksum(archive), StripComponents: 1},
+		{Name: "silero", URL: server.URL + "/silero_vad.onnx", SHA256: checksum(single)},
+	}}
+	m := NewManager(cfg)
+	if err := m.EnsureAll(context.Background()); err != nil {
+		t.Fatalf("EnsureAll() error = %v", err)
+	}
+
+	for path, want := range map[string]string{
+		filepath.Join(cacheDir, "sense-voice", "model.onnx"): "model",
+		filepath.Join(cacheDir, "sense-voice", "tokens.txt"): "tokens",
+		filepath.Join(cacheDir, "silero", "silero_vad.onnx"): "silero",
+	} {
+		if got, err := os.ReadFile(path); err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v, want %q", path, got, err, want)
+		}
+	}
+
+	installed := m.Installed()
+	if len(installed) != 2 {
+		t.Fatalf("Installed() = %v, want 2 models", installed)
+	}
+	if got := installed[0]; got.Version != "2024-07-17" || got.SHA256 != checksum(archive) || got.Size != int64(len(archive)) {
+		t.Errorf("Installed()[0] = %+v", got)
+	}
+	if got := installed[0].URL; got != server.URL+"/sense-voice.tar.gz" {
+		t.Errorf("Installed()[0].URL = %q, want the query removed", got)
+	}
+
+	// Installed models are not downloaded again
+	if err := m.EnsureAll(context.Background()); err != nil {
+		t.Fatalf("EnsureAll() error = %v", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("requests = %d, want 2", got)
+	}
+
+	tests := []struct {
+		name    string
+		src     config.ModelSource
+		wantErr error
+	}{
+		{"checksum mismatch", config.ModelSource{Name: "bad", URL: server.URL + "/silero_vad.onnx", SHA256: checksum(archive)}, ErrChecksumMismatch},
+		{"not found", config.ModelSource{Name: "missing", URL: server.URL + "/missing.onnx", SHA256: checksum(single)}, nil},
+		{"path traversal", config.ModelSource{Name: "unsafe", URL: server.URL + "/unsafe.tar.gz", SHA256: checksum(unsafe)}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := m.Ensure(context.Background(), tt.src)
+			if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Fatalf("Ensure() error = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := os.Stat(filepath.Join(cacheDir, tt.src.Name)); !os.IsNotExist(err) {
+				t.Errorf("model directory left behind after a failed install")
+			}
+		})
+	}
+
+	// Only the installed models remain in the cache
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("cache entries = %v, want only the installed models", entries)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(cacheDir), "escape.txt")); !os.IsNotExist(err) {
+		t.Error("archive entry written outside the model directory")
+	}
+}
@@ -0,0 +1,396 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"asr_server/config"
+	"asr_server/internal/logger"
+	"asr_server/internal/session"
+	"asr_server/internal/ws"
+)
+
+const (
+	// queueSize bounds results buffered while the broker connection is down
+	queueSize = 1000
+	// reconnectDelay is the wait between reconnect attempts
+	reconnectDelay = 2 * time.Second
+	// dialTimeout bounds connection establishment and the CONNECT handshake
+	dialTimeout = 5 * time.Second
+	// writeTimeout bounds writing a single packet
+	writeTimeout = 5 * time.Second
+	// subscribePacketID identifies the audio topic subscription
+	subscribePacketID = 1
+)
+
+// DeviceTopicPlaceholder is replaced by the device ID in the result topic
+const DeviceTopicPlaceholder = "{device}"
+
+// device tracks the session fed by one device's audio topic
+type device struct {
+	sessionID string
+	lastSeen  time.Time
+}
+
+// Bridge subscribes to per-device audio topics on an MQTT broker, transcribes each device
+// as a session and publishes results back to the device's result topic.
+// All dependencies are explicitly injected via constructor.
+type Bridge struct {
+	cfg            *config.Config
+	sessionManager *session.Manager
+
+	addr        string
+	username    string
+	password    string
+	deviceLevel int
+
+	msgs chan []byte
+	done chan struct{}
+	wg   sync.WaitGroup
+
+	mu      sync.Mutex
+	devices map[string]*device
+}
+
+// NewBridge creates a new MQTT bridge with explicit dependencies
+func NewBridge(cfg *config.Config, sessionManager *session.Manager) (*Bridge, error) {
+	u, err := url.Parse(cfg.MQTT.BrokerURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid MQTT broker URL: %s", cfg.MQTT.BrokerURL)
+	}
+	if u.Scheme != "tcp" && u.Scheme != "mqtt" {
+		return nil, fmt.Errorf("unsupported MQTT broker scheme: %s", u.Scheme)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "1883")
+	}
+
+	deviceLevel := wildcardLevel(cfg.MQTT.AudioTopic)
+	if deviceLevel < 0 {
+		return nil, fmt.Errorf("audio topic %q must contain exactly one '+' level for the device ID", cfg.MQTT.AudioTopic)
+	}
+
+	b := &Bridge{
+		cfg:            cfg,
+		sessionManager: sessionManager,
+		addr:           addr,
+		deviceLevel:    deviceLevel,
+		msgs:           make(chan []byte, queueSize),
+		done:           make(chan struct{}),
+		devices:        make(map[string]*device),
+	}
+	if u.User != nil {
+		b.username = u.User.Username()
+		b.password, _ = u.User.Password()
+	}
+	return b, nil
+}
+
+// Start connects to the broker in the background and reconnects after failures
+func (b *Bridge) Start() {
+	b.wg.Add(2)
+	go b.run()
+	go b.reapLoop()
+
+	logger.Info("mqtt_bridge_started", "broker", b.addr, "audio_topic", b.cfg.MQTT.AudioTopic, "result_topic", b.cfg.MQTT.ResultTopic)
+}
+
+// Stop disconnects from the broker and removes all device sessions
+func (b *Bridge) Stop() {
+	close(b.done)
+	b.wg.Wait()
+
+	b.mu.Lock()
+	devices := b.devices
+	b.devices = make(map[string]*device)
+	b.mu.Unlock()
+
+	for _, d := range devices {
+		b.sessionManager.RemoveSession(d.sessionID)
+	}
+	logger.Info("mqtt_bridge_stopped")
+}
+
+// run keeps a broker connection open and reconnects after failures
+func (b *Bridge) run() {
+	defer b.wg.Done()
+
+	for {
+		conn, reader, err := b.connect()
+		if err != nil {
+			logger.Warn("mqtt_connect_failed", "broker", b.addr, "error", err)
+		} else {
+			logger.Info("mqtt_connected", "broker", b.addr)
+			err = b.serve(conn, reader)
+			conn.Close()
+			if err == nil {
+				return
+			}
+			logger.Warn("mqtt_connection_lost", "broker", b.addr, "error", err)
+		}
+
+		select {
+		case <-b.done:
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+// connect dials the broker, performs the CONNECT handshake and subscribes to the audio topic
+func (b *Bridge) connect() (net.Conn, *bufio.Reader, error) {
+	conn, err := net.DialTimeout("tcp", b.addr, dialTimeout)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conn.SetDeadline(time.Now().Add(dialTimeout))
+	clientID := b.cfg.MQTT.ClientID
+	if clientID == "" {
+		clientID = config.DefaultMQTTClientID
+	}
+	if _, err := conn.Write(encodeConnect(clientID, b.username, b.password, uint16(b.cfg.MQTT.KeepAlive))); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	ack, err := readPacket(reader)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	if ack.kind != packetConnAck || len(ack.body) < 2 {
+		conn.Close()
+		return nil, nil, fmt.Errorf("unexpected packet type %d during handshake", ack.kind)
+	}
+	if code := ack.body[1]; code != 0 {
+		conn.Close()
+		return nil, nil, fmt.Errorf("broker refused connection with code %d", code)
+	}
+
+	if _, err := conn.Write(encodeSubscribe(subscribePacketID, b.cfg.MQTT.AudioTopic)); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	conn.SetDeadline(time.Time{})
+
+	return conn, reader, nil
+}
+
+// serve publishes queued results, sends keepalive pings and dispatches incoming audio until
+// the connection fails. It returns nil when the bridge is stopped.
+func (b *Bridge) serve(conn net.Conn, reader *bufio.Reader) error {
+	var writeMu sync.Mutex
+	write := func(data []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		_, err := conn.Write(data)
+		return err
+	}
+
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			p, err := readPacket(reader)
+			if err != nil {
+				readErr <- err
+				return
+			}
+			switch p.kind {
+			case packetPublish:
+				topic, packetID, payload, err := decodePublish(p)
+				if err != nil {
+					logger.Warn("mqtt_invalid_publish", "error", err)
+					continue
+				}
+				if packetID != 0 {
+					if err := write(encodePubAck(packetID)); err != nil {
+						readErr <- err
+						return
+					}
+				}
+				b.handleAudio(topic, payload)
+			case packetSubAck:
+				if len(p.body) >= 3 && p.body[2] == 0x80 {
+					logger.Error("mqtt_subscribe_rejected", "topic", b.cfg.MQTT.AudioTopic)
+				}
+			}
+		}
+	}()
+
+	// Ping at half the keepalive so the broker never sees a silent interval
+	keepAlive := time.Duration(b.cfg.MQTT.KeepAlive) * time.Second / 2
+	var ping <-chan time.Time
+	if keepAlive > 0 {
+		ticker := time.NewTicker(keepAlive)
+		defer ticker.Stop()
+		ping = ticker.C
+	}
+
+	for {
+		select {
+		case err := <-readErr:
+			return err
+		case msg := <-b.msgs:
+			if err := write(msg); err != nil {
+				return err
+			}
+		case <-ping:
+			if err := write(encodePacket(packetPingReq, 0, nil)); err != nil {
+				return err
+			}
+		case <-b.done:
+			for {
+				select {
+				case msg := <-b.msgs:
+					if err := write(msg); err != nil {
+						return nil
+					}
+				default:
+					write(encodePacket(packetDisconnect, 0, nil))
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// handleAudio forwards a 16-bit PCM payload to the session of the device that published it
+func (b *Bridge) handleAudio(topic string, payload []byte) {
+	deviceID := b.deviceID(topic)
+	if deviceID == "" {
+		logger.Debug("mqtt_unexpected_topic", "topic", topic)
+		return
+	}
+	if len(payload) < 2 {
+		return
+	}
+
+	d, err := b.getOrCreateDevice(deviceID)
+	if err != nil {
+		logger.Error("mqtt_device_setup_failed", "device", deviceID, "error", err)
+		return
+	}
+
+	// Keep whole 16-bit samples; an odd trailing byte is dropped
+	if err := b.sessionManager.ProcessAudioData(d.sessionID, payload[:len(payload)&^1]); err != nil {
+		logger.Error("failed_to_process_audio", "session_id", d.sessionID, "error", err)
+	}
+}
+
+// getOrCreateDevice returns the device, creating a session on its first message
+func (b *Bridge) getOrCreateDevice(deviceID string) (*device, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if d, exists := b.devices[deviceID]; exists {
+		d.lastSeen = time.Now()
+		return d, nil
+	}
+
+	sessionID := ws.GenerateSessionID()
+	topic := strings.ReplaceAll(b.cfg.MQTT.ResultTopic, DeviceTopicPlaceholder, deviceID)
+	if _, err := b.sessionManager.CreateSession(sessionID, &deviceConn{bridge: b, topic: topic}); err != nil {
+		return nil, err
+	}
+
+	d := &device{sessionID: sessionID, lastSeen: time.Now()}
+	b.devices[deviceID] = d
+	logger.Info("mqtt_device_session_started", "session_id", sessionID, "device", deviceID, "result_topic", topic)
+	return d, nil
+}
+
+// deviceID extracts the device ID from the wildcard level of an audio topic
+func (b *Bridge) deviceID(topic string) string {
+	levels := strings.Split(topic, "/")
+	if len(levels) != strings.Count(b.cfg.MQTT.AudioTopic, "/")+1 {
+		return ""
+	}
+	return levels[b.deviceLevel]
+}
+
+// reapLoop ends device sessions that stopped publishing audio for longer than the idle timeout
+func (b *Bridge) reapLoop() {
+	defer b.wg.Done()
+
+	idleTimeout := time.Duration(b.cfg.MQTT.IdleTimeout) * time.Second
+	if idleTimeout <= 0 {
+		idleTimeout = time.Duration(config.DefaultMQTTIdleTimeout) * time.Second
+	}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			now := time.Now()
+			b.mu.Lock()
+			for id, d := range b.devices {
+				if now.Sub(d.lastSeen) > idleTimeout {
+					delete(b.devices, id)
+					go b.sessionManager.RemoveSession(d.sessionID)
+					logger.Info("mqtt_device_session_ended", "session_id", d.sessionID, "device", id)
+				}
+			}
+			b.mu.Unlock()
+		}
+	}
+}
+
+// publish queues a message for the broker, dropping it if the queue is full
+func (b *Bridge) publish(topic string, payload []byte) {
+	select {
+	case b.msgs <- encodePublish(topic, payload):
+	default:
+		logger.Warn("mqtt_queue_full", "topic", topic, "action", "dropped_message")
+	}
+}
+
+// wildcardLevel returns the index of the single '+' level in a topic filter, or -1
+func wildcardLevel(filter string) int {
+	level := -1
+	for i, part := range strings.Split(filter, "/") {
+		switch {
+		case part == "+":
+			if level >= 0 {
+				return -1
+			}
+			level = i
+		case strings.ContainsAny(part, "+#"):
+			return -1
+		}
+	}
+	return level
+}
+
+// deviceConn publishes a device session's messages to its result topic
+type deviceConn struct {
+	bridge *Bridge
+	topic  string
+}
+
+// WriteJSON implements session.Conn
+func (d *deviceConn) WriteJSON(v interface{}) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	d.bridge.publish(d.topic, payload)
+	return nil
+}
+
+// Close implements session.Conn
+func (d *deviceConn) Close() error {
+	return nil
+}
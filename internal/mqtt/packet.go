@@ -0,0 +1,160 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MQTT 3.1.1 control packet types
+const (
+	packetConnect    byte = 1
+	packetConnAck    byte = 2
+	packetPublish    byte = 3
+	packetPubAck     byte = 4
+	packetSubscribe  byte = 8
+	packetSubAck     byte = 9
+	packetPingReq    byte = 12
+	packetPingResp   byte = 13
+	packetDisconnect byte = 14
+)
+
+// protocolLevel is the MQTT 3.1.1 protocol level sent in CONNECT
+const protocolLevel = 4
+
+// maxPacketSize bounds a single packet received from the broker
+const maxPacketSize = 4 << 20
+
+// packet is a decoded MQTT control packet
+type packet struct {
+	kind  byte
+	flags byte
+	body  []byte
+}
+
+// readPacket reads one control packet
+func readPacket(r *bufio.Reader) (*packet, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	// Remaining length is a variable byte integer of at most four bytes
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return nil, errors.New("malformed remaining length")
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		length += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+	}
+	if length > maxPacketSize {
+		return nil, fmt.Errorf("packet of %d bytes exceeds maximum of %d", length, maxPacketSize)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return &packet{kind: header >> 4, flags: header & 0x0f, body: body}, nil
+}
+
+// encodePacket prefixes body with the fixed header
+func encodePacket(kind, flags byte, body []byte) []byte {
+	b := []byte{kind<<4 | flags}
+	length := len(body)
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if length == 0 {
+			break
+		}
+	}
+	return append(b, body...)
+}
+
+// appendString appends a length-prefixed UTF-8 string
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// encodeConnect builds a CONNECT packet with a clean session
+func encodeConnect(clientID, username, password string, keepAlive uint16) []byte {
+	flags := byte(0x02)
+	if username != "" {
+		flags |= 0x80
+	}
+	if password != "" {
+		flags |= 0x40
+	}
+
+	body := appendString(nil, "MQTT")
+	body = append(body, protocolLevel, flags)
+	body = binary.BigEndian.AppendUint16(body, keepAlive)
+	body = appendString(body, clientID)
+	if username != "" {
+		body = appendString(body, username)
+	}
+	if password != "" {
+		body = appendString(body, password)
+	}
+	return encodePacket(packetConnect, 0, body)
+}
+
+// encodeSubscribe builds a SUBSCRIBE packet requesting QoS 0 for each topic filter
+func encodeSubscribe(packetID uint16, filters ...string) []byte {
+	body := binary.BigEndian.AppendUint16(nil, packetID)
+	for _, filter := range filters {
+		body = appendString(body, filter)
+		body = append(body, 0)
+	}
+	return encodePacket(packetSubscribe, 0x02, body)
+}
+
+// encodePublish builds a QoS 0 PUBLISH packet
+func encodePublish(topic string, payload []byte) []byte {
+	body := appendString(nil, topic)
+	return encodePacket(packetPublish, 0, append(body, payload...))
+}
+
+// encodePubAck builds a PUBACK packet for a QoS 1 delivery
+func encodePubAck(packetID uint16) []byte {
+	return encodePacket(packetPubAck, 0, binary.BigEndian.AppendUint16(nil, packetID))
+}
+
+// decodePublish extracts the topic, packet ID (QoS > 0 only) and payload from a PUBLISH packet
+func decodePublish(p *packet) (topic string, packetID uint16, payload []byte, err error) {
+	b := p.body
+	if len(b) < 2 {
+		return "", 0, nil, errors.New("short publish packet")
+	}
+	n := int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+n {
+		return "", 0, nil, errors.New("short publish topic")
+	}
+	topic = string(b[2 : 2+n])
+	b = b[2+n:]
+
+	if qos := (p.flags >> 1) & 0x03; qos > 0 {
+		if len(b) < 2 {
+			return "", 0, nil, errors.New("missing packet identifier")
+		}
+		packetID = binary.BigEndian.Uint16(b)
+		b = b[2:]
+	}
+	return topic, packetID, b, nil
+}
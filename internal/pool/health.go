@@ -0,0 +1,202 @@
+package pool
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"asr_server/internal/logger"
+)
+
+// healthCheckSamples 健康检查使用的合成音频长度，16kHz下为0.1秒
+const healthCheckSamples = 1600
+
+// HealthConfig VAD池健康检查与实例回收配置
+type HealthConfig struct {
+	CheckInterval time.Duration // 空闲实例健康检查间隔，0 为关闭
+	MaxUses       int           // 实例复用次数上限，达到后归还时重建，0 为不限制
+}
+
+// syntheticAudio 返回健康检查使用的低音量正弦波
+func syntheticAudio(n int) []float32 {
+	samples := make([]float32, n)
+	for i := range samples {
+		samples[i] = float32(0.1 * math.Sin(2*math.Pi*440*float64(i)/16000))
+	}
+	return samples
+}
+
+// instanceHealth 实例的使用次数与处理音频时发生的错误
+type instanceHealth struct {
+	uses int64
+
+	errMu sync.Mutex
+	err   error
+}
+
+// MarkFailed 记录实例处理音频时的错误，实例归还时将被重建而不是复用
+func (h *instanceHealth) MarkFailed(err error) {
+	h.errMu.Lock()
+	defer h.errMu.Unlock()
+	if h.err == nil {
+		h.err = err
+	}
+}
+
+// failure 返回实例记录的错误
+func (h *instanceHealth) failure() error {
+	h.errMu.Lock()
+	defer h.errMu.Unlock()
+	return h.err
+}
+
+// use 记录一次使用
+func (h *instanceHealth) use() {
+	atomic.AddInt64(&h.uses, 1)
+}
+
+// poolHealth 池的健康检查与实例回收统计
+type poolHealth struct {
+	config HealthConfig
+	wg     sync.WaitGroup
+
+	checks   int64
+	failures int64
+	recycled int64
+	evicted  int64
+
+	mu           sync.Mutex
+	lastCheck    time.Time
+	lastFailures int
+	lastError    string
+}
+
+// start 按检查间隔运行round直到ctx取消，未配置间隔时不运行
+func (h *poolHealth) start(ctx context.Context, round func()) {
+	if h.config.CheckInterval <= 0 {
+		return
+	}
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+
+		ticker := time.NewTicker(h.config.CheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				round()
+			}
+		}
+	}()
+}
+
+// wait 等待正在进行的检查结束，池在取消上下文后、销毁实例前调用
+func (h *poolHealth) wait() {
+	h.wg.Wait()
+}
+
+// recycleReason 返回归还的实例需要重建的原因，可以复用时返回空字符串
+func (h *poolHealth) recycleReason(instance *instanceHealth) string {
+	if instance.failure() != nil {
+		return "error"
+	}
+	if h.config.MaxUses > 0 && atomic.LoadInt64(&instance.uses) >= int64(h.config.MaxUses) {
+		return "max_uses"
+	}
+	return ""
+}
+
+// recycle 记录一次实例重建
+func (h *poolHealth) recycle(instance VADInstanceInterface, reason string) {
+	atomic.AddInt64(&h.recycled, 1)
+	logger.Info("vad_instance_recycled", "type", instance.GetType(), "id", instance.GetID(), "reason", reason)
+}
+
+// checkIdle 逐个取出空闲队列中的实例运行check。通过检查的实例放回队列；未通过的实例被淘汰，
+// 由replace销毁并返回替代它的新实例，无法新建时返回nil。每次只取出一个实例，检查期间池的
+// 可用实例最多减少一个。
+func (h *poolHealth) checkIdle(available chan VADInstanceInterface, check func(VADInstanceInterface) error, replace func(VADInstanceInterface) VADInstanceInterface) {
+	failed := 0
+	var lastErr error
+
+	for n := len(available); n > 0; n-- {
+		var instance VADInstanceInterface
+		select {
+		case instance = <-available:
+		default:
+		}
+		if instance == nil {
+			break
+		}
+
+		atomic.AddInt64(&h.checks, 1)
+		if err := check(instance); err != nil {
+			failed++
+			lastErr = err
+			atomic.AddInt64(&h.failures, 1)
+			atomic.AddInt64(&h.evicted, 1)
+			logger.Warn("vad_health_check_failed", "type", instance.GetType(), "id", instance.GetID(), "error", err)
+			if instance = replace(instance); instance == nil {
+				continue
+			}
+		}
+
+		select {
+		case available <- instance:
+		default:
+			// 队列满，销毁实例
+			instance.Destroy()
+		}
+	}
+
+	h.mu.Lock()
+	h.lastCheck = time.Now()
+	h.lastFailures = failed
+	if lastErr != nil {
+		h.lastError = lastErr.Error()
+	}
+	h.mu.Unlock()
+
+	if failed > 0 {
+		logger.Warn("vad_health_check_completed", "failed", failed)
+	}
+}
+
+// stats 返回健康状态与统计。instances为池中的实例数，target为池大小：实例全部被淘汰时为
+// unhealthy，实例不足或上一轮检查有实例未通过时为degraded。
+func (h *poolHealth) stats(instances, target int) map[string]interface{} {
+	h.mu.Lock()
+	lastCheck, lastFailures, lastError := h.lastCheck, h.lastFailures, h.lastError
+	h.mu.Unlock()
+
+	status := "healthy"
+	switch {
+	case instances == 0 && target > 0:
+		status = "unhealthy"
+	case instances < target || lastFailures > 0:
+		status = "degraded"
+	}
+
+	stats := map[string]interface{}{
+		"status":                 status,
+		"check_interval_seconds": h.config.CheckInterval.Seconds(),
+		"max_uses":               h.config.MaxUses,
+		"checks":                 atomic.LoadInt64(&h.checks),
+		"check_failures":         atomic.LoadInt64(&h.failures),
+		"recycled":               atomic.LoadInt64(&h.recycled),
+		"evicted":                atomic.LoadInt64(&h.evicted),
+	}
+	if !lastCheck.IsZero() {
+		stats["last_check"] = lastCheck.Format(time.RFC3339)
+	}
+	if lastError != "" {
+		stats["last_error"] = lastError
+	}
+	return stats
+}
@@ -0,0 +1,100 @@
+package pool
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPoolHealthRecycleReason(t *testing.T) {
+	h := &poolHealth{config: HealthConfig{MaxUses: 3}}
+	var instance instanceHealth
+
+	for i := 0; i < 2; i++ {
+		instance.use()
+	}
+	if reason := h.recycleReason(&instance); reason != "" {
+		t.Errorf("recycleReason() = %q after 2 uses, want none", reason)
+	}
+	instance.use()
+	if reason := h.recycleReason(&instance); reason != "max_uses" {
+		t.Errorf("recycleReason() = %q after 3 uses, want max_uses", reason)
+	}
+
+	var failed instanceHealth
+	failed.MarkFailed(errors.New("process failed"))
+	if reason := h.recycleReason(&failed); reason != "error" {
+		t.Errorf("recycleReason() = %q after an error, want error", reason)
+	}
+
+	unlimited := &poolHealth{}
+	if reason := unlimited.recycleReason(&instance); reason != "" {
+		t.Errorf("recycleReason() = %q without a use limit, want none", reason)
+	}
+}
+
+func TestPoolHealthCheckIdle(t *testing.T) {
+	h := &poolHealth{}
+	available := make(chan VADInstanceInterface, 4)
+	for id := 0; id < 4; id++ {
+		available <- &fakeInstance{id: id}
+	}
+
+	// Instance 1 is replaced, instance 2 fails and cannot be replaced
+	var replaced []int
+	check := func(instance VADInstanceInterface) error {
+		if id := instance.GetID(); id == 1 || id == 2 {
+			return errors.New("invalid output")
+		}
+		return nil
+	}
+	replace := func(instance VADInstanceInterface) VADInstanceInterface {
+		replaced = append(replaced, instance.GetID())
+		if instance.GetID() == 2 {
+			return nil
+		}
+		return &fakeInstance{id: instance.GetID() + 10}
+	}
+	h.checkIdle(available, check, replace)
+
+	if len(replaced) != 2 || replaced[0] != 1 || replaced[1] != 2 {
+		t.Errorf("replaced instances %v, want [1 2]", replaced)
+	}
+	var ids []int
+	for len(available) > 0 {
+		ids = append(ids, (<-available).GetID())
+	}
+	if len(ids) != 3 || ids[0] != 0 || ids[1] != 11 || ids[2] != 3 {
+		t.Errorf("available instances %v, want [0 11 3]", ids)
+	}
+
+	stats := h.stats(3, 4)
+	if stats["status"] != "degraded" || stats["checks"] != int64(4) || stats["evicted"] != int64(2) {
+		t.Errorf("stats() = %v, want degraded with 4 checks and 2 evicted", stats)
+	}
+	if stats["last_error"] != "invalid output" {
+		t.Errorf("stats() last_error = %v, want invalid output", stats["last_error"])
+	}
+}
+
+func TestPoolHealthStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		instances int
+		target    int
+		failures  int
+		want      string
+	}{
+		{"full pool", 4, 4, 0, "healthy"},
+		{"failed last check", 4, 4, 1, "degraded"},
+		{"instances evicted", 3, 4, 0, "degraded"},
+		{"all instances evicted", 0, 4, 0, "unhealthy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &poolHealth{lastFailures: tt.failures}
+			if got := h.stats(tt.instances, tt.target)["status"]; got != tt.want {
+				t.Errorf("stats() status = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package pool
+
+import (
+	"sync"
+
+	"asr_server/internal/logger"
+)
+
+// ReloadablePool serves VAD instances from a pool that can be replaced at runtime, e.g.
+// when a configuration reload changes the VAD provider or pool size. Instances are returned
+// to the pool they came from; a replaced pool is shut down once all of its instances are back,
+// since shutting down destroys instances still in use.
+type ReloadablePool struct {
+	mu          sync.Mutex
+	current     VADPoolInterface
+	owners      map[VADInstanceInterface]VADPoolInterface
+	outstanding map[VADPoolInterface]int // Instances handed out per pool
+	retired     map[VADPoolInterface]bool
+}
+
+// NewReloadablePool wraps the initial pool
+func NewReloadablePool(initial VADPoolInterface) *ReloadablePool {
+	return &ReloadablePool{
+		current:     initial,
+		owners:      make(map[VADInstanceInterface]VADPoolInterface),
+		outstanding: make(map[VADPoolInterface]int),
+		retired:     make(map[VADPoolInterface]bool),
+	}
+}
+
+// Initialize initializes the current pool
+func (p *ReloadablePool) Initialize() error {
+	p.mu.Lock()
+	current := p.current
+	p.mu.Unlock()
+	return current.Initialize()
+}
+
+// Get takes an instance from the current pool
+func (p *ReloadablePool) Get() (VADInstanceInterface, error) {
+	// Count the instance before taking it, so a concurrent Replace does not shut the pool down
+	p.mu.Lock()
+	current := p.current
+	p.outstanding[current]++
+	p.mu.Unlock()
+
+	instance, err := current.Get()
+	if err != nil {
+		p.release(current)
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.owners[instance] = current
+	p.mu.Unlock()
+	return instance, nil
+}
+
+// Put returns an instance to the pool it came from
+func (p *ReloadablePool) Put(instance VADInstanceInterface) {
+	if instance == nil {
+		return
+	}
+
+	p.mu.Lock()
+	owner, exists := p.owners[instance]
+	delete(p.owners, instance)
+	current := p.current
+	p.mu.Unlock()
+
+	if !exists {
+		current.Put(instance)
+		return
+	}
+	owner.Put(instance)
+	p.release(owner)
+}
+
+// release uncounts an instance of owner and shuts owner down when it was the last one of a
+// replaced pool
+func (p *ReloadablePool) release(owner VADPoolInterface) {
+	p.mu.Lock()
+	p.outstanding[owner]--
+	drained := p.outstanding[owner] <= 0
+	if drained {
+		delete(p.outstanding, owner)
+	}
+	shutdown := drained && p.retired[owner]
+	if shutdown {
+		delete(p.retired, owner)
+	}
+	p.mu.Unlock()
+
+	if shutdown {
+		logger.Info("replaced_vad_pool_drained")
+		owner.Shutdown()
+	}
+}
+
+// Replace makes next the pool new instances are taken from. The previous pool is shut down
+// immediately when none of its instances are in use, otherwise when the last one is returned.
+// next must already be initialized.
+func (p *ReloadablePool) Replace(next VADPoolInterface) {
+	p.mu.Lock()
+	previous := p.current
+	p.current = next
+	inUse := p.outstanding[previous]
+	if inUse > 0 {
+		p.retired[previous] = true
+	}
+	p.mu.Unlock()
+
+	logger.Info("vad_pool_replaced", "previous_in_use", inUse)
+	if inUse == 0 {
+		previous.Shutdown()
+	}
+}
+
+// Retire shuts the current pool down once its instances are returned, for a pool that is no
+// longer handed out
+func (p *ReloadablePool) Retire() {
+	p.mu.Lock()
+	current := p.current
+	inUse := p.outstanding[current]
+	if inUse > 0 {
+		p.retired[current] = true
+	}
+	p.mu.Unlock()
+
+	logger.Info("vad_pool_retired", "in_use", inUse)
+	if inUse == 0 {
+		current.Shutdown()
+	}
+}
+
+// GetStats returns the statistics of the current pool and the number of replaced pools that
+// still have instances in use
+func (p *ReloadablePool) GetStats() map[string]interface{} {
+	p.mu.Lock()
+	current := p.current
+	draining := len(p.retired)
+	p.mu.Unlock()
+
+	stats := current.GetStats()
+	if draining > 0 {
+		stats["draining_pools"] = draining
+	}
+	return stats
+}
+
+// Shutdown shuts down the current pool and the replaced pools that were not drained yet
+func (p *ReloadablePool) Shutdown() {
+	p.mu.Lock()
+	pools := []VADPoolInterface{p.current}
+	for retired := range p.retired {
+		pools = append(pools, retired)
+	}
+	p.retired = make(map[VADPoolInterface]bool)
+	p.mu.Unlock()
+
+	for _, vadPool := range pools {
+		vadPool.Shutdown()
+	}
+}
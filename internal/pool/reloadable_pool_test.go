@@ -0,0 +1,104 @@
+package pool
+
+import "testing"
+
+// fakeInstance is a VAD instance without a detector
+type fakeInstance struct {
+	id    int
+	inUse bool
+}
+
+func (i *fakeInstance) GetID() int          { return i.id }
+func (i *fakeInstance) GetType() string     { return "fake" }
+func (i *fakeInstance) IsInUse() bool       { return i.inUse }
+func (i *fakeInstance) SetInUse(inUse bool) { i.inUse = inUse }
+func (i *fakeInstance) GetLastUsed() int64  { return 0 }
+func (i *fakeInstance) SetLastUsed(int64)   {}
+func (i *fakeInstance) Reset() error        { return nil }
+func (i *fakeInstance) Destroy() error      { return nil }
+
+// fakePool records the instances it hands out and whether it was shut down
+type fakePool struct {
+	next     int
+	active   int
+	returned []VADInstanceInterface
+	shutdown bool
+}
+
+func (p *fakePool) Initialize() error { return nil }
+
+func (p *fakePool) Get() (VADInstanceInterface, error) {
+	p.next++
+	p.active++
+	return &fakeInstance{id: p.next, inUse: true}, nil
+}
+
+func (p *fakePool) Put(instance VADInstanceInterface) {
+	p.active--
+	p.returned = append(p.returned, instance)
+}
+
+func (p *fakePool) GetStats() map[string]interface{} {
+	return map[string]interface{}{"active_count": p.active}
+}
+
+func (p *fakePool) Shutdown() { p.shutdown = true }
+
+func TestReloadablePoolReplace(t *testing.T) {
+	oldPool, newPool := &fakePool{}, &fakePool{}
+	p := NewReloadablePool(oldPool)
+
+	held, _ := p.Get()
+	returned, _ := p.Get()
+	p.Put(returned)
+
+	p.Replace(newPool)
+	if oldPool.shutdown {
+		t.Fatal("replaced pool shut down while an instance is in use")
+	}
+	if stats := p.GetStats(); stats["draining_pools"] != 1 {
+		t.Errorf("stats = %v, want 1 draining pool", stats)
+	}
+
+	// New instances come from the new pool, and each instance returns to its own pool
+	fresh, _ := p.Get()
+	p.Put(fresh)
+	if newPool.active != 0 || len(newPool.returned) != 1 {
+		t.Errorf("new pool active = %d, returned = %d, want 0 and 1", newPool.active, len(newPool.returned))
+	}
+
+	p.Put(held)
+	if len(oldPool.returned) != 2 || !oldPool.shutdown {
+		t.Errorf("old pool returned = %d, shut down = %v, want 2 and true", len(oldPool.returned), oldPool.shutdown)
+	}
+	if stats := p.GetStats(); stats["draining_pools"] != nil {
+		t.Errorf("stats = %v, want no draining pools", stats)
+	}
+
+	// A pool without instances in use is shut down right away
+	idle := &fakePool{}
+	p.Replace(idle)
+	if !newPool.shutdown {
+		t.Error("idle replaced pool not shut down")
+	}
+
+	p.Shutdown()
+	if !idle.shutdown {
+		t.Error("current pool not shut down")
+	}
+}
+
+func TestReloadablePoolRetire(t *testing.T) {
+	inner := &fakePool{}
+	p := NewReloadablePool(inner)
+
+	held, _ := p.Get()
+	p.Retire()
+	if inner.shutdown {
+		t.Fatal("retired pool shut down while an instance is in use")
+	}
+	p.Put(held)
+	if !inner.shutdown {
+		t.Error("retired pool not shut down after its last instance returned")
+	}
+}
@@ -3,6 +3,7 @@ package pool
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -18,6 +19,7 @@ type SileroVADConfig struct {
 	BufferSizeSeconds float32
 	PoolSize          int
 	MaxIdle           int
+	Health            HealthConfig
 }
 
 // SileroVADInstance Silero VAD实例
@@ -27,6 +29,7 @@ type SileroVADInstance struct {
 	LastUsed int64
 	InUse    int32
 	mu       sync.RWMutex
+	instanceHealth
 }
 
 // GetID 获取实例ID
@@ -92,6 +95,33 @@ func (i *SileroVADInstance) Destroy() error {
 	return nil
 }
 
+// check 运行一段合成音频，检查检测器能否重置回没有语音段、不在语音中的初始状态
+func (i *SileroVADInstance) check() error {
+	if i.VAD == nil {
+		return fmt.Errorf("Silero VAD instance destroyed")
+	}
+	i.VAD.AcceptWaveform(syntheticAudio(healthCheckSamples))
+	i.VAD.Reset()
+	if !i.VAD.IsEmpty() || i.VAD.IsSpeech() {
+		return fmt.Errorf("Silero VAD state not cleared by reset")
+	}
+	return nil
+}
+
+// NewSileroVADInstance 创建不属于任何池的Silero VAD实例，由调用方销毁
+func NewSileroVADInstance(config *SileroVADConfig) (*SileroVADInstance, error) {
+	vad := sherpa.NewVoiceActivityDetector(config.ModelConfig, config.BufferSizeSeconds)
+	if vad == nil {
+		return nil, fmt.Errorf("failed to create Silero VAD instance")
+	}
+	return &SileroVADInstance{
+		VAD:      vad,
+		LastUsed: time.Now().UnixNano(),
+		InUse:    1,
+		ID:       -1, // 专用实例
+	}, nil
+}
+
 // SileroVADPool Silero VAD资源池
 type SileroVADPool struct {
 	instances []*SileroVADInstance
@@ -102,6 +132,7 @@ type SileroVADPool struct {
 	totalCreated int64
 	totalReused  int64
 	totalActive  int64
+	health       poolHealth
 
 	// 控制
 	mu     sync.RWMutex
@@ -117,6 +148,7 @@ func NewSileroVADPool(config *SileroVADConfig) *SileroVADPool {
 		instances: make([]*SileroVADInstance, 0, config.PoolSize),
 		available: make(chan VADInstanceInterface, config.PoolSize),
 		config:    config,
+		health:    poolHealth{config: config.Health},
 		ctx:       ctx,
 		cancel:    cancel,
 	}
@@ -187,6 +219,7 @@ func (p *SileroVADPool) Initialize() error {
 		return fmt.Errorf("failed to initialize any Silero VAD instances")
 	}
 
+	p.health.start(p.ctx, p.checkHealth)
 	return nil
 }
 
@@ -201,6 +234,7 @@ func (p *SileroVADPool) Get() (VADInstanceInterface, error) {
 		case instance := <-p.available:
 			logger.Debug("got_silero_vad_instance", "id", instance.GetID())
 			if atomic.CompareAndSwapInt32(&instance.(*SileroVADInstance).InUse, 0, 1) {
+				instance.(*SileroVADInstance).use()
 				instance.SetLastUsed(time.Now().UnixNano())
 				atomic.AddInt64(&p.totalReused, 1)
 				atomic.AddInt64(&p.totalActive, 1)
@@ -244,8 +278,14 @@ func (p *SileroVADPool) Put(instance VADInstanceInterface) {
 		atomic.AddInt64(&p.totalActive, -1)
 		logger.Debug("silero_vad_marked_available", "id", instance.GetID(), "active", atomic.LoadInt64(&p.totalActive))
 
-		// 重置VAD状态
-		if err := instance.Reset(); err != nil {
+		// 达到复用次数或出过错的实例重建，其余重置VAD状态
+		sileroInstance := instance.(*SileroVADInstance)
+		if reason := p.health.recycleReason(&sileroInstance.instanceHealth); reason != "" {
+			p.health.recycle(instance, reason)
+			if instance = p.replace(sileroInstance); instance == nil {
+				return
+			}
+		} else if err := instance.Reset(); err != nil {
 			logger.Warn("failed_to_reset_silero_vad", "id", instance.GetID(), "error", err)
 		}
 
@@ -263,6 +303,41 @@ func (p *SileroVADPool) Put(instance VADInstanceInterface) {
 	}
 }
 
+// replace 销毁实例并按相同ID新建一个替代它。新建失败时返回nil，池的实例数相应减少
+func (p *SileroVADPool) replace(old *SileroVADInstance) VADInstanceInterface {
+	old.Destroy()
+	instance, err := NewSileroVADInstance(p.config)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	index := slices.Index(p.instances, old)
+	if err != nil {
+		if index >= 0 {
+			p.instances = slices.Delete(p.instances, index, index+1)
+		}
+		logger.Error("failed_to_replace_silero_vad", "id", old.ID, "error", err)
+		return nil
+	}
+
+	instance.ID = old.ID
+	instance.InUse = 0
+	if index >= 0 {
+		p.instances[index] = instance
+	}
+	atomic.AddInt64(&p.totalCreated, 1)
+	return instance
+}
+
+// checkHealth 检查空闲实例，淘汰未通过检查的实例并新建替代
+func (p *SileroVADPool) checkHealth() {
+	p.health.checkIdle(p.available, func(instance VADInstanceInterface) error {
+		return instance.(*SileroVADInstance).check()
+	}, func(instance VADInstanceInterface) VADInstanceInterface {
+		return p.replace(instance.(*SileroVADInstance))
+	})
+}
+
 // createNewInstance 创建新的VAD实例
 func (p *SileroVADPool) createNewInstance() (VADInstanceInterface, error) {
 	vad := sherpa.NewVoiceActivityDetector(p.config.ModelConfig, p.config.BufferSizeSeconds)
@@ -276,6 +351,7 @@ func (p *SileroVADPool) createNewInstance() (VADInstanceInterface, error) {
 		InUse:    1,
 		ID:       -1, // 临时实例
 	}
+	instance.use()
 
 	atomic.AddInt64(&p.totalCreated, 1)
 	atomic.AddInt64(&p.totalActive, 1)
@@ -298,6 +374,7 @@ func (p *SileroVADPool) GetStats() map[string]interface{} {
 		"active_count":    atomic.LoadInt64(&p.totalActive),
 		"total_created":   atomic.LoadInt64(&p.totalCreated),
 		"total_reused":    atomic.LoadInt64(&p.totalReused),
+		"health":          p.health.stats(len(p.instances), p.config.PoolSize),
 	}
 }
 
@@ -305,8 +382,9 @@ func (p *SileroVADPool) GetStats() map[string]interface{} {
 func (p *SileroVADPool) Shutdown() {
 	logger.Info("shutting_down_silero_vad_pool")
 
-	// 取消上下文
+	// 取消上下文，等待进行中的健康检查结束
 	p.cancel()
+	p.health.wait()
 
 	// 销毁所有实例
 	p.mu.Lock()
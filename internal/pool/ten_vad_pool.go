@@ -3,6 +3,8 @@ package pool
 import (
 	"context"
 	"fmt"
+	"math"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -17,6 +19,7 @@ type TenVADConfig struct {
 	Threshold float32
 	PoolSize  int
 	MaxIdle   int
+	Health    HealthConfig
 }
 
 // TenVADInstance TEN-VAD实例
@@ -26,6 +29,11 @@ type TenVADInstance struct {
 	LastUsed int64
 	InUse    int32
 	mu       sync.RWMutex
+	instanceHealth
+
+	// 创建句柄所用的参数，重置时按相同参数重建
+	hopSize   int
+	threshold float32
 }
 
 // GetID 获取实例ID
@@ -66,9 +74,18 @@ func (i *TenVADInstance) SetLastUsed(timestamp int64) {
 	i.LastUsed = timestamp
 }
 
-// Reset 重置实例状态
+// Reset 重置实例状态。TEN-VAD的句柄保存了前几帧的特征与模型隐状态，且没有重置接口，
+// 因此按相同参数新建句柄并销毁旧句柄，避免上一个会话的状态影响下一个会话的检测结果
 func (i *TenVADInstance) Reset() error {
-	// TEN-VAD不需要重置，每次处理都是独立的
+	tenVAD := GetInstance()
+	handle, err := tenVAD.CreateInstance(i.hopSize, i.threshold)
+	if err != nil {
+		return fmt.Errorf("failed to recreate TEN-VAD handle: %v", err)
+	}
+	if i.Handle != nil {
+		tenVAD.DestroyInstance(i.Handle)
+	}
+	i.Handle = handle
 	return nil
 }
 
@@ -83,6 +100,42 @@ func (i *TenVADInstance) Destroy() error {
 	return nil
 }
 
+// check 运行一段合成音频，检查句柄能否输出有效的概率与标志，检查后重建句柄以清除合成音频留下的状态
+func (i *TenVADInstance) check() error {
+	tenVAD := GetInstance()
+	samples := syntheticAudio(max(healthCheckSamples, i.hopSize))
+	frame := make([]int16, i.hopSize)
+	for start := 0; start+i.hopSize <= len(samples); start += i.hopSize {
+		for j := range frame {
+			frame[j] = int16(samples[start+j] * 32767)
+		}
+		prob, flag, err := tenVAD.ProcessAudio(i.Handle, frame)
+		if err != nil {
+			return err
+		}
+		if math.IsNaN(float64(prob)) || prob < 0 || prob > 1 || (flag != 0 && flag != 1) {
+			return fmt.Errorf("invalid TEN-VAD output: probability %v, flag %d", prob, flag)
+		}
+	}
+	return i.Reset()
+}
+
+// NewTenVADInstance 创建不属于任何池的TEN-VAD实例，由调用方销毁
+func NewTenVADInstance(config *TenVADConfig) (*TenVADInstance, error) {
+	handle, err := GetInstance().CreateInstance(config.HopSize, config.Threshold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create TEN-VAD instance: %v", err)
+	}
+	return &TenVADInstance{
+		Handle:    handle,
+		LastUsed:  time.Now().UnixNano(),
+		InUse:     1,
+		ID:        -1, // 专用实例
+		hopSize:   config.HopSize,
+		threshold: config.Threshold,
+	}, nil
+}
+
 // TenVADPool TEN-VAD资源池
 type TenVADPool struct {
 	instances []*TenVADInstance
@@ -93,6 +146,7 @@ type TenVADPool struct {
 	totalCreated int64
 	totalReused  int64
 	totalActive  int64
+	health       poolHealth
 
 	// 控制
 	mu     sync.RWMutex
@@ -108,6 +162,7 @@ func NewTenVADPool(config *TenVADConfig) *TenVADPool {
 		instances: make([]*TenVADInstance, 0, config.PoolSize),
 		available: make(chan VADInstanceInterface, config.PoolSize),
 		config:    config,
+		health:    poolHealth{config: config.Health},
 		ctx:       ctx,
 		cancel:    cancel,
 	}
@@ -129,19 +184,13 @@ func (p *TenVADPool) Initialize() error {
 			defer initWg.Done()
 
 			// 创建TEN-VAD实例
-			tenVAD := GetInstance()
-			handle, err := tenVAD.CreateInstance(p.config.HopSize, p.config.Threshold)
+			instance, err := NewTenVADInstance(p.config)
 			if err != nil {
 				errorChan <- fmt.Errorf("failed to create TEN-VAD instance %d: %v", instanceID, err)
 				return
 			}
-
-			instance := &TenVADInstance{
-				Handle:   handle,
-				LastUsed: time.Now().UnixNano(),
-				InUse:    0,
-				ID:       instanceID,
-			}
+			instance.ID = instanceID
+			instance.InUse = 0
 
 			p.mu.Lock()
 			p.instances = append(p.instances, instance)
@@ -154,7 +203,7 @@ func (p *TenVADPool) Initialize() error {
 				logger.Debug("ten_vad_instance_initialized", "id", instanceID)
 			default:
 				// 队列满，销毁实例
-				tenVAD.DestroyInstance(handle)
+				instance.Destroy()
 				errorChan <- fmt.Errorf("TEN-VAD pool queue full, instance %d discarded", instanceID)
 			}
 		}(i)
@@ -179,6 +228,7 @@ func (p *TenVADPool) Initialize() error {
 		return fmt.Errorf("failed to initialize any TEN-VAD instances")
 	}
 
+	p.health.start(p.ctx, p.checkHealth)
 	return nil
 }
 
@@ -190,6 +240,7 @@ func (p *TenVADPool) Get() (VADInstanceInterface, error) {
 	case instance := <-p.available:
 		logger.Debug("got_ten_vad_instance", "id", instance.GetID())
 		if atomic.CompareAndSwapInt32(&instance.(*TenVADInstance).InUse, 0, 1) {
+			instance.(*TenVADInstance).use()
 			instance.SetLastUsed(time.Now().UnixNano())
 			atomic.AddInt64(&p.totalReused, 1)
 			atomic.AddInt64(&p.totalActive, 1)
@@ -227,9 +278,20 @@ func (p *TenVADPool) Put(instance VADInstanceInterface) {
 		atomic.AddInt64(&p.totalActive, -1)
 		logger.Debug("ten_vad_marked_available", "id", instance.GetID(), "active", atomic.LoadInt64(&p.totalActive))
 
-		// 重置VAD状态
-		if err := instance.Reset(); err != nil {
-			logger.Warn("failed_to_reset_ten_vad", "id", instance.GetID(), "error", err)
+		// 达到复用次数或出过错的实例重建，其余重置VAD状态，无法重置的实例同样重建
+		tenInstance := instance.(*TenVADInstance)
+		reason := p.health.recycleReason(&tenInstance.instanceHealth)
+		if reason == "" {
+			if err := instance.Reset(); err != nil {
+				logger.Warn("failed_to_reset_ten_vad", "id", instance.GetID(), "error", err)
+				reason = "reset_failed"
+			}
+		}
+		if reason != "" {
+			p.health.recycle(instance, reason)
+			if instance = p.replace(tenInstance); instance == nil {
+				return
+			}
 		}
 
 		select {
@@ -246,20 +308,48 @@ func (p *TenVADPool) Put(instance VADInstanceInterface) {
 	}
 }
 
+// replace 销毁实例并按相同ID新建一个替代它。新建失败时返回nil，池的实例数相应减少
+func (p *TenVADPool) replace(old *TenVADInstance) VADInstanceInterface {
+	old.Destroy()
+	instance, err := NewTenVADInstance(p.config)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	index := slices.Index(p.instances, old)
+	if err != nil {
+		if index >= 0 {
+			p.instances = slices.Delete(p.instances, index, index+1)
+		}
+		logger.Error("failed_to_replace_ten_vad", "id", old.ID, "error", err)
+		return nil
+	}
+
+	instance.ID = old.ID
+	instance.InUse = 0
+	if index >= 0 {
+		p.instances[index] = instance
+	}
+	atomic.AddInt64(&p.totalCreated, 1)
+	return instance
+}
+
+// checkHealth 检查空闲实例，淘汰未通过检查的实例并新建替代
+func (p *TenVADPool) checkHealth() {
+	p.health.checkIdle(p.available, func(instance VADInstanceInterface) error {
+		return instance.(*TenVADInstance).check()
+	}, func(instance VADInstanceInterface) VADInstanceInterface {
+		return p.replace(instance.(*TenVADInstance))
+	})
+}
+
 // createNewInstance 创建新的VAD实例
 func (p *TenVADPool) createNewInstance() (VADInstanceInterface, error) {
-	tenVAD := GetInstance()
-	handle, err := tenVAD.CreateInstance(p.config.HopSize, p.config.Threshold)
+	instance, err := NewTenVADInstance(p.config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create new TEN-VAD instance: %v", err)
 	}
-
-	instance := &TenVADInstance{
-		Handle:   handle,
-		LastUsed: time.Now().UnixNano(),
-		InUse:    1,
-		ID:       -1, // 临时实例
-	}
+	instance.use()
 
 	atomic.AddInt64(&p.totalCreated, 1)
 	atomic.AddInt64(&p.totalActive, 1)
@@ -282,6 +372,7 @@ func (p *TenVADPool) GetStats() map[string]interface{} {
 		"active_count":    atomic.LoadInt64(&p.totalActive),
 		"total_created":   atomic.LoadInt64(&p.totalCreated),
 		"total_reused":    atomic.LoadInt64(&p.totalReused),
+		"health":          p.health.stats(len(p.instances), p.config.PoolSize),
 	}
 }
 
@@ -289,8 +380,9 @@ func (p *TenVADPool) GetStats() map[string]interface{} {
 func (p *TenVADPool) Shutdown() {
 	logger.Info("shutting_down_ten_vad_pool")
 
-	// 取消上下文
+	// 取消上下文，等待进行中的健康检查结束
 	p.cancel()
+	p.health.wait()
 
 	// 销毁所有实例
 	p.mu.Lock()
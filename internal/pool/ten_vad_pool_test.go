@@ -0,0 +1,98 @@
+package pool
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+const testHopSize = 256
+
+// testFrames returns frames of a tone with varying pitch and loudness over noise, which keeps
+// the detector's feature history and hidden state changing from frame to frame
+func testFrames(n int) [][]int16 {
+	rng := rand.New(rand.NewPCG(1, 2))
+	frames := make([][]int16, n)
+	phase := 0.0
+	for i := range frames {
+		frame := make([]int16, testHopSize)
+		freq := 150 + 50*float64(i%7)
+		amplitude := 4000 + 3000*math.Sin(float64(i)/3)
+		for j := range frame {
+			phase += 2 * math.Pi * freq / 16000
+			frame[j] = int16(amplitude*math.Sin(phase) + 500*rng.NormFloat64())
+		}
+		frames[i] = frame
+	}
+	return frames
+}
+
+// probabilities runs frames through an instance and returns the speech probability of each
+func probabilities(t *testing.T, instance *TenVADInstance, frames [][]int16) []float32 {
+	t.Helper()
+	probs := make([]float32, len(frames))
+	for i, frame := range frames {
+		prob, _, err := GetInstance().ProcessAudio(instance.Handle, frame)
+		if err != nil {
+			t.Fatalf("ProcessAudio() error = %v", err)
+		}
+		probs[i] = prob
+	}
+	return probs
+}
+
+func TestTenVADPoolResetsState(t *testing.T) {
+	cfg := &TenVADConfig{HopSize: testHopSize, Threshold: 0.5, PoolSize: 1}
+	frames := testFrames(40)
+
+	fresh, err := NewTenVADInstance(cfg)
+	if err != nil {
+		t.Fatalf("NewTenVADInstance() error = %v", err)
+	}
+	defer fresh.Destroy()
+	want := probabilities(t, fresh, frames)
+
+	// Without a reset the input is detected differently after earlier audio
+	continued := probabilities(t, fresh, frames)
+	if equalProbabilities(continued, want) {
+		t.Fatal("test input does not exercise the detector state")
+	}
+
+	p := NewTenVADPool(cfg)
+	if err := p.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	defer p.Shutdown()
+
+	// The first session leaves the detector in the middle of the input
+	first, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	probabilities(t, first.(*TenVADInstance), frames[:25])
+	p.Put(first)
+
+	second, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer p.Put(second)
+	if second.GetID() != first.GetID() {
+		t.Fatalf("Get() returned instance %d, want the recycled instance %d", second.GetID(), first.GetID())
+	}
+	if got := probabilities(t, second.(*TenVADInstance), frames); !equalProbabilities(got, want) {
+		t.Errorf("probabilities after reuse = %v, want %v as from a new instance", got, want)
+	}
+}
+
+func equalProbabilities(a, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -2,6 +2,7 @@ package pool
 
 import (
 	"fmt"
+	"time"
 
 	"asr_server/config"
 	"asr_server/internal/logger"
@@ -38,8 +39,11 @@ func (f *VADFactory) RegisterFactory(vadType string, factory VADPoolFactory) {
 
 // CreateVADPool creates a VAD pool based on configuration
 func (f *VADFactory) CreateVADPool() (VADPoolInterface, error) {
-	vadType := f.cfg.VAD.Provider
+	return f.CreateVADPoolForType(f.cfg.VAD.Provider)
+}
 
+// CreateVADPoolForType creates a VAD pool of the given type using the configured settings for that type
+func (f *VADFactory) CreateVADPoolForType(vadType string) (VADPoolInterface, error) {
 	logger.Info("creating_vad_pool", "type", vadType)
 
 	factory, exists := f.factories[vadType]
@@ -53,9 +57,9 @@ func (f *VADFactory) CreateVADPool() (VADPoolInterface, error) {
 
 	switch vadType {
 	case SILERO_TYPE:
-		vadConfig, err = f.createSileroConfig()
+		vadConfig, err = f.createSileroConfig(&f.cfg.VAD)
 	case TEN_VAD_TYPE:
-		vadConfig, err = f.createTenVADConfig()
+		vadConfig, err = f.createTenVADConfig(&f.cfg.VAD)
 	default:
 		return nil, fmt.Errorf("unsupported VAD type: %s", vadType)
 	}
@@ -73,16 +77,37 @@ func (f *VADFactory) CreateVADPool() (VADPoolInterface, error) {
 	return pool, nil
 }
 
-// createSileroConfig creates Silero VAD configuration
-func (f *VADFactory) createSileroConfig() (*SileroVADConfig, error) {
+// CreateInstance creates a VAD instance of the given type outside any pool, using vad in place
+// of the configured VAD settings. The caller destroys it when done.
+func (f *VADFactory) CreateInstance(vadType string, vad config.VADConfig) (VADInstanceInterface, error) {
+	switch vadType {
+	case SILERO_TYPE:
+		sileroConfig, err := f.createSileroConfig(&vad)
+		if err != nil {
+			return nil, err
+		}
+		return NewSileroVADInstance(sileroConfig)
+	case TEN_VAD_TYPE:
+		tenVADConfig, err := f.createTenVADConfig(&vad)
+		if err != nil {
+			return nil, err
+		}
+		return NewTenVADInstance(tenVADConfig)
+	default:
+		return nil, fmt.Errorf("unsupported VAD type: %s", vadType)
+	}
+}
+
+// createSileroConfig creates Silero VAD configuration from the given VAD settings
+func (f *VADFactory) createSileroConfig(vad *config.VADConfig) (*SileroVADConfig, error) {
 	vadConfig := &sherpa.VadModelConfig{
 		SileroVad: sherpa.SileroVadModelConfig{
-			Model:              f.cfg.VAD.SileroVAD.ModelPath,
-			Threshold:          f.cfg.VAD.SileroVAD.Threshold,
-			MinSilenceDuration: f.cfg.VAD.SileroVAD.MinSilenceDuration,
-			MinSpeechDuration:  f.cfg.VAD.SileroVAD.MinSpeechDuration,
-			WindowSize:         f.cfg.VAD.SileroVAD.WindowSize,
-			MaxSpeechDuration:  f.cfg.VAD.SileroVAD.MaxSpeechDuration,
+			Model:              vad.SileroVAD.ModelPath,
+			Threshold:          vad.SileroVAD.Threshold,
+			MinSilenceDuration: vad.SileroVAD.MinSilenceDuration,
+			MinSpeechDuration:  vad.SileroVAD.MinSpeechDuration,
+			WindowSize:         vad.SileroVAD.WindowSize,
+			MaxSpeechDuration:  vad.SileroVAD.MaxSpeechDuration,
 		},
 		SampleRate: f.cfg.Audio.SampleRate,
 		NumThreads: f.cfg.Recognition.NumThreads,
@@ -92,22 +117,32 @@ func (f *VADFactory) createSileroConfig() (*SileroVADConfig, error) {
 
 	return &SileroVADConfig{
 		ModelConfig:       vadConfig,
-		BufferSizeSeconds: f.cfg.VAD.SileroVAD.BufferSizeSeconds,
-		PoolSize:          f.cfg.VAD.PoolSize,
+		BufferSizeSeconds: vad.SileroVAD.BufferSizeSeconds,
+		PoolSize:          vad.PoolSize,
 		MaxIdle:           0,
+		Health:            healthConfig(vad),
 	}, nil
 }
 
-// createTenVADConfig creates TEN-VAD configuration
-func (f *VADFactory) createTenVADConfig() (*TenVADConfig, error) {
+// createTenVADConfig creates TEN-VAD configuration from the given VAD settings
+func (f *VADFactory) createTenVADConfig(vad *config.VADConfig) (*TenVADConfig, error) {
 	return &TenVADConfig{
-		HopSize:   f.cfg.VAD.TenVAD.HopSize,
-		Threshold: f.cfg.VAD.Threshold,
-		PoolSize:  f.cfg.VAD.PoolSize,
+		HopSize:   vad.TenVAD.HopSize,
+		Threshold: vad.Threshold,
+		PoolSize:  vad.PoolSize,
 		MaxIdle:   0,
+		Health:    healthConfig(vad),
 	}, nil
 }
 
+// healthConfig creates the pool health check settings from the given VAD settings
+func healthConfig(vad *config.VADConfig) HealthConfig {
+	return HealthConfig{
+		CheckInterval: time.Duration(vad.HealthCheckInterval) * time.Second,
+		MaxUses:       vad.MaxInstanceUses,
+	}
+}
+
 // GetVADType returns the current VAD type from configuration
 func (f *VADFactory) GetVADType() string {
 	return f.cfg.VAD.Provider
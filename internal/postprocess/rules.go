@@ -0,0 +1,146 @@
+package postprocess
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"asr_server/internal/logger"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Rule replaces a phrase, or a regular expression when Regex is set, in transcripts.
+// Regex replacements may reference groups as $1.
+type Rule struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+	Regex       bool   `json:"regex,omitempty"`
+}
+
+// compiledRule is a rule ready to be applied
+type compiledRule struct {
+	Rule
+	re *regexp.Regexp
+}
+
+// Rules applies replacement rules loaded from a JSON file to transcripts. The file is
+// watched and reloaded when it changes; a file that fails to load keeps the previous rules.
+type Rules struct {
+	path    string
+	rules   atomic.Pointer[[]compiledRule]
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+}
+
+// NewRules loads the rules in path
+func NewRules(path string) (*Rules, error) {
+	r := &Rules{path: path, done: make(chan struct{})}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Apply applies every rule to text in file order. It is safe to call on a nil *Rules.
+func (r *Rules) Apply(text string) string {
+	if r == nil || text == "" {
+		return text
+	}
+	for _, rule := range *r.rules.Load() {
+		if rule.re != nil {
+			text = rule.re.ReplaceAllString(text, rule.Replacement)
+		} else {
+			text = strings.ReplaceAll(text, rule.Pattern, rule.Replacement)
+		}
+	}
+	return text
+}
+
+// Count returns the number of loaded rules
+func (r *Rules) Count() int {
+	return len(*r.rules.Load())
+}
+
+// load reads and compiles the rules file, replacing the current rules on success
+func (r *Rules) load() error {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to read rules file: %v", err)
+	}
+
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return fmt.Errorf("failed to parse rules file: %v", err)
+	}
+
+	compiled := make([]compiledRule, 0, len(rules))
+	for i, rule := range rules {
+		if rule.Pattern == "" {
+			return fmt.Errorf("rule %d: pattern cannot be empty", i)
+		}
+		c := compiledRule{Rule: rule}
+		if rule.Regex {
+			if c.re, err = regexp.Compile(rule.Pattern); err != nil {
+				return fmt.Errorf("rule %d: invalid regex: %v", i, err)
+			}
+		}
+		compiled = append(compiled, c)
+	}
+
+	r.rules.Store(&compiled)
+	logger.Info("text_rules_loaded", "path", r.path, "rules", len(compiled))
+	return nil
+}
+
+// Watch reloads the rules whenever the file changes. The directory is watched because
+// editors often replace the file rather than writing to it.
+func (r *Rules) Watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(filepath.Dir(r.path)); err != nil {
+		watcher.Close()
+		return err
+	}
+	r.watcher = watcher
+
+	go func() {
+		name := filepath.Clean(r.path)
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != name || event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+				if err := r.load(); err != nil {
+					logger.Warn("text_rules_reload_failed", "path", r.path, "error", err)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Warn("text_rules_watch_error", "error", err)
+			case <-r.done:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops watching the rules file
+func (r *Rules) Stop() {
+	close(r.done)
+	if r.watcher != nil {
+		r.watcher.Close()
+	}
+}
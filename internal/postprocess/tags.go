@@ -0,0 +1,88 @@
+package postprocess
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Transcript is recognized text together with the tags SenseVoice emits alongside it
+type Transcript struct {
+	Text     string   `json:"text"`
+	Language string   `json:"language,omitempty"` // Detected language, e.g. zh
+	Emotion  string   `json:"emotion,omitempty"`  // Speaker emotion, e.g. happy
+	Events   []string `json:"events,omitempty"`   // Audio events, e.g. speech, laughter
+	// English translation, set when the session requested translation
+	Translation string `json:"translation,omitempty"`
+	// Who spoke the segment, set when speaker recognition is enabled
+	Speaker Speaker `json:"speaker"`
+}
+
+// Speaker identifies who spoke a segment: a registered speaker by ID and name, and a
+// diarization label that is stable within the audio stream
+type Speaker struct {
+	ID    string `json:"speaker_id,omitempty"`
+	Name  string `json:"speaker_name,omitempty"`
+	Label string `json:"speaker,omitempty"` // e.g. spk_1
+}
+
+// SenseVoice emotion tokens and the names they are reported as
+var emotions = map[string]string{
+	"HAPPY":     "happy",
+	"SAD":       "sad",
+	"ANGRY":     "angry",
+	"NEUTRAL":   "neutral",
+	"FEARFUL":   "fearful",
+	"DISGUSTED": "disgusted",
+	"SURPRISED": "surprised",
+}
+
+// SenseVoice audio event tokens and the names they are reported as
+var events = map[string]string{
+	"Speech":   "speech",
+	"BGM":      "bgm",
+	"Applause": "applause",
+	"Laughter": "laughter",
+	"Cry":      "cry",
+	"Sneeze":   "sneeze",
+	"Breath":   "breath",
+	"Cough":    "cough",
+}
+
+// tagPattern matches special tokens such as <|zh|>, <|HAPPY|> or <|Speech|>
+var tagPattern = regexp.MustCompile(`<\|([^|<>]*)\|>`)
+
+// ParseResult builds a transcript from a recognizer result. Tags are read from the
+// dedicated result fields and from tokens left in the text, which are removed from it.
+// Unknown emotions and tokens such as <|withitn|> are dropped.
+func ParseResult(text, lang, emotion, event string) Transcript {
+	t := Transcript{}
+	for _, tag := range []string{lang, emotion, event} {
+		for _, m := range tagPattern.FindAllStringSubmatch(tag, -1) {
+			t.addTag(m[1])
+		}
+	}
+	for _, m := range tagPattern.FindAllStringSubmatch(text, -1) {
+		t.addTag(m[1])
+	}
+	t.Text = strings.TrimSpace(tagPattern.ReplaceAllString(text, ""))
+	return t
+}
+
+// addTag classifies a special token by its name
+func (t *Transcript) addTag(name string) {
+	if e, ok := emotions[name]; ok {
+		t.Emotion = e
+		return
+	}
+	if e, ok := events[name]; ok {
+		if !slices.Contains(t.Events, e) {
+			t.Events = append(t.Events, e)
+		}
+		return
+	}
+	// Language tokens are short lower-case codes; nospeech marks a segment without speech
+	if t.Language == "" && name != "nospeech" && len(name) <= 3 && name == strings.ToLower(name) {
+		t.Language = name
+	}
+}
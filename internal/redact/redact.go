@@ -0,0 +1,75 @@
+// Package redact decides which keys hold sensitive values. Configuration output masks them
+// and the logger redacts them, both with the same rules.
+package redact
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultKeywords mark a key as sensitive when it contains one of them, ignoring case
+var DefaultKeywords = []string{
+	"password", "passwd", "pwd",
+	"secret", "private",
+	"key", "apikey", "api_key",
+	"token", "auth",
+	"credential", "cred",
+	"certificate", "cert",
+}
+
+// Rules decide which keys are sensitive
+type Rules struct {
+	keywords []string
+	allow    map[string]bool
+}
+
+// NewRules returns the default keywords with add appended and remove taken out. Keys in
+// allow are never sensitive, for names a keyword matches by accident, e.g. "key" in "hotkey".
+func NewRules(add, remove, allow []string) *Rules {
+	r := &Rules{allow: make(map[string]bool, len(allow))}
+	removed := make(map[string]bool, len(remove))
+	for _, keyword := range remove {
+		removed[strings.ToLower(keyword)] = true
+	}
+	for _, keyword := range append(append([]string{}, DefaultKeywords...), add...) {
+		keyword = strings.ToLower(keyword)
+		if !removed[keyword] {
+			r.keywords = append(r.keywords, keyword)
+		}
+	}
+	for _, key := range allow {
+		r.allow[strings.ToLower(key)] = true
+	}
+	return r
+}
+
+// IsSensitive reports whether key names a sensitive value
+func (r *Rules) IsSensitive(key string) bool {
+	key = strings.ToLower(key)
+	if r.allow[key] {
+		return false
+	}
+	for _, keyword := range r.keywords {
+		if strings.Contains(key, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+var current atomic.Pointer[Rules]
+
+func init() {
+	current.Store(NewRules(nil, nil, nil))
+}
+
+// Configure replaces the rules IsSensitiveKey applies
+func Configure(rules *Rules) {
+	current.Store(rules)
+}
+
+// IsSensitiveKey reports whether key names a sensitive value under the configured rules,
+// the defaults until Configure is called
+func IsSensitiveKey(key string) bool {
+	return current.Load().IsSensitive(key)
+}
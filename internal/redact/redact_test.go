@@ -0,0 +1,40 @@
+package redact
+
+import "testing"
+
+func TestRulesIsSensitive(t *testing.T) {
+	tests := []struct {
+		name   string
+		rules  *Rules
+		key    string
+		expect bool
+	}{
+		{"default keyword", NewRules(nil, nil, nil), "API_KEY", true},
+		{"keyword inside a name", NewRules(nil, nil, nil), "hotkey", true},
+		{"not sensitive", NewRules(nil, nil, nil), "model_path", false},
+		{"added keyword", NewRules([]string{"Phone"}, nil, nil), "caller_phone", true},
+		{"removed keyword", NewRules(nil, []string{"cert"}, nil), "cert_file", false},
+		{"other keywords kept after removal", NewRules(nil, []string{"cert"}, nil), "certificate", true},
+		{"allowed key", NewRules(nil, nil, []string{"HotKey"}), "hotkey", false},
+		{"allowed key matches whole names only", NewRules(nil, nil, []string{"hotkey"}), "hotkey_secret", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rules.IsSensitive(tt.key); got != tt.expect {
+				t.Errorf("IsSensitive(%q) = %v, want %v", tt.key, got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	defer Configure(NewRules(nil, nil, nil))
+
+	if !IsSensitiveKey("hotkey") {
+		t.Fatal("default rules should match hotkey")
+	}
+	Configure(NewRules(nil, nil, []string{"hotkey"}))
+	if IsSensitiveKey("hotkey") || !IsSensitiveKey("token") {
+		t.Error("configured rules not applied")
+	}
+}
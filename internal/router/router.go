@@ -1,8 +1,10 @@
 package router
 
 import (
+	"asr_server/internal/auth"
 	"asr_server/internal/bootstrap"
 	"asr_server/internal/handlers"
+	"asr_server/internal/logger"
 	"asr_server/internal/middleware"
 	"asr_server/internal/ws"
 
@@ -13,24 +15,52 @@ import (
 // All dependencies are explicitly injected through AppDependencies.
 func NewRouter(deps *bootstrap.AppDependencies) *gin.Engine {
 	ginRouter := gin.New()
+	// c.ClientIP() honours forwarding headers only from the configured reverse proxies;
+	// entries are validated with the configuration
+	if err := ginRouter.SetTrustedProxies(deps.Config.Server.TrustedProxies); err != nil {
+		logger.Warn("invalid_trusted_proxies", "error", err)
+	}
+	// Multipart forms parsed outside UploadLimit routes keep the same memory budget
+	if memory := deps.Config.Server.MultipartMemory; memory > 0 {
+		ginRouter.MaxMultipartMemory = memory
+	}
 
 	// Middleware order is important:
 	// 1. RequestID must come first to generate request_id
 	// 2. Logger uses the request_id for traceability
 	// 3. Recovery handles panics
 	ginRouter.Use(middleware.RequestID())
-	ginRouter.Use(middleware.Logger())
+	ginRouter.Use(middleware.Logger(&deps.Config.Logging))
 	ginRouter.Use(gin.Recovery())
+	// 4. Authentication (JWT or HMAC signature), after RequestID so rejections are logged with the request_id
+	if deps.AuthVerifier != nil || deps.HMACVerifier != nil {
+		ginRouter.Use(middleware.Authenticate(deps.AuthVerifier, deps.HMACVerifier, &deps.Config.Auth))
+	}
+
+	// Route groups by the scope they require when auth.enforce_scopes is set
+	stream := ginRouter.Group("", middleware.RequireScope(auth.ScopeASRStream))
+	transcription := ginRouter.Group("", middleware.RequireScope(auth.ScopeASRTranscribe))
 
 	// Create WebSocket handler with explicit dependencies
 	wsHandler := ws.NewHandler(deps.Config, deps.SessionManager, deps.GlobalRecognizer)
 
 	// Register base routes
-	ginRouter.GET("/ws", func(c *gin.Context) {
+	stream.GET("/ws", func(c *gin.Context) {
 		wsHandler.HandleWebSocket(c.Writer, c.Request)
 	})
+	stream.GET("/twilio", func(c *gin.Context) {
+		wsHandler.HandleTwilio(c.Writer, c.Request)
+	})
+	stream.GET("/vosk", func(c *gin.Context) {
+		wsHandler.HandleVosk(c.Writer, c.Request)
+	})
+	wsHandler.RegisterPollRoutes(stream)
 	ginRouter.GET("/health", handlers.HealthHandler(deps))
+	ginRouter.GET("/livez", handlers.LiveHandler())
+	ginRouter.GET("/readyz", handlers.ReadyHandler(deps))
 	ginRouter.GET("/stats", handlers.StatsHandler(deps))
+	ginRouter.GET("/metrics", handlers.MetricsHandler(deps))
+	ginRouter.GET("/api/v1/models", handlers.ModelsHandler(deps))
 
 	// Static file service
 	ginRouter.Static("/static", "./static")
@@ -41,5 +71,35 @@ func NewRouter(deps *bootstrap.AppDependencies) *gin.Engine {
 		deps.SpeakerHandler.RegisterRoutes(ginRouter)
 	}
 
+	// Register file transcription routes (if enabled)
+	if deps.TranscribeHandler != nil {
+		deps.TranscribeHandler.RegisterRoutes(transcription)
+	}
+
+	// Register batch transcription job routes (if enabled)
+	if deps.JobsHandler != nil {
+		deps.JobsHandler.RegisterRoutes(transcription)
+	}
+
+	// Register WebRTC ingest routes (if enabled)
+	if deps.RTCHandler != nil {
+		deps.RTCHandler.RegisterRoutes(stream)
+	}
+
+	// Register admin routes (if enabled)
+	if deps.Config.Admin.Enabled {
+		// The admin token is the only admin credential; JWT and HMAC authentication do not apply
+		admin := ginRouter.Group(middleware.AdminPath, middleware.AdminAuth(deps.Config.Admin.Token))
+		admin.POST("/model/reload", handlers.ReloadModelHandler(deps))
+		admin.GET("/config", handlers.ConfigHandler(deps))
+		admin.PATCH("/config", handlers.UpdateConfigHandler(deps))
+		admin.GET("/sessions", handlers.ListSessionsHandler(deps))
+		admin.DELETE("/sessions/:id", handlers.CloseSessionHandler(deps))
+		admin.GET("/events", handlers.AdminEventsHandler(deps))
+		if deps.SpeakerAudit != nil {
+			admin.GET("/speaker/audit", handlers.SpeakerAuditHandler(deps))
+		}
+	}
+
 	return ginRouter
 }
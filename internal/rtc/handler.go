@@ -0,0 +1,248 @@
+//go:build webrtc
+
+package rtc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"asr_server/config"
+	"asr_server/internal/audio"
+	"asr_server/internal/logger"
+	"asr_server/internal/session"
+	"asr_server/internal/ws"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pion/webrtc/v4"
+)
+
+// opusSampleRate is the RTP clock rate of Opus, independent of the encoder's input rate
+const opusSampleRate = 48000
+
+// maxOfferSize bounds the SDP offer body
+const maxOfferSize = 64 * 1024
+
+// Handler accepts WHIP-style WebRTC offers and feeds the received Opus audio into ASR sessions.
+// All dependencies are explicitly injected via constructor.
+type Handler struct {
+	cfg            *config.Config
+	sessionManager *session.Manager
+
+	mu    sync.Mutex
+	peers map[string]*webrtc.PeerConnection
+}
+
+// NewHandler creates a new WebRTC handler with explicit dependencies
+func NewHandler(cfg *config.Config, sessionManager *session.Manager) *Handler {
+	return &Handler{
+		cfg:            cfg,
+		sessionManager: sessionManager,
+		peers:          make(map[string]*webrtc.PeerConnection),
+	}
+}
+
+// RegisterRoutes registers routes
+func (h *Handler) RegisterRoutes(router gin.IRouter) {
+	router.POST("/rtc", h.Offer)
+	router.DELETE("/rtc/:session_id", h.Close)
+}
+
+// Offer accepts an SDP offer, starts a session and answers with the server's SDP
+func (h *Handler) Offer(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxOfferSize))
+	if err != nil || len(body) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "SDP offer is required"})
+		return
+	}
+
+	iceServers := make([]webrtc.ICEServer, 0, len(h.cfg.WebRTC.ICEServers))
+	for _, url := range h.cfg.WebRTC.ICEServers {
+		iceServers = append(iceServers, webrtc.ICEServer{URLs: []string{url}})
+	}
+
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{ICEServers: iceServers})
+	if err != nil {
+		logger.Error("webrtc_peer_connection_failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create peer connection"})
+		return
+	}
+
+	sessionID := ws.GenerateSessionID()
+	conn := &dataChannelConn{sessionID: sessionID, pc: pc}
+
+	if _, err := h.sessionManager.CreateSession(sessionID, conn); err != nil {
+		logger.Error("failed_to_create_session", "session_id", sessionID, "error", err)
+		pc.Close()
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.mu.Lock()
+	h.peers[sessionID] = pc
+	h.mu.Unlock()
+
+	pc.OnDataChannel(func(dc *webrtc.DataChannel) {
+		conn.setChannel(dc)
+	})
+
+	pc.OnTrack(func(track *webrtc.TrackRemote, _ *webrtc.RTPReceiver) {
+		if !strings.EqualFold(track.Codec().MimeType, webrtc.MimeTypeOpus) {
+			logger.Warn("webrtc_unsupported_track", "session_id", sessionID, "mime_type", track.Codec().MimeType)
+			return
+		}
+		h.readTrack(sessionID, track)
+	})
+
+	pc.OnConnectionStateChange(func(state webrtc.PeerConnectionState) {
+		logger.Info("webrtc_connection_state", "session_id", sessionID, "state", state.String())
+		switch state {
+		case webrtc.PeerConnectionStateFailed, webrtc.PeerConnectionStateClosed, webrtc.PeerConnectionStateDisconnected:
+			// RemoveSession closes the peer connection, which may re-enter this callback
+			go h.removeSession(sessionID)
+		}
+	})
+
+	answer, err := h.negotiate(pc, string(body))
+	if err != nil {
+		logger.Error("webrtc_negotiation_failed", "session_id", sessionID, "error", err)
+		h.removeSession(sessionID)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("webrtc_connection_established", "session_id", sessionID)
+
+	c.Header("Location", "/rtc/"+sessionID)
+	c.Data(http.StatusCreated, "application/sdp", []byte(answer))
+}
+
+// Close tears down the session created by a previous offer
+func (h *Handler) Close(c *gin.Context) {
+	sessionID := c.Param("session_id")
+
+	h.mu.Lock()
+	_, exists := h.peers[sessionID]
+	h.mu.Unlock()
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+		return
+	}
+
+	h.removeSession(sessionID)
+	c.Status(http.StatusOK)
+}
+
+// negotiate applies the remote offer and returns the answer once ICE gathering has completed
+func (h *Handler) negotiate(pc *webrtc.PeerConnection, offer string) (string, error) {
+	if err := pc.SetRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: offer}); err != nil {
+		return "", fmt.Errorf("invalid SDP offer: %v", err)
+	}
+
+	answer, err := pc.CreateAnswer(nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create answer: %v", err)
+	}
+
+	gatherComplete := webrtc.GatheringCompletePromise(pc)
+	if err := pc.SetLocalDescription(answer); err != nil {
+		return "", fmt.Errorf("failed to set local description: %v", err)
+	}
+	<-gatherComplete
+
+	return pc.LocalDescription().SDP, nil
+}
+
+// readTrack decodes Opus RTP packets, resamples them and forwards chunk-sized PCM to the session
+func (h *Handler) readTrack(sessionID string, track *webrtc.TrackRemote) {
+	decoder, err := audio.NewOpusDecoder(opusSampleRate, 1)
+	if err != nil {
+		logger.Error("opus_decoder_init_failed", "session_id", sessionID, "error", err)
+		h.removeSession(sessionID)
+		return
+	}
+	defer decoder.Close()
+
+	chunkSize := h.cfg.Audio.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = config.DefaultChunkSize
+	}
+	buffer := make([]float32, 0, chunkSize*2)
+
+	for {
+		packet, _, err := track.ReadRTP()
+		if err != nil {
+			if err != io.EOF {
+				logger.Warn("webrtc_track_read_error", "session_id", sessionID, "error", err)
+			}
+			return
+		}
+
+		pcm, err := decoder.Decode(packet.Payload)
+		if err != nil {
+			logger.Warn("opus_decode_failed", "session_id", sessionID, "error", err)
+			continue
+		}
+		buffer = append(buffer, audio.Resample(pcm, opusSampleRate, h.cfg.Audio.SampleRate)...)
+
+		if len(buffer) < chunkSize {
+			continue
+		}
+		if err := h.sessionManager.ProcessAudioData(sessionID, audio.Float32ToPCM16(buffer)); err != nil {
+			logger.Error("failed_to_process_audio", "session_id", sessionID, "error", err)
+			return
+		}
+		buffer = buffer[:0]
+	}
+}
+
+// removeSession removes the ASR session and forgets the peer connection
+func (h *Handler) removeSession(sessionID string) {
+	h.mu.Lock()
+	delete(h.peers, sessionID)
+	h.mu.Unlock()
+
+	h.sessionManager.RemoveSession(sessionID)
+}
+
+// dataChannelConn delivers session results over the client's data channel.
+// Results produced before the client opens a channel are dropped.
+type dataChannelConn struct {
+	sessionID string
+	pc        *webrtc.PeerConnection
+
+	mu      sync.RWMutex
+	channel *webrtc.DataChannel
+}
+
+func (d *dataChannelConn) setChannel(dc *webrtc.DataChannel) {
+	d.mu.Lock()
+	d.channel = dc
+	d.mu.Unlock()
+}
+
+// WriteJSON implements session.Conn
+func (d *dataChannelConn) WriteJSON(v interface{}) error {
+	d.mu.RLock()
+	dc := d.channel
+	d.mu.RUnlock()
+
+	if dc == nil || dc.ReadyState() != webrtc.DataChannelStateOpen {
+		logger.Debug("webrtc_data_channel_not_open", "session_id", d.sessionID, "action", "dropped_message")
+		return nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return dc.SendText(string(data))
+}
+
+// Close implements session.Conn
+func (d *dataChannelConn) Close() error {
+	return d.pc.Close()
+}
@@ -0,0 +1,32 @@
+//go:build !webrtc
+
+package rtc
+
+import (
+	"net/http"
+
+	"asr_server/config"
+	"asr_server/internal/session"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler reports that WebRTC ingest is unavailable in builds without the "webrtc" tag
+type Handler struct{}
+
+// NewHandler creates a placeholder handler
+func NewHandler(cfg *config.Config, sessionManager *session.Manager) *Handler {
+	return &Handler{}
+}
+
+// RegisterRoutes registers routes
+func (h *Handler) RegisterRoutes(router gin.IRouter) {
+	router.POST("/rtc", h.notCompiled)
+	router.DELETE("/rtc/:session_id", h.notCompiled)
+}
+
+func (h *Handler) notCompiled(c *gin.Context) {
+	c.JSON(http.StatusNotImplemented, gin.H{
+		"error": `WebRTC support not compiled in (rebuild with -tags "webrtc opus")`,
+	})
+}
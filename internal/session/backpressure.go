@@ -0,0 +1,81 @@
+package session
+
+import (
+	"sync/atomic"
+	"time"
+
+	"asr_server/internal/logger"
+)
+
+// updateBackpressure compares the fill level of the session's send queue and of the shared
+// recognition queue with the configured watermarks. When the higher of the two reaches the
+// high watermark the client is sent {"type": "backpressure", "active": true}, and once it
+// falls to the low watermark an "active": false message follows. It returns whether the
+// session is under backpressure.
+func (m *Manager) updateBackpressure(session *Session) bool {
+	high := m.cfg.Session.BackpressureHighWatermark
+	if high <= 0 {
+		return false
+	}
+
+	sendQueued, sendCapacity := len(session.SendQueue), cap(session.SendQueue)
+	workQueued, workCapacity := m.workers.load()
+	load := max(fill(sendQueued, sendCapacity), fill(workQueued, workCapacity))
+
+	var active bool
+	switch {
+	case load >= high:
+		active = true
+	case load <= m.cfg.Session.BackpressureLowWatermark:
+		active = false
+	default:
+		// Between the watermarks the state is unchanged
+		return atomic.LoadInt32(&session.backpressure) == 1
+	}
+
+	state := int32(0)
+	if active {
+		state = 1
+	}
+	if atomic.SwapInt32(&session.backpressure, state) == state {
+		return active
+	}
+
+	dropped := atomic.LoadInt32(&session.droppedResults)
+	logger.Info("session_backpressure_changed", "session_id", session.ID, "active", active,
+		"send_queue", sendQueued, "recognition_queue", workQueued, "dropped_results", dropped)
+	select {
+	case session.SendQueue <- map[string]interface{}{
+		"type":                   "backpressure",
+		"active":                 active,
+		"send_queue":             sendQueued,
+		"send_queue_size":        sendCapacity,
+		"recognition_queue":      workQueued,
+		"recognition_queue_size": workCapacity,
+		"dropped_results":        dropped,
+		"timestamp":              time.Now().UnixMilli(),
+	}:
+	default:
+		session.dropMessage()
+		logger.Warn("session_send_queue_full", "session_id", session.ID, "action", "dropped_backpressure_message")
+	}
+	return active
+}
+
+// Backpressured re-evaluates and reports whether a session is under backpressure. Transports
+// may stop reading from the client while it is.
+func (m *Manager) Backpressured(sessionID string) bool {
+	session, exists := m.GetSession(sessionID)
+	if !exists || atomic.LoadInt32(&session.closed) == 1 {
+		return false
+	}
+	return m.updateBackpressure(session)
+}
+
+// fill returns n as a fraction of capacity
+func fill(n, capacity int) float64 {
+	if capacity <= 0 {
+		return 0
+	}
+	return float64(n) / float64(capacity)
+}
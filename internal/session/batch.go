@@ -0,0 +1,114 @@
+package session
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"asr_server/internal/logger"
+
+	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
+)
+
+// decodeRequest is a stream waiting to be decoded in the next batch
+type decodeRequest struct {
+	recognizer *sherpa.OfflineRecognizer
+	stream     *sherpa.OfflineStream
+	done       chan struct{}
+}
+
+// batchDecoder collects streams submitted within a short window and decodes them together,
+// keeping the GPU busy with one large call instead of many small ones. Streams are grouped by
+// recognizer since a batch can only be decoded by a single recognizer.
+type batchDecoder struct {
+	maxBatch int
+	wait     time.Duration
+	requests chan *decodeRequest
+
+	batches  int64
+	segments int64
+}
+
+func newBatchDecoder(maxBatch int, wait time.Duration) *batchDecoder {
+	return &batchDecoder{
+		maxBatch: maxBatch,
+		wait:     wait,
+		requests: make(chan *decodeRequest),
+	}
+}
+
+// run collects and decodes batches until ctx is cancelled
+func (b *batchDecoder) run(ctx context.Context) {
+	for {
+		var first *decodeRequest
+		select {
+		case first = <-b.requests:
+		case <-ctx.Done():
+			return
+		}
+
+		batch := []*decodeRequest{first}
+		timer := time.NewTimer(b.wait)
+	collect:
+		for len(batch) < b.maxBatch {
+			select {
+			case req := <-b.requests:
+				batch = append(batch, req)
+			case <-timer.C:
+				break collect
+			}
+		}
+		timer.Stop()
+
+		b.decode(batch)
+	}
+}
+
+// decode decodes a batch, one call per recognizer, and releases the waiting callers
+func (b *batchDecoder) decode(batch []*decodeRequest) {
+	groups := make(map[*sherpa.OfflineRecognizer][]*sherpa.OfflineStream)
+	for _, req := range batch {
+		groups[req.recognizer] = append(groups[req.recognizer], req.stream)
+	}
+	for recognizer, streams := range groups {
+		recognizer.DecodeStreams(streams)
+	}
+
+	atomic.AddInt64(&b.batches, 1)
+	atomic.AddInt64(&b.segments, int64(len(batch)))
+	logger.Debug("batch_decoded", "segments", len(batch), "recognizers", len(groups))
+
+	for _, req := range batch {
+		close(req.done)
+	}
+}
+
+// submit decodes a stream in the next batch and waits for the result. It returns false
+// without decoding if ctx is cancelled before the stream joins a batch.
+func (b *batchDecoder) submit(ctx context.Context, recognizer *sherpa.OfflineRecognizer, stream *sherpa.OfflineStream) bool {
+	req := &decodeRequest{recognizer: recognizer, stream: stream, done: make(chan struct{})}
+	select {
+	case b.requests <- req:
+	case <-ctx.Done():
+		return false
+	}
+	// The stream is owned by the batch now, so wait even if ctx is cancelled meanwhile
+	<-req.done
+	return true
+}
+
+// stats returns the number of batches and the average batch size
+func (b *batchDecoder) stats() map[string]interface{} {
+	batches := atomic.LoadInt64(&b.batches)
+	segments := atomic.LoadInt64(&b.segments)
+	avg := 0.0
+	if batches > 0 {
+		avg = float64(segments) / float64(batches)
+	}
+	return map[string]interface{}{
+		"max_batch_size": b.maxBatch,
+		"batches":        batches,
+		"segments":       segments,
+		"avg_batch_size": avg,
+	}
+}
@@ -0,0 +1,83 @@
+package session
+
+import (
+	"math/bits"
+	"sync"
+
+	"asr_server/internal/audio"
+)
+
+// Decode buffers are pooled by capacity class: each power-of-two size has its own sync.Pool,
+// so a buffer put back after a short message is never handed out for a longer one, and the
+// pools hold slice pointers so putting a buffer back does not allocate.
+const (
+	minBufferClass = 8  // 256 samples
+	maxBufferClass = 20 // 1M samples, about a minute at 16kHz; larger buffers are not pooled
+)
+
+var (
+	// sampleBuffers holds decoded audio until processSamples returns
+	sampleBuffers bufferPool[float32]
+	// frameBuffers holds the int16 frame passed to TEN-VAD
+	frameBuffers bufferPool[int16]
+)
+
+// bufferPool is a set of sync.Pools, one per capacity class
+type bufferPool[T float32 | int16] struct {
+	classes [maxBufferClass - minBufferClass + 1]sync.Pool
+}
+
+// bufferClass returns the smallest class holding n elements, or -1 when n is too large to pool
+func bufferClass(n int) int {
+	if n > 1<<maxBufferClass {
+		return -1
+	}
+	return max(bits.Len(uint(max(n, 1)-1)), minBufferClass) - minBufferClass
+}
+
+// get returns a buffer of length n with undefined contents
+func (p *bufferPool[T]) get(n int) *[]T {
+	class := bufferClass(n)
+	if class < 0 {
+		buf := make([]T, n)
+		return &buf
+	}
+	if buf, ok := p.classes[class].Get().(*[]T); ok {
+		*buf = (*buf)[:n]
+		return buf
+	}
+	buf := make([]T, n, 1<<(class+minBufferClass))
+	return &buf
+}
+
+// put returns a buffer taken with get; the caller must not use it afterwards. Buffers whose
+// capacity is not exactly a class size are left to the garbage collector.
+func (p *bufferPool[T]) put(buf *[]T) {
+	size := cap(*buf)
+	if class := bufferClass(size); class >= 0 && size == 1<<(class+minBufferClass) {
+		p.classes[class].Put(buf)
+	}
+}
+
+// decodePCM16 converts 16-bit little-endian PCM to samples at targetRate in a buffer from
+// sampleBuffers
+func decodePCM16(data []byte, normalizeFactor float32, sampleRate, targetRate int) *[]float32 {
+	buf := sampleBuffers.get(len(data) / 2)
+	samples := *buf
+	for i := range samples {
+		sample := int16(data[i*2]) | int16(data[i*2+1])<<8
+		samples[i] = float32(sample) / normalizeFactor
+	}
+	return resamplePooled(buf, sampleRate, targetRate)
+}
+
+// resamplePooled resamples a buffer from sampleBuffers into another, putting the input back
+func resamplePooled(buf *[]float32, fromRate, toRate int) *[]float32 {
+	if fromRate == toRate || fromRate <= 0 || toRate <= 0 || len(*buf) == 0 {
+		return buf
+	}
+	out := sampleBuffers.get(audio.ResampledLen(len(*buf), fromRate, toRate))
+	audio.ResampleInto(*out, *buf, fromRate, toRate)
+	sampleBuffers.put(buf)
+	return out
+}
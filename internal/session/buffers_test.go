@@ -0,0 +1,153 @@
+package session
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"testing"
+
+	"asr_server/config"
+	"asr_server/internal/audio"
+)
+
+func TestBufferPool(t *testing.T) {
+	var p bufferPool[float32]
+	tests := []struct {
+		n       int
+		wantCap int
+	}{
+		{0, 256},
+		{1, 256},
+		{256, 256},
+		{257, 512},
+		{1600, 2048},
+		{1 << 20, 1 << 20},
+		{1<<20 + 1, 1<<20 + 1},
+	}
+	for _, tt := range tests {
+		buf := p.get(tt.n)
+		if len(*buf) != tt.n || cap(*buf) != tt.wantCap {
+			t.Errorf("get(%d) len = %d, cap = %d, want cap %d", tt.n, len(*buf), cap(*buf), tt.wantCap)
+		}
+		p.put(buf)
+	}
+
+	// A buffer put back is only handed out for lengths of its class
+	buf := p.get(1000)
+	p.put(buf)
+	if got := p.get(1500); cap(*got) != 2048 {
+		t.Errorf("get(1500) cap = %d, want 2048", cap(*got))
+	}
+	if got := p.get(300); len(*got) != 300 || cap(*got) != 512 {
+		t.Errorf("get(300) len = %d, cap = %d, want cap 512", len(*got), cap(*got))
+	}
+
+	// Buffers of other capacities are not pooled
+	odd := make([]float32, 1000)
+	p.put(&odd)
+	if got := p.get(1000); cap(*got) != 1024 {
+		t.Errorf("get(1000) cap = %d after putting an unpooled buffer, want 1024", cap(*got))
+	}
+}
+
+func TestDecodePCM16(t *testing.T) {
+	data := make([]byte, 0, 8)
+	for _, s := range []int16{0, 16384, -32768, 32767} {
+		data = binary.LittleEndian.AppendUint16(data, uint16(s))
+	}
+	samples := decodePCM16(data, 32768, 16000, 16000)
+	want := []float32{0, 0.5, -1, 32767.0 / 32768}
+	if fmt.Sprint(*samples) != fmt.Sprint(want) {
+		t.Errorf("decodePCM16() = %v, want %v", *samples, want)
+	}
+	sampleBuffers.put(samples)
+
+	resampled := decodePCM16(data, 32768, 8000, 16000)
+	if len(*resampled) != 8 || (*resampled)[2] != 0.5 {
+		t.Errorf("decodePCM16() resampled = %v", *resampled)
+	}
+	sampleBuffers.put(resampled)
+}
+
+// legacyFloat32Pool is the pool decodeAudio used before sampleBuffers, kept as a baseline. It
+// has no New function and stores slices by value, so every Put allocates, and a buffer put
+// back after a short message is reallocated for the next longer one.
+var legacyFloat32Pool = sync.Pool{}
+
+func legacyDecodePCM16(data []byte, normalizeFactor float32, sampleRate, targetRate int, consume func([]float32)) {
+	numSamples := len(data) / 2
+	var samples []float32
+	if pooled := legacyFloat32Pool.Get(); pooled != nil {
+		samples = pooled.([]float32)
+	} else {
+		samples = make([]float32, config.DefaultChunkSize)
+	}
+	if cap(samples) < numSamples {
+		samples = make([]float32, numSamples)
+	}
+	samples = samples[:numSamples]
+	defer legacyFloat32Pool.Put(samples)
+
+	for i := 0; i < numSamples; i++ {
+		sample := int16(data[i*2]) | int16(data[i*2+1])<<8
+		samples[i] = float32(sample) / normalizeFactor
+	}
+	if sampleRate != targetRate {
+		samples = audio.Resample(samples, sampleRate, targetRate)
+	}
+	consume(samples)
+}
+
+// benchmarkMessages returns audio messages of the given durations in milliseconds at sampleRate
+func benchmarkMessages(sampleRate int, durations ...int) [][]byte {
+	messages := make([][]byte, len(durations))
+	for i, ms := range durations {
+		messages[i] = make([]byte, sampleRate*ms/1000*2)
+		for j := range messages[i] {
+			messages[i][j] = byte(j * 31)
+		}
+	}
+	return messages
+}
+
+var benchmarkDecodeCases = []struct {
+	name       string
+	sampleRate int
+	durations  []int
+}{
+	{"16k_100ms", 16000, []int{100}},
+	{"8k_100ms_resampled", 8000, []int{100}},
+	{"16k_mixed", 16000, []int{20, 100, 250, 60}},
+}
+
+// benchmarkDecode decodes messages from parallel goroutines, as concurrent sessions do
+func benchmarkDecode(b *testing.B, decode func(data []byte, sampleRate int, consume func([]float32))) {
+	for _, bc := range benchmarkDecodeCases {
+		b.Run(bc.name, func(b *testing.B) {
+			messages := benchmarkMessages(bc.sampleRate, bc.durations...)
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				var total float32
+				consume := func(samples []float32) { total += samples[len(samples)-1] }
+				for i := 0; pb.Next(); i++ {
+					decode(messages[i%len(messages)], bc.sampleRate, consume)
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkDecodePCM16(b *testing.B) {
+	benchmarkDecode(b, func(data []byte, sampleRate int, consume func([]float32)) {
+		samples := decodePCM16(data, 32768, sampleRate, 16000)
+		consume(*samples)
+		sampleBuffers.put(samples)
+	})
+}
+
+func BenchmarkLegacyDecodePCM16(b *testing.B) {
+	benchmarkDecode(b, func(data []byte, sampleRate int, consume func([]float32)) {
+		legacyDecodePCM16(data, 32768, sampleRate, 16000, consume)
+	})
+}
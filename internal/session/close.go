@@ -0,0 +1,47 @@
+package session
+
+// CloseReason tells a connection why the server is ending its session
+type CloseReason int
+
+const (
+	// CloseNormal ends a session whose client disconnected or finished
+	CloseNormal CloseReason = iota
+	// CloseShutdown ends sessions still open when the server shuts down
+	CloseShutdown
+	// CloseTimeout ends a session that was inactive for longer than the session timeout
+	CloseTimeout
+	// CloseByAdmin ends a session force-closed through the admin API
+	CloseByAdmin
+	// CloseQuotaExceeded ends a session that used up its quota
+	CloseQuotaExceeded
+)
+
+// String returns the reason in snake_case, as logged
+func (r CloseReason) String() string {
+	switch r {
+	case CloseShutdown:
+		return "shutdown"
+	case CloseTimeout:
+		return "timeout"
+	case CloseByAdmin:
+		return "closed_by_admin"
+	case CloseQuotaExceeded:
+		return "quota_exceeded"
+	default:
+		return "normal"
+	}
+}
+
+// ReasonCloser is implemented by connections that can tell the client why they are closed,
+// such as WebSocket connections sending a close frame. Other connections are just closed.
+type ReasonCloser interface {
+	CloseWithReason(reason CloseReason) error
+}
+
+// closeConn closes conn, passing on the reason when the connection supports it
+func closeConn(conn Conn, reason CloseReason) error {
+	if rc, ok := conn.(ReasonCloser); ok {
+		return rc.CloseWithReason(reason)
+	}
+	return conn.Close()
+}
@@ -0,0 +1,679 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"asr_server/config"
+	"asr_server/internal/logger"
+	"asr_server/internal/pool"
+
+	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
+)
+
+// Sample rate limits accepted from clients
+const (
+	MinClientSampleRate = 8000
+	MaxClientSampleRate = 48000
+)
+
+// Hotword list limits accepted from clients
+const (
+	MaxHotwords      = 100
+	MaxHotwordLength = 64
+	MaxHotwordsScore = 10.0
+)
+
+// Per-session VAD tuning limits accepted from clients, in seconds
+const (
+	MaxVADMinSilence = 5.0
+	MaxVADMinSpeech  = 5.0
+	MaxVADMaxSpeech  = 60.0
+)
+
+// Control commands sent by clients as text frames
+const (
+	CommandStart     = "start"
+	CommandStop      = "stop"
+	CommandFlush     = "flush"
+	CommandConfigure = "configure"
+	CommandStats     = "stats"
+	CommandAck       = "ack"
+	CommandHello     = "hello"
+)
+
+// Options are per-session overrides of the global configuration.
+// Zero values mean "use the global setting".
+type Options struct {
+	SampleRate int      `json:"sample_rate,omitempty"` // Sample rate of the audio sent by the client
+	Language   string   `json:"language,omitempty"`    // Recognition language
+	Model      string   `json:"model,omitempty"`       // Named model from recognition.models
+	Hotwords   []string `json:"hotwords,omitempty"`    // Contextual biasing phrases
+	// Boost applied to hotwords, 0 uses recognition.hotwords_score
+	HotwordsScore float32 `json:"hotwords_score,omitempty"`
+	VAD           string  `json:"vad,omitempty"` // VAD provider, only before the first audio
+	// VAD tuning and endpointing, only before the first audio: speech probability threshold,
+	// seconds of silence ending an utterance, and shortest and longest utterance in seconds
+	VADThreshold  float32 `json:"vad_threshold,omitempty"`
+	VADMinSilence float32 `json:"vad_min_silence,omitempty"`
+	VADMinSpeech  float32 `json:"vad_min_speech,omitempty"`
+	VADMaxSpeech  float32 `json:"vad_max_speech,omitempty"`
+	Encoding      string  `json:"encoding,omitempty"` // Encoding of binary audio frames
+	// Interleaved channels in PCM audio, mixed or selected down to mono
+	Channels      int    `json:"channels,omitempty"`
+	ChannelSelect string `json:"channel_select,omitempty"` // mix, left or right
+	Framing       string `json:"framing,omitempty"`        // none or sequenced
+	// Milliseconds between audio_level messages, 0 disables them
+	AudioLevelInterval int `json:"audio_level_interval,omitempty"`
+	// Send the speech probability of each VAD frame in vad messages, TEN-VAD only
+	VADProbs bool `json:"vad_probs,omitempty"`
+	// Scheduling priority of recognition tasks: high, normal or batch
+	Priority string `json:"priority,omitempty"`
+	// Translate non-English speech to English alongside the transcript
+	Translate bool `json:"translate,omitempty"`
+	// Retain results until the client acknowledges them, for retransmission after a resume
+	Acks bool `json:"acks,omitempty"`
+}
+
+// Validate checks that the options are within supported ranges
+func (o *Options) Validate() error {
+	if o.SampleRate != 0 && (o.SampleRate < MinClientSampleRate || o.SampleRate > MaxClientSampleRate) {
+		return fmt.Errorf("sample_rate must be between %d and %d", MinClientSampleRate, MaxClientSampleRate)
+	}
+	if o.Language != "" && !slices.Contains(config.ValidLanguages, o.Language) {
+		return fmt.Errorf("unsupported language %q, must be one of %v", o.Language, config.ValidLanguages)
+	}
+	if len(o.Hotwords) > MaxHotwords {
+		return fmt.Errorf("at most %d hotwords are allowed", MaxHotwords)
+	}
+	for _, word := range o.Hotwords {
+		if word == "" || len(word) > MaxHotwordLength || strings.ContainsAny(word, "\r\n") {
+			return fmt.Errorf("invalid hotword %q, must be a single line of 1 to %d bytes", word, MaxHotwordLength)
+		}
+	}
+	if o.HotwordsScore < 0 || o.HotwordsScore > MaxHotwordsScore {
+		return fmt.Errorf("hotwords_score must be between 0 and %g", MaxHotwordsScore)
+	}
+	if o.VAD != "" && !slices.Contains(config.ValidVADTypes, o.VAD) {
+		return fmt.Errorf("unsupported vad %q, must be one of %v", o.VAD, config.ValidVADTypes)
+	}
+	if o.VADThreshold < 0 || o.VADThreshold >= 1 {
+		return fmt.Errorf("vad_threshold must be between 0 and 1")
+	}
+	if o.VADMinSilence < 0 || o.VADMinSilence > MaxVADMinSilence {
+		return fmt.Errorf("vad_min_silence must be between 0 and %g seconds", MaxVADMinSilence)
+	}
+	if o.VADMinSpeech < 0 || o.VADMinSpeech > MaxVADMinSpeech {
+		return fmt.Errorf("vad_min_speech must be between 0 and %g seconds", MaxVADMinSpeech)
+	}
+	if o.VADMaxSpeech < 0 || o.VADMaxSpeech > MaxVADMaxSpeech {
+		return fmt.Errorf("vad_max_speech must be between 0 and %g seconds", MaxVADMaxSpeech)
+	}
+	if o.Encoding != "" && !slices.Contains(ValidEncodings, o.Encoding) {
+		return fmt.Errorf("unsupported encoding %q, must be one of %v", o.Encoding, ValidEncodings)
+	}
+	if o.Channels < 0 || o.Channels > MaxChannels {
+		return fmt.Errorf("channels must be between 1 and %d", MaxChannels)
+	}
+	if o.ChannelSelect != "" && !slices.Contains(ValidChannelSelects, o.ChannelSelect) {
+		return fmt.Errorf("unsupported channel_select %q, must be one of %v", o.ChannelSelect, ValidChannelSelects)
+	}
+	if o.Framing != "" && !slices.Contains(ValidFramings, o.Framing) {
+		return fmt.Errorf("unsupported framing %q, must be one of %v", o.Framing, ValidFramings)
+	}
+	if o.Priority != "" && !slices.Contains(ValidPriorities, o.Priority) {
+		return fmt.Errorf("unsupported priority %q, must be one of %v", o.Priority, ValidPriorities)
+	}
+	if o.AudioLevelInterval != 0 && (o.AudioLevelInterval < MinAudioLevelInterval || o.AudioLevelInterval > MaxAudioLevelInterval) {
+		return fmt.Errorf("audio_level_interval must be between %d and %d ms", MinAudioLevelInterval, MaxAudioLevelInterval)
+	}
+	return nil
+}
+
+// merge overlays the non-zero fields of other onto o
+func (o *Options) merge(other Options) {
+	if other.SampleRate != 0 {
+		o.SampleRate = other.SampleRate
+	}
+	if other.Language != "" {
+		o.Language = other.Language
+	}
+	if other.Model != "" {
+		o.Model = other.Model
+	}
+	if other.Hotwords != nil {
+		o.Hotwords = other.Hotwords
+	}
+	if other.HotwordsScore != 0 {
+		o.HotwordsScore = other.HotwordsScore
+	}
+	if other.VAD != "" {
+		o.VAD = other.VAD
+	}
+	if other.VADThreshold != 0 {
+		o.VADThreshold = other.VADThreshold
+	}
+	if other.VADMinSilence != 0 {
+		o.VADMinSilence = other.VADMinSilence
+	}
+	if other.VADMinSpeech != 0 {
+		o.VADMinSpeech = other.VADMinSpeech
+	}
+	if other.VADMaxSpeech != 0 {
+		o.VADMaxSpeech = other.VADMaxSpeech
+	}
+	if other.Encoding != "" {
+		o.Encoding = other.Encoding
+	}
+	if other.Channels != 0 {
+		o.Channels = other.Channels
+	}
+	if other.ChannelSelect != "" {
+		o.ChannelSelect = other.ChannelSelect
+	}
+	if other.Framing != "" {
+		o.Framing = other.Framing
+	}
+	if other.AudioLevelInterval != 0 {
+		o.AudioLevelInterval = other.AudioLevelInterval
+	}
+	if other.Priority != "" {
+		o.Priority = other.Priority
+	}
+	if other.Translate {
+		o.Translate = true
+	}
+	if other.VADProbs {
+		o.VADProbs = true
+	}
+	if other.Acks {
+		o.Acks = true
+	}
+}
+
+// ControlMessage is a JSON command sent by the client in a text frame.
+// start and configure may carry options that are applied to the session.
+type ControlMessage struct {
+	Type string `json:"type"`
+	Options
+	// Acknowledges results numbered up to and including Seq, for the ack command
+	Seq uint64 `json:"seq,omitempty"`
+	// Client metadata, for the hello command
+	Metadata Metadata `json:"metadata,omitempty"`
+}
+
+// VADPoolFactory creates and initializes a VAD pool for a provider type
+type VADPoolFactory func(vadType string) (pool.VADPoolInterface, error)
+
+// VADInstanceFactory creates a VAD instance for a provider type outside any pool, using the
+// given settings in place of the configured ones
+type VADInstanceFactory func(vadType string, vad config.VADConfig) (pool.VADInstanceInterface, error)
+
+// SetVADPoolFactory enables per-session VAD providers. A pool for a provider other than the
+// configured one is created on first use and shut down with the manager.
+// It must be called before the manager starts processing audio.
+func (m *Manager) SetVADPoolFactory(factory VADPoolFactory) {
+	m.vadPoolFactory = factory
+}
+
+// SetVADInstanceFactory enables per-session VAD parameters. Sessions tuning settings that VAD
+// instances are created with get a dedicated instance, destroyed when the session ends.
+// It must be called before the manager starts processing audio.
+func (m *Manager) SetVADInstanceFactory(factory VADInstanceFactory) {
+	m.vadInstanceFactory = factory
+}
+
+// tunesVAD reports whether the options override any VAD parameter
+func (o *Options) tunesVAD() bool {
+	return o.VADThreshold != 0 || o.VADMinSilence != 0 || o.VADMinSpeech != 0 || o.VADMaxSpeech != 0
+}
+
+// sessionVADConfig returns the VAD settings of a session: the configured ones with the
+// session's VAD parameters applied
+func (m *Manager) sessionVADConfig(opts Options) config.VADConfig {
+	vad := m.cfg.VAD
+	if opts.VADThreshold != 0 {
+		vad.Threshold = opts.VADThreshold
+		vad.SileroVAD.Threshold = opts.VADThreshold
+	}
+	if opts.VADMinSilence != 0 {
+		vad.SileroVAD.MinSilenceDuration = opts.VADMinSilence
+		// TEN-VAD ends a segment after max_silence_frames frames without speech
+		if hopSize := vad.TenVAD.HopSize; hopSize > 0 {
+			samples := float64(opts.VADMinSilence) * float64(m.cfg.Audio.SampleRate)
+			vad.TenVAD.MaxSilenceFrames = max(1, int(math.Ceil(samples/float64(hopSize))))
+		}
+	}
+	if opts.VADMinSpeech != 0 {
+		vad.SileroVAD.MinSpeechDuration = opts.VADMinSpeech
+		if hopSize := vad.TenVAD.HopSize; hopSize > 0 {
+			samples := float64(opts.VADMinSpeech) * float64(m.cfg.Audio.SampleRate)
+			vad.TenVAD.MinSpeechFrames = max(1, int(math.Ceil(samples/float64(hopSize))))
+		}
+	}
+	if opts.VADMaxSpeech != 0 {
+		vad.SileroVAD.MaxSpeechDuration = opts.VADMaxSpeech
+	}
+	return vad
+}
+
+// sessionVADType returns the VAD provider a session with opts uses
+func (m *Manager) sessionVADType(opts Options) string {
+	if opts.VAD != "" {
+		return opts.VAD
+	}
+	return m.cfg.VAD.Provider
+}
+
+// acquireVAD assigns a VAD instance to a session on its first audio: a dedicated instance when
+// the session tunes settings its instance is created with and fewer than
+// vad.max_dedicated_instances exist, otherwise one from the pool
+func (m *Manager) acquireVAD(session *Session) error {
+	opts := session.Options()
+	vadType := m.sessionVADType(opts)
+
+	// TEN-VAD frame counts are applied on use, only the threshold is set on creation
+	dedicated := opts.VADThreshold != 0 || (vadType == pool.SILERO_TYPE && opts.tunesVAD())
+	if dedicated && m.vadInstanceFactory != nil {
+		if !m.reserveDedicatedVAD() {
+			// The session's endpointing rules still apply to a pooled instance
+			logger.Warn("dedicated_vad_limit_reached", "session_id", session.ID, "type", vadType, "max", m.cfg.VAD.MaxDedicatedInstances)
+		} else {
+			instance, err := m.vadInstanceFactory(vadType, m.sessionVADConfig(opts))
+			if err != nil {
+				m.releaseDedicatedVAD()
+				return fmt.Errorf("failed to create VAD instance for session %s: %v", session.ID, err)
+			}
+			session.VADInstance = instance
+			logger.Info("session_assigned_dedicated_vad", "session_id", session.ID, "type", vadType,
+				"threshold", opts.VADThreshold, "min_silence", opts.VADMinSilence, "min_speech", opts.VADMinSpeech, "max_speech", opts.VADMaxSpeech)
+			return nil
+		}
+	}
+
+	vadPool, err := m.vadPoolFor(opts.VAD)
+	if err != nil {
+		return err
+	}
+	instance, err := vadPool.Get()
+	if err != nil {
+		return fmt.Errorf("failed to get VAD instance for session %s: %v", session.ID, err)
+	}
+	session.VADInstance = instance
+	session.vadPool = vadPool
+	logger.Info("session_assigned_vad", "session_id", session.ID, "type", instance.GetType(), "id", instance.GetID())
+	return nil
+}
+
+// reserveDedicatedVAD counts a dedicated VAD instance about to be created, unless
+// vad.max_dedicated_instances exist already. Dedicated instances are not part of any pool, so
+// their number is capped separately from vad.pool_size.
+func (m *Manager) reserveDedicatedVAD() bool {
+	limit := int64(m.cfg.VAD.MaxDedicatedInstances)
+	for {
+		n := atomic.LoadInt64(&m.dedicatedVADs)
+		if n >= limit {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&m.dedicatedVADs, n, n+1) {
+			return true
+		}
+	}
+}
+
+// releaseDedicatedVAD uncounts a dedicated VAD instance that was destroyed or not created
+func (m *Manager) releaseDedicatedVAD() {
+	atomic.AddInt64(&m.dedicatedVADs, -1)
+}
+
+// vadPoolFor returns the pool for a VAD type, creating it on first use
+func (m *Manager) vadPoolFor(vadType string) (pool.VADPoolInterface, error) {
+	if vadType == "" || vadType == m.cfg.VAD.Provider {
+		return m.vadPool, nil
+	}
+	if m.vadPoolFactory == nil {
+		return nil, fmt.Errorf("VAD provider %s is not available", vadType)
+	}
+
+	m.vadPoolsMu.Lock()
+	defer m.vadPoolsMu.Unlock()
+
+	if p, exists := m.vadPools[vadType]; exists {
+		return p, nil
+	}
+
+	logger.Info("creating_session_vad_pool", "type", vadType)
+	p, err := m.vadPoolFactory(vadType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s VAD pool: %v", vadType, err)
+	}
+	reloadable := pool.NewReloadablePool(p)
+	m.vadPools[vadType] = reloadable
+	return reloadable, nil
+}
+
+// ReloadVADPools updates the per-session VAD pools created so far after a configuration
+// reload: pools whose settings changed are rebuilt, and the pool of the newly configured
+// provider is retired since sessions asking for it now use the default pool. Sessions keep
+// their instances until they end; a pool that fails to rebuild keeps serving with its old
+// settings.
+func (m *Manager) ReloadVADPools(provider string, changed func(vadType string) bool) {
+	if m.vadPoolFactory == nil {
+		return
+	}
+
+	m.vadPoolsMu.Lock()
+	defer m.vadPoolsMu.Unlock()
+
+	for vadType, reloadable := range m.vadPools {
+		if vadType == provider {
+			delete(m.vadPools, vadType)
+			reloadable.Retire()
+			logger.Info("session_vad_pool_retired", "type", vadType)
+			continue
+		}
+		if !changed(vadType) {
+			continue
+		}
+		p, err := m.vadPoolFactory(vadType)
+		if err != nil {
+			logger.Error("failed_to_reload_session_vad_pool", "type", vadType, "error", err)
+			continue
+		}
+		reloadable.Replace(p)
+		logger.Info("session_vad_pool_reloaded", "type", vadType)
+	}
+}
+
+// RecognizerSpec identifies a recognizer variant. Sherpa applies hotwords per recognizer,
+// so every distinct hotword list needs a recognizer of its own.
+type RecognizerSpec struct {
+	Model         string // Named model, empty for the default model
+	Language      string // Language of the default model, empty for named models
+	Hotwords      string // Newline-separated phrases, empty for none
+	HotwordsScore float32
+}
+
+// RecognizerFactory creates a recognizer for a spec
+type RecognizerFactory func(spec RecognizerSpec) (*sherpa.OfflineRecognizer, error)
+
+// SetRecognizerFactory enables per-session languages and hotwords. Recognizers are created
+// lazily, once per spec, and shared by all sessions using that spec.
+// It must be called before the manager starts processing audio.
+func (m *Manager) SetRecognizerFactory(factory RecognizerFactory) {
+	m.recognizerFactory = factory
+}
+
+// RegisterModel makes a preloaded recognizer selectable by name through the model option.
+// It must be called before the manager starts processing audio.
+func (m *Manager) RegisterModel(name string, recognizer *sherpa.OfflineRecognizer) {
+	m.recognizersMu.Lock()
+	defer m.recognizersMu.Unlock()
+	m.recognizers[RecognizerSpec{Model: name}] = recognizer
+}
+
+// SetTranslator enables the translate option. The recognizer must be a multilingual
+// Whisper model configured for the translate task.
+// It must be called before the manager starts processing audio.
+func (m *Manager) SetTranslator(recognizer *sherpa.OfflineRecognizer) {
+	m.translator = recognizer
+}
+
+// SetSpeakerTrackerFactory attributes every final result of a session to a speaker.
+// It must be called before sessions are created.
+func (m *Manager) SetSpeakerTrackerFactory(factory SpeakerTrackerFactory) {
+	m.speakerTrackerFactory = factory
+}
+
+// HasModel reports whether a named model is registered
+func (m *Manager) HasModel(name string) bool {
+	m.recognizersMu.Lock()
+	defer m.recognizersMu.Unlock()
+	_, exists := m.recognizers[RecognizerSpec{Model: name}]
+	return exists
+}
+
+// recognizerFor returns the recognizer for a session's model, language and hotwords.
+// Named models are configured with their own language, so the language option only
+// applies to the default model.
+func (m *Manager) recognizerFor(opts Options) *sherpa.OfflineRecognizer {
+	spec := RecognizerSpec{Model: opts.Model}
+	if spec.Model == "" {
+		spec.Language = opts.Language
+		if spec.Language == "" {
+			spec.Language = m.cfg.Recognition.Language
+		}
+	}
+	if len(opts.Hotwords) > 0 {
+		spec.Hotwords = strings.Join(opts.Hotwords, "\n")
+		spec.HotwordsScore = opts.HotwordsScore
+		if spec.HotwordsScore == 0 {
+			spec.HotwordsScore = m.cfg.Recognition.HotwordsScore
+		}
+	}
+	return m.recognizerForSpec(spec)
+}
+
+// recognizerForSpec returns the recognizer for a spec, creating it on first use. Without a
+// factory, or when the spec's recognizer cannot be created, the global recognizer is used.
+func (m *Manager) recognizerForSpec(spec RecognizerSpec) *sherpa.OfflineRecognizer {
+	if spec == (RecognizerSpec{Language: m.cfg.Recognition.Language}) || m.recognizerFactory == nil {
+		return m.recognizer
+	}
+
+	m.recognizersMu.Lock()
+	if recognizer, exists := m.recognizers[spec]; exists {
+		m.recognizersMu.Unlock()
+		return recognizer
+	}
+
+	// Each hotword recognizer holds its own copy of the model, so their number is capped
+	if spec.Hotwords != "" && m.hotwordRecognizers >= m.cfg.Recognition.MaxHotwordRecognizers {
+		m.recognizersMu.Unlock()
+		logger.Warn("hotword_recognizer_limit_reached", "language", spec.Language, "max", m.cfg.Recognition.MaxHotwordRecognizers)
+		return m.recognizerForSpec(RecognizerSpec{Model: spec.Model, Language: spec.Language})
+	}
+	defer m.recognizersMu.Unlock()
+
+	hotwords := 0
+	if spec.Hotwords != "" {
+		hotwords = strings.Count(spec.Hotwords, "\n") + 1
+	}
+	logger.Info("creating_recognizer", "model", spec.Model, "language", spec.Language, "hotwords", hotwords)
+	recognizer, err := m.recognizerFactory(spec)
+	if err != nil {
+		logger.Error("failed_to_create_recognizer", "model", spec.Model, "language", spec.Language, "error", err)
+		return m.recognizer
+	}
+	m.recognizers[spec] = recognizer
+	if spec.Hotwords != "" {
+		m.hotwordRecognizers++
+	}
+	return recognizer
+}
+
+// Options returns a copy of the session's current options
+func (s *Session) Options() Options {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.options
+}
+
+// Configure validates and applies option overrides to a session
+func (m *Manager) Configure(sessionID string, opts Options) error {
+	session, exists := m.GetSession(sessionID)
+	if !exists {
+		return fmt.Errorf("session %s not found", sessionID)
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if opts.Model != "" && !m.HasModel(opts.Model) {
+		return fmt.Errorf("unknown model %q", opts.Model)
+	}
+	if opts.Translate && m.translator == nil {
+		return fmt.Errorf("translation is not configured")
+	}
+	if opts.VAD != "" && session.VADInstance != nil && session.VADInstance.GetType() != opts.VAD {
+		return fmt.Errorf("vad can only be changed before audio is sent")
+	}
+	if opts.tunesVAD() && session.VADInstance != nil {
+		return fmt.Errorf("vad parameters can only be changed before audio is sent")
+	}
+	// Only TEN-VAD reports the probability of each frame
+	if opts.VADProbs || opts.VAD != "" {
+		merged := session.Options()
+		merged.merge(opts)
+		if merged.VADProbs && m.onlineRecognizer != nil {
+			return fmt.Errorf("vad_probs is not available in streaming mode")
+		}
+		if merged.VADProbs && m.sessionVADType(merged) != pool.TEN_VAD_TYPE {
+			return fmt.Errorf("vad_probs requires the %s provider", pool.TEN_VAD_TYPE)
+		}
+	}
+
+	session.mu.Lock()
+	// Create the decoder up front so clients learn immediately if Opus is unavailable
+	if opts.Encoding == EncodingOpus && session.opus == nil {
+		stream, err := newOpusStream(m.cfg.Audio.SampleRate)
+		if err != nil {
+			session.mu.Unlock()
+			return err
+		}
+		session.opus = stream
+	}
+	// An explicit encoding replaces the layout declared by a streamed WAV header
+	if opts.Encoding != "" {
+		session.pcmFormat = nil
+	}
+	session.options.merge(opts)
+	applied := session.options
+	session.mu.Unlock()
+
+	logger.Info("session_configured", "session_id", sessionID, "sample_rate", applied.SampleRate, "model", applied.Model, "language", applied.Language, "vad", applied.VAD, "encoding", applied.Encoding, "channels", applied.Channels, "channel_select", applied.ChannelSelect, "hotwords", len(applied.Hotwords))
+	return nil
+}
+
+// HandleControl parses and executes a control message for a session and queues an acknowledgement
+func (m *Manager) HandleControl(sessionID string, data []byte) error {
+	session, exists := m.GetSession(sessionID)
+	if !exists {
+		return fmt.Errorf("session %s not found", sessionID)
+	}
+
+	var msg ControlMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return fmt.Errorf("invalid control message: %v", err)
+	}
+
+	var err error
+	switch msg.Type {
+	case CommandStart:
+		if err = m.Configure(sessionID, msg.Options); err == nil {
+			atomic.StoreInt32(&session.paused, 0)
+		}
+	case CommandStop:
+		atomic.StoreInt32(&session.paused, 1)
+		err = m.FlushSession(sessionID)
+	case CommandFlush:
+		err = m.FlushSession(sessionID)
+	case CommandConfigure:
+		err = m.Configure(sessionID, msg.Options)
+	case CommandStats:
+		err = m.sendStats(session)
+	case CommandHello:
+		err = m.hello(session, msg)
+	case CommandAck:
+		// Acknowledgements are frequent and are not acknowledged themselves
+		session.order.ack(msg.Seq)
+		return nil
+	default:
+		err = fmt.Errorf("unknown command %q", msg.Type)
+	}
+	if err != nil {
+		return err
+	}
+
+	select {
+	case session.SendQueue <- map[string]interface{}{
+		"type":      "ack",
+		"command":   msg.Type,
+		"timestamp": time.Now().UnixMilli(),
+	}:
+	default:
+		session.dropMessage()
+		logger.Warn("session_send_queue_full", "session_id", sessionID, "action", "dropped_ack")
+	}
+	return nil
+}
+
+// sendStats queues the session's jitter buffer statistics
+func (m *Manager) sendStats(session *Session) error {
+	session.mu.RLock()
+	jitter := session.jitter
+	session.mu.RUnlock()
+
+	msg := map[string]interface{}{
+		"type":      "stats",
+		"timestamp": time.Now().UnixMilli(),
+	}
+	if jitter != nil {
+		msg["jitter"] = jitter.snapshot()
+	}
+
+	select {
+	case session.SendQueue <- msg:
+		return nil
+	default:
+		return fmt.Errorf("send queue full")
+	}
+}
+
+// WithRecognizer runs fn with the default recognizer on a recognition worker, waiting behind
+// queued tasks of higher priority. The recognizer is not replaced until fn returns. If ctx is
+// cancelled while waiting for a worker, fn is not run and the context error is returned.
+func (m *Manager) WithRecognizer(ctx context.Context, priority string, fn func(recognizer *sherpa.OfflineRecognizer)) error {
+	return m.workers.do(ctx, priority, func() {
+		m.swapMu.RLock()
+		defer m.swapMu.RUnlock()
+		fn(m.recognizer)
+	})
+}
+
+// ReplaceRecognizer installs a recognizer for a new default model and records its paths in
+// the configuration. It waits for in-flight decodes to finish, then deletes the old
+// recognizer and those derived from it for other languages or hotwords; the derived ones
+// are recreated from the new model on next use. Named models are not affected.
+func (m *Manager) ReplaceRecognizer(recognizer *sherpa.OfflineRecognizer, modelPath, tokensPath string) {
+	m.swapMu.Lock()
+	retired := []*sherpa.OfflineRecognizer{m.recognizer}
+	m.recognizersMu.Lock()
+	for spec, r := range m.recognizers {
+		if spec.Model == "" {
+			retired = append(retired, r)
+			delete(m.recognizers, spec)
+		}
+	}
+	m.hotwordRecognizers = 0
+	for spec := range m.recognizers {
+		if spec.Hotwords != "" {
+			m.hotwordRecognizers++
+		}
+	}
+	m.recognizersMu.Unlock()
+	m.recognizer = recognizer
+	m.cfg.Recognition.ModelPath = modelPath
+	m.cfg.Recognition.TokensPath = tokensPath
+	m.swapMu.Unlock()
+
+	for _, r := range retired {
+		sherpa.DeleteOfflineRecognizer(r)
+	}
+	logger.Info("recognizer_replaced", "model_path", modelPath, "retired", len(retired))
+}
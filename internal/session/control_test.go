@@ -0,0 +1,71 @@
+package session
+
+import (
+	"testing"
+
+	"asr_server/config"
+	"asr_server/internal/pool"
+)
+
+// fakeVADPool hands out fake instances and counts those returned
+type fakeVADPool struct {
+	returned int
+}
+
+func (p *fakeVADPool) Initialize() error { return nil }
+func (p *fakeVADPool) Get() (pool.VADInstanceInterface, error) {
+	return &fakeVADInstance{destroyed: make(chan struct{})}, nil
+}
+func (p *fakeVADPool) Put(instance pool.VADInstanceInterface) { p.returned++ }
+func (p *fakeVADPool) GetStats() map[string]interface{}       { return nil }
+func (p *fakeVADPool) Shutdown()                              {}
+
+func TestAcquireVADDedicatedLimit(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.VAD.Provider = pool.SILERO_TYPE
+	cfg.VAD.MaxDedicatedInstances = 2
+	vadPool := &fakeVADPool{}
+	m := &Manager{
+		cfg:     cfg,
+		vadPool: vadPool,
+		vadInstanceFactory: func(vadType string, vad config.VADConfig) (pool.VADInstanceInterface, error) {
+			return &fakeVADInstance{destroyed: make(chan struct{})}, nil
+		},
+	}
+	acquire := func() *Session {
+		session := &Session{ID: "test", options: Options{VADThreshold: 0.6}}
+		if err := m.acquireVAD(session); err != nil {
+			t.Fatalf("acquireVAD() error = %v", err)
+		}
+		return session
+	}
+
+	first, second := acquire(), acquire()
+	if first.vadPool != nil || second.vadPool != nil {
+		t.Fatal("sessions below the limit were not given dedicated instances")
+	}
+	third := acquire()
+	if third.vadPool == nil {
+		t.Fatal("session above the limit was given a dedicated instance")
+	}
+	if got := m.dedicatedVADs; got != 2 {
+		t.Errorf("dedicated instances = %d, want 2", got)
+	}
+
+	// Closing sessions frees their places
+	m.releaseVAD(first)
+	m.releaseVAD(third)
+	if got := m.dedicatedVADs; got != 1 || vadPool.returned != 1 {
+		t.Errorf("after release: dedicated instances = %d, returned = %d, want 1 and 1", got, vadPool.returned)
+	}
+	if fourth := acquire(); fourth.vadPool != nil {
+		t.Error("session was not given a freed place")
+	}
+
+	// A limit of 0 disables dedicated instances
+	cfg.VAD.MaxDedicatedInstances = 0
+	m.dedicatedVADs = 0
+	if session := acquire(); session.vadPool == nil {
+		t.Error("session was given a dedicated instance with max_dedicated_instances 0")
+	}
+}
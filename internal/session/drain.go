@@ -0,0 +1,66 @@
+package session
+
+import (
+	"errors"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"asr_server/internal/logger"
+)
+
+// drainPollInterval is how often Drain checks whether sessions are idle
+const drainPollInterval = 50 * time.Millisecond
+
+// ErrDraining is returned when a session is requested while the server is shutting down
+var ErrDraining = errors.New("server is shutting down")
+
+// Drain prepares the manager for shutdown. New sessions and audio are refused, every session
+// is sent {"type": "server_shutdown"}, and Drain waits up to timeout for in-flight recognition
+// tasks to finish and their results to be written. Shutdown closes the sessions afterwards.
+func (m *Manager) Drain(timeout time.Duration) {
+	if !atomic.CompareAndSwapInt32(&m.draining, 0, 1) {
+		return
+	}
+
+	sessions := m.sessions.snapshot()
+
+	logger.Info("draining_sessions", "sessions", len(sessions), "timeout", timeout)
+	for _, session := range sessions {
+		select {
+		case session.SendQueue <- map[string]interface{}{
+			"type":      "server_shutdown",
+			"message":   "Server is shutting down, pending results will be delivered before the connection closes",
+			"timestamp": time.Now().UnixMilli(),
+		}:
+		default:
+			session.dropMessage()
+			logger.Warn("session_send_queue_full", "session_id", session.ID, "action", "dropped_shutdown_notice")
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) && slices.ContainsFunc(sessions, (*Session).busy) {
+		time.Sleep(drainPollInterval)
+	}
+
+	if remaining := len(slices.DeleteFunc(sessions, func(s *Session) bool { return !s.busy() })); remaining > 0 {
+		logger.Warn("session_drain_timed_out", "busy_sessions", remaining, "timeout", timeout)
+	} else {
+		logger.Info("session_drain_completed")
+	}
+}
+
+// Draining reports whether the manager is shutting down
+func (m *Manager) Draining() bool {
+	return atomic.LoadInt32(&m.draining) == 1
+}
+
+// busy reports whether a session still has recognition tasks running or results to write.
+// Results of a detached session wait for a client that may not return, so they are not waited for.
+func (s *Session) busy() bool {
+	if atomic.LoadInt32(&s.closed) == 1 || !s.detachedSince().IsZero() {
+		return false
+	}
+	return atomic.LoadInt32(&s.inflight) > 0 || len(s.SendQueue) > 0 || s.spillPending() > 0
+}
@@ -0,0 +1,195 @@
+package session
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+
+	"asr_server/internal/audio"
+	"asr_server/internal/logger"
+)
+
+// Audio encodings accepted for binary audio frames
+const (
+	EncodingPCM16 = "pcm16" // 16-bit little-endian PCM (default)
+	EncodingS16LE = "s16le" // Same as pcm16
+	EncodingS16BE = "s16be" // 16-bit big-endian PCM
+	EncodingF32LE = "f32le" // 32-bit little-endian IEEE float PCM
+	EncodingOpus  = "opus"  // One Opus packet per frame
+	EncodingMuLaw = "mulaw" // G.711 μ-law, one byte per sample
+	EncodingALaw  = "alaw"  // G.711 A-law, one byte per sample
+)
+
+// ValidEncodings lists the encodings a session can be configured with
+var ValidEncodings = []string{EncodingPCM16, EncodingS16LE, EncodingS16BE, EncodingF32LE, EncodingOpus, EncodingMuLaw, EncodingALaw}
+
+// MaxChannels bounds the channel count of interleaved PCM streams
+const MaxChannels = 8
+
+// Channel selection for multi-channel streams
+const (
+	ChannelSelectMix   = "mix"   // Average all channels (default)
+	ChannelSelectLeft  = "left"  // First channel only
+	ChannelSelectRight = "right" // Second channel only
+)
+
+// ValidChannelSelects lists the accepted channel_select values
+var ValidChannelSelects = []string{ChannelSelectMix, ChannelSelectLeft, ChannelSelectRight}
+
+// g711SampleRate is assumed for G.711 audio unless the session sets a sample rate
+const g711SampleRate = 8000
+
+// decodeG711 expands G.711 audio to samples at targetRate in a buffer from sampleBuffers
+func decodeG711(data []byte, encoding string, sampleRate, targetRate int) *[]float32 {
+	buf := sampleBuffers.get(len(data))
+	if encoding == EncodingALaw {
+		audio.DecodeALawInto(*buf, data)
+	} else {
+		audio.DecodeMuLawInto(*buf, data)
+	}
+	if sampleRate == 0 {
+		sampleRate = g711SampleRate
+	}
+	return resamplePooled(buf, sampleRate, targetRate)
+}
+
+// opusSampleRates are the output rates libopus can decode to directly
+var opusSampleRates = []int{8000, 12000, 16000, 24000, 48000}
+
+// opusStream decodes a session's Opus packets. Opus is stateful, so each session
+// keeps its own decoder for the lifetime of the stream.
+type opusStream struct {
+	decoder    *audio.OpusDecoder
+	sampleRate int
+}
+
+// newOpusStream creates a mono decoder producing samples at targetRate, decoding at
+// 48kHz and resampling when libopus cannot output targetRate directly
+func newOpusStream(targetRate int) (*opusStream, error) {
+	rate := targetRate
+	if !slices.Contains(opusSampleRates, rate) {
+		rate = 48000
+	}
+	decoder, err := audio.NewOpusDecoder(rate, 1)
+	if err != nil {
+		return nil, err
+	}
+	return &opusStream{decoder: decoder, sampleRate: rate}, nil
+}
+
+// decode decodes one packet to mono samples at targetRate.
+// The returned slice may be reused by the next call.
+func (s *opusStream) decode(packet []byte, targetRate int) ([]float32, error) {
+	samples, err := s.decoder.Decode(packet)
+	if err != nil {
+		return nil, err
+	}
+	return audio.Resample(samples, s.sampleRate, targetRate), nil
+}
+
+// close releases the native decoder
+func (s *opusStream) close() {
+	s.decoder.Close()
+}
+
+// pcmFormat describes the sample layout of linear PCM
+type pcmFormat struct {
+	bitDepth  int
+	float     bool
+	bigEndian bool
+}
+
+// pcm16Format is the default sample layout
+var pcm16Format = pcmFormat{bitDepth: 16}
+
+// linearFormats maps linear PCM encodings to their sample layout
+var linearFormats = map[string]pcmFormat{
+	"":            pcm16Format,
+	EncodingPCM16: pcm16Format,
+	EncodingS16LE: pcm16Format,
+	EncodingS16BE: {bitDepth: 16, bigEndian: true},
+	EncodingF32LE: {bitDepth: 32, float: true},
+}
+
+// applyWAVHeader configures a session from the WAV header at the start of its first audio
+// message and returns the audio following the header
+func (m *Manager) applyWAVHeader(session *Session, data []byte) ([]byte, error) {
+	header, offset, err := audio.ParseWAVHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if header.SampleRate < MinClientSampleRate || header.SampleRate > MaxClientSampleRate {
+		return nil, fmt.Errorf("WAV sample rate %d not supported, must be between %d and %d", header.SampleRate, MinClientSampleRate, MaxClientSampleRate)
+	}
+	if header.NumChannels < 1 || header.NumChannels > MaxChannels {
+		return nil, fmt.Errorf("WAV with %d channels not supported, must be between 1 and %d", header.NumChannels, MaxChannels)
+	}
+
+	opts := Options{SampleRate: header.SampleRate, Channels: header.NumChannels}
+	var format *pcmFormat
+	switch {
+	case header.Format == audio.WAVFormatMuLaw || header.Format == audio.WAVFormatALaw:
+		if header.NumChannels != 1 || header.BitDepth != 8 {
+			return nil, fmt.Errorf("only 8-bit mono G.711 WAV streams are supported")
+		}
+		opts.Encoding = EncodingMuLaw
+		if header.Format == audio.WAVFormatALaw {
+			opts.Encoding = EncodingALaw
+		}
+	case header.Format == audio.WAVFormatPCM && slices.Contains([]int{8, 16, 24, 32}, header.BitDepth),
+		header.Format == audio.WAVFormatFloat && header.BitDepth == 32:
+		opts.Encoding = EncodingPCM16
+		if header.BitDepth != 16 || header.Format == audio.WAVFormatFloat {
+			format = &pcmFormat{
+				bitDepth: header.BitDepth,
+				float:    header.Format == audio.WAVFormatFloat,
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported WAV format %d with %d-bit samples", header.Format, header.BitDepth)
+	}
+
+	session.mu.Lock()
+	session.options.merge(opts)
+	session.pcmFormat = format
+	session.mu.Unlock()
+
+	logger.Info("wav_header_detected", "session_id", session.ID, "format", header.Format, "sample_rate", header.SampleRate, "channels", header.NumChannels, "bit_depth", header.BitDepth)
+	return data[offset:], nil
+}
+
+// byteOrder returns the byte order of the samples
+func (f pcmFormat) byteOrder() binary.ByteOrder {
+	if f.bigEndian {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+// decodeFrames converts interleaved PCM to mono samples at the stream's rate, mixing or
+// selecting channels as configured. Bytes of an incomplete trailing frame are kept for the
+// next message.
+func (s *Session) decodeFrames(format pcmFormat, channels int, channelSelect string, data []byte) []float32 {
+	channels = max(channels, 1)
+	frameSize := format.bitDepth / 8 * channels
+
+	if len(s.pending) > 0 {
+		data = append(s.pending, data...)
+	}
+	n := len(data) - len(data)%frameSize
+	decoded := &audio.Audio{
+		NumChannels: channels,
+		Samples:     audio.DecodePCM(data[:n], format.bitDepth, format.float, format.byteOrder()),
+	}
+	// data may share s.pending's array, so the remainder is kept only once decoded
+	s.pending = append(s.pending[:0], data[n:]...)
+
+	switch channelSelect {
+	case ChannelSelectLeft:
+		return decoded.Channel(0)
+	case ChannelSelectRight:
+		return decoded.Channel(min(1, channels-1))
+	default:
+		return decoded.Mono()
+	}
+}
@@ -0,0 +1,97 @@
+package session
+
+import (
+	"asr_server/config"
+	"asr_server/internal/logger"
+	"asr_server/internal/pool"
+)
+
+// EndpointRules decide how the utterances a VAD detects become recognition segments. Both VAD
+// backends apply them the same way, in live sessions and file transcription: speech is
+// finalized after the trailing silence, utterances with less speech than the minimum are
+// dropped, and utterances longer than the maximum are split and recognized in parts rather
+// than truncated. The trailing silence is applied by the VAD itself, see sessionVADConfig.
+type EndpointRules struct {
+	minSamples int // Least speech in an utterance, not counting the trailing silence
+	maxSamples int // Longest segment before recognition is forced
+}
+
+// NewEndpointRules returns the rules for vadType under the global VAD settings: the minimum
+// comes from the VAD's own settings and the maximum from session.max_segment_seconds, lowered
+// to silero_vad.max_speech_duration for Silero
+func NewEndpointRules(cfg *config.Config, vadType string) EndpointRules {
+	return newEndpointRules(cfg, cfg.VAD, vadType)
+}
+
+// newEndpointRules returns the rules for vadType under the VAD settings vad
+func newEndpointRules(cfg *config.Config, vad config.VADConfig, vadType string) EndpointRules {
+	sampleRate := float64(cfg.Audio.SampleRate)
+	rules := EndpointRules{maxSamples: MaxSegmentSamples(cfg)}
+
+	switch vadType {
+	case pool.SILERO_TYPE:
+		rules.minSamples = int(float64(vad.SileroVAD.MinSpeechDuration) * sampleRate)
+		if maxSpeech := vad.SileroVAD.MaxSpeechDuration; maxSpeech > 0 {
+			rules.maxSamples = min(rules.maxSamples, int(float64(maxSpeech)*sampleRate))
+		}
+	case pool.TEN_VAD_TYPE:
+		rules.minSamples = vad.TenVAD.MinSpeechFrames * vad.TenVAD.HopSize
+	}
+	rules.maxSamples = max(rules.maxSamples, 1)
+	return rules
+}
+
+// endpointRules returns the rules of a session using vadType, applying the session's own
+// minimum and maximum over those of NewEndpointRules
+func (m *Manager) endpointRules(opts Options, vadType string) EndpointRules {
+	rules := newEndpointRules(m.cfg, m.sessionVADConfig(opts), vadType)
+	sampleRate := float64(m.cfg.Audio.SampleRate)
+	if opts.VADMinSpeech != 0 {
+		rules.minSamples = int(float64(opts.VADMinSpeech) * sampleRate)
+	}
+	if opts.VADMaxSpeech != 0 {
+		rules.maxSamples = max(min(rules.maxSamples, int(float64(opts.VADMaxSpeech)*sampleRate)), 1)
+	}
+	return rules
+}
+
+// Accept reports whether an utterance with speech samples of speech is recognized
+func (r EndpointRules) Accept(speech int) bool {
+	return speech >= r.minSamples
+}
+
+// tenVADSpeech returns the speech in the TEN-VAD utterance in progress: from its start,
+// including the parts already split off, to the current segment's end without the trailing
+// silence frames
+func (s *Session) tenVADSpeech(hopSize int) int {
+	return s.segmentStart + len(s.currentSegment) - s.silenceFrameCount*hopSize - s.utteranceStart
+}
+
+// Split returns the parts of an utterance, each at most the maximum long
+func (r EndpointRules) Split(samples []float32) [][]float32 {
+	parts := make([][]float32, 0, (len(samples)+r.maxSamples-1)/r.maxSamples)
+	for len(samples) > r.maxSamples {
+		parts = append(parts, samples[:r.maxSamples])
+		samples = samples[r.maxSamples:]
+	}
+	return append(parts, samples)
+}
+
+// submitUtterance queues an utterance the VAD ended at stream position start for recognition,
+// split at the maximum length, unless it has less speech than the minimum. speech is the
+// length of the whole utterance not counting its trailing silence, which for the remainder of
+// an utterance already split during speech includes the parts submitted before.
+func (m *Manager) submitUtterance(session *Session, sessionID string, samples []float32, start, speech int, rules EndpointRules) {
+	if !rules.Accept(speech) {
+		logger.Debug("skipping_short_segment", "session_id", sessionID, "samples", speech, "min", rules.minSamples)
+		return
+	}
+	parts := rules.Split(samples)
+	if len(parts) > 1 {
+		logger.Warn("segment_max_length_exceeded", "session_id", sessionID, "samples", len(samples), "max", rules.maxSamples)
+	}
+	for i, part := range parts {
+		m.submitSegment(session, sessionID, part, start, i == len(parts)-1)
+		start += len(part)
+	}
+}
@@ -0,0 +1,83 @@
+package session
+
+import (
+	"testing"
+
+	"asr_server/config"
+	"asr_server/internal/pool"
+)
+
+func TestEndpointRules(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Audio.SampleRate = 16000
+	cfg.Session.MaxSegmentSeconds = 30
+	cfg.VAD.SileroVAD.MinSpeechDuration = 0.25
+	cfg.VAD.SileroVAD.MaxSpeechDuration = 8
+	cfg.VAD.TenVAD.HopSize = 256
+	cfg.VAD.TenVAD.MinSpeechFrames = 12
+	m := &Manager{cfg: cfg}
+
+	tests := []struct {
+		name    string
+		opts    Options
+		vadType string
+		wantMin int
+		wantMax int
+	}{
+		{"silero defaults", Options{}, pool.SILERO_TYPE, 4000, 128000},
+		{"ten_vad defaults", Options{}, pool.TEN_VAD_TYPE, 3072, 480000},
+		{"silero session rules", Options{VADMinSpeech: 0.5, VADMaxSpeech: 4}, pool.SILERO_TYPE, 8000, 64000},
+		{"ten_vad session rules", Options{VADMinSpeech: 0.5, VADMaxSpeech: 4}, pool.TEN_VAD_TYPE, 8000, 64000},
+		{"maximum above the configured one", Options{VADMaxSpeech: 60}, pool.TEN_VAD_TYPE, 3072, 480000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := m.endpointRules(tt.opts, tt.vadType)
+			if rules.minSamples != tt.wantMin || rules.maxSamples != tt.wantMax {
+				t.Errorf("endpointRules() = %+v, want min %d, max %d", rules, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestEndpointRulesSplit(t *testing.T) {
+	rules := EndpointRules{minSamples: 2, maxSamples: 4}
+	tests := []struct {
+		n    int
+		want []int
+	}{
+		{3, []int{3}},
+		{4, []int{4}},
+		{9, []int{4, 4, 1}},
+	}
+	for _, tt := range tests {
+		parts := rules.Split(positions(0, tt.n))
+		if len(parts) != len(tt.want) {
+			t.Fatalf("Split(%d) = %d parts, want %d", tt.n, len(parts), len(tt.want))
+		}
+		next := 0
+		for i, part := range parts {
+			if len(part) != tt.want[i] || int(part[0]) != next {
+				t.Errorf("Split(%d) part %d = %v, want %d samples from %d", tt.n, i, part, tt.want[i], next)
+			}
+			next += len(part)
+		}
+	}
+
+	if rules.Accept(1) || !rules.Accept(2) {
+		t.Errorf("Accept() does not require %d samples of speech", rules.minSamples)
+	}
+}
+
+func TestTenVADSpeech(t *testing.T) {
+	// An utterance split once at 1000 samples, with 300 samples since and 2 frames of silence
+	s := &Session{
+		utteranceStart:    500,
+		segmentStart:      1500,
+		currentSegment:    make([]float32, 300),
+		silenceFrameCount: 2,
+	}
+	if got := s.tenVADSpeech(100); got != 1100 {
+		t.Errorf("tenVADSpeech() = %d, want 1100", got)
+	}
+}
@@ -0,0 +1,32 @@
+package session
+
+// Hook receives session lifecycle callbacks, so custom builds can add behaviour such as
+// billing, storage or enrichment without changing the manager. Callbacks run synchronously
+// on the goroutine that triggered them and must not block.
+type Hook interface {
+	// OnSessionStart is called when a session is created, before any audio arrives
+	OnSessionStart(sessionID string)
+	// OnSegment is called from the audio path when a speech segment is submitted for recognition
+	OnSegment(segment SegmentEvent)
+	// OnResult is called from recognition workers with each final result before it is
+	// published and sent to the client. Changes to the event, such as setting Extra fields,
+	// are delivered with the result.
+	OnResult(result *ResultEvent)
+	// OnSessionEnd is called once when a session is closed, with its final statistics
+	OnSessionEnd(info SessionInfo, reason CloseReason)
+}
+
+// SegmentEvent is a speech segment submitted for recognition
+type SegmentEvent struct {
+	SessionID  string
+	StartTime  float64   // Segment start in seconds since the stream started
+	EndTime    float64   // Segment end in seconds since the stream started
+	Samples    []float32 // Owned by the recognizer; copy to keep beyond the callback
+	SampleRate int
+}
+
+// AddHook registers a lifecycle hook. Hooks are called in registration order.
+// It must be called before the manager starts creating sessions.
+func (m *Manager) AddHook(hook Hook) {
+	m.hooks = append(m.hooks, hook)
+}
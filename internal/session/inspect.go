@@ -0,0 +1,101 @@
+package session
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// SessionInfo describes an active session for the admin API
+type SessionInfo struct {
+	ID            string    `json:"id"`
+	RemoteAddr    string    `json:"remote_addr,omitempty"`
+	Metadata      *Metadata `json:"metadata,omitempty"` // From the hello message
+	CreatedAt     time.Time `json:"created_at"`
+	UptimeSeconds float64   `json:"uptime_seconds"`
+	IdleSeconds   float64   `json:"idle_seconds"` // Since the last audio or keepalive
+	BytesReceived int64     `json:"bytes_received"`
+	Segments      int64     `json:"segments"` // Speech segments submitted for recognition
+	Results       uint64    `json:"results"`  // Final results numbered so far
+	SendQueue     int       `json:"send_queue"`
+	SendQueueSize int       `json:"send_queue_size"`
+	// Results spilled while the send queue was full, recovered from the spill buffer so far,
+	// and still waiting in it
+	SpilledResults   int64 `json:"spilled_results"`
+	RecoveredResults int64 `json:"recovered_results"`
+	SpillPending     int   `json:"spill_pending"`
+	Inflight         int32 `json:"inflight"` // Recognition tasks not yet completed
+	Paused           bool  `json:"paused"`
+	Detached         bool  `json:"detached"` // Disconnected and awaiting resume
+	Backpressure     bool  `json:"backpressure"`
+}
+
+// SetRemoteAddr records the client address reported by ListSessions
+func (s *Session) SetRemoteAddr(addr string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remoteAddr = addr
+}
+
+// info returns a snapshot of the session
+func (s *Session) info(now time.Time) SessionInfo {
+	s.mu.RLock()
+	remoteAddr := s.remoteAddr
+	var metadata *Metadata
+	if s.metadata != (Metadata{}) {
+		md := s.metadata
+		metadata = &md
+	}
+	s.mu.RUnlock()
+	s.order.mu.Lock()
+	results := s.order.seq
+	s.order.mu.Unlock()
+
+	return SessionInfo{
+		ID:               s.ID,
+		RemoteAddr:       remoteAddr,
+		Metadata:         metadata,
+		CreatedAt:        s.createdAt,
+		UptimeSeconds:    now.Sub(s.createdAt).Seconds(),
+		IdleSeconds:      now.Sub(time.Unix(0, atomic.LoadInt64(&s.LastSeen))).Seconds(),
+		BytesReceived:    atomic.LoadInt64(&s.bytesReceived),
+		Segments:         atomic.LoadInt64(&s.segments),
+		Results:          results,
+		SendQueue:        len(s.SendQueue),
+		SendQueueSize:    cap(s.SendQueue),
+		SpilledResults:   atomic.LoadInt64(&s.spilledResults),
+		RecoveredResults: atomic.LoadInt64(&s.recoveredResults),
+		SpillPending:     s.spillPending(),
+		Inflight:         atomic.LoadInt32(&s.inflight),
+		Paused:           atomic.LoadInt32(&s.paused) == 1,
+		Detached:         !s.detachedSince().IsZero(),
+		Backpressure:     atomic.LoadInt32(&s.backpressure) == 1,
+	}
+}
+
+// ListSessions returns a snapshot of every active session, oldest first
+func (m *Manager) ListSessions() []SessionInfo {
+	sessions := m.sessions.snapshot()
+	now := time.Now()
+	infos := make([]SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		infos = append(infos, session.info(now))
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if !infos[i].CreatedAt.Equal(infos[j].CreatedAt) {
+			return infos[i].CreatedAt.Before(infos[j].CreatedAt)
+		}
+		return infos[i].ID < infos[j].ID
+	})
+	return infos
+}
+
+// CloseSession force-closes a session, closing its connection. It reports whether the
+// session existed.
+func (m *Manager) CloseSession(sessionID string) bool {
+	if _, exists := m.sessions.get(sessionID); !exists {
+		return false
+	}
+	m.removeSession(sessionID, CloseByAdmin)
+	return true
+}
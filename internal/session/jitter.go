@@ -0,0 +1,138 @@
+package session
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+)
+
+// Audio framing modes
+const (
+	FramingNone      = "none"      // Each binary message is plain audio (default)
+	FramingSequenced = "sequenced" // Each binary message starts with a sequence number and timestamp
+)
+
+// ValidFramings lists the accepted framing values
+var ValidFramings = []string{FramingNone, FramingSequenced}
+
+// sequencedHeaderSize is the 4-byte big-endian sequence number followed by the
+// 4-byte big-endian sender timestamp in milliseconds
+const sequencedHeaderSize = 8
+
+// JitterStats reports how a sequenced stream arrived
+type JitterStats struct {
+	Received   int64 `json:"received"`   // Frames received, including duplicates
+	Delivered  int64 `json:"delivered"`  // Frames passed on in sequence order
+	Duplicates int64 `json:"duplicates"` // Repeated or too-late frames that were dropped
+	Reordered  int64 `json:"reordered"`  // Frames that arrived ahead of a missing predecessor
+	Lost       int64 `json:"lost"`       // Frames skipped because they never arrived in time
+	Gaps       int64 `json:"gaps"`       // Runs of lost frames
+	MaxGap     int64 `json:"max_gap"`    // Longest run of lost frames
+}
+
+// jitterBuffer reorders and deduplicates sequence-numbered frames. Frames are held until
+// their predecessors arrive or depth later frames are waiting, at which point the
+// missing ones are counted as lost.
+type jitterBuffer struct {
+	mu      sync.Mutex
+	depth   int
+	started bool
+	next    uint32
+	pending map[uint32][]byte
+	stats   JitterStats
+}
+
+func newJitterBuffer(depth int) *jitterBuffer {
+	return &jitterBuffer{
+		depth:   max(depth, 1),
+		pending: make(map[uint32][]byte),
+	}
+}
+
+// push adds a frame and returns the payloads that are now in order
+func (j *jitterBuffer) push(frame []byte) ([][]byte, error) {
+	if len(frame) < sequencedHeaderSize {
+		return nil, fmt.Errorf("sequenced frame shorter than its %d-byte header", sequencedHeaderSize)
+	}
+	seq := binary.BigEndian.Uint32(frame)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.stats.Received++
+	if !j.started {
+		j.started = true
+		j.next = seq
+	}
+
+	// Sequence numbers wrap, so order is decided by the signed distance
+	diff := int32(seq - j.next)
+	if _, buffered := j.pending[seq]; diff < 0 || buffered {
+		j.stats.Duplicates++
+		return nil, nil
+	}
+	if diff > 0 {
+		j.stats.Reordered++
+	}
+	j.pending[seq] = append([]byte(nil), frame[sequencedHeaderSize:]...)
+
+	out := j.drain(nil)
+	for len(j.pending) > j.depth {
+		out = j.skipGap(out)
+	}
+	return out, nil
+}
+
+// flush returns every buffered payload in order, counting missing frames as lost
+func (j *jitterBuffer) flush() [][]byte {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var out [][]byte
+	for len(j.pending) > 0 {
+		out = j.skipGap(j.drain(out))
+	}
+	return out
+}
+
+// snapshot returns a copy of the statistics
+func (j *jitterBuffer) snapshot() JitterStats {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.stats
+}
+
+// drain appends consecutive payloads starting at the next expected sequence number
+func (j *jitterBuffer) drain(out [][]byte) [][]byte {
+	for {
+		payload, ok := j.pending[j.next]
+		if !ok {
+			return out
+		}
+		delete(j.pending, j.next)
+		j.next++
+		j.stats.Delivered++
+		out = append(out, payload)
+	}
+}
+
+// skipGap gives up on the missing frames before the earliest buffered one and drains from there
+func (j *jitterBuffer) skipGap(out [][]byte) [][]byte {
+	if len(j.pending) == 0 {
+		return out
+	}
+
+	var gap uint32
+	first := true
+	for seq := range j.pending {
+		if d := seq - j.next; first || d < gap {
+			gap, first = d, false
+		}
+	}
+
+	j.stats.Lost += int64(gap)
+	j.stats.Gaps++
+	j.stats.MaxGap = max(j.stats.MaxGap, int64(gap))
+	j.next += gap
+	return j.drain(out)
+}
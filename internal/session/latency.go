@@ -0,0 +1,192 @@
+package session
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recognition stages timed per segment
+const (
+	StageQueueWait = "queue_wait" // From submission until a recognition worker picks the segment up
+	StageDecode    = "decode"     // Decoding the segment, including waiting for a GPU batch
+	StageEndToEnd  = "end_to_end" // From submission until the result is handed to the session
+)
+
+// latencyBuckets are the histogram upper bounds in seconds
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// defaultModelLabel labels segments recognized with the default model
+const defaultModelLabel = "default"
+
+type latencyKey struct {
+	model string
+	stage string
+}
+
+// latencyHistogram counts observations per bucket; the last count is for +Inf
+type latencyHistogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// LatencySink receives every stage duration, for pushing to an external metrics system.
+// Timing is called from recognition workers and must not block.
+type LatencySink interface {
+	Timing(name string, d time.Duration, tags ...string)
+}
+
+// latencyRecorder keeps a histogram per model and stage
+type latencyRecorder struct {
+	mu         sync.Mutex
+	histograms map[latencyKey]*latencyHistogram
+	sink       LatencySink // nil when no exporter is configured
+}
+
+func newLatencyRecorder() *latencyRecorder {
+	return &latencyRecorder{histograms: make(map[latencyKey]*latencyHistogram)}
+}
+
+// observe records a stage duration for a model, "" being the default model
+func (r *latencyRecorder) observe(model, stage string, d time.Duration) {
+	model = modelLabel(model)
+	seconds := d.Seconds()
+	if r.sink != nil {
+		r.sink.Timing("recognition."+stage, d, "model:"+model)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := latencyKey{model: model, stage: stage}
+	h, exists := r.histograms[key]
+	if !exists {
+		h = &latencyHistogram{counts: make([]uint64, len(latencyBuckets)+1)}
+		r.histograms[key] = h
+	}
+	h.counts[sort.SearchFloat64s(latencyBuckets, seconds)]++
+	h.count++
+	h.sum += seconds
+}
+
+// snapshot returns copies of the histograms sorted by model and stage
+func (r *latencyRecorder) snapshot() ([]latencyKey, []latencyHistogram) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keys := make([]latencyKey, 0, len(r.histograms))
+	for key := range r.histograms {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].model != keys[j].model {
+			return keys[i].model < keys[j].model
+		}
+		return keys[i].stage < keys[j].stage
+	})
+	histograms := make([]latencyHistogram, len(keys))
+	for i, key := range keys {
+		h := r.histograms[key]
+		histograms[i] = latencyHistogram{counts: append([]uint64(nil), h.counts...), count: h.count, sum: h.sum}
+	}
+	return keys, histograms
+}
+
+// stats returns the count, mean and estimated percentiles in milliseconds per model and stage
+func (r *latencyRecorder) stats() map[string]interface{} {
+	keys, histograms := r.snapshot()
+	stats := make(map[string]interface{})
+	for i, key := range keys {
+		h := histograms[i]
+		stages, ok := stats[key.model].(map[string]interface{})
+		if !ok {
+			stages = make(map[string]interface{})
+			stats[key.model] = stages
+		}
+		stages[key.stage] = map[string]interface{}{
+			"count":   h.count,
+			"mean_ms": roundMs(h.sum / float64(h.count)),
+			"p50_ms":  roundMs(h.quantile(0.5)),
+			"p95_ms":  roundMs(h.quantile(0.95)),
+			"p99_ms":  roundMs(h.quantile(0.99)),
+		}
+	}
+	return stats
+}
+
+// quantile estimates the q-quantile in seconds by interpolating within its bucket.
+// Observations above the last bucket are reported as the last bound.
+func (h *latencyHistogram) quantile(q float64) float64 {
+	if h.count == 0 {
+		return 0
+	}
+	rank := q * float64(h.count)
+	var cumulative uint64
+	for i, n := range h.counts {
+		if float64(cumulative+n) < rank || n == 0 {
+			cumulative += n
+			continue
+		}
+		if i == len(latencyBuckets) {
+			break
+		}
+		lower := 0.0
+		if i > 0 {
+			lower = latencyBuckets[i-1]
+		}
+		return lower + (latencyBuckets[i]-lower)*(rank-float64(cumulative))/float64(n)
+	}
+	return latencyBuckets[len(latencyBuckets)-1]
+}
+
+// writeMetrics writes the histograms in the Prometheus text exposition format
+func (r *latencyRecorder) writeMetrics(w io.Writer) {
+	keys, histograms := r.snapshot()
+	fmt.Fprintln(w, "# HELP asr_recognition_latency_seconds Recognition latency per segment by model and stage.")
+	fmt.Fprintln(w, "# TYPE asr_recognition_latency_seconds histogram")
+	for i, key := range keys {
+		h := histograms[i]
+		labels := fmt.Sprintf(`model="%s",stage="%s"`, escapeLabel(key.model), key.stage)
+		var cumulative uint64
+		for j, bound := range latencyBuckets {
+			cumulative += h.counts[j]
+			fmt.Fprintf(w, "asr_recognition_latency_seconds_bucket{%s,le=\"%s\"} %d\n", labels, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(w, "asr_recognition_latency_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(w, "asr_recognition_latency_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(w, "asr_recognition_latency_seconds_count{%s} %d\n", labels, h.count)
+	}
+}
+
+// modelLabel names the model in metrics and logs, "" being the default model
+func modelLabel(model string) string {
+	if model == "" {
+		return defaultModelLabel
+	}
+	return model
+}
+
+// escapeLabel escapes a Prometheus label value
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func roundMs(seconds float64) float64 {
+	return math.Round(seconds*1e5) / 100
+}
+
+// WriteMetrics writes recognition metrics in the Prometheus text exposition format
+func (m *Manager) WriteMetrics(w io.Writer) {
+	m.latency.writeMetrics(w)
+	m.workers.writeMetrics(w)
+}
+
+// SetLatencySink also sends stage durations to sink, as recognition.<stage> timings tagged
+// with the model. It must be called before the manager starts processing audio.
+func (m *Manager) SetLatencySink(sink LatencySink) {
+	m.latency.sink = sink
+}
@@ -0,0 +1,72 @@
+package session
+
+import (
+	"math"
+	"time"
+
+	"asr_server/internal/logger"
+)
+
+// Audio level reporting interval limits in milliseconds
+const (
+	MinAudioLevelInterval = 50
+	MaxAudioLevelInterval = 10000
+)
+
+// silenceFloorDB is reported for digital silence, where the RMS level is undefined
+const silenceFloorDB = -100.0
+
+// levelMeter accumulates signal energy between audio level reports
+type levelMeter struct {
+	sumSquares float64
+	samples    int
+}
+
+// sendVADProbability queues a vad message with the speech probability of the VAD frame at
+// stream position start, for clients doing their own endpointing. time is in seconds since the
+// start of the stream, as start_time in final results. Like audio_level messages, they are
+// dropped rather than delaying results when the send queue is full.
+func (m *Manager) sendVADProbability(session *Session, prob float32, speech bool, start int) {
+	select {
+	case session.SendQueue <- map[string]interface{}{
+		"type":   "vad",
+		"prob":   math.Round(float64(prob)*1000) / 1000,
+		"speech": speech,
+		"time":   math.Round(float64(start)*1000/float64(m.cfg.Audio.SampleRate)) / 1000,
+	}:
+	default:
+		session.dropMessage()
+		logger.Debug("session_send_queue_full", "session_id", session.ID, "action", "dropped_vad")
+	}
+}
+
+// trackAudioLevel adds samples to the session's level meter and queues an audio_level
+// message each time interval milliseconds of audio have been measured
+func (m *Manager) trackAudioLevel(session *Session, samples []float32, intervalMs int) {
+	meter := &session.level
+	for _, s := range samples {
+		meter.sumSquares += float64(s) * float64(s)
+	}
+	meter.samples += len(samples)
+
+	if meter.samples < m.cfg.Audio.SampleRate*intervalMs/1000 {
+		return
+	}
+
+	rmsDB := silenceFloorDB
+	if meter.sumSquares > 0 {
+		rmsDB = max(10*math.Log10(meter.sumSquares/float64(meter.samples)), silenceFloorDB)
+	}
+	*meter = levelMeter{}
+
+	select {
+	case session.SendQueue <- map[string]interface{}{
+		"type":      "audio_level",
+		"rms_db":    math.Round(rmsDB*10) / 10,
+		"timestamp": time.Now().UnixMilli(),
+	}:
+	default:
+		session.dropMessage()
+		logger.Debug("session_send_queue_full", "session_id", session.ID, "action", "dropped_audio_level")
+	}
+}
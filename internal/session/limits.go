@@ -0,0 +1,76 @@
+package session
+
+import (
+	"time"
+
+	"asr_server/config"
+	"asr_server/internal/logger"
+)
+
+// sessionTimeout returns how long a session may be inactive before the cleanup sweep removes it
+func (m *Manager) sessionTimeout() time.Duration {
+	return configuredDuration(m.cfg.Session.Timeout, config.DefaultSessionTimeout)
+}
+
+// cleanupInterval returns how often inactive sessions are swept
+func (m *Manager) cleanupInterval() time.Duration {
+	return configuredDuration(m.cfg.Session.CleanupInterval, config.DefaultCleanupInterval)
+}
+
+// MaxSegmentSamples returns the longest speech segment, in samples at the model sample rate,
+// that is buffered before recognition is forced, bounding memory for endless speech
+func MaxSegmentSamples(cfg *config.Config) int {
+	seconds := cfg.Session.MaxSegmentSeconds
+	if seconds <= 0 {
+		seconds = config.DefaultMaxSegmentSeconds
+	}
+	return seconds * cfg.Audio.SampleRate
+}
+
+// recognitionLimits returns the number of recognition workers and the queue limit
+func recognitionLimits(cfg *config.Config) (int, int) {
+	workers, maxQueued := cfg.Pool.MaxRecognitionWorkers, cfg.Pool.MaxQueuedRecognitionTasks
+	if workers <= 0 {
+		workers = config.DefaultMaxRecognitionWorkers
+	}
+	if maxQueued <= 0 {
+		maxQueued = config.DefaultMaxQueuedRecognitionTasks
+	}
+	return workers, maxQueued
+}
+
+// recognitionQueueTimeout returns how long a recognition task may wait for a worker, 0 for
+// no limit
+func recognitionQueueTimeout(cfg *config.Config) time.Duration {
+	return time.Duration(cfg.Pool.RecognitionQueueTimeoutMs) * time.Millisecond
+}
+
+// RecognitionQueueLoad returns the number of recognition tasks waiting for a worker and
+// the queue limit
+func (m *Manager) RecognitionQueueLoad() (int, int) {
+	return m.workers.load()
+}
+
+// ApplyLimits applies the session timeout, cleanup interval and recognition worker and queue
+// limits of a reloaded configuration. Running recognition tasks are not interrupted when the
+// number of workers shrinks; surplus workers exit as they finish. The segment limit and timeout
+// are read from the configuration on use.
+func (m *Manager) ApplyLimits(cfg *config.Config) {
+	workers, maxQueued := recognitionLimits(cfg)
+	queueTimeout := recognitionQueueTimeout(cfg)
+	m.workers.resize(workers, maxQueued, queueTimeout)
+	if m.cleanupTicker != nil {
+		m.cleanupTicker.Reset(m.cleanupInterval())
+	}
+	logger.Info("session_limits_applied", "timeout", m.sessionTimeout(), "cleanup_interval", m.cleanupInterval(),
+		"max_segment_samples", MaxSegmentSamples(cfg), "max_recognition_workers", workers, "max_queued_recognition_tasks", maxQueued,
+		"recognition_queue_timeout", queueTimeout)
+}
+
+// configuredDuration converts a setting in seconds, using the default when it is unset
+func configuredDuration(seconds, defaultSeconds int) time.Duration {
+	if seconds <= 0 {
+		seconds = defaultSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -2,28 +2,44 @@ package session
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/gorilla/websocket"
-
 	"asr_server/config"
+	"asr_server/internal/audio"
 	"asr_server/internal/logger"
 	"asr_server/internal/pool"
+	"asr_server/internal/postprocess"
 
 	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
+	"golang.org/x/time/rate"
 )
 
+// Conn is the transport a session delivers results over.
+// *websocket.Conn satisfies it; other transports provide their own adapters.
+type Conn interface {
+	WriteJSON(v interface{}) error
+	Close() error
+}
+
 // Session represents a WebSocket session
 type Session struct {
 	ID          string
-	Conn        *websocket.Conn
+	Conn        Conn
 	VADInstance pool.VADInstanceInterface
+	vadPool     pool.VADPoolInterface // Pool VADInstance is returned to
 	LastSeen    int64
 	mu          sync.RWMutex
 	closed      int32
+	// Serializes processing audio with closeSession releasing the VAD instance, Opus decoder
+	// and online stream, so none is released while a message is using it
+	processMu sync.Mutex
+	// Closed when a Silero pass that timed out returns; the instance is in use until then
+	vadPending chan struct{}
 
 	// Context for cancellation propagation
 	ctx    context.Context
@@ -32,8 +48,88 @@ type Session struct {
 	// Send queue and channels
 	SendQueue    chan interface{}
 	sendDone     chan struct{}
+	sendExited   chan struct{} // Closed when the send loop returns
 	sendErrCount int32
 
+	// Resume state, see resume.go. connMu guards Conn, resume, detachedAt and reattached.
+	connMu     sync.Mutex
+	resume     *resumeState
+	detachedAt time.Time     // When the connection dropped, zero while connected
+	reattached chan struct{} // Closed when a client resumes the detached session
+
+	// Number of recognition tasks submitted but not yet completed
+	inflight int32
+	// Orders and numbers final results, see ordering.go
+	order resultOrder
+
+	// Reported by ListSessions; remoteAddr is guarded by mu
+	remoteAddr    string
+	createdAt     time.Time
+	bytesReceived int64
+	segments      int64
+	// Set while the client has been told to slow down, see updateBackpressure
+	backpressure int32
+	// Final results dropped because the send queue and spill buffer were full, and other
+	// messages dropped because the send queue was full, see summary.go
+	droppedResults  int32
+	droppedMessages int64
+	// Samples received at the model sample rate, and the number and total time of decodes,
+	// for the session summary
+	audioSamples int64
+	decodes      int64
+	decodeNanos  int64
+	// Final results spilled while the send queue was full and replayed from the spill
+	// buffer, see spill.go; spillTotals aggregates them for the manager
+	spilledResults   int64
+	recoveredResults int64
+	spillTotals      *spillTotals
+	// API key selecting the session's quota, guarded by mu, and whether the quota was
+	// exceeded, see quota.go
+	apiKey        string
+	quotaExceeded int32
+	// Client metadata from the hello message and whether it was received, guarded by mu,
+	// see metadata.go
+	metadata      Metadata
+	helloReceived bool
+	// Limits the rate audio is read at, guarded by mu, see throttle.go
+	audioRate *rate.Limiter
+
+	// Per-session overrides set via control messages, guarded by mu
+	options Options
+	// Decoder for Opus-encoded audio, created when the encoding is configured, guarded by mu
+	opus *opusStream
+	// Set once the first audio message has been checked for a WAV header
+	headerChecked bool
+	// Sample layout declared by a WAV header when it is not 16-bit integer, guarded by mu
+	pcmFormat *pcmFormat
+	// Incomplete trailing frame carried over to the next audio message
+	pending []byte
+	// Reorders sequence-numbered frames, created on first use, guarded by mu
+	jitter *jitterBuffer
+	// Signal energy since the last audio level report
+	level levelMeter
+	// Online recognition stream in streaming mode, created on first audio
+	online *sherpa.OnlineStream
+	// Last partial hypothesis sent and samples fed since the last final result
+	lastPartial   string
+	onlineSamples int
+	// Set by the stop command; audio is ignored until the next start
+	paused int32
+
+	// Samples at the model sample rate received since the stream started, and the stream
+	// position at which the VAD instance last started counting, moved on by the audio the
+	// energy prefilter keeps from it since
+	streamSamples int
+	vadOrigin     int
+	// Whether speech is in progress, as last reported to the client
+	speaking bool
+	// Adds the surrounding audio to VAD segments, nil when padding is disabled
+	padder *segmentPadder
+	// Keeps quiet audio from the VAD instance, nil when the prefilter is disabled
+	prefilter *energyPrefilter
+	// Attributes segments to speakers, nil when speaker recognition is disabled
+	speakers SpeakerTracker
+
 	// Activity detection
 	lastActivity time.Time
 
@@ -41,6 +137,9 @@ type Session struct {
 	isInSpeech        bool
 	currentSegment    []float32
 	silenceFrameCount int
+	segmentStart      int
+	// Stream position where the utterance in progress started, before any forced split
+	utteranceStart int
 
 	// Configuration reference (for session-specific settings)
 	cfg *config.Config
@@ -50,74 +149,162 @@ type Session struct {
 // All configuration is explicitly injected via constructor.
 type Manager struct {
 	cfg        *config.Config
-	sessions   map[string]*Session
+	sessions   *sessionMap
 	recognizer *sherpa.OfflineRecognizer
 	vadPool    pool.VADPoolInterface
-	mu         sync.RWMutex
 
 	// Statistics
 	totalSessions  int64
 	activeSessions int64
 	totalMessages  int64
+	// Samples the energy prefilter kept from the VAD instances
+	prefilteredSamples int64
+
+	// Session cleanup, see limits.go for the timeout
+	cleanupTicker *time.Ticker
+
+	// Recognition worker pool, scheduling queued tasks by priority
+	workers *scheduler
+
+	// Batches decodes on GPU, nil when segments are decoded one at a time
+	batcher *batchDecoder
+
+	// Replacement rules applied to transcripts, nil when none are configured
+	rules *postprocess.Rules
+
+	// Downstream consumers of final results
+	publishers []ResultPublisher
 
-	// Session cleanup
-	cleanupTicker  *time.Ticker
-	sessionTimeout time.Duration
+	// Lifecycle callbacks registered by custom builds, see hooks.go
+	hooks []Hook
 
-	// Recognition worker pool to limit concurrent goroutines
-	recognitionWorkers    chan struct{}
-	maxRecognitionWorkers int
+	// Results spilled and recovered across sessions
+	spillTotals spillTotals
+
+	// Per-stage recognition latency by model, see latency.go
+	latency *latencyRecorder
+
+	// Held for reading while a recognizer is in use and for writing while the default
+	// model is replaced, so a swap waits for in-flight decodes
+	swapMu sync.RWMutex
+
+	// Recognizers for sessions overriding the global language or setting hotwords
+	recognizerFactory  RecognizerFactory
+	recognizers        map[RecognizerSpec]*sherpa.OfflineRecognizer
+	hotwordRecognizers int
+	recognizersMu      sync.Mutex
+
+	// Whisper recognizer translating segments to English, nil when not configured
+	translator *sherpa.OfflineRecognizer
+
+	// Creates a speaker tracker for each session, nil when speaker recognition is disabled
+	speakerTrackerFactory SpeakerTrackerFactory
+
+	// Streaming recognizer; when set, audio bypasses the VAD
+	onlineRecognizer *sherpa.OnlineRecognizer
+
+	// VAD pools for sessions overriding the global provider
+	vadPoolFactory VADPoolFactory
+	// Creates dedicated VAD instances for sessions overriding VAD parameters, and the number
+	// of those instances
+	vadInstanceFactory VADInstanceFactory
+	dedicatedVADs      int64
+	vadPools           map[string]*pool.ReloadablePool
+	vadPoolsMu         sync.Mutex
+
+	// Set once Drain starts, see drain.go
+	draining int32
+	// Connections of closed sessions still being sent their summary, see summary.go
+	closing sync.WaitGroup
 
 	// Cleanup
 	ctx    context.Context
 	cancel context.CancelFunc
 }
 
-// Default settings for session management
-const (
-	DefaultSessionTimeout        = 5 * time.Minute
-	DefaultMaxRecognitionWorkers = 50
-	CleanupInterval              = 30 * time.Second
-	// MaxSegmentSamples limits the maximum size of audio segment to prevent memory exhaustion
-	// At 16kHz sample rate, 60 seconds = 960000 samples
-	MaxSegmentSamples = 960000
-)
+// ResultEvent is a final recognition result delivered to publishers
+type ResultEvent struct {
+	SessionID string   `json:"session_id"`
+	Text      string   `json:"text"`
+	Timestamp int64    `json:"timestamp"`         // Unix milliseconds when the result was produced
+	Duration  float64  `json:"duration"`          // Length of the recognized speech segment in seconds
+	StartTime float64  `json:"start_time"`        // Segment start in seconds since the stream started
+	EndTime   float64  `json:"end_time"`          // Segment end in seconds since the stream started
+	Emotion   string   `json:"emotion,omitempty"` // Speaker emotion reported by the model
+	Events    []string `json:"events,omitempty"`  // Audio events reported by the model
+	// English translation when the session requested translation
+	Translation string `json:"translation,omitempty"`
+	// Diarization label such as spk_1, and the matching registered speaker if any
+	Speaker     string `json:"speaker,omitempty"`
+	SpeakerID   string `json:"speaker_id,omitempty"`
+	SpeakerName string `json:"speaker_name,omitempty"`
+	// Client metadata from the session's hello message
+	Metadata *Metadata `json:"metadata,omitempty"`
+	// Fields added by hooks, also sent to the client under "extra"
+	Extra map[string]interface{} `json:"extra,omitempty"`
+}
 
-// Global buffer pool (8KB)
-var bufferPool = sync.Pool{
-	New: func() interface{} {
-		return make([]byte, 8192)
-	},
+// SpeakerTracker attributes the speech segments of one session to speakers.
+// Identify is called concurrently from recognition workers.
+type SpeakerTracker interface {
+	Identify(samples []float32, sampleRate int) (postprocess.Speaker, error)
 }
 
-// Global float32 slice pool
-var float32Pool = sync.Pool{}
+// SpeakerTrackerFactory creates the speaker tracker of a new session
+type SpeakerTrackerFactory func() SpeakerTracker
 
-// Global int16 slice pool for TEN-VAD frame processing
-var int16Pool = sync.Pool{}
+// ResultPublisher receives every final recognition result.
+// Publish is called from recognition workers and must not block.
+type ResultPublisher interface {
+	Publish(event ResultEvent)
+}
 
-// getFloat32PoolSlice returns a float32 slice from pool or creates new one
-func getFloat32PoolSlice(chunkSize int) []float32 {
-	if chunkSize <= 0 {
-		chunkSize = config.DefaultChunkSize
-	}
-	return make([]float32, chunkSize)
+// Session lifecycle event types
+const (
+	EventSessionStarted = "session_started"
+	EventSessionEnded   = "session_ended"
+)
+
+// SessionEvent is a session lifecycle event
+type SessionEvent struct {
+	Type      string `json:"type"`
+	SessionID string `json:"session_id"`
+	Timestamp int64  `json:"timestamp"` // Unix milliseconds
+}
+
+// SessionEventPublisher is implemented by publishers that also consume session lifecycle events.
+// PublishSessionEvent may be called with the manager lock held and must not block.
+type SessionEventPublisher interface {
+	PublishSessionEvent(event SessionEvent)
 }
 
+// errVADTimeout is returned when the VAD does not accept a message within the response timeout
+var errVADTimeout = errors.New("VAD processing timeout")
+
 // NewManager creates a new session manager with explicit dependencies
 func NewManager(cfg *config.Config, recognizer *sherpa.OfflineRecognizer, vadPool pool.VADPoolInterface) *Manager {
 	ctx, cancel := context.WithCancel(context.Background())
+	workers, maxQueued := recognitionLimits(cfg)
 
 	manager := &Manager{
-		cfg:                   cfg,
-		sessions:              make(map[string]*Session),
-		recognizer:            recognizer,
-		vadPool:               vadPool,
-		ctx:                   ctx,
-		cancel:                cancel,
-		sessionTimeout:        DefaultSessionTimeout,
-		maxRecognitionWorkers: DefaultMaxRecognitionWorkers,
-		recognitionWorkers:    make(chan struct{}, DefaultMaxRecognitionWorkers),
+		cfg:         cfg,
+		sessions:    newSessionMap(),
+		recognizer:  recognizer,
+		vadPool:     vadPool,
+		ctx:         ctx,
+		cancel:      cancel,
+		workers:     newScheduler(workers, maxQueued, recognitionQueueTimeout(cfg)),
+		recognizers: make(map[RecognizerSpec]*sherpa.OfflineRecognizer),
+		vadPools:    make(map[string]*pool.ReloadablePool),
+		latency:     newLatencyRecorder(),
+	}
+
+	// Batch decodes when recognizing on GPU
+	if cfg.Recognition.Provider == "cuda" && cfg.Recognition.BatchSize > 1 {
+		wait := time.Duration(cfg.Recognition.BatchTimeoutMs) * time.Millisecond
+		manager.batcher = newBatchDecoder(cfg.Recognition.BatchSize, wait)
+		go manager.batcher.run(ctx)
+		logger.Info("batch_decoding_enabled", "max_batch_size", cfg.Recognition.BatchSize, "timeout", wait)
 	}
 
 	// Start session cleanup routine
@@ -128,7 +315,7 @@ func NewManager(cfg *config.Config, recognizer *sherpa.OfflineRecognizer, vadPoo
 
 // startCleanupRoutine starts the background session cleanup goroutine
 func (m *Manager) startCleanupRoutine() {
-	m.cleanupTicker = time.NewTicker(CleanupInterval)
+	m.cleanupTicker = time.NewTicker(m.cleanupInterval())
 	go func() {
 		for {
 			select {
@@ -140,80 +327,256 @@ func (m *Manager) startCleanupRoutine() {
 			}
 		}
 	}()
-	logger.Info("session_cleanup_routine_started", "interval", CleanupInterval, "timeout", m.sessionTimeout)
+	logger.Info("session_cleanup_routine_started", "interval", m.cleanupInterval(), "timeout", m.sessionTimeout())
 }
 
 // cleanupInactiveSessions removes sessions that have been inactive for too long
 func (m *Manager) cleanupInactiveSessions() {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	now := time.Now().UnixNano()
-	timeoutNano := int64(m.sessionTimeout)
+	timeoutNano := int64(m.sessionTimeout())
+	grace := m.resumeGracePeriod()
 	cleanedCount := 0
 
-	for id, session := range m.sessions {
+	for _, session := range m.sessions.snapshot() {
+		id := session.ID
+		// Detached sessions are kept for the resume grace period instead of the inactivity timeout
+		if detachedAt := session.detachedSince(); !detachedAt.IsZero() {
+			if time.Since(detachedAt) > grace && m.sessions.compareAndRemove(id, session) {
+				logger.Info("session_resume_expired", "session_id", id, "grace_period", grace)
+				m.closeSession(session, CloseTimeout)
+				atomic.AddInt64(&m.activeSessions, -1)
+				cleanedCount++
+			}
+			continue
+		}
+
+		// Sessions that stopped sending audio still end when their time is up
+		if session.durationExceeded(session.quota()) {
+			m.enforceQuota(session, QuotaMaxDuration)
+			continue
+		}
+
 		lastSeen := atomic.LoadInt64(&session.LastSeen)
-		if now-lastSeen > timeoutNano {
+		if now-lastSeen > timeoutNano && m.sessions.compareAndRemove(id, session) {
 			inactiveDuration := time.Duration(now - lastSeen)
 			logger.Warn("session_timeout_cleanup", "session_id", id, "inactive_duration", inactiveDuration)
-			m.closeSession(session)
-			delete(m.sessions, id)
+			m.closeSession(session, CloseTimeout)
 			atomic.AddInt64(&m.activeSessions, -1)
 			cleanedCount++
 		}
 	}
 
 	if cleanedCount > 0 {
-		logger.Info("session_cleanup_completed", "cleaned_count", cleanedCount, "remaining", len(m.sessions))
+		logger.Info("session_cleanup_completed", "cleaned_count", cleanedCount, "remaining", m.sessions.len())
 	}
 }
 
-// submitRecognitionTask submits a recognition task with worker pool limiting
-func (m *Manager) submitRecognitionTask(sessionCtx context.Context, samples []float32, sampleRate int, sessionID string) {
-	select {
-	case m.recognitionWorkers <- struct{}{}:
-		go func() {
-			defer func() { <-m.recognitionWorkers }()
+// newSegmentPadder returns a padder for a session using vadType, nil when padding is disabled
+func (m *Manager) newSegmentPadder(vadType string) *segmentPadder {
+	sampleRate := m.cfg.Audio.SampleRate
+	pre := m.cfg.VAD.PrePaddingMs * sampleRate / 1000
+	post := m.cfg.VAD.PostPaddingMs * sampleRate / 1000
+	if pre <= 0 && post <= 0 {
+		return nil
+	}
+	lookback := 0
+	if vadType == pool.SILERO_TYPE {
+		// Silero segments start up to min_speech_duration and a few windows before the audio in
+		// which speech is reported; TEN-VAD segments start in the frame reporting speech
+		silero := m.cfg.VAD.SileroVAD
+		lookback = int(silero.MinSpeechDuration*float32(sampleRate)) + 4*silero.WindowSize
+	}
+	return newSegmentPadder(pre, post, lookback)
+}
 
-			// Check if session context is cancelled
-			select {
-			case <-sessionCtx.Done():
-				logger.Debug("recognition_task_cancelled", "session_id", sessionID)
-				return
-			default:
-			}
+// newEnergyPrefilter returns the first VAD stage for a session, nil when vad.prefilter is disabled
+func (m *Manager) newEnergyPrefilter() *energyPrefilter {
+	prefilter := m.cfg.VAD.Prefilter
+	if !prefilter.Enabled {
+		return nil
+	}
+	return newEnergyPrefilter(prefilter.ThresholdDB, prefilter.HangoverMs, m.cfg.Audio.SampleRate)
+}
 
-			stream := sherpa.NewOfflineStream(m.recognizer)
-			defer sherpa.DeleteOfflineStream(stream)
-			stream.AcceptWaveform(sampleRate, samples)
-			m.recognizer.Decode(stream)
-			result := stream.GetResult()
+// submitSegment queues a VAD segment for recognition, once padded when padding is enabled.
+// padAfter is false for segments split off at the maximum length.
+func (m *Manager) submitSegment(session *Session, sessionID string, samples []float32, offset int, padAfter bool) {
+	if session.padder == nil {
+		m.submitRecognitionTask(session.ctx, samples, offset, m.cfg.Audio.SampleRate, sessionID)
+		return
+	}
+	session.padder.add(samples, offset, padAfter)
+}
 
-			// Check again after decoding
-			select {
-			case <-sessionCtx.Done():
-				logger.Debug("recognition_result_discarded_session_closed", "session_id", sessionID)
-				return
-			default:
+// submitPadded queues padded segments for recognition
+func (m *Manager) submitPadded(session *Session, sessionID string, segments []paddedSegment) {
+	for _, segment := range segments {
+		m.submitRecognitionTask(session.ctx, segment.samples, segment.start, m.cfg.Audio.SampleRate, sessionID)
+	}
+}
+
+// submitRecognitionTask queues a recognition task on the worker pool at the session's
+// priority. offset is the position of the first sample in the session's audio stream.
+func (m *Manager) submitRecognitionTask(sessionCtx context.Context, samples []float32, offset, sampleRate int, sessionID string) {
+	session, exists := m.sessions.get(sessionID)
+	var opts Options
+	var slot uint64
+	if exists {
+		atomic.AddInt32(&session.inflight, 1)
+		atomic.AddInt64(&session.segments, 1)
+		opts = session.Options()
+		slot = session.order.reserve()
+		if len(m.hooks) > 0 {
+			segment := SegmentEvent{
+				SessionID:  sessionID,
+				StartTime:  float64(offset) / float64(sampleRate),
+				EndTime:    float64(offset+len(samples)) / float64(sampleRate),
+				Samples:    samples,
+				SampleRate: sampleRate,
+			}
+			for _, hook := range m.hooks {
+				hook.OnSegment(segment)
 			}
+		}
+	}
 
-			if result != nil {
-				m.handleRecognitionResult(sessionID, result.Text, nil)
-			} else {
-				m.handleRecognitionResult(sessionID, "", fmt.Errorf("recognition failed"))
+	// A segment that cannot be recognized still completes its slot, telling the client why
+	drop := func(reason string) {
+		if !exists {
+			return
+		}
+		atomic.AddInt32(&session.inflight, -1)
+		var notice map[string]interface{}
+		if atomic.LoadInt32(&session.closed) == 0 {
+			notice = map[string]interface{}{
+				"type":       "segment_dropped",
+				"reason":     reason,
+				"timestamp":  time.Now().UnixMilli(),
+				"start_time": float64(offset) / float64(sampleRate),
+				"end_time":   float64(offset+len(samples)) / float64(sampleRate),
 			}
-		}()
-	default:
-		logger.Warn("recognition_worker_pool_full", "session_id", sessionID, "max_workers", m.maxRecognitionWorkers)
+		}
+		// Release the slot so later results are not held back
+		m.deliverResult(session, slot, notice)
+	}
+
+	submittedAt := time.Now()
+	submitted := m.workers.submit(opts.Priority, func() {
+		if exists {
+			defer atomic.AddInt32(&session.inflight, -1)
+		}
+		queueWait := time.Since(submittedAt)
+		m.latency.observe(opts.Model, StageQueueWait, queueWait)
+
+		// Check if session context is cancelled
+		select {
+		case <-sessionCtx.Done():
+			logger.Debug("recognition_task_cancelled", "session_id", sessionID)
+			return
+		default:
+		}
+
+		m.swapMu.RLock()
+		defer m.swapMu.RUnlock()
+		decodeStart := time.Now()
+		result, ok := m.decodeSegment(sessionCtx, m.recognizerFor(opts), samples, sampleRate)
+		if !ok {
+			logger.Debug("recognition_task_cancelled", "session_id", sessionID)
+			return
+		}
+		decodeTime := time.Since(decodeStart)
+		m.latency.observe(opts.Model, StageDecode, decodeTime)
+		if exists {
+			session.recordDecode(decodeTime)
+		}
+
+		// Check again after decoding
+		select {
+		case <-sessionCtx.Done():
+			logger.Debug("recognition_result_discarded_session_closed", "session_id", sessionID)
+			return
+		default:
+		}
+
+		if result != nil {
+			start := float64(offset) / float64(sampleRate)
+			end := float64(offset+len(samples)) / float64(sampleRate)
+			transcript := postprocess.ParseResult(result.Text, result.Lang, result.Emotion, result.Event)
+			var translateTime, speakerTime time.Duration
+			if opts.Translate && m.translator != nil && transcript.Text != "" && transcript.Language != "en" {
+				translateStart := time.Now()
+				if translated, ok := m.decodeSegment(sessionCtx, m.translator, samples, sampleRate); ok && translated != nil {
+					transcript.Translation = strings.TrimSpace(translated.Text)
+				}
+				translateTime = time.Since(translateStart)
+			}
+			if exists && session.speakers != nil && transcript.Text != "" {
+				speakerStart := time.Now()
+				speaker, err := session.speakers.Identify(samples, sampleRate)
+				if err != nil {
+					logger.Debug("speaker_attribution_failed", "session_id", sessionID, "error", err)
+				}
+				transcript.Speaker = speaker
+				speakerTime = time.Since(speakerStart)
+			}
+			endToEnd := time.Since(submittedAt)
+			m.latency.observe(opts.Model, StageEndToEnd, endToEnd)
+			if threshold := time.Duration(m.cfg.Logging.SlowDecodeMs) * time.Millisecond; threshold > 0 && decodeTime > threshold {
+				logger.Warn("slow_decode",
+					"session_id", sessionID,
+					"model", modelLabel(opts.Model),
+					"language", opts.Language,
+					"priority", opts.Priority,
+					"audio_seconds", end-start,
+					"rtf", decodeTime.Seconds()/(end-start),
+					"queue_wait", queueWait,
+					"decode", decodeTime,
+					"translate", translateTime,
+					"speaker", speakerTime,
+					"end_to_end", endToEnd,
+					"batched", m.batcher != nil,
+					"threshold", threshold,
+				)
+			}
+			m.handleRecognitionResult(sessionID, slot, transcript, start, end, nil)
+		} else {
+			m.handleRecognitionResult(sessionID, slot, postprocess.Transcript{}, 0, 0, fmt.Errorf("recognition failed"))
+		}
+	}, func() {
+		logger.Warn("recognition_queue_timeout", "session_id", sessionID, "priority", opts.Priority, "queue_wait", time.Since(submittedAt))
+		drop(DropQueueTimeout)
+	})
+	if !submitted {
+		_, maxQueued := m.workers.load()
+		logger.Warn("recognition_queue_full", "session_id", sessionID, "priority", opts.Priority, "max_queued", maxQueued)
+		drop(DropQueueFull)
 	}
 }
 
+// decodeSegment decodes samples with a recognizer, batching with other sessions on GPU.
+// It returns false when the session was cancelled while waiting for a batch.
+func (m *Manager) decodeSegment(ctx context.Context, recognizer *sherpa.OfflineRecognizer, samples []float32, sampleRate int) (*sherpa.OfflineRecognizerResult, bool) {
+	stream := sherpa.NewOfflineStream(recognizer)
+	defer sherpa.DeleteOfflineStream(stream)
+	stream.AcceptWaveform(sampleRate, samples)
+	if m.batcher != nil {
+		if !m.batcher.submit(ctx, recognizer, stream) {
+			return nil, false
+		}
+	} else {
+		recognizer.Decode(stream)
+	}
+	return stream.GetResult(), true
+}
+
 // CreateSession creates a new session
-func (m *Manager) CreateSession(sessionID string, conn *websocket.Conn) (*Session, error) {
+func (m *Manager) CreateSession(sessionID string, conn Conn) (*Session, error) {
 	if m.vadPool == nil {
 		return nil, fmt.Errorf("VAD pool is not initialized")
 	}
+	if m.Draining() {
+		return nil, ErrDraining
+	}
 
 	// Create session context for cancellation propagation
 	sessionCtx, sessionCancel := context.WithCancel(m.ctx)
@@ -228,32 +591,39 @@ func (m *Manager) CreateSession(sessionID string, conn *websocket.Conn) (*Sessio
 		cancel:            sessionCancel,
 		SendQueue:         make(chan interface{}, m.cfg.Session.SendQueueSize),
 		sendDone:          make(chan struct{}),
+		sendExited:        make(chan struct{}),
 		sendErrCount:      0,
 		lastActivity:      time.Now(),
+		createdAt:         time.Now(),
+		spillTotals:       &m.spillTotals,
 		isInSpeech:        false,
 		currentSegment:    nil,
 		silenceFrameCount: 0,
 		cfg:               m.cfg,
 	}
+	if m.speakerTrackerFactory != nil {
+		session.speakers = m.speakerTrackerFactory()
+	}
 
 	// Start send goroutine
 	go session.sendLoop()
 
-	m.mu.Lock()
-	m.sessions[sessionID] = session
-	m.mu.Unlock()
+	m.sessions.set(sessionID, session)
 
 	atomic.AddInt64(&m.totalSessions, 1)
 	atomic.AddInt64(&m.activeSessions, 1)
 
+	m.publishSessionEvent(EventSessionStarted, sessionID)
+	for _, hook := range m.hooks {
+		hook.OnSessionStart(sessionID)
+	}
+
 	return session, nil
 }
 
 // GetSession retrieves a session by ID
 func (m *Manager) GetSession(sessionID string) (*Session, bool) {
-	m.mu.RLock()
-	session, exists := m.sessions[sessionID]
-	m.mu.RUnlock()
+	session, exists := m.sessions.get(sessionID)
 
 	if exists {
 		atomic.StoreInt64(&session.LastSeen, time.Now().UnixNano())
@@ -262,14 +632,25 @@ func (m *Manager) GetSession(sessionID string) (*Session, bool) {
 	return session, exists
 }
 
+// Touch marks a session as active without delivering audio, e.g. on keepalive pongs,
+// so quiet but connected sessions are not removed by the inactivity sweep
+func (m *Manager) Touch(sessionID string) {
+	session, exists := m.sessions.get(sessionID)
+
+	if exists {
+		atomic.StoreInt64(&session.LastSeen, time.Now().UnixNano())
+	}
+}
+
 // RemoveSession removes a session
 func (m *Manager) RemoveSession(sessionID string) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.removeSession(sessionID, CloseNormal)
+}
 
-	if session, exists := m.sessions[sessionID]; exists {
-		m.closeSession(session)
-		delete(m.sessions, sessionID)
+// removeSession removes a session, telling its connection why it is closed
+func (m *Manager) removeSession(sessionID string, reason CloseReason) {
+	if session, exists := m.sessions.remove(sessionID); exists {
+		m.closeSession(session, reason)
 		atomic.AddInt64(&m.activeSessions, -1)
 		logger.Info("session_removed", "session_id", sessionID)
 	}
@@ -277,6 +658,7 @@ func (m *Manager) RemoveSession(sessionID string) {
 
 // sendLoop handles the send queue for a session
 func (s *Session) sendLoop() {
+	defer close(s.sendExited)
 	defer func() {
 		if r := recover(); r != nil {
 			logger.Error("session_send_loop_panicked", "session_id", s.ID, "recover", r)
@@ -290,7 +672,29 @@ func (s *Session) sendLoop() {
 				return
 			}
 
-			if err := s.Conn.WriteJSON(msg); err != nil {
+			for {
+				conn, reattached := s.connection()
+				if conn == nil {
+					// Detached: hold the message until the client resumes or the session ends
+					select {
+					case <-reattached:
+						continue
+					case <-s.sendDone:
+						return
+					}
+				}
+
+				err := conn.WriteJSON(msg)
+				if err == nil {
+					atomic.StoreInt32(&s.sendErrCount, 0)
+					s.retainSent(msg)
+					break
+				}
+				// A resumable session keeps the message for the next connection
+				if s.detach(conn) {
+					logger.Warn("failed_to_send_message", "session_id", s.ID, "error", err, "action", "awaiting_resume")
+					continue
+				}
 				atomic.AddInt32(&s.sendErrCount, 1)
 				logger.Error("failed_to_send_message", "session_id", s.ID, "error", err)
 				if atomic.LoadInt32(&s.sendErrCount) > int32(s.cfg.Session.MaxSendErrors) {
@@ -298,9 +702,9 @@ func (s *Session) sendLoop() {
 					atomic.StoreInt32(&s.closed, 1)
 					return
 				}
-			} else {
-				atomic.StoreInt32(&s.sendErrCount, 0)
+				break
 			}
+			s.replaySpilled()
 		case <-s.sendDone:
 			return
 		}
@@ -320,20 +724,54 @@ func (m *Manager) ProcessAudioData(sessionID string, audioData []byte) error {
 		return fmt.Errorf("session %s is closed", sessionID)
 	}
 
+	if atomic.LoadInt32(&session.paused) == 1 {
+		logger.Debug("audio_ignored_session_stopped", "session_id", sessionID, "bytes", len(audioData))
+		return nil
+	}
+
+	if m.Draining() {
+		logger.Debug("audio_ignored_server_draining", "session_id", sessionID, "bytes", len(audioData))
+		return nil
+	}
+
+	if atomic.LoadInt32(&session.quotaExceeded) == 1 {
+		logger.Debug("audio_ignored_quota_exceeded", "session_id", sessionID, "bytes", len(audioData))
+		return nil
+	}
+
+	m.updateBackpressure(session)
+
+	session.processMu.Lock()
+	defer session.processMu.Unlock()
+	// The session may have been closed and its VAD instance released while waiting
+	if atomic.LoadInt32(&session.closed) == 1 {
+		return fmt.Errorf("session %s is closed", sessionID)
+	}
+
 	// Lazy VAD instance allocation
-	if session.VADInstance == nil {
-		vadInstance, err := m.vadPool.Get()
-		if err != nil {
+	if session.VADInstance == nil && m.onlineRecognizer == nil {
+		if err := m.acquireVAD(session); err != nil {
 			logger.Error("failed_to_get_vad_instance", "session_id", sessionID, "error", err)
-			return fmt.Errorf("failed to get VAD instance for session %s: %v", sessionID, err)
+			return err
+		}
+		session.vadOrigin = session.streamSamples
+		if session.padder == nil {
+			session.padder = m.newSegmentPadder(session.VADInstance.GetType())
+		}
+		if session.prefilter == nil {
+			session.prefilter = m.newEnergyPrefilter()
 		}
-		session.VADInstance = vadInstance
-		logger.Info("session_assigned_vad", "session_id", sessionID, "type", vadInstance.GetType(), "id", vadInstance.GetID())
 	}
 
 	// Update session activity
 	atomic.StoreInt64(&session.LastSeen, time.Now().UnixNano())
 	atomic.AddInt64(&m.totalMessages, 1)
+	atomic.AddInt64(&session.bytesReceived, int64(len(audioData)))
+
+	if limit := m.exceededQuota(session); limit != "" {
+		m.enforceQuota(session, limit)
+		return nil
+	}
 
 	// Validate input data
 	if len(audioData) == 0 {
@@ -341,43 +779,176 @@ func (m *Manager) ProcessAudioData(sessionID string, audioData []byte) error {
 		return fmt.Errorf("empty audio data")
 	}
 
+	// Sequenced frames pass through the jitter buffer and are decoded in sequence order
+	if session.Options().Framing == FramingSequenced {
+		session.mu.Lock()
+		if session.jitter == nil {
+			session.jitter = newJitterBuffer(m.cfg.Session.JitterBufferSize)
+		}
+		jitter := session.jitter
+		session.mu.Unlock()
+
+		payloads, err := jitter.push(audioData)
+		if err != nil {
+			logger.Warn("invalid_sequenced_frame", "session_id", sessionID, "error", err)
+			return err
+		}
+		for _, payload := range payloads {
+			if len(payload) == 0 {
+				continue
+			}
+			if err := m.decodeAudio(session, sessionID, payload); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return m.decodeAudio(session, sessionID, audioData)
+}
+
+// decodeAudio converts an audio message in the session's encoding to samples at the model
+// sample rate and runs them through the VAD
+func (m *Manager) decodeAudio(session *Session, sessionID string, audioData []byte) error {
+	// Clients streaming a WAV file send its header first; it declares the audio format
+	if !session.headerChecked {
+		session.headerChecked = true
+		if audio.IsWAVHeader(audioData) {
+			data, err := m.applyWAVHeader(session, audioData)
+			if err != nil {
+				logger.Warn("invalid_wav_header", "session_id", sessionID, "error", err)
+				return err
+			}
+			if len(data) == 0 {
+				return nil
+			}
+			audioData = data
+		}
+	}
+
+	opts := session.Options()
+	session.mu.RLock()
+	format := session.pcmFormat
+	session.mu.RUnlock()
+
+	switch opts.Encoding {
+	case EncodingMuLaw, EncodingALaw:
+		samples := decodeG711(audioData, opts.Encoding, opts.SampleRate, m.cfg.Audio.SampleRate)
+		logger.Debug("audio_decoded", "session_id", sessionID, "bytes", len(audioData), "samples", len(*samples))
+		return m.processPooledSamples(session, sessionID, samples)
+	case EncodingOpus:
+		session.mu.RLock()
+		stream := session.opus
+		session.mu.RUnlock()
+		if stream == nil {
+			return fmt.Errorf("opus decoder not initialized")
+		}
+		float32Slice, err := stream.decode(audioData, m.cfg.Audio.SampleRate)
+		if err != nil {
+			logger.Warn("opus_decode_failed", "session_id", sessionID, "error", err)
+			return err
+		}
+		logger.Debug("audio_decoded", "session_id", sessionID, "bytes", len(audioData), "samples", len(float32Slice))
+		return m.processSamples(session, sessionID, float32Slice)
+	}
+
+	// Linear PCM in any layout other than 16-bit little-endian mono is decoded frame by frame
+	layout := linearFormats[opts.Encoding]
+	if format != nil {
+		layout = *format
+	}
+	if layout != pcm16Format || opts.Channels > 1 {
+		samples := session.decodeFrames(layout, opts.Channels, opts.ChannelSelect, audioData)
+		float32Slice := audio.Resample(samples, opts.SampleRate, m.cfg.Audio.SampleRate)
+		if len(float32Slice) == 0 {
+			return nil
+		}
+		return m.processSamples(session, sessionID, float32Slice)
+	}
+
 	if len(audioData)%2 != 0 {
 		logger.Warn("invalid_audio_length", "session_id", sessionID, "length", len(audioData))
 		return fmt.Errorf("invalid audio data length: %d", len(audioData))
 	}
 
-	// Convert audio data
-	numSamples := len(audioData) / 2
-	samples := float32Pool.Get()
-	var float32Slice []float32
-	if samples == nil {
-		float32Slice = getFloat32PoolSlice(m.cfg.Audio.ChunkSize)
-	} else {
-		float32Slice = samples.([]float32)
+	// Convert audio data, resampling audio from clients sending at a different rate than the
+	// models expect
+	samples := decodePCM16(audioData, m.cfg.Audio.NormalizeFactor, opts.SampleRate, m.cfg.Audio.SampleRate)
+	logger.Debug("audio_converted", "session_id", sessionID, "bytes", len(audioData), "samples", len(*samples))
+	return m.processPooledSamples(session, sessionID, samples)
+}
+
+// processPooledSamples runs samples decoded into a buffer from sampleBuffers through the VAD
+// and puts the buffer back. The VAD copies what it keeps, except that a Silero call that timed
+// out may still be reading the samples, so that buffer is left to the garbage collector.
+func (m *Manager) processPooledSamples(session *Session, sessionID string, samples *[]float32) error {
+	err := m.processSamples(session, sessionID, *samples)
+	if !errors.Is(err, errVADTimeout) {
+		sampleBuffers.put(samples)
 	}
-	if cap(float32Slice) < numSamples {
-		float32Slice = make([]float32, numSamples)
+	return err
+}
+
+// processSamples runs decoded samples at the model sample rate through the session's VAD
+func (m *Manager) processSamples(session *Session, sessionID string, float32Slice []float32) error {
+	if interval := session.Options().AudioLevelInterval; interval > 0 {
+		m.trackAudioLevel(session, float32Slice, interval)
 	}
-	float32Slice = float32Slice[:numSamples]
-	defer float32Pool.Put(float32Slice)
+	offset := session.streamSamples
+	session.streamSamples += len(float32Slice)
+	atomic.AddInt64(&session.audioSamples, int64(len(float32Slice)))
 
-	normalizeFactor := m.cfg.Audio.NormalizeFactor
-	for i := 0; i < numSamples; i++ {
-		sample := int16(audioData[i*2]) | int16(audioData[i*2+1])<<8
-		float32Slice[i] = float32(sample) / normalizeFactor
+	if m.onlineRecognizer != nil {
+		return m.processOnline(session, sessionID, float32Slice)
 	}
 
-	logger.Debug("audio_converted", "session_id", sessionID, "bytes", len(audioData), "samples", numSamples)
+	// Segments completed by this message are submitted once the audio after them is padded
+	if padder := session.padder; padder != nil {
+		padder.write(float32Slice)
+		defer func() {
+			m.submitPadded(session, sessionID, padder.ready())
+			padder.trim()
+		}()
+	}
 
 	// Process based on VAD type
-	switch session.VADInstance.GetType() {
+	vadType := session.VADInstance.GetType()
+	if session.prefilter != nil && !session.prefilter.pass(float32Slice, session.speaking || session.isInSpeech) {
+		// Silero positions count only the samples it is given
+		if vadType == pool.SILERO_TYPE {
+			session.vadOrigin += len(float32Slice)
+		}
+		atomic.AddInt64(&m.prefilteredSamples, int64(len(float32Slice)))
+		return nil
+	}
+	vadStart := time.Now()
+	var err error
+	switch vadType {
 	case pool.SILERO_TYPE:
-		return m.processSileroVAD(session, sessionID, float32Slice)
+		err = m.processSileroVAD(session, sessionID, float32Slice)
 	case pool.TEN_VAD_TYPE:
-		return m.processTenVAD(session, sessionID, float32Slice)
+		err = m.processTenVAD(session, sessionID, float32Slice, offset)
 	default:
-		return fmt.Errorf("unsupported VAD type: %s", session.VADInstance.GetType())
+		return fmt.Errorf("unsupported VAD type: %s", vadType)
 	}
+
+	if threshold := time.Duration(m.cfg.Logging.SlowVADMs) * time.Millisecond; threshold > 0 && len(float32Slice) > 0 {
+		if elapsed := time.Since(vadStart); elapsed > threshold {
+			audioSeconds := float64(len(float32Slice)) / float64(m.cfg.Audio.SampleRate)
+			logger.Warn("slow_vad",
+				"session_id", sessionID,
+				"vad_type", vadType,
+				"model", modelLabel(session.Options().Model),
+				"samples", len(float32Slice),
+				"audio_seconds", audioSeconds,
+				"rtf", elapsed.Seconds()/audioSeconds,
+				"stream_seconds", float64(offset)/float64(m.cfg.Audio.SampleRate),
+				"duration", elapsed,
+				"threshold", threshold,
+			)
+		}
+	}
+	return err
 }
 
 // processSileroVAD processes audio with Silero VAD
@@ -392,6 +963,17 @@ func (m *Manager) processSileroVAD(session *Session, sessionID string, float32Sl
 	vadCtx, vadCancel := context.WithTimeout(context.Background(), vadTimeout)
 	defer vadCancel()
 
+	// A pass that timed out earlier must end before the instance is used again
+	if session.vadPending != nil {
+		select {
+		case <-session.vadPending:
+			session.vadPending = nil
+		case <-vadCtx.Done():
+			logger.Warn("vad_processing_timeout", "session_id", sessionID, "pending", true)
+			return errVADTimeout
+		}
+	}
+
 	vadDone := make(chan struct{})
 	go func() {
 		defer close(vadDone)
@@ -403,13 +985,21 @@ func (m *Manager) processSileroVAD(session *Session, sessionID string, float32Sl
 		// VAD processing complete
 	case <-vadCtx.Done():
 		logger.Warn("vad_processing_timeout", "session_id", sessionID)
-		return fmt.Errorf("VAD processing timeout")
+		session.vadPending = vadDone
+		return errVADTimeout
+	}
+	isSpeech := sileroInstance.VAD.IsSpeech()
+	if isSpeech && !session.speaking && session.padder != nil {
+		session.padder.markOnset()
 	}
+	m.setSpeaking(session, sessionID, isSpeech)
 
 	// Process speech segments
 	segmentCount := 0
 	var speechSegments [][]float32
+	var offsets []int
 	sampleRate := m.cfg.Audio.SampleRate
+	rules := m.endpointRules(session.Options(), pool.SILERO_TYPE)
 
 	for !sileroInstance.VAD.IsEmpty() {
 		segment := sileroInstance.VAD.Front()
@@ -428,51 +1018,40 @@ func (m *Manager) processSileroVAD(session *Session, sessionID string, float32Sl
 			}
 
 			duration := float64(len(segment.Samples)) / float64(sampleRate)
-			minSpeechDuration := float64(m.cfg.VAD.SileroVAD.MinSpeechDuration)
-			if duration < minSpeechDuration {
-				logger.Debug("skipping_short_segment", "session_id", sessionID, "segment_index", segmentCount, "duration", duration, "min", minSpeechDuration)
-				continue
-			}
-
-			maxDuration := float64(m.cfg.VAD.SileroVAD.MaxSpeechDuration)
-			if duration > maxDuration {
-				logger.Warn("segment_too_long", "session_id", sessionID, "segment_index", segmentCount, "duration", duration, "max", maxDuration)
-				maxSamples := int(maxDuration * float64(sampleRate))
-				segment.Samples = segment.Samples[:maxSamples]
-			}
-
 			speechSegments = append(speechSegments, segment.Samples)
+			offsets = append(offsets, session.vadOrigin+segment.Start)
 			logger.Debug("collected_segment", "session_id", sessionID, "segment_index", segmentCount, "samples", len(segment.Samples), "duration", duration)
 		} else {
 			logger.Warn("empty_speech_segment", "session_id", sessionID, "segment_index", segmentCount)
 		}
 	}
 
-	// Process collected speech segments using worker pool
-	for _, samples := range speechSegments {
-		m.submitRecognitionTask(session.ctx, samples, sampleRate, sessionID)
+	// Process collected speech segments using worker pool; Silero segments end at the speech
+	for i, samples := range speechSegments {
+		m.submitUtterance(session, sessionID, samples, offsets[i], len(samples), rules)
 	}
 
 	return nil
 }
 
-// processTenVAD processes audio with TEN-VAD
-func (m *Manager) processTenVAD(session *Session, sessionID string, float32Slice []float32) error {
+// processTenVAD processes audio with TEN-VAD. offset is the stream position of the first sample.
+func (m *Manager) processTenVAD(session *Session, sessionID string, float32Slice []float32, offset int) error {
 	tenVADInstance, ok := session.VADInstance.(*pool.TenVADInstance)
 	if !ok {
 		return fmt.Errorf("invalid TEN-VAD instance type")
 	}
 
-	hopSize := m.cfg.VAD.TenVAD.HopSize
-	minSpeechFrames := m.cfg.VAD.TenVAD.MinSpeechFrames
-	maxSilenceFrames := m.cfg.VAD.TenVAD.MaxSilenceFrames
+	opts := session.Options()
+	tenVAD := m.sessionVADConfig(opts).TenVAD
+	hopSize := tenVAD.HopSize
+	maxSilenceFrames := tenVAD.MaxSilenceFrames
+	rules := m.endpointRules(opts, pool.TEN_VAD_TYPE)
 	sampleRate := m.cfg.Audio.SampleRate
+	sendProbs := opts.VADProbs
 
-	// Get or create int16 buffer from pool for frame processing
-	var int16Buffer []int16
-	if pooled := int16Pool.Get(); pooled != nil {
-		int16Buffer = pooled.([]int16)
-	}
+	// Frames are converted to int16 in a pooled buffer
+	frameBuffer := frameBuffers.get(hopSize)
+	defer frameBuffers.put(frameBuffer)
 
 	// Frame processing
 	for i := 0; i < len(float32Slice); i += hopSize {
@@ -482,81 +1061,211 @@ func (m *Manager) processTenVAD(session *Session, sessionID string, float32Slice
 		}
 		frame := float32Slice[i:end]
 
-		// Reuse or allocate int16 buffer
-		frameLen := len(frame)
-		if int16Buffer == nil || cap(int16Buffer) < frameLen {
-			int16Buffer = make([]int16, frameLen)
-		}
-		int16Frame := int16Buffer[:frameLen]
+		int16Frame := (*frameBuffer)[:len(frame)]
 		for j, f := range frame {
 			int16Frame[j] = int16(f * 32768)
 		}
 
-		_, flag, err := pool.GetInstance().ProcessAudio(tenVADInstance.Handle, int16Frame)
+		prob, flag, err := pool.GetInstance().ProcessAudio(tenVADInstance.Handle, int16Frame)
 		if err != nil {
-			// Return buffer to pool before returning error
-			if int16Buffer != nil {
-				int16Pool.Put(int16Buffer)
-			}
+			tenVADInstance.MarkFailed(err)
 			return fmt.Errorf("TEN-VAD ProcessAudio error: %v", err)
 		}
+		if sendProbs {
+			m.sendVADProbability(session, prob, flag == 1, offset+i)
+		}
 
 		if flag == 1 {
 			if !session.isInSpeech {
 				logger.Debug("speech_started", "session_id", sessionID)
 				session.isInSpeech = true
-				session.currentSegment = make([]float32, 0)
+				session.currentSegment = nil
 				session.silenceFrameCount = 0
+				session.segmentStart = offset + i
+				session.utteranceStart = session.segmentStart
+				if session.padder != nil {
+					session.padder.markOnset()
+				}
+				m.setSpeaking(session, sessionID, true)
 			}
 			session.currentSegment = append(session.currentSegment, frame...)
 			session.silenceFrameCount = 0
 
 			// Check if segment exceeds maximum length to prevent memory exhaustion
-			if len(session.currentSegment) >= MaxSegmentSamples {
+			if len(session.currentSegment) >= rules.maxSamples {
 				logger.Warn("segment_max_length_exceeded", "session_id", sessionID,
-					"samples", len(session.currentSegment), "max", MaxSegmentSamples)
-				// Force recognition of current segment
-				segmentCopy := make([]float32, len(session.currentSegment))
-				copy(segmentCopy, session.currentSegment)
-				m.submitRecognitionTask(session.ctx, segmentCopy, sampleRate, sessionID)
+					"samples", len(session.currentSegment), "max", rules.maxSamples)
+				// Force recognition of current segment, handing it to the task
+				segment := session.currentSegment
+				m.submitSegment(session, sessionID, segment, session.segmentStart, false)
 				// Reset segment state
-				session.currentSegment = make([]float32, 0)
+				session.segmentStart += len(segment)
+				session.currentSegment = nil
 			}
 		} else {
 			if session.isInSpeech {
 				session.silenceFrameCount++
 				session.currentSegment = append(session.currentSegment, frame...)
 				if session.silenceFrameCount >= maxSilenceFrames {
-					frameCount := len(session.currentSegment) / hopSize
-					if frameCount >= minSpeechFrames {
-						logger.Debug("speech_segment_completed", "session_id", sessionID, "samples", len(session.currentSegment), "frames", frameCount)
-						duration := float64(len(session.currentSegment)) / float64(sampleRate)
-						logger.Info("asr_segment_stats", "duration", duration, "samples", len(session.currentSegment))
-						segmentCopy := make([]float32, len(session.currentSegment))
-						copy(segmentCopy, session.currentSegment)
-						// Use worker pool for recognition task
-						m.submitRecognitionTask(session.ctx, segmentCopy, sampleRate, sessionID)
-					} else {
-						logger.Debug("speech_segment_too_short", "session_id", sessionID, "frames", frameCount)
-					}
+					logger.Debug("speech_segment_completed", "session_id", sessionID, "samples", len(session.currentSegment))
+					duration := float64(len(session.currentSegment)) / float64(sampleRate)
+					logger.Info("asr_segment_stats", "duration", duration, "samples", len(session.currentSegment))
+					// The segment is handed to the recognition task; a new one starts with the next speech
+					m.submitUtterance(session, sessionID, session.currentSegment, session.segmentStart, session.tenVADSpeech(hopSize), rules)
 					session.isInSpeech = false
 					session.silenceFrameCount = 0
 					session.currentSegment = nil
+					m.setSpeaking(session, sessionID, false)
 				}
 			}
 		}
 	}
 
-	// Return int16 buffer to pool
-	if int16Buffer != nil {
-		int16Pool.Put(int16Buffer)
+	return nil
+}
+
+// FlushSession forces recognition of any buffered speech, waits for in-flight recognition
+// to finish and then queues a "flushed" message. Because the send queue is FIFO, every
+// result for audio received before the flush is delivered before that message.
+func (m *Manager) FlushSession(sessionID string) error {
+	session, exists := m.GetSession(sessionID)
+	if !exists {
+		return fmt.Errorf("session %s not found", sessionID)
+	}
+	if atomic.LoadInt32(&session.closed) == 1 {
+		return fmt.Errorf("session %s is closed", sessionID)
+	}
+
+	if err := m.flushBuffered(session, sessionID); err != nil {
+		return err
 	}
 
+	deadline := time.Now().Add(time.Duration(m.cfg.Response.Timeout) * time.Second)
+	for atomic.LoadInt32(&session.inflight) > 0 {
+		if time.Now().After(deadline) {
+			logger.Warn("flush_timeout", "session_id", sessionID, "inflight", atomic.LoadInt32(&session.inflight))
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case session.SendQueue <- map[string]interface{}{
+		"type":      "flushed",
+		"timestamp": time.Now().UnixMilli(),
+	}:
+	default:
+		session.dropMessage()
+		logger.Warn("session_send_queue_full", "session_id", sessionID, "action", "dropped_flushed_message")
+		return fmt.Errorf("send queue full")
+	}
 	return nil
 }
 
-// handleRecognitionResult handles recognition results
-func (m *Manager) handleRecognitionResult(sessionID, result string, err error) {
+// flushBuffered submits the speech buffered in the jitter buffer, the VAD and the padder for
+// recognition
+func (m *Manager) flushBuffered(session *Session, sessionID string) error {
+	session.processMu.Lock()
+	defer session.processMu.Unlock()
+	if atomic.LoadInt32(&session.closed) == 1 {
+		return fmt.Errorf("session %s is closed", sessionID)
+	}
+
+	// Release frames still waiting for missing predecessors
+	session.mu.RLock()
+	jitter := session.jitter
+	session.mu.RUnlock()
+	if jitter != nil {
+		for _, payload := range jitter.flush() {
+			if len(payload) == 0 {
+				continue
+			}
+			if err := m.decodeAudio(session, sessionID, payload); err != nil {
+				logger.Warn("failed_to_process_buffered_audio", "session_id", sessionID, "error", err)
+			}
+		}
+	}
+
+	if m.onlineRecognizer != nil {
+		m.flushOnline(session, sessionID)
+	}
+
+	switch instance := session.VADInstance.(type) {
+	case *pool.SileroVADInstance:
+		if session.vadBusy() {
+			logger.Warn("flush_skipped_vad_busy", "session_id", sessionID)
+			break
+		}
+		rules := m.endpointRules(session.Options(), pool.SILERO_TYPE)
+		instance.VAD.Flush()
+		for !instance.VAD.IsEmpty() {
+			segment := instance.VAD.Front()
+			instance.VAD.Pop()
+			if segment != nil && len(segment.Samples) > 0 {
+				m.submitUtterance(session, sessionID, segment.Samples, session.vadOrigin+segment.Start, len(segment.Samples), rules)
+			}
+		}
+		instance.VAD.Reset()
+		session.vadOrigin = session.streamSamples
+	case *pool.TenVADInstance:
+		if session.isInSpeech {
+			opts := session.Options()
+			hopSize := m.sessionVADConfig(opts).TenVAD.HopSize
+			m.submitUtterance(session, sessionID, session.currentSegment, session.segmentStart, session.tenVADSpeech(hopSize), m.endpointRules(opts, pool.TEN_VAD_TYPE))
+		}
+		session.isInSpeech = false
+		session.silenceFrameCount = 0
+		session.currentSegment = nil
+	}
+	if session.padder != nil {
+		m.submitPadded(session, sessionID, session.padder.flush())
+	}
+	m.setSpeaking(session, sessionID, false)
+	return nil
+}
+
+// vadBusy reports whether a Silero pass that timed out is still using the VAD instance
+func (s *Session) vadBusy() bool {
+	if s.vadPending == nil {
+		return false
+	}
+	select {
+	case <-s.vadPending:
+		s.vadPending = nil
+		return false
+	default:
+		return true
+	}
+}
+
+// setSpeaking queues a speech_start or speech_end message when the session's speech state
+// changes, so clients can react to voice activity before a result is available
+func (m *Manager) setSpeaking(session *Session, sessionID string, speaking bool) {
+	if session.speaking == speaking {
+		return
+	}
+	session.speaking = speaking
+
+	eventType := "speech_end"
+	if speaking {
+		eventType = "speech_start"
+	}
+	select {
+	case session.SendQueue <- map[string]interface{}{
+		"type":      eventType,
+		"timestamp": time.Now().UnixMilli(),
+	}:
+	default:
+		session.dropMessage()
+		logger.Warn("session_send_queue_full", "session_id", sessionID, "action", "dropped_"+eventType)
+	}
+}
+
+// handleRecognitionResult handles recognition results. start and end locate the segment in
+// seconds since the start of the session's audio stream.
+func (m *Manager) handleRecognitionResult(sessionID string, slot uint64, transcript postprocess.Transcript, start, end float64, err error) {
+	transcript.Text = m.rules.Apply(transcript.Text)
+	result := transcript.Text
 	session, exists := m.GetSession(sessionID)
 	if !exists {
 		logger.Warn("recognition_session_not_found", "session_id", sessionID)
@@ -569,29 +1278,97 @@ func (m *Manager) handleRecognitionResult(sessionID, result string, err error) {
 	}
 
 	if err == nil && len(result) > 0 {
+		event := ResultEvent{
+			SessionID:   sessionID,
+			Text:        result,
+			Timestamp:   time.Now().UnixMilli(),
+			Duration:    end - start,
+			StartTime:   start,
+			EndTime:     end,
+			Emotion:     transcript.Emotion,
+			Events:      transcript.Events,
+			Translation: transcript.Translation,
+			Speaker:     transcript.Speaker.Label,
+			SpeakerID:   transcript.Speaker.ID,
+			SpeakerName: transcript.Speaker.Name,
+		}
+		if metadata := session.Metadata(); metadata != (Metadata{}) {
+			event.Metadata = &metadata
+		}
+		for _, hook := range m.hooks {
+			hook.OnResult(&event)
+		}
+		for _, publisher := range m.publishers {
+			publisher.Publish(event)
+		}
+
 		response := map[string]interface{}{
-			"type":      "final",
-			"text":      result,
-			"timestamp": time.Now().UnixMilli(),
+			"type":       "final",
+			"text":       event.Text,
+			"timestamp":  event.Timestamp,
+			"start_time": event.StartTime,
+			"end_time":   event.EndTime,
 		}
-		select {
-		case session.SendQueue <- response:
-			// Log result length instead of content to prevent sensitive data exposure
-			logger.Info("recognition_result_queued", "session_id", sessionID, "result_length", len(result))
-		default:
-			logger.Warn("recognition_result_dropped", "session_id", sessionID)
+		if transcript.Language != "" {
+			response["language"] = transcript.Language
 		}
+		if event.Emotion != "" {
+			response["emotion"] = event.Emotion
+		}
+		if len(event.Events) > 0 {
+			response["events"] = event.Events
+		}
+		if event.Translation != "" {
+			response["translation"] = event.Translation
+		}
+		if event.Speaker != "" {
+			response["speaker"] = event.Speaker
+		}
+		if event.SpeakerID != "" {
+			response["speaker_id"] = event.SpeakerID
+			response["speaker_name"] = event.SpeakerName
+		}
+		if event.Metadata != nil {
+			response["metadata"] = event.Metadata
+		}
+		if len(event.Extra) > 0 {
+			response["extra"] = event.Extra
+		}
+		m.deliverResult(session, slot, response)
 		return
 	}
 
 	if err != nil {
 		logger.Error("recognition_error", "session_id", sessionID, "error", err)
 	}
+	m.deliverResult(session, slot, nil)
+}
+
+// publishSessionEvent notifies publishers that consume lifecycle events
+func (m *Manager) publishSessionEvent(eventType, sessionID string) {
+	event := SessionEvent{
+		Type:      eventType,
+		SessionID: sessionID,
+		Timestamp: time.Now().UnixMilli(),
+	}
+	for _, publisher := range m.publishers {
+		if p, ok := publisher.(SessionEventPublisher); ok {
+			p.PublishSessionEvent(event)
+		}
+	}
 }
 
 // closeSession closes a session
-func (m *Manager) closeSession(session *Session) {
+func (m *Manager) closeSession(session *Session, reason CloseReason) {
 	if atomic.CompareAndSwapInt32(&session.closed, 0, 1) {
+		m.publishSessionEvent(EventSessionEnded, session.ID)
+		if len(m.hooks) > 0 {
+			info := session.info(time.Now())
+			for _, hook := range m.hooks {
+				hook.OnSessionEnd(info, reason)
+			}
+		}
+
 		// Cancel session context to stop any in-progress recognition tasks
 		if session.cancel != nil {
 			session.cancel()
@@ -602,23 +1379,79 @@ func (m *Manager) closeSession(session *Session) {
 			<-session.SendQueue
 		}
 
-		if session.VADInstance != nil && m.vadPool != nil {
-			m.vadPool.Put(session.VADInstance)
-			session.VADInstance = nil
-			logger.Info("vad_instance_returned", "session_id", session.ID)
+		// Waits for a message being processed, which then sees the session closed
+		session.processMu.Lock()
+		m.releaseVAD(session)
+
+		session.mu.Lock()
+		if session.opus != nil {
+			session.opus.close()
+			session.opus = nil
 		}
+		if session.online != nil {
+			sherpa.DeleteOnlineStream(session.online)
+			session.online = nil
+		}
+		if session.jitter != nil {
+			stats := session.jitter.snapshot()
+			logger.Info("session_jitter_stats", "session_id", session.ID, "received", stats.Received, "duplicates", stats.Duplicates, "reordered", stats.Reordered, "lost", stats.Lost, "gaps", stats.Gaps, "max_gap", stats.MaxGap)
+		}
+		session.mu.Unlock()
+		session.processMu.Unlock()
 
-		if session.Conn != nil {
-			session.Conn.Close()
+		if conn, _ := session.connection(); conn != nil {
+			m.closeWithSummary(session, conn, reason)
 		}
 	}
 }
 
+// releaseVAD returns the session's VAD instance to its pool, or destroys a dedicated one. It
+// is called with processMu held. An instance a timed-out Silero pass is still using is
+// released once that pass returns.
+func (m *Manager) releaseVAD(session *Session) {
+	instance, vadPool := session.VADInstance, session.vadPool
+	busy := session.vadBusy()
+	pending := session.vadPending
+	session.VADInstance, session.vadPool, session.vadPending = nil, nil, nil
+	if instance == nil {
+		return
+	}
+
+	release := func() {
+		if vadPool != nil {
+			vadPool.Put(instance)
+			logger.Info("vad_instance_returned", "session_id", session.ID)
+			return
+		}
+		// Dedicated instances created for the session's VAD parameters
+		instance.Destroy()
+		m.releaseDedicatedVAD()
+	}
+	if busy {
+		logger.Warn("vad_release_deferred", "session_id", session.ID)
+		go func() {
+			<-pending
+			release()
+		}()
+		return
+	}
+	release()
+}
+
+// SetRules sets the replacement rules applied to every transcript.
+// It must be called before the manager starts processing audio.
+func (m *Manager) SetRules(rules *postprocess.Rules) {
+	m.rules = rules
+}
+
+// AddPublisher registers a publisher for final results.
+// It must be called before the manager starts processing audio.
+func (m *Manager) AddPublisher(publisher ResultPublisher) {
+	m.publishers = append(m.publishers, publisher)
+}
+
 // GetStats returns manager statistics
 func (m *Manager) GetStats() map[string]interface{} {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
 	var poolStats map[string]interface{}
 	if m.vadPool != nil {
 		poolStats = m.vadPool.GetStats()
@@ -626,13 +1459,29 @@ func (m *Manager) GetStats() map[string]interface{} {
 		poolStats = map[string]interface{}{"status": "not_initialized"}
 	}
 
-	return map[string]interface{}{
+	stats := map[string]interface{}{
 		"total_sessions":   atomic.LoadInt64(&m.totalSessions),
 		"active_sessions":  atomic.LoadInt64(&m.activeSessions),
 		"total_messages":   atomic.LoadInt64(&m.totalMessages),
-		"current_sessions": len(m.sessions),
+		"current_sessions": m.sessions.len(),
 		"pool_stats":       poolStats,
 	}
+	stats["spilled_results"] = atomic.LoadInt64(&m.spillTotals.spilled)
+	stats["recovered_results"] = atomic.LoadInt64(&m.spillTotals.recovered)
+	stats["recognition_workers"] = m.workers.stats()
+	stats["recognition_latency"] = m.latency.stats()
+	stats["per_session"] = m.sessionStats()
+	stats["dedicated_vad_instances"] = atomic.LoadInt64(&m.dedicatedVADs)
+	if m.cfg.VAD.Prefilter.Enabled {
+		stats["vad_prefilter"] = map[string]interface{}{
+			"threshold_db":    m.cfg.VAD.Prefilter.ThresholdDB,
+			"skipped_seconds": float64(atomic.LoadInt64(&m.prefilteredSamples)) / float64(m.cfg.Audio.SampleRate),
+		}
+	}
+	if m.batcher != nil {
+		stats["batch_decoding"] = m.batcher.stats()
+	}
+	return stats
 }
 
 // Shutdown shuts down the manager
@@ -645,13 +1494,20 @@ func (m *Manager) Shutdown() {
 		m.cleanupTicker.Stop()
 	}
 
-	m.mu.Lock()
-	for sessionID, session := range m.sessions {
-		logger.Info("closing_session", "session_id", sessionID)
-		m.closeSession(session)
+	for _, session := range m.sessions.snapshot() {
+		if m.sessions.compareAndRemove(session.ID, session) {
+			logger.Info("closing_session", "session_id", session.ID)
+			m.closeSession(session, CloseShutdown)
+		}
+	}
+	m.waitForSummaries()
+
+	m.vadPoolsMu.Lock()
+	for vadType, vadPool := range m.vadPools {
+		logger.Info("shutting_down_session_vad_pool", "type", vadType)
+		vadPool.Shutdown()
 	}
-	m.sessions = make(map[string]*Session)
-	m.mu.Unlock()
+	m.vadPoolsMu.Unlock()
 
 	logger.Info("session_manager_shutdown_complete")
 }
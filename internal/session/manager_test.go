@@ -0,0 +1,62 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"asr_server/internal/pool"
+)
+
+// fakeVADInstance is a VAD instance recording whether it was destroyed
+type fakeVADInstance struct {
+	destroyed chan struct{}
+}
+
+func (f *fakeVADInstance) GetID() int                  { return 0 }
+func (f *fakeVADInstance) GetType() string             { return pool.SILERO_TYPE }
+func (f *fakeVADInstance) IsInUse() bool               { return true }
+func (f *fakeVADInstance) SetInUse(inUse bool)         {}
+func (f *fakeVADInstance) GetLastUsed() int64          { return 0 }
+func (f *fakeVADInstance) SetLastUsed(timestamp int64) {}
+func (f *fakeVADInstance) Reset() error                { return nil }
+func (f *fakeVADInstance) Destroy() error {
+	close(f.destroyed)
+	return nil
+}
+
+func TestReleaseVAD(t *testing.T) {
+	m := &Manager{}
+
+	t.Run("idle instance", func(t *testing.T) {
+		instance := &fakeVADInstance{destroyed: make(chan struct{})}
+		session := &Session{VADInstance: instance}
+		m.releaseVAD(session)
+		select {
+		case <-instance.destroyed:
+		default:
+			t.Fatal("idle instance not released")
+		}
+		if session.VADInstance != nil {
+			t.Error("session still holds the released instance")
+		}
+	})
+
+	t.Run("instance used by a timed-out pass", func(t *testing.T) {
+		instance := &fakeVADInstance{destroyed: make(chan struct{})}
+		pending := make(chan struct{})
+		session := &Session{VADInstance: instance, vadPending: pending}
+		m.releaseVAD(session)
+		select {
+		case <-instance.destroyed:
+			t.Fatal("instance released while a pass is using it")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		close(pending)
+		select {
+		case <-instance.destroyed:
+		case <-time.After(time.Second):
+			t.Fatal("instance not released once the pass returned")
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package session
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"asr_server/internal/logger"
+)
+
+// MaxMetadataLength bounds each metadata field sent in a hello message
+const MaxMetadataLength = 128
+
+// Metadata identifies the client application behind a session, so results, logs and
+// events can be correlated with an application user. It is sent in the hello message.
+type Metadata struct {
+	DeviceID string `json:"device_id,omitempty"`
+	UserID   string `json:"user_id,omitempty"`
+	Locale   string `json:"locale,omitempty"` // BCP 47 tag such as zh-CN, informational
+	// Audio codec the client will send, one of ValidEncodings; it selects the encoding
+	// unless the message sets one explicitly
+	Codec string `json:"codec,omitempty"`
+}
+
+// Validate checks that the metadata fields are short single-line strings
+func (md *Metadata) Validate() error {
+	fields := []struct{ name, value string }{
+		{"device_id", md.DeviceID}, {"user_id", md.UserID}, {"locale", md.Locale}, {"codec", md.Codec},
+	}
+	for _, field := range fields {
+		if len(field.value) > MaxMetadataLength || strings.ContainsAny(field.value, "\r\n") {
+			return fmt.Errorf("invalid %s, must be a single line of at most %d bytes", field.name, MaxMetadataLength)
+		}
+	}
+	if md.Codec != "" && !slices.Contains(ValidEncodings, md.Codec) {
+		return fmt.Errorf("unsupported codec %q, must be one of %v", md.Codec, ValidEncodings)
+	}
+	return nil
+}
+
+// Metadata returns the metadata the client sent in its hello message
+func (s *Session) Metadata() Metadata {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.metadata
+}
+
+// hello handles the hello handshake: it records the client metadata and applies any options
+// the message carries. It must be the first message of the session, sent before any audio.
+func (m *Manager) hello(session *Session, msg ControlMessage) error {
+	if err := msg.Metadata.Validate(); err != nil {
+		return err
+	}
+
+	session.mu.RLock()
+	received := session.helloReceived
+	session.mu.RUnlock()
+	if received || atomic.LoadInt64(&session.bytesReceived) > 0 {
+		return fmt.Errorf("hello must be sent once, before any audio")
+	}
+
+	opts := msg.Options
+	if opts.Encoding == "" {
+		opts.Encoding = msg.Metadata.Codec
+	}
+	if err := m.Configure(session.ID, opts); err != nil {
+		return err
+	}
+
+	session.mu.Lock()
+	session.metadata = msg.Metadata
+	session.helloReceived = true
+	session.mu.Unlock()
+
+	logger.Info("session_hello", "session_id", session.ID, "device_id", msg.Metadata.DeviceID, "user_id", msg.Metadata.UserID, "locale", msg.Metadata.Locale, "codec", msg.Metadata.Codec)
+	return nil
+}
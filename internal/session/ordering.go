@@ -0,0 +1,116 @@
+package session
+
+import (
+	"sync"
+
+	"asr_server/internal/logger"
+)
+
+// resultOrder releases the results of a session's recognition tasks in the order their
+// segments were submitted and numbers them with a "seq" field starting at 1. Tasks run
+// concurrently on the worker pool and can finish out of order.
+//
+// With the acks option, results written to the client are also retained until acknowledged
+// so they can be retransmitted when the client resumes the session after a disconnect.
+type resultOrder struct {
+	mu      sync.Mutex
+	next    uint64                            // Next slot to reserve
+	release uint64                            // Next slot to release
+	ready   map[uint64]map[string]interface{} // Completed slots waiting for earlier ones, nil for no result
+	seq     uint64                            // Last sequence number assigned
+
+	unacked []map[string]interface{} // Written results not yet acknowledged, in seq order
+	spilled []map[string]interface{} // Released results waiting for room in the send queue, see spill.go
+}
+
+// reserve returns the slot of a segment about to be recognized
+func (o *resultOrder) reserve() uint64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	slot := o.next
+	o.next++
+	return slot
+}
+
+// complete records the result of a slot, nil when the segment produced none, and passes the
+// results that can now be delivered to deliver, numbered and in order. deliver is called with
+// the lock held so concurrent completions cannot reorder them and must not block.
+func (o *resultOrder) complete(slot uint64, response map[string]interface{}, deliver func(map[string]interface{})) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.ready == nil {
+		o.ready = make(map[uint64]map[string]interface{})
+	}
+	o.ready[slot] = response
+
+	for {
+		response, done := o.ready[o.release]
+		if !done {
+			return
+		}
+		delete(o.ready, o.release)
+		o.release++
+		if response != nil {
+			o.seq++
+			response["seq"] = o.seq
+			deliver(response)
+		}
+	}
+}
+
+// retain keeps a written result until the client acknowledges it, dropping the oldest
+// beyond limit
+func (o *resultOrder) retain(msg map[string]interface{}, limit int) (dropped bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.unacked = append(o.unacked, msg)
+	if limit > 0 && len(o.unacked) > limit {
+		o.unacked = o.unacked[1:]
+		return true
+	}
+	return false
+}
+
+// ack forgets the retained results numbered up to and including seq
+func (o *resultOrder) ack(seq uint64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	n := 0
+	for n < len(o.unacked) && resultSeqOf(o.unacked[n]) <= seq {
+		n++
+	}
+	o.unacked = o.unacked[n:]
+}
+
+// pending returns the retained results that have not been acknowledged
+func (o *resultOrder) pending() []map[string]interface{} {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]map[string]interface{}(nil), o.unacked...)
+}
+
+// resultSeqOf returns the sequence number of a numbered result, 0 for other messages
+func resultSeqOf(msg map[string]interface{}) uint64 {
+	seq, _ := msg["seq"].(uint64)
+	return seq
+}
+
+// deliverResult completes a recognition slot and queues the results it releases
+func (m *Manager) deliverResult(session *Session, slot uint64, response map[string]interface{}) {
+	session.order.complete(slot, response, func(released map[string]interface{}) {
+		m.queueResult(session, released)
+	})
+	m.updateBackpressure(session)
+}
+
+// retainSent keeps a result the send loop wrote while the session uses acknowledgements
+func (s *Session) retainSent(msg interface{}) {
+	response, ok := msg.(map[string]interface{})
+	if !ok || resultSeqOf(response) == 0 || !s.Options().Acks {
+		return
+	}
+	if s.order.retain(response, s.cfg.Session.SendQueueSize) {
+		logger.Warn("unacked_results_limit_reached", "session_id", s.ID, "action", "dropped_oldest")
+	}
+}
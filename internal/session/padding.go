@@ -0,0 +1,144 @@
+package session
+
+// segmentPadder adds audio around VAD segments, since the VADs start and end segments close
+// to the detected speech and clip word onsets. It keeps the recent audio of the stream for
+// the padding before a segment and holds each segment until the audio after it has arrived,
+// so segments are delayed by at most the padding after them. Padding never reaches into the
+// previous segment. It is used only from the session's audio path.
+type segmentPadder struct {
+	pre, post int // Padding in samples
+	keep      int // Samples of history kept between messages
+
+	history  []float32 // Recent audio of the stream, ending at stream position end
+	end      int
+	onset    []float32 // History when speech last started, ending at stream position onsetEnd
+	onsetEnd int
+	last     int // Stream position the last segment is padded to
+	pending  []paddedSegment
+}
+
+// paddedSegment is a segment waiting for the audio after it
+type paddedSegment struct {
+	samples []float32
+	start   int // Stream position of the first sample
+	until   int // Stream position the segment is extended to
+}
+
+func (s *paddedSegment) complete() bool {
+	return s.start+len(s.samples) >= s.until
+}
+
+// newSegmentPadder creates a padder adding pre and post samples around segments. lookback is
+// how far before the audio in which the VAD reports speech a segment may start.
+func newSegmentPadder(pre, post, lookback int) *segmentPadder {
+	return &segmentPadder{pre: pre, post: post, keep: pre + lookback}
+}
+
+// write adds the samples of a message to the history. It is called before the message is
+// run through the VAD.
+func (p *segmentPadder) write(samples []float32) {
+	p.history = append(p.history, samples...)
+	p.end += len(samples)
+}
+
+// trim drops the history no longer needed once a message has been processed
+func (p *segmentPadder) trim() {
+	if drop := len(p.history) - p.keep; drop > 0 {
+		p.history = p.history[:copy(p.history, p.history[drop:])]
+	}
+}
+
+// markOnset keeps the history when speech starts, which holds the audio before segments
+// that only end several messages later
+func (p *segmentPadder) markOnset() {
+	p.onset = append(p.onset[:0], p.history...)
+	p.onsetEnd = p.end
+}
+
+// add pads a segment starting at stream position start. Segments split off because they
+// reached the maximum length are continued by the next one, so padAfter is false for them.
+func (p *segmentPadder) add(samples []float32, start int, padAfter bool) {
+	// A segment starting within the padding of the previous one ends that padding
+	if n := len(p.pending); n > 0 {
+		prev := &p.pending[n-1]
+		prev.until = max(min(prev.until, start), prev.start+len(prev.samples))
+		p.last = prev.until
+	}
+
+	before := p.before(start)
+	segment := paddedSegment{
+		samples: make([]float32, 0, len(before)+len(samples)+p.post),
+		start:   start - len(before),
+		until:   start + len(samples),
+	}
+	if padAfter {
+		segment.until += p.post
+	}
+	segment.samples = append(append(segment.samples, before...), samples...)
+	p.pending = append(p.pending, segment)
+	p.last = segment.until
+}
+
+// ready extends the pending segments with the history and removes and returns the leading
+// ones that are fully padded, in order. It is called once a message has been processed.
+func (p *segmentPadder) ready() []paddedSegment {
+	for i := range p.pending {
+		p.extend(&p.pending[i])
+	}
+	n := 0
+	for n < len(p.pending) && p.pending[n].complete() {
+		n++
+	}
+	ready := p.pending[:n:n]
+	p.pending = p.pending[n:]
+	return ready
+}
+
+// flush removes and returns all pending segments with the padding available so far
+func (p *segmentPadder) flush() []paddedSegment {
+	for i := range p.pending {
+		p.extend(&p.pending[i])
+	}
+	pending := p.pending
+	p.pending = nil
+	return pending
+}
+
+// before returns up to pre samples preceding start from the history or the onset, without
+// reaching into the previous segment
+func (p *segmentPadder) before(start int) []float32 {
+	from := max(start-p.pre, p.last)
+	padding := window(p.history, p.end, from, start)
+	if onset := window(p.onset, p.onsetEnd, from, start); len(onset) > len(padding) {
+		padding = onset
+	}
+	return padding
+}
+
+// extend appends the history following a segment, up to the position it is extended to
+func (p *segmentPadder) extend(s *paddedSegment) {
+	end := s.start + len(s.samples)
+	if end >= s.until {
+		return
+	}
+	if end < p.end-len(p.history) {
+		// The audio after the segment is no longer held
+		s.until = end
+		return
+	}
+	s.samples = append(s.samples, window(p.history, p.end, end, min(s.until, p.end))...)
+}
+
+// window returns the samples in [from, to) of buf, which ends at stream position end. It
+// returns those held in buf when buf starts after from, and nil when buf does not hold to.
+func window(buf []float32, end, from, to int) []float32 {
+	start := end - len(buf)
+	if to < start || to > end {
+		return nil
+	}
+	from = max(from, start)
+	if from >= to {
+		return nil
+	}
+	return buf[from-start : to-start]
+}
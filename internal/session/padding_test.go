@@ -0,0 +1,91 @@
+package session
+
+import "testing"
+
+// positions returns samples holding their stream positions in [from, to)
+func positions(from, to int) []float32 {
+	samples := make([]float32, 0, to-from)
+	for i := from; i < to; i++ {
+		samples = append(samples, float32(i))
+	}
+	return samples
+}
+
+// checkSegment fails unless segment holds the stream positions in [from, to)
+func checkSegment(t *testing.T, segment paddedSegment, from, to int) {
+	t.Helper()
+	if segment.start != from || len(segment.samples) != to-from {
+		t.Fatalf("segment = [%d, %d), want [%d, %d)", segment.start, segment.start+len(segment.samples), from, to)
+	}
+	for i, sample := range segment.samples {
+		if int(sample) != from+i {
+			t.Fatalf("segment sample %d = %v, want %d", i, sample, from+i)
+		}
+	}
+}
+
+func TestSegmentPadder(t *testing.T) {
+	t.Run("padding after arrives with the next message", func(t *testing.T) {
+		p := newSegmentPadder(2, 3, 0)
+		p.write(positions(0, 10))
+		p.add(positions(5, 8), 5, true)
+		if ready := p.ready(); len(ready) != 0 {
+			t.Fatalf("ready() = %d segments before the padding after arrived", len(ready))
+		}
+		p.trim()
+		p.write(positions(10, 20))
+		ready := p.ready()
+		if len(ready) != 1 {
+			t.Fatalf("ready() = %d segments, want 1", len(ready))
+		}
+		checkSegment(t, ready[0], 3, 11)
+	})
+
+	t.Run("padding before a segment longer than the history", func(t *testing.T) {
+		p := newSegmentPadder(2, 0, 0)
+		p.write(positions(0, 10))
+		p.markOnset()
+		p.trim()
+		p.write(positions(10, 20))
+		p.trim()
+		p.write(positions(20, 30))
+		p.add(positions(8, 25), 8, true)
+		ready := p.ready()
+		if len(ready) != 1 {
+			t.Fatalf("ready() = %d segments, want 1", len(ready))
+		}
+		checkSegment(t, ready[0], 6, 25)
+	})
+
+	t.Run("padding does not overlap the neighbouring segments", func(t *testing.T) {
+		p := newSegmentPadder(3, 3, 0)
+		p.write(positions(0, 10))
+		p.add(positions(2, 4), 2, true)
+		p.add(positions(5, 9), 5, true)
+		ready := p.ready()
+		if len(ready) != 1 {
+			t.Fatalf("ready() = %d segments, want 1", len(ready))
+		}
+		checkSegment(t, ready[0], 0, 5)
+
+		// The second segment is waiting for its padding after
+		flushed := p.flush()
+		if len(flushed) != 1 {
+			t.Fatalf("flush() = %d segments, want 1", len(flushed))
+		}
+		checkSegment(t, flushed[0], 5, 10)
+	})
+
+	t.Run("split segments are not padded at the split", func(t *testing.T) {
+		p := newSegmentPadder(2, 2, 0)
+		p.write(positions(0, 20))
+		p.add(positions(4, 10), 4, false)
+		p.add(positions(10, 14), 10, true)
+		ready := p.ready()
+		if len(ready) != 2 {
+			t.Fatalf("ready() = %d segments, want 2", len(ready))
+		}
+		checkSegment(t, ready[0], 2, 10)
+		checkSegment(t, ready[1], 10, 16)
+	})
+}
@@ -0,0 +1,54 @@
+package session
+
+import "math"
+
+// prefilterWindowMs is the length of the windows whose level the energy prefilter measures, so
+// that a short sound in an otherwise quiet message still reaches the model VAD
+const prefilterWindowMs = 10
+
+// energyPrefilter is the first stage of the two-stage VAD: it keeps audio that is obviously
+// silent from the Silero/TEN-VAD instance, so sessions that are connected but idle cost no
+// model inference. Audio is only held back once the session has been quiet for the hangover
+// and the model VAD has no speech in progress, so segments still end the way the model VAD
+// ends them. Levels are RMS in dBFS, as in audio_level messages.
+type energyPrefilter struct {
+	window   int     // Samples per level window
+	power    float64 // Mean square of a window at the threshold
+	hangover int     // Quiet samples still run through the model VAD
+	quiet    int     // Samples since the last loud window
+}
+
+// newEnergyPrefilter creates a prefilter passing audio with a window at thresholdDB or above
+func newEnergyPrefilter(thresholdDB float64, hangoverMs, sampleRate int) *energyPrefilter {
+	return &energyPrefilter{
+		window:   max(sampleRate*prefilterWindowMs/1000, 1),
+		power:    math.Pow(10, thresholdDB/10),
+		hangover: hangoverMs * sampleRate / 1000,
+	}
+}
+
+// pass reports whether samples are run through the model VAD. inSpeech is whether the model
+// VAD has speech in progress.
+func (f *energyPrefilter) pass(samples []float32, inSpeech bool) bool {
+	if f.loud(samples) {
+		f.quiet = 0
+		return true
+	}
+	f.quiet += len(samples)
+	return inSpeech || f.quiet <= f.hangover
+}
+
+// loud reports whether any window of samples reaches the threshold
+func (f *energyPrefilter) loud(samples []float32) bool {
+	for start := 0; start < len(samples); start += f.window {
+		window := samples[start:min(start+f.window, len(samples))]
+		var sumSquares float64
+		for _, s := range window {
+			sumSquares += float64(s) * float64(s)
+		}
+		if sumSquares >= f.power*float64(len(window)) {
+			return true
+		}
+	}
+	return false
+}
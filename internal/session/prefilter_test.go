@@ -0,0 +1,67 @@
+package session
+
+import (
+	"math"
+	"testing"
+)
+
+// tone returns n samples of a 440Hz sine at levelDB dBFS RMS
+func tone(n int, levelDB float64) []float32 {
+	amplitude := math.Sqrt2 * math.Pow(10, levelDB/20)
+	samples := make([]float32, n)
+	for i := range samples {
+		samples[i] = float32(amplitude * math.Sin(2*math.Pi*440*float64(i)/16000))
+	}
+	return samples
+}
+
+func TestEnergyPrefilterLoud(t *testing.T) {
+	f := newEnergyPrefilter(-50, 0, 16000)
+	click := make([]float32, 1600)
+	copy(click[800:], tone(160, -30))
+
+	tests := []struct {
+		name    string
+		samples []float32
+		want    bool
+	}{
+		{"digital silence", make([]float32, 1600), false},
+		{"below threshold", tone(1600, -60), false},
+		{"above threshold", tone(1600, -40), true},
+		{"short sound in quiet message", click, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := f.loud(tt.samples); got != tt.want {
+				t.Errorf("loud() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnergyPrefilterPass(t *testing.T) {
+	// 100ms messages with a 250ms hangover
+	f := newEnergyPrefilter(-50, 250, 16000)
+	quiet := tone(1600, -70)
+	speech := tone(1600, -20)
+
+	steps := []struct {
+		samples  []float32
+		inSpeech bool
+		want     bool
+	}{
+		{speech, true, true},
+		{quiet, true, true},
+		{quiet, false, true},
+		{quiet, false, false}, // 300ms quiet, past the hangover
+		{quiet, true, true},   // the model VAD still has speech in progress
+		{quiet, false, false},
+		{speech, false, true},
+		{quiet, false, true}, // the hangover starts again
+	}
+	for i, step := range steps {
+		if got := f.pass(step.samples, step.inSpeech); got != step.want {
+			t.Errorf("message %d: pass() = %v, want %v", i, got, step.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package session
+
+import (
+	"context"
+	"fmt"
+
+	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
+)
+
+// probeDuration is the length of the silent clip decoded by ProbeDecode, in seconds
+const probeDuration = 0.1
+
+// ProbeDecode decodes a short silent clip for readiness checks. The default recognizer is run
+// on a recognition worker at high priority, so the probe fails when the recognizer is missing
+// or every worker is stuck; in streaming mode the online recognizer is exercised as well.
+func (m *Manager) ProbeDecode(ctx context.Context) error {
+	sampleRate := m.cfg.Audio.SampleRate
+	samples := make([]float32, int(float64(sampleRate)*probeDuration))
+
+	decoded := false
+	err := m.WithRecognizer(ctx, PriorityHigh, func(recognizer *sherpa.OfflineRecognizer) {
+		if recognizer == nil {
+			return
+		}
+		stream := sherpa.NewOfflineStream(recognizer)
+		defer sherpa.DeleteOfflineStream(stream)
+		stream.AcceptWaveform(sampleRate, samples)
+		recognizer.Decode(stream)
+		decoded = true
+	})
+	if err != nil {
+		return fmt.Errorf("recognition workers unavailable: %v", err)
+	}
+	if !decoded {
+		return fmt.Errorf("recognizer is not loaded")
+	}
+
+	if m.onlineRecognizer != nil {
+		stream := sherpa.NewOnlineStream(m.onlineRecognizer)
+		defer sherpa.DeleteOnlineStream(stream)
+		stream.AcceptWaveform(sampleRate, samples)
+		stream.InputFinished()
+		for m.onlineRecognizer.IsReady(stream) {
+			m.onlineRecognizer.Decode(stream)
+		}
+	}
+	return nil
+}
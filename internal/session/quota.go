@@ -0,0 +1,91 @@
+package session
+
+import (
+	"sync/atomic"
+	"time"
+
+	"asr_server/config"
+	"asr_server/internal/logger"
+)
+
+// quotaCloseTimeout bounds how long a session that exceeded its quota waits for pending
+// results, and the quota_exceeded notice, to be written before it is closed
+const quotaCloseTimeout = 5 * time.Second
+
+// Quota limits reported in quota_exceeded messages
+const (
+	QuotaMaxDuration     = "max_duration"
+	QuotaMaxAudioSeconds = "max_audio_seconds"
+	QuotaMaxAudioBytes   = "max_audio_bytes"
+)
+
+// SetAPIKey records the API key the session was opened with, which selects its quota
+func (s *Session) SetAPIKey(apiKey string) {
+	s.mu.Lock()
+	s.apiKey = apiKey
+	s.mu.Unlock()
+}
+
+// quota returns the session's limits, read from the configuration on use so reloaded
+// quotas apply to open sessions
+func (s *Session) quota() config.SessionQuota {
+	s.mu.RLock()
+	apiKey := s.apiKey
+	s.mu.RUnlock()
+	return s.cfg.Session.QuotaFor(apiKey)
+}
+
+// durationExceeded reports whether the session has been open for longer than its quota allows
+func (s *Session) durationExceeded(quota config.SessionQuota) bool {
+	return quota.MaxDuration > 0 && time.Since(s.createdAt) >= time.Duration(quota.MaxDuration)*time.Second
+}
+
+// exceededQuota returns the limit the session has reached, or "" while it is within its quota.
+// It is called from the audio path, which owns streamSamples.
+func (m *Manager) exceededQuota(session *Session) string {
+	quota := session.quota()
+	switch {
+	case session.durationExceeded(quota):
+		return QuotaMaxDuration
+	case quota.MaxAudioBytes > 0 && atomic.LoadInt64(&session.bytesReceived) > quota.MaxAudioBytes:
+		return QuotaMaxAudioBytes
+	case quota.MaxAudioSeconds > 0 && session.streamSamples >= quota.MaxAudioSeconds*m.cfg.Audio.SampleRate:
+		return QuotaMaxAudioSeconds
+	}
+	return ""
+}
+
+// enforceQuota ends a session that reached limit. The client is sent
+// {"type": "quota_exceeded", "limit": "..."}; once pending results and the notice are
+// written, the session is closed. Audio received meanwhile is ignored.
+func (m *Manager) enforceQuota(session *Session, limit string) {
+	if !atomic.CompareAndSwapInt32(&session.quotaExceeded, 0, 1) {
+		return
+	}
+	logger.Warn("session_quota_exceeded", "session_id", session.ID, "limit", limit)
+
+	select {
+	case session.SendQueue <- map[string]interface{}{
+		"type":       "quota_exceeded",
+		"limit":      limit,
+		"message":    "Session quota exceeded: " + limit,
+		"session_id": session.ID,
+		"timestamp":  time.Now().UnixMilli(),
+	}:
+	default:
+		session.dropMessage()
+		logger.Warn("session_send_queue_full", "session_id", session.ID, "action", "dropped_quota_notice")
+	}
+
+	go func() {
+		deadline := time.Now().Add(quotaCloseTimeout)
+		for time.Now().Before(deadline) && session.busy() {
+			time.Sleep(drainPollInterval)
+		}
+		if m.sessions.compareAndRemove(session.ID, session) {
+			m.closeSession(session, CloseQuotaExceeded)
+			atomic.AddInt64(&m.activeSessions, -1)
+			logger.Info("session_removed", "session_id", session.ID, "reason", "quota_exceeded")
+		}
+	}()
+}
@@ -0,0 +1,165 @@
+package session
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"asr_server/internal/logger"
+)
+
+// ErrResumeTokenInvalid is returned when a resume token matches no session that can be resumed
+var ErrResumeTokenInvalid = errors.New("resume token is invalid or expired")
+
+// resumeState is shared by the sessions of one connection, which are resumed together
+type resumeState struct {
+	token      string
+	sessionIDs []string // In channel order
+}
+
+// EnableResume issues a resume token for the sessions of one connection. When the connection
+// drops, ReleaseSession keeps the sessions for the configured grace period, buffering results,
+// and a new connection presenting the token can attach to them. It returns "" when session
+// resume is disabled.
+func (m *Manager) EnableResume(sessionIDs []string) string {
+	if m.cfg.Session.ResumeGracePeriod <= 0 {
+		return ""
+	}
+
+	token := make([]byte, 32)
+	rand.Read(token)
+	state := &resumeState{token: hex.EncodeToString(token), sessionIDs: slices.Clone(sessionIDs)}
+
+	for _, id := range sessionIDs {
+		if session, exists := m.sessions.get(id); exists {
+			session.connMu.Lock()
+			session.resume = state
+			session.connMu.Unlock()
+		}
+	}
+	return state.token
+}
+
+// ResumeSessions returns the sessions a resume token was issued for, in channel order.
+// Sessions that are still connected can be resumed too; attaching takes them over.
+func (m *Manager) ResumeSessions(token string) ([]*Session, error) {
+	grace := m.resumeGracePeriod()
+	if grace <= 0 || token == "" || m.Draining() {
+		return nil, ErrResumeTokenInvalid
+	}
+
+	for _, session := range m.sessions.snapshot() {
+		session.connMu.Lock()
+		state, detachedAt := session.resume, session.detachedAt
+		session.connMu.Unlock()
+		if state == nil || subtle.ConstantTimeCompare([]byte(state.token), []byte(token)) != 1 {
+			continue
+		}
+		// The cleanup sweep may not have removed an expired session yet
+		if !detachedAt.IsZero() && time.Since(detachedAt) > grace {
+			return nil, ErrResumeTokenInvalid
+		}
+
+		sessions := make([]*Session, 0, len(state.sessionIDs))
+		for _, id := range state.sessionIDs {
+			s, exists := m.sessions.get(id)
+			if !exists || atomic.LoadInt32(&s.closed) == 1 {
+				return nil, ErrResumeTokenInvalid
+			}
+			sessions = append(sessions, s)
+		}
+		return sessions, nil
+	}
+	return nil, ErrResumeTokenInvalid
+}
+
+// ReleaseSession ends a connection's use of a session. A session with a resume token is kept
+// detached for the grace period, or left alone if another connection has taken it over;
+// other sessions are removed.
+func (m *Manager) ReleaseSession(sessionID string, conn Conn) {
+	session, exists := m.sessions.get(sessionID)
+	if !exists {
+		return
+	}
+
+	if session.detach(conn) {
+		logger.Info("session_detached", "session_id", sessionID, "grace_period", m.resumeGracePeriod())
+		return
+	}
+	m.RemoveSession(sessionID)
+}
+
+// Attach delivers the session's results over conn, replacing its previous connection, which
+// is closed. With the acks option, unacknowledged results are retransmitted first, followed by
+// the results buffered while the session was detached.
+func (s *Session) Attach(conn Conn) {
+	s.connMu.Lock()
+	// Written before the send loop can use conn
+	for _, msg := range s.order.pending() {
+		if err := conn.WriteJSON(msg); err != nil {
+			logger.Warn("failed_to_retransmit_results", "session_id", s.ID, "error", err)
+			break
+		}
+	}
+	previous := s.Conn
+	s.Conn = conn
+	s.detachedAt = time.Time{}
+	if s.reattached != nil {
+		close(s.reattached)
+		s.reattached = nil
+	}
+	s.connMu.Unlock()
+
+	atomic.StoreInt32(&s.sendErrCount, 0)
+	atomic.StoreInt64(&s.LastSeen, time.Now().UnixNano())
+	if previous != nil && previous != conn {
+		previous.Close()
+	}
+	logger.Info("session_resumed", "session_id", s.ID, "taken_over", previous != nil)
+}
+
+// detach drops the session's connection if it is still conn, so results are buffered until
+// the client resumes. It reports false when the session cannot be resumed.
+func (s *Session) detach(conn Conn) bool {
+	s.connMu.Lock()
+	if s.resume == nil || atomic.LoadInt32(&s.closed) == 1 {
+		s.connMu.Unlock()
+		return false
+	}
+	dropped := s.Conn != nil && s.Conn == conn
+	if dropped {
+		s.Conn = nil
+		s.detachedAt = time.Now()
+		s.reattached = make(chan struct{})
+	}
+	s.connMu.Unlock()
+
+	if dropped {
+		conn.Close()
+	}
+	return true
+}
+
+// connection returns the connection results are delivered over, or nil and a channel that is
+// closed when a client resumes the detached session
+func (s *Session) connection() (Conn, <-chan struct{}) {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	return s.Conn, s.reattached
+}
+
+// detachedSince returns when the session lost its connection, or the zero time if it is connected
+func (s *Session) detachedSince() time.Time {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	return s.detachedAt
+}
+
+// resumeGracePeriod returns how long detached sessions are kept
+func (m *Manager) resumeGracePeriod() time.Duration {
+	return time.Duration(m.cfg.Session.ResumeGracePeriod) * time.Second
+}
@@ -0,0 +1,251 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Recognition task priorities. Interactive sessions default to normal; batch jobs only
+// get a worker when no higher-priority task is waiting.
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal"
+	PriorityBatch  = "batch"
+)
+
+// ValidPriorities lists the priorities accepted from clients, highest first
+var ValidPriorities = []string{PriorityHigh, PriorityNormal, PriorityBatch}
+
+// Reasons a speech segment is dropped without recognition, as sent to the client
+const (
+	DropQueueFull    = "queue_full"    // The recognition queue was full
+	DropQueueTimeout = "queue_timeout" // The segment waited longer than the queue timeout
+)
+
+// scheduler runs recognition tasks on a fixed number of workers. When all workers are busy,
+// tasks wait in one FIFO queue per priority and a freed worker takes the oldest task of the
+// highest non-empty priority. Running tasks are never interrupted.
+//
+// The queue is bounded: a task submitted while it is full is rejected, and a task that waits
+// longer than the timeout is taken out of the queue and expired instead of run.
+type scheduler struct {
+	mu        sync.Mutex
+	workers   int
+	running   int
+	maxQueued int
+	timeout   time.Duration // Longest wait for a worker, 0 for no limit
+	queued    int
+	queues    [][]*queuedTask
+	rejected  int64 // Tasks rejected because the queue was full
+	expired   int64 // Tasks that waited longer than the timeout
+}
+
+// queuedTask is a task waiting for a worker
+type queuedTask struct {
+	run      func()
+	expire   func() // Called instead of run when the task times out, nil if it never does
+	level    int
+	queuedAt time.Time
+	timer    *time.Timer
+}
+
+func newScheduler(workers, maxQueued int, timeout time.Duration) *scheduler {
+	return &scheduler{
+		workers:   workers,
+		maxQueued: maxQueued,
+		timeout:   timeout,
+		queues:    make([][]*queuedTask, len(ValidPriorities)),
+	}
+}
+
+// submit runs task on a worker, queueing it when none is free. It returns false without
+// running the task when the queue is full. If the task waits longer than the queue timeout,
+// expire is called instead of task.
+func (s *scheduler) submit(priority string, task, expire func()) bool {
+	return s.enqueue(priority, task, expire, true)
+}
+
+// do runs fn on a worker and waits for it to finish. It is not subject to the queue limit;
+// if ctx is cancelled before a worker is free, fn is skipped and the context error returned.
+func (s *scheduler) do(ctx context.Context, priority string, fn func()) error {
+	done := make(chan struct{})
+	var err error
+	s.enqueue(priority, func() {
+		defer close(done)
+		if err = ctx.Err(); err == nil {
+			fn()
+		}
+	}, nil, false)
+	<-done
+	return err
+}
+
+func (s *scheduler) enqueue(priority string, task, expire func(), bounded bool) bool {
+	level := slices.Index(ValidPriorities, priority)
+	if level < 0 {
+		level = slices.Index(ValidPriorities, PriorityNormal)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running < s.workers {
+		s.running++
+		go s.work(task)
+		return true
+	}
+	if bounded && s.queued >= s.maxQueued {
+		s.rejected++
+		return false
+	}
+	entry := &queuedTask{run: task, expire: expire, level: level, queuedAt: time.Now()}
+	if expire != nil && s.timeout > 0 {
+		entry.timer = time.AfterFunc(s.timeout, func() { s.expireTask(entry) })
+	}
+	s.queues[level] = append(s.queues[level], entry)
+	s.queued++
+	return true
+}
+
+// expireTask takes a task that timed out out of its queue and calls its expire function.
+// A task a worker dequeued in the meantime is left alone.
+func (s *scheduler) expireTask(entry *queuedTask) {
+	s.mu.Lock()
+	queue := s.queues[entry.level]
+	i := slices.Index(queue, entry)
+	if i < 0 {
+		s.mu.Unlock()
+		return
+	}
+	s.queues[entry.level] = slices.Delete(queue, i, i+1)
+	s.queued--
+	s.expired++
+	s.mu.Unlock()
+
+	entry.expire()
+}
+
+// work runs task, then keeps taking queued tasks until the queues are empty or the
+// number of workers has been reduced below the number running
+func (s *scheduler) work(task func()) {
+	for task != nil {
+		task()
+
+		s.mu.Lock()
+		task = nil
+		if s.running <= s.workers {
+			task = s.next()
+		}
+		if task == nil {
+			s.running--
+		}
+		s.mu.Unlock()
+	}
+}
+
+// next dequeues the oldest task of the highest non-empty priority. The caller holds s.mu.
+func (s *scheduler) next() func() {
+	for level, queue := range s.queues {
+		if len(queue) > 0 {
+			entry := queue[0]
+			queue[0] = nil
+			s.queues[level] = queue[1:]
+			s.queued--
+			if entry.timer != nil {
+				// If the timer already fired, expireTask no longer finds the task
+				entry.timer.Stop()
+			}
+			return entry.run
+		}
+	}
+	return nil
+}
+
+// resize changes the number of workers, the queue limit and the queue timeout. Added workers
+// start on queued tasks right away; when the number shrinks, surplus workers exit after their
+// current task. The timeout applies to tasks queued from now on.
+func (s *scheduler) resize(workers, maxQueued int, timeout time.Duration) {
+	s.mu.Lock()
+	s.workers, s.maxQueued, s.timeout = workers, maxQueued, timeout
+	var started []func()
+	for s.running < s.workers {
+		task := s.next()
+		if task == nil {
+			break
+		}
+		s.running++
+		started = append(started, task)
+	}
+	s.mu.Unlock()
+
+	for _, task := range started {
+		go s.work(task)
+	}
+}
+
+// load returns the number of waiting tasks and the queue limit
+func (s *scheduler) load() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queued, s.maxQueued
+}
+
+// stats returns worker usage, the number of waiting tasks per priority, how long the oldest
+// one has waited and the number of tasks rejected or expired
+func (s *scheduler) stats() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	queued := make(map[string]int, len(ValidPriorities))
+	var oldest time.Duration
+	now := time.Now()
+	for level, priority := range ValidPriorities {
+		queued[priority] = len(s.queues[level])
+		if len(s.queues[level]) > 0 {
+			oldest = max(oldest, now.Sub(s.queues[level][0].queuedAt))
+		}
+	}
+	return map[string]interface{}{
+		"workers":          s.workers,
+		"running":          s.running,
+		"queued":           queued,
+		"max_queued":       s.maxQueued,
+		"queue_timeout_ms": s.timeout.Milliseconds(),
+		"oldest_wait_ms":   oldest.Milliseconds(),
+		"dropped": map[string]int64{
+			DropQueueFull:    s.rejected,
+			DropQueueTimeout: s.expired,
+		},
+	}
+}
+
+// writeMetrics writes the queue depth per priority, busy workers and dropped tasks in the
+// Prometheus text exposition format
+func (s *scheduler) writeMetrics(w io.Writer) {
+	s.mu.Lock()
+	depths := make([]int, len(ValidPriorities))
+	for level := range ValidPriorities {
+		depths[level] = len(s.queues[level])
+	}
+	running, workers, rejected, expired := s.running, s.workers, s.rejected, s.expired
+	s.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP asr_recognition_queue_depth Recognition tasks waiting for a worker by priority.")
+	fmt.Fprintln(w, "# TYPE asr_recognition_queue_depth gauge")
+	for level, priority := range ValidPriorities {
+		fmt.Fprintf(w, "asr_recognition_queue_depth{priority=\"%s\"} %d\n", priority, depths[level])
+	}
+	fmt.Fprintln(w, "# HELP asr_recognition_workers_busy Recognition workers running a task.")
+	fmt.Fprintln(w, "# TYPE asr_recognition_workers_busy gauge")
+	fmt.Fprintf(w, "asr_recognition_workers_busy %d\n", running)
+	fmt.Fprintln(w, "# HELP asr_recognition_workers_max Configured recognition workers.")
+	fmt.Fprintln(w, "# TYPE asr_recognition_workers_max gauge")
+	fmt.Fprintf(w, "asr_recognition_workers_max %d\n", workers)
+	fmt.Fprintln(w, "# HELP asr_recognition_segments_dropped_total Speech segments dropped without recognition by reason.")
+	fmt.Fprintln(w, "# TYPE asr_recognition_segments_dropped_total counter")
+	fmt.Fprintf(w, "asr_recognition_segments_dropped_total{reason=\"%s\"} %d\n", DropQueueFull, rejected)
+	fmt.Fprintf(w, "asr_recognition_segments_dropped_total{reason=\"%s\"} %d\n", DropQueueTimeout, expired)
+}
@@ -0,0 +1,77 @@
+package session
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSchedulerQueueLimits(t *testing.T) {
+	s := newScheduler(1, 2, 50*time.Millisecond)
+
+	// Occupy the only worker
+	release := make(chan struct{})
+	if !s.submit(PriorityNormal, func() { <-release }, nil) {
+		t.Fatal("first task rejected")
+	}
+
+	ran := make(chan string, 3)
+	expired := make(chan string, 3)
+	task := func(name string) (func(), func()) {
+		return func() { ran <- name }, func() { expired <- name }
+	}
+	for _, name := range []string{"a", "b"} {
+		run, expire := task(name)
+		if !s.submit(PriorityNormal, run, expire) {
+			t.Fatalf("task %s rejected with room in the queue", name)
+		}
+	}
+	run, expire := task("c")
+	if s.submit(PriorityNormal, run, expire) {
+		t.Fatal("task c accepted with the queue full")
+	}
+
+	// Both queued tasks time out instead of running
+	for i := 0; i < 2; i++ {
+		select {
+		case <-expired:
+		case <-time.After(5 * time.Second):
+			t.Fatal("queued task not expired")
+		}
+	}
+	if queued, _ := s.load(); queued != 0 {
+		t.Errorf("queued = %d after expiry, want 0", queued)
+	}
+
+	// A task taken by a worker before its timeout runs and does not expire
+	run, expire = task("d")
+	s.submit(PriorityNormal, run, expire)
+	close(release)
+	select {
+	case name := <-ran:
+		if name != "d" {
+			t.Errorf("ran %s, want d", name)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("task d not run")
+	}
+	time.Sleep(100 * time.Millisecond)
+	select {
+	case name := <-expired:
+		t.Errorf("task %s expired after running", name)
+	case name := <-ran:
+		t.Errorf("expired task %s ran", name)
+	default:
+	}
+
+	dropped := s.stats()["dropped"].(map[string]int64)
+	if dropped[DropQueueFull] != 1 || dropped[DropQueueTimeout] != 2 {
+		t.Errorf("dropped = %v, want 1 queue_full and 2 queue_timeout", dropped)
+	}
+	var metrics bytes.Buffer
+	s.writeMetrics(&metrics)
+	if !strings.Contains(metrics.String(), `asr_recognition_segments_dropped_total{reason="queue_timeout"} 2`) {
+		t.Errorf("metrics missing dropped segments:\n%s", metrics.String())
+	}
+}
@@ -0,0 +1,100 @@
+package session
+
+import (
+	"hash/maphash"
+	"sync"
+)
+
+// sessionShards is the number of independently locked shards of the session map
+const sessionShards = 64
+
+// sessionMap is the manager's session registry. Every audio message looks up its session, so
+// sessions are spread over shards by a hash of their ID, each with its own lock, and lookups
+// from different connections rarely contend.
+type sessionMap struct {
+	seed   maphash.Seed
+	shards [sessionShards]sessionShard
+}
+
+type sessionShard struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+}
+
+func newSessionMap() *sessionMap {
+	m := &sessionMap{seed: maphash.MakeSeed()}
+	for i := range m.shards {
+		m.shards[i].sessions = make(map[string]*Session)
+	}
+	return m
+}
+
+// shard returns the shard holding id
+func (m *sessionMap) shard(id string) *sessionShard {
+	return &m.shards[maphash.String(m.seed, id)%sessionShards]
+}
+
+func (m *sessionMap) get(id string) (*Session, bool) {
+	shard := m.shard(id)
+	shard.mu.RLock()
+	session, exists := shard.sessions[id]
+	shard.mu.RUnlock()
+	return session, exists
+}
+
+func (m *sessionMap) set(id string, session *Session) {
+	shard := m.shard(id)
+	shard.mu.Lock()
+	shard.sessions[id] = session
+	shard.mu.Unlock()
+}
+
+// remove deletes a session and returns it, so only one of concurrent callers closes it
+func (m *sessionMap) remove(id string) (*Session, bool) {
+	shard := m.shard(id)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	session, exists := shard.sessions[id]
+	if exists {
+		delete(shard.sessions, id)
+	}
+	return session, exists
+}
+
+// compareAndRemove deletes id only if it still maps to session
+func (m *sessionMap) compareAndRemove(id string, session *Session) bool {
+	shard := m.shard(id)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if shard.sessions[id] != session {
+		return false
+	}
+	delete(shard.sessions, id)
+	return true
+}
+
+// snapshot returns every session. Shards are locked one at a time, so sessions created or
+// removed meanwhile may or may not be included.
+func (m *sessionMap) snapshot() []*Session {
+	sessions := make([]*Session, 0, m.len())
+	for i := range m.shards {
+		shard := &m.shards[i]
+		shard.mu.RLock()
+		for _, session := range shard.sessions {
+			sessions = append(sessions, session)
+		}
+		shard.mu.RUnlock()
+	}
+	return sessions
+}
+
+func (m *sessionMap) len() int {
+	n := 0
+	for i := range m.shards {
+		shard := &m.shards[i]
+		shard.mu.RLock()
+		n += len(shard.sessions)
+		shard.mu.RUnlock()
+	}
+	return n
+}
@@ -0,0 +1,132 @@
+package session
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// benchmarkSessions is the number of concurrent sessions registered in the benchmarks
+const benchmarkSessions = 4096
+
+// lockedSessionMap is the single-lock registry sessionMap replaced, kept as a baseline
+type lockedSessionMap struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+}
+
+func (m *lockedSessionMap) get(id string) (*Session, bool) {
+	m.mu.RLock()
+	session, exists := m.sessions[id]
+	m.mu.RUnlock()
+	return session, exists
+}
+
+func (m *lockedSessionMap) set(id string, session *Session) {
+	m.mu.Lock()
+	m.sessions[id] = session
+	m.mu.Unlock()
+}
+
+func (m *lockedSessionMap) remove(id string) (*Session, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, exists := m.sessions[id]
+	delete(m.sessions, id)
+	return session, exists
+}
+
+type sessionRegistry interface {
+	get(id string) (*Session, bool)
+	set(id string, session *Session)
+	remove(id string) (*Session, bool)
+}
+
+func benchmarkSessionIDs() []string {
+	ids := make([]string, benchmarkSessions)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("%032x", i*2654435761)
+	}
+	return ids
+}
+
+// benchmarkRegistry looks up sessions from parallel goroutines, as audio messages do; one in
+// writeEvery operations replaces a session, as connects and disconnects do (0 for none)
+func benchmarkRegistry(b *testing.B, registry sessionRegistry, writeEvery int) {
+	ids := benchmarkSessionIDs()
+	for _, id := range ids {
+		registry.set(id, &Session{ID: id})
+	}
+
+	var worker int64
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := int(atomic.AddInt64(&worker, 1)) * 7919
+		for pb.Next() {
+			id := ids[i%len(ids)]
+			if writeEvery > 0 && i%writeEvery == 0 {
+				if session, exists := registry.remove(id); exists {
+					registry.set(id, session)
+				}
+			} else if _, exists := registry.get(id); !exists {
+				// A concurrent replacement may briefly remove the session
+				if writeEvery == 0 {
+					b.Errorf("session %s not found", id)
+				}
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkSessionMapGet(b *testing.B) {
+	benchmarkRegistry(b, newSessionMap(), 0)
+}
+
+func BenchmarkLockedSessionMapGet(b *testing.B) {
+	benchmarkRegistry(b, &lockedSessionMap{sessions: make(map[string]*Session)}, 0)
+}
+
+func BenchmarkSessionMapMixed(b *testing.B) {
+	benchmarkRegistry(b, newSessionMap(), 100)
+}
+
+func BenchmarkLockedSessionMapMixed(b *testing.B) {
+	benchmarkRegistry(b, &lockedSessionMap{sessions: make(map[string]*Session)}, 100)
+}
+
+func TestSessionMap(t *testing.T) {
+	m := newSessionMap()
+	ids := benchmarkSessionIDs()
+	for _, id := range ids {
+		m.set(id, &Session{ID: id})
+	}
+	if n := m.len(); n != len(ids) {
+		t.Fatalf("len() = %d, want %d", n, len(ids))
+	}
+	if n := len(m.snapshot()); n != len(ids) {
+		t.Fatalf("len(snapshot()) = %d, want %d", n, len(ids))
+	}
+
+	session, exists := m.get(ids[0])
+	if !exists || session.ID != ids[0] {
+		t.Fatalf("get(%q) = %v, %v", ids[0], session, exists)
+	}
+	if m.compareAndRemove(ids[0], &Session{ID: ids[0]}) {
+		t.Error("compareAndRemove() removed a different session")
+	}
+	if !m.compareAndRemove(ids[0], session) {
+		t.Error("compareAndRemove() did not remove the session")
+	}
+	if _, exists := m.remove(ids[0]); exists {
+		t.Error("remove() found a removed session")
+	}
+	if _, exists := m.remove(ids[1]); !exists {
+		t.Error("remove() did not find the session")
+	}
+	if n := m.len(); n != len(ids)-2 {
+		t.Errorf("len() = %d, want %d", n, len(ids)-2)
+	}
+}
@@ -0,0 +1,78 @@
+package session
+
+import (
+	"sync/atomic"
+
+	"asr_server/internal/logger"
+)
+
+// spillTotals counts results spilled and recovered across all sessions, for GetStats
+type spillTotals struct {
+	spilled   int64
+	recovered int64
+}
+
+// queueResult queues a released result for sending. When the send queue is full the result
+// is spilled to the session's buffer of up to session.spill_buffer_size results, and dropped
+// beyond that. Once results are spilled later ones follow them, so they stay in order.
+// It is called with the session's order lock held.
+func (m *Manager) queueResult(session *Session, result map[string]interface{}) {
+	o := &session.order
+	if len(o.spilled) == 0 {
+		select {
+		case session.SendQueue <- result:
+			// Log result length instead of content to prevent sensitive data exposure
+			text, _ := result["text"].(string)
+			metadata := session.Metadata()
+			logger.Info("recognition_result_queued", "session_id", session.ID, "seq", result["seq"], "result_length", len(text), "user_id", metadata.UserID, "device_id", metadata.DeviceID)
+			return
+		default:
+		}
+	}
+
+	if len(o.spilled) < m.cfg.Session.SpillBufferSize {
+		o.spilled = append(o.spilled, result)
+		atomic.AddInt64(&session.spilledResults, 1)
+		atomic.AddInt64(&m.spillTotals.spilled, 1)
+		logger.Debug("recognition_result_spilled", "session_id", session.ID, "seq", result["seq"], "spilled", len(o.spilled))
+		return
+	}
+
+	atomic.AddInt32(&session.droppedResults, 1)
+	logger.Warn("recognition_result_dropped", "session_id", session.ID, "seq", result["seq"])
+}
+
+// replaySpilled moves spilled results into the send queue while it has room. The send loop
+// calls it after every message it takes from the queue.
+func (s *Session) replaySpilled() {
+	o := &s.order
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	n := 0
+replay:
+	for n < len(o.spilled) {
+		select {
+		case s.SendQueue <- o.spilled[n]:
+			n++
+		default:
+			break replay
+		}
+	}
+	if n == 0 {
+		return
+	}
+	o.spilled = o.spilled[n:]
+	atomic.AddInt64(&s.recoveredResults, int64(n))
+	if s.spillTotals != nil {
+		atomic.AddInt64(&s.spillTotals.recovered, int64(n))
+	}
+	logger.Debug("spilled_results_recovered", "session_id", s.ID, "recovered", n, "spilled", len(o.spilled))
+}
+
+// spillPending returns the number of spilled results waiting for room in the send queue
+func (s *Session) spillPending() int {
+	s.order.mu.Lock()
+	defer s.order.mu.Unlock()
+	return len(s.order.spilled)
+}
@@ -0,0 +1,103 @@
+package session
+
+import (
+	"sync/atomic"
+	"time"
+
+	"asr_server/internal/logger"
+	"asr_server/internal/postprocess"
+
+	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
+)
+
+// SetOnlineRecognizer switches the manager to streaming recognition. Audio is decoded
+// incrementally by the online recognizer instead of being segmented by the VAD; clients
+// receive partial hypotheses while speaking and a final result at each endpoint.
+// It must be called before the manager starts processing audio.
+func (m *Manager) SetOnlineRecognizer(recognizer *sherpa.OnlineRecognizer) {
+	m.onlineRecognizer = recognizer
+}
+
+// Streaming reports whether the manager uses an online recognizer
+func (m *Manager) Streaming() bool {
+	return m.onlineRecognizer != nil
+}
+
+// processOnline feeds samples at the model sample rate to the session's online stream
+func (m *Manager) processOnline(session *Session, sessionID string, samples []float32) error {
+	if session.online == nil {
+		session.online = sherpa.NewOnlineStream(m.onlineRecognizer)
+	}
+	session.online.AcceptWaveform(m.cfg.Audio.SampleRate, samples)
+	session.onlineSamples += len(samples)
+
+	m.decodeOnline(session, sessionID)
+	return nil
+}
+
+// decodeOnline decodes all buffered frames, queueing a partial result when the hypothesis
+// changed and a final result when an endpoint was detected
+func (m *Manager) decodeOnline(session *Session, sessionID string) {
+	recognizer := m.onlineRecognizer
+	stream := session.online
+	for recognizer.IsReady(stream) {
+		recognizer.Decode(stream)
+	}
+	text := recognizer.GetResult(stream).Text
+
+	if recognizer.IsEndpoint(stream) {
+		m.finishUtterance(session, sessionID, text)
+		recognizer.Reset(stream)
+		return
+	}
+	if text == session.lastPartial {
+		return
+	}
+	session.lastPartial = text
+	if text != "" {
+		m.setSpeaking(session, sessionID, true)
+	}
+
+	select {
+	case session.SendQueue <- map[string]interface{}{
+		"type":      "partial",
+		"text":      m.rules.Apply(text),
+		"timestamp": time.Now().UnixMilli(),
+	}:
+	default:
+		session.dropMessage()
+		logger.Debug("session_send_queue_full", "session_id", sessionID, "action", "dropped_partial_result")
+	}
+}
+
+// flushOnline finalizes the current utterance. The stream cannot accept audio after its
+// input is finished, so a new one is created by the next audio message.
+func (m *Manager) flushOnline(session *Session, sessionID string) {
+	if session.online == nil {
+		return
+	}
+	session.online.InputFinished()
+	for m.onlineRecognizer.IsReady(session.online) {
+		m.onlineRecognizer.Decode(session.online)
+	}
+	m.finishUtterance(session, sessionID, m.onlineRecognizer.GetResult(session.online).Text)
+
+	sherpa.DeleteOnlineStream(session.online)
+	session.online = nil
+}
+
+// finishUtterance delivers the text of an utterance as a final result and resets the
+// per-utterance state
+func (m *Manager) finishUtterance(session *Session, sessionID, text string) {
+	sampleRate := float64(m.cfg.Audio.SampleRate)
+	end := float64(session.streamSamples) / sampleRate
+	start := float64(session.streamSamples-session.onlineSamples) / sampleRate
+	session.lastPartial = ""
+	session.onlineSamples = 0
+	m.setSpeaking(session, sessionID, false)
+
+	if text != "" {
+		atomic.AddInt64(&session.segments, 1)
+		m.handleRecognitionResult(sessionID, session.order.reserve(), postprocess.Transcript{Text: text}, start, end, nil)
+	}
+}
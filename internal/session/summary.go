@@ -0,0 +1,174 @@
+package session
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"asr_server/internal/logger"
+)
+
+// summaryWriteTimeout bounds how long a closed session's summary waits for its send loop to
+// stop, and how long Shutdown waits for the summaries of all sessions it closed
+const summaryWriteTimeout = time.Second
+
+// SessionStats is the usage of one session, listed by GetStats and sent to the client in
+// the session_summary message when the session closes, e.g. for billing
+type SessionStats struct {
+	// Session IDs double as long-poll stream tokens, so GetStats lists sessions by a hash
+	// of the ID instead, see statsKey
+	ID              string  `json:"-"`
+	Key             string  `json:"session"`
+	DurationSeconds float64 `json:"duration_seconds"` // Since the session was created
+	AudioSeconds    float64 `json:"audio_seconds"`    // Audio received, after decoding and resampling
+	Segments        int64   `json:"segments"`         // Speech segments submitted for recognition
+	Results         uint64  `json:"results"`          // Final results numbered so far
+	AvgDecodeMs     float64 `json:"avg_decode_ms"`    // Mean decode time of the session's segments
+	// Results and notices dropped because the send queue was full
+	DroppedMessages int64 `json:"dropped_messages"`
+}
+
+// stats returns the session's usage so far
+func (s *Session) stats(now time.Time) SessionStats {
+	s.order.mu.Lock()
+	results := s.order.seq
+	s.order.mu.Unlock()
+
+	return SessionStats{
+		ID:              s.ID,
+		Key:             statsKey(s.ID),
+		DurationSeconds: now.Sub(s.createdAt).Seconds(),
+		AudioSeconds:    s.audioSeconds(),
+		Segments:        atomic.LoadInt64(&s.segments),
+		Results:         results,
+		AvgDecodeMs:     s.avgDecodeMs(),
+		DroppedMessages: s.droppedCount(),
+	}
+}
+
+// audioSeconds returns the length of the audio received so far
+func (s *Session) audioSeconds() float64 {
+	if s.cfg == nil || s.cfg.Audio.SampleRate <= 0 {
+		return 0
+	}
+	return float64(atomic.LoadInt64(&s.audioSamples)) / float64(s.cfg.Audio.SampleRate)
+}
+
+// recordDecode adds a segment's decode time to the session's average
+func (s *Session) recordDecode(d time.Duration) {
+	atomic.AddInt64(&s.decodes, 1)
+	atomic.AddInt64(&s.decodeNanos, int64(d))
+}
+
+// avgDecodeMs returns the mean decode time in milliseconds, 0 before the first decode
+func (s *Session) avgDecodeMs() float64 {
+	decodes := atomic.LoadInt64(&s.decodes)
+	if decodes == 0 {
+		return 0
+	}
+	return float64(atomic.LoadInt64(&s.decodeNanos)) / float64(decodes) / float64(time.Millisecond)
+}
+
+// dropMessage counts a message other than a final result that did not fit in the send queue
+func (s *Session) dropMessage() {
+	atomic.AddInt64(&s.droppedMessages, 1)
+}
+
+// droppedCount returns the number of final results and other messages dropped so far
+func (s *Session) droppedCount() int64 {
+	return int64(atomic.LoadInt32(&s.droppedResults)) + atomic.LoadInt64(&s.droppedMessages)
+}
+
+// statsKey returns an opaque key identifying a session in GetStats. It is a truncated
+// SHA-256 of the ID, so operators can match it against a known ID without the stats exposing it.
+func statsKey(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:8])
+}
+
+// sessionStats returns the usage of every active session, ordered by key
+func (m *Manager) sessionStats() []SessionStats {
+	sessions := m.sessions.snapshot()
+	now := time.Now()
+	stats := make([]SessionStats, 0, len(sessions))
+	for _, session := range sessions {
+		stats = append(stats, session.stats(now))
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Key < stats[j].Key })
+	return stats
+}
+
+// closeWithSummary writes the summary of a closed session and then closes its connection.
+// It runs in the background so closing many sessions, as Drain and Shutdown do, does not
+// wait for each send loop in turn; Shutdown waits for all of them at once.
+func (m *Manager) closeWithSummary(session *Session, conn Conn, reason CloseReason) {
+	m.closing.Add(1)
+	go func() {
+		defer m.closing.Done()
+		m.sendSummary(session, conn, reason)
+		closeConn(conn, reason)
+	}()
+}
+
+// waitForSummaries waits up to summaryWriteTimeout for the connections of closed sessions
+// to be sent their summaries and closed
+func (m *Manager) waitForSummaries() {
+	done := make(chan struct{})
+	go func() {
+		m.closing.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(summaryWriteTimeout):
+		logger.Warn("session_summaries_timed_out", "timeout", summaryWriteTimeout)
+	}
+}
+
+// sendSummary logs the usage of a closing session and writes it to conn as
+// {"type": "session_summary", ...}. It is called after the session's send queue is closed
+// and waits for the send loop to stop, so the summary is the last message on the connection.
+// A client that already disconnected simply does not receive it.
+func (m *Manager) sendSummary(session *Session, conn Conn, reason CloseReason) {
+	stats := session.stats(time.Now())
+	logger.Info("session_summary",
+		"session_id", session.ID,
+		"reason", reason.String(),
+		"duration_seconds", stats.DurationSeconds,
+		"audio_seconds", stats.AudioSeconds,
+		"segments", stats.Segments,
+		"results", stats.Results,
+		"avg_decode_ms", stats.AvgDecodeMs,
+		"dropped_messages", stats.DroppedMessages,
+	)
+
+	if session.sendExited != nil {
+		select {
+		case <-session.sendExited:
+		case <-time.After(summaryWriteTimeout):
+			logger.Warn("session_summary_skipped", "session_id", session.ID, "reason", "send_loop_busy")
+			return
+		}
+	}
+
+	msg := map[string]interface{}{
+		"type":             "session_summary",
+		"session_id":       session.ID,
+		"reason":           reason.String(),
+		"duration_seconds": stats.DurationSeconds,
+		"audio_seconds":    stats.AudioSeconds,
+		"segments":         stats.Segments,
+		"results":          stats.Results,
+		"avg_decode_ms":    stats.AvgDecodeMs,
+		"dropped_messages": stats.DroppedMessages,
+		"timestamp":        time.Now().UnixMilli(),
+	}
+	if metadata := session.Metadata(); metadata != (Metadata{}) {
+		msg["metadata"] = metadata
+	}
+	if err := conn.WriteJSON(msg); err != nil {
+		logger.Debug("failed_to_send_session_summary", "session_id", session.ID, "error", err)
+	}
+}
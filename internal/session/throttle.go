@@ -0,0 +1,56 @@
+package session
+
+import (
+	"time"
+
+	"asr_server/internal/logger"
+
+	"golang.org/x/time/rate"
+)
+
+// ThrottleAudio blocks until the session may receive n more bytes of audio under
+// rate_limit.audio_bytes_per_second, so a client sending faster than allowed is slowed down
+// by TCP backpressure instead of flooding the recognizer. It reports whether it waited, so
+// the caller can extend its read deadline. It returns early when the session is closed.
+//
+// Only transports that read each session on its own goroutine call it before
+// ProcessAudioData; shared loops such as the MQTT bridge would stall every session.
+func (m *Manager) ThrottleAudio(sessionID string, n int) bool {
+	bytesPerSecond := m.cfg.RateLimit.AudioBytesPerSecond
+	if !m.cfg.RateLimit.Enabled || bytesPerSecond <= 0 || n <= 0 {
+		return false
+	}
+	session, exists := m.GetSession(sessionID)
+	if !exists {
+		return false
+	}
+	limiter := session.audioLimiter(bytesPerSecond)
+
+	start := time.Now()
+	if limiter.AllowN(start, n) {
+		return false
+	}
+	// WaitN fails for more than the burst, one second of audio, so wait in chunks
+	for n > 0 {
+		chunk := min(n, limiter.Burst())
+		if err := limiter.WaitN(session.ctx, chunk); err != nil {
+			break
+		}
+		n -= chunk
+	}
+	logger.Debug("audio_throttled", "session_id", sessionID, "waited", time.Since(start).String(), "audio_bytes_per_second", bytesPerSecond)
+	return true
+}
+
+// audioLimiter returns the session's audio rate limiter, following configuration reloads
+func (s *Session) audioLimiter(bytesPerSecond int) *rate.Limiter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.audioRate == nil {
+		s.audioRate = rate.NewLimiter(rate.Limit(bytesPerSecond), bytesPerSecond)
+	} else if s.audioRate.Burst() != bytesPerSecond {
+		s.audioRate.SetLimit(rate.Limit(bytesPerSecond))
+		s.audioRate.SetBurst(bytesPerSecond)
+	}
+	return s.audioRate
+}
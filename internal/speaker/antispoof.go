@@ -0,0 +1,48 @@
+package speaker
+
+// SpoofDetector scores how likely audio is live human speech rather than synthesized or
+// replayed. Implementations must be safe for concurrent use.
+type SpoofDetector interface {
+	// Score returns the probability, between 0 and 1, that the audio is genuine speech
+	Score(audioData []float32, sampleRate int) (float32, error)
+	Close()
+}
+
+// SpoofConfig configures the ONNX anti-spoofing model
+type SpoofConfig struct {
+	ModelPath   string // AASIST-style model: raw 16kHz waveform in, [spoof, bonafide] logits out
+	LibraryPath string // onnxruntime shared library, empty for the platform default
+	NumThreads  int
+}
+
+// LivenessResult is the anti-spoofing verdict attached to a verification
+type LivenessResult struct {
+	Score     float32 `json:"score"`
+	Threshold float32 `json:"threshold"`
+	Spoofed   bool    `json:"spoofed"`
+}
+
+// SetSpoofDetector enables liveness checks in VerifySpeaker. Audio scoring below threshold
+// is flagged as spoofed and fails verification. The manager closes the detector.
+func (m *Manager) SetSpoofDetector(detector SpoofDetector, threshold float32) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.spoofDetector = detector
+	m.spoofThreshold = threshold
+}
+
+// checkLiveness scores audio with the spoof detector, or returns nil when none is set
+func (m *Manager) checkLiveness(audioData []float32, sampleRate int) (*LivenessResult, error) {
+	if m.spoofDetector == nil {
+		return nil, nil
+	}
+	score, err := m.spoofDetector.Score(audioData, sampleRate)
+	if err != nil {
+		return nil, err
+	}
+	return &LivenessResult{
+		Score:     score,
+		Threshold: m.spoofThreshold,
+		Spoofed:   score < m.spoofThreshold,
+	}, nil
+}
@@ -0,0 +1,134 @@
+//go:build antispoof
+
+package speaker
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"asr_server/internal/audio"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+const (
+	// spoofSampleRate is the rate the countermeasure model was trained on
+	spoofSampleRate = 16000
+	// spoofWindow is the model's fixed input length, about 4 seconds
+	spoofWindow = 64600
+	// spoofMaxWindows caps the audio scored per request
+	spoofMaxWindows = 8
+)
+
+var ortInit struct {
+	once sync.Once
+	err  error
+}
+
+// onnxSpoofDetector runs an AASIST-style countermeasure model with onnxruntime
+type onnxSpoofDetector struct {
+	session *ort.DynamicAdvancedSession
+}
+
+// NewSpoofDetector loads the anti-spoofing model
+func NewSpoofDetector(config *SpoofConfig) (SpoofDetector, error) {
+	ortInit.once.Do(func() {
+		if config.LibraryPath != "" {
+			ort.SetSharedLibraryPath(config.LibraryPath)
+		}
+		ortInit.err = ort.InitializeEnvironment()
+	})
+	if ortInit.err != nil {
+		return nil, fmt.Errorf("failed to initialize onnxruntime: %v", ortInit.err)
+	}
+
+	inputs, outputs, err := ort.GetInputOutputInfo(config.ModelPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect anti-spoofing model: %v", err)
+	}
+	if len(inputs) == 0 || len(outputs) == 0 {
+		return nil, fmt.Errorf("anti-spoofing model has no inputs or outputs")
+	}
+
+	options, err := ort.NewSessionOptions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session options: %v", err)
+	}
+	defer options.Destroy()
+	if config.NumThreads > 0 {
+		if err := options.SetIntraOpNumThreads(config.NumThreads); err != nil {
+			return nil, fmt.Errorf("failed to set thread count: %v", err)
+		}
+	}
+
+	// The countermeasure outputs may include an embedding before the logits; use the last
+	session, err := ort.NewDynamicAdvancedSession(config.ModelPath,
+		[]string{inputs[0].Name}, []string{outputs[len(outputs)-1].Name}, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load anti-spoofing model: %v", err)
+	}
+
+	return &onnxSpoofDetector{session: session}, nil
+}
+
+// Score averages the bonafide probability over consecutive model windows. A short final
+// window is filled by repeating the audio, as in the model's training pipeline.
+func (d *onnxSpoofDetector) Score(audioData []float32, sampleRate int) (float32, error) {
+	if sampleRate != spoofSampleRate {
+		audioData = audio.Resample(audioData, sampleRate, spoofSampleRate)
+	}
+	if len(audioData) == 0 {
+		return 0, fmt.Errorf("no audio to score")
+	}
+
+	var total float32
+	windows := 0
+	for start := 0; start < len(audioData) && windows < spoofMaxWindows; start += spoofWindow {
+		window := make([]float32, spoofWindow)
+		segment := audioData[start:min(start+spoofWindow, len(audioData))]
+		for filled := 0; filled < spoofWindow; filled += len(segment) {
+			copy(window[filled:], segment)
+		}
+
+		score, err := d.scoreWindow(window)
+		if err != nil {
+			return 0, err
+		}
+		total += score
+		windows++
+	}
+
+	return total / float32(windows), nil
+}
+
+func (d *onnxSpoofDetector) scoreWindow(window []float32) (float32, error) {
+	input, err := ort.NewTensor(ort.NewShape(1, int64(len(window))), window)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create input tensor: %v", err)
+	}
+	defer input.Destroy()
+
+	outputs := []ort.Value{nil}
+	if err := d.session.Run([]ort.Value{input}, outputs); err != nil {
+		return 0, fmt.Errorf("anti-spoofing inference failed: %v", err)
+	}
+	defer outputs[0].Destroy()
+
+	logits, ok := outputs[0].(*ort.Tensor[float32])
+	if !ok {
+		return 0, fmt.Errorf("unexpected anti-spoofing output type")
+	}
+	data := logits.GetData()
+	if len(data) < 2 {
+		return 0, fmt.Errorf("unexpected anti-spoofing output size %d", len(data))
+	}
+
+	// Softmax over [spoof, bonafide]
+	spoof, bonafide := float64(data[0]), float64(data[1])
+	return float32(1 / (1 + math.Exp(spoof-bonafide))), nil
+}
+
+func (d *onnxSpoofDetector) Close() {
+	d.session.Destroy()
+}
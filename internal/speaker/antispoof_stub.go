@@ -0,0 +1,10 @@
+//go:build !antispoof
+
+package speaker
+
+import "fmt"
+
+// NewSpoofDetector always fails without the "antispoof" build tag
+func NewSpoofDetector(config *SpoofConfig) (SpoofDetector, error) {
+	return nil, fmt.Errorf("anti-spoofing not compiled in (rebuild with -tags antispoof)")
+}
@@ -0,0 +1,274 @@
+package speaker
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"asr_server/internal/logger"
+)
+
+// Audited actions
+const (
+	AuditRegister = "register"
+	AuditUpdate   = "update"
+	AuditDelete   = "delete"
+	AuditImport   = "import"
+)
+
+// Audit sinks
+const (
+	AuditSinkFile     = "file"
+	AuditSinkDatabase = "database"
+)
+
+// AuditEntry records one change to the speaker database
+type AuditEntry struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Action     string    `json:"action"`
+	SpeakerID  string    `json:"speaker_id"`
+	Actor      string    `json:"actor"`  // See RequestActor
+	Source     string    `json:"source"` // http or grpc
+	RemoteAddr string    `json:"remote_addr,omitempty"`
+	RequestID  string    `json:"request_id,omitempty"`
+}
+
+// AuditQuery selects audit entries, newest first. Zero values match everything.
+type AuditQuery struct {
+	From      time.Time
+	To        time.Time
+	Action    string
+	SpeakerID string
+	Actor     string
+	Page      int // From 1
+	PageSize  int
+}
+
+// matches reports whether an entry is selected by the query
+func (q *AuditQuery) matches(e *AuditEntry) bool {
+	return (q.From.IsZero() || !e.Timestamp.Before(q.From)) &&
+		(q.To.IsZero() || !e.Timestamp.After(q.To)) &&
+		(q.Action == "" || e.Action == q.Action) &&
+		(q.SpeakerID == "" || e.SpeakerID == q.SpeakerID) &&
+		(q.Actor == "" || e.Actor == q.Actor)
+}
+
+// AuditConfig selects where audit entries are written
+type AuditConfig struct {
+	Sink string // AuditSinkFile or AuditSinkDatabase
+	Path string // File sink path, defaults to <data_dir>/audit.jsonl
+}
+
+// auditSink stores audit entries
+type auditSink interface {
+	write(entry *AuditEntry) error
+	query(q *AuditQuery) ([]*AuditEntry, int, error)
+	close() error
+}
+
+// AuditLog is the audit trail of speaker registrations, updates and deletions.
+// A nil *AuditLog records nothing.
+type AuditLog struct {
+	sink auditSink
+}
+
+// NewAuditLog opens the audit trail. The database sink writes to the speaker_audit table of
+// the manager's SQL storage and requires the sqlite or postgres storage backend.
+func (m *Manager) NewAuditLog(config *AuditConfig) (*AuditLog, error) {
+	switch config.Sink {
+	case "", AuditSinkFile:
+		path := config.Path
+		if path == "" {
+			path = filepath.Join(m.dataDir, "audit.jsonl")
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create audit directory: %v", err)
+		}
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open audit file: %v", err)
+		}
+		return &AuditLog{sink: &fileAuditSink{path: path, file: file}}, nil
+	case AuditSinkDatabase:
+		store, ok := m.store.(*sqlStore)
+		if !ok {
+			return nil, fmt.Errorf("audit sink %q requires sqlite or postgres speaker storage", config.Sink)
+		}
+		return &AuditLog{sink: &sqlAuditSink{store: store}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported audit sink %q", config.Sink)
+	}
+}
+
+// Record writes an audit entry, stamping it with the current time. Failures are logged
+// rather than returned because the change being audited has already been applied.
+func (a *AuditLog) Record(entry AuditEntry) {
+	if a == nil {
+		return
+	}
+	entry.Timestamp = time.Now()
+	if err := a.sink.write(&entry); err != nil {
+		logger.Error("speaker_audit_write_failed", "action", entry.Action, "speaker_id", entry.SpeakerID, "error", err)
+	}
+}
+
+// Query returns one page of matching entries, newest first, and the total number of matches
+func (a *AuditLog) Query(q *AuditQuery) ([]*AuditEntry, int, error) {
+	return a.sink.query(q)
+}
+
+// Close releases the sink
+func (a *AuditLog) Close() error {
+	if a == nil {
+		return nil
+	}
+	return a.sink.close()
+}
+
+// RequestActor identifies the caller of a request for the audit log. API keys are recorded
+// as "key:" and a fingerprint so the log never contains credentials; callers without a key
+// are recorded as "ip:" and their address.
+func RequestActor(apiKey, remoteAddr string) string {
+	if apiKey != "" {
+		sum := sha256.Sum256([]byte(apiKey))
+		return "key:" + hex.EncodeToString(sum[:8])
+	}
+	return "ip:" + remoteAddr
+}
+
+// fileAuditSink appends entries as JSON lines. Queries scan the whole file.
+type fileAuditSink struct {
+	path string
+	mu   sync.Mutex
+	file *os.File
+}
+
+func (s *fileAuditSink) write(entry *AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+func (s *fileAuditSink) query(q *AuditQuery) ([]*AuditEntry, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open audit file: %v", err)
+	}
+	defer file.Close()
+
+	var matches []*AuditEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// A partially written line from a crash; skip it
+			continue
+		}
+		if q.matches(&entry) {
+			matches = append(matches, &entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read audit file: %v", err)
+	}
+
+	// Entries are appended in time order; return the newest first
+	total := len(matches)
+	start := total - (q.Page-1)*q.PageSize
+	end := max(start-q.PageSize, 0)
+	page := make([]*AuditEntry, 0, max(start-end, 0))
+	for i := start - 1; i >= end; i-- {
+		page = append(page, matches[i])
+	}
+	return page, total, nil
+}
+
+func (s *fileAuditSink) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// sqlAuditSink writes entries to the speaker_audit table of the speaker database. The store
+// owns the connection.
+type sqlAuditSink struct {
+	store *sqlStore
+}
+
+func (s *sqlAuditSink) write(entry *AuditEntry) error {
+	_, err := s.store.db.Exec(s.store.query(`INSERT INTO speaker_audit
+		(timestamp, action, speaker_id, actor, source, remote_addr, request_id) VALUES (?, ?, ?, ?, ?, ?, ?)`),
+		entry.Timestamp, entry.Action, entry.SpeakerID, entry.Actor, entry.Source, entry.RemoteAddr, entry.RequestID)
+	return err
+}
+
+func (s *sqlAuditSink) query(q *AuditQuery) ([]*AuditEntry, int, error) {
+	var conditions []string
+	var args []interface{}
+	if !q.From.IsZero() {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, q.From)
+	}
+	if !q.To.IsZero() {
+		conditions = append(conditions, "timestamp <= ?")
+		args = append(args, q.To)
+	}
+	for _, filter := range []struct{ column, value string }{
+		{"action", q.Action}, {"speaker_id", q.SpeakerID}, {"actor", q.Actor},
+	} {
+		if filter.value != "" {
+			conditions = append(conditions, filter.column+" = ?")
+			args = append(args, filter.value)
+		}
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := s.store.db.QueryRow(s.store.query("SELECT COUNT(*) FROM speaker_audit"+where), args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit entries: %v", err)
+	}
+
+	rows, err := s.store.db.Query(s.store.query(`SELECT timestamp, action, speaker_id, actor, source, remote_addr, request_id
+		FROM speaker_audit`+where+" ORDER BY id DESC LIMIT ? OFFSET ?"),
+		append(args, q.PageSize, (q.Page-1)*q.PageSize)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query audit entries: %v", err)
+	}
+	defer rows.Close()
+
+	entries := []*AuditEntry{}
+	for rows.Next() {
+		entry := &AuditEntry{}
+		if err := rows.Scan(&entry.Timestamp, &entry.Action, &entry.SpeakerID, &entry.Actor, &entry.Source, &entry.RemoteAddr, &entry.RequestID); err != nil {
+			return nil, 0, fmt.Errorf("failed to read audit entry: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read audit entries: %v", err)
+	}
+	return entries, total, nil
+}
+
+func (s *sqlAuditSink) close() error {
+	return nil
+}
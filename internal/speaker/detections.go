@@ -0,0 +1,312 @@
+package speaker
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"asr_server/internal/logger"
+	"asr_server/internal/session"
+)
+
+const (
+	// detectionQueueSize bounds detections waiting to be written
+	detectionQueueSize = 10000
+	// detectionPruneInterval is how often detections older than the retention are removed
+	detectionPruneInterval = time.Hour
+	// maxDetectionLineSize bounds one line of the detection file
+	maxDetectionLineSize = 64 << 10
+)
+
+// Detection is a speech segment of a session attributed to an enrolled speaker
+type Detection struct {
+	SessionID   string    `json:"session_id"`
+	SpeakerID   string    `json:"speaker_id"`
+	SpeakerName string    `json:"speaker_name"`
+	StartTime   float64   `json:"start_time"` // Seconds since the stream started
+	EndTime     float64   `json:"end_time"`
+	Timestamp   time.Time `json:"timestamp"` // When the segment was recognized
+}
+
+// SessionSpeaker summarizes the detections of one speaker in a session
+type SessionSpeaker struct {
+	SpeakerID     string    `json:"speaker_id"`
+	SpeakerName   string    `json:"speaker_name"`
+	Segments      int       `json:"segments"`
+	SpeechSeconds float64   `json:"speech_seconds"`
+	FirstSeen     time.Time `json:"first_seen"`
+	LastSeen      time.Time `json:"last_seen"`
+}
+
+// SessionDetections lists the enrolled speakers detected in a session in order of appearance
+type SessionDetections struct {
+	SessionID string            `json:"session_id"`
+	FirstSeen time.Time         `json:"first_seen"`
+	Speakers  []*SessionSpeaker `json:"speakers"`
+}
+
+// DetectionQuery selects detections. Zero values match everything.
+type DetectionQuery struct {
+	From       time.Time
+	To         time.Time
+	SessionIDs []string
+	SpeakerID  string
+}
+
+// matches reports whether a detection is selected by the query
+func (q *DetectionQuery) matches(d *Detection) bool {
+	if !q.From.IsZero() && d.Timestamp.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && d.Timestamp.After(q.To) {
+		return false
+	}
+	if len(q.SessionIDs) > 0 && !slices.Contains(q.SessionIDs, d.SessionID) {
+		return false
+	}
+	return q.SpeakerID == "" || d.SpeakerID == q.SpeakerID
+}
+
+// DetectionLog persists the enrolled speakers recognized in session results as JSON lines in
+// <data_dir>/detections.jsonl. It implements session.ResultPublisher; results are written by a
+// background goroutine so publishing never blocks recognition.
+type DetectionLog struct {
+	path      string
+	retention time.Duration // Detections older than this are pruned, 0 keeps them forever
+
+	mu   sync.Mutex // Guards file and the file contents
+	file *os.File
+
+	queue chan *Detection
+	done  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// NewDetectionLog opens the detection file in dataDir, pruning detections older than retention
+func NewDetectionLog(dataDir string, retention time.Duration) (*DetectionLog, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %v", err)
+	}
+
+	l := &DetectionLog{
+		path:      filepath.Join(dataDir, "detections.jsonl"),
+		retention: retention,
+		queue:     make(chan *Detection, detectionQueueSize),
+		done:      make(chan struct{}),
+	}
+	if retention > 0 {
+		if err := l.prune(); err != nil {
+			return nil, err
+		}
+	}
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open detection file: %v", err)
+	}
+	l.file = file
+
+	l.wg.Add(1)
+	go l.run()
+
+	return l, nil
+}
+
+// Publish implements session.ResultPublisher, recording results attributed to an enrolled speaker
+func (l *DetectionLog) Publish(event session.ResultEvent) {
+	if event.SpeakerID == "" {
+		return
+	}
+
+	detection := &Detection{
+		SessionID:   event.SessionID,
+		SpeakerID:   event.SpeakerID,
+		SpeakerName: event.SpeakerName,
+		StartTime:   event.StartTime,
+		EndTime:     event.EndTime,
+		Timestamp:   time.UnixMilli(event.Timestamp),
+	}
+	select {
+	case l.queue <- detection:
+	default:
+		logger.Warn("speaker_detection_queue_full", "session_id", event.SessionID, "action", "dropped_detection")
+	}
+}
+
+// Close writes queued detections and closes the file
+func (l *DetectionLog) Close() error {
+	close(l.done)
+	l.wg.Wait()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// run writes queued detections and periodically prunes expired ones
+func (l *DetectionLog) run() {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(detectionPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case detection := <-l.queue:
+			l.write(detection)
+		case <-ticker.C:
+			if l.retention > 0 {
+				if err := l.prune(); err != nil {
+					logger.Warn("speaker_detection_prune_failed", "error", err)
+				}
+			}
+		case <-l.done:
+			for {
+				select {
+				case detection := <-l.queue:
+					l.write(detection)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// write appends one detection to the file
+func (l *DetectionLog) write(detection *Detection) {
+	line, err := json.Marshal(detection)
+	if err != nil {
+		logger.Warn("speaker_detection_marshal_failed", "error", err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		logger.Warn("speaker_detection_write_failed", "error", err)
+	}
+}
+
+// scan calls fn for every readable detection in the file. The caller must hold l.mu.
+func (l *DetectionLog) scan(fn func(*Detection)) error {
+	file, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open detection file: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 4096), maxDetectionLineSize)
+	for scanner.Scan() {
+		var detection Detection
+		if err := json.Unmarshal(scanner.Bytes(), &detection); err != nil {
+			// A partially written line from a crash; skip it
+			continue
+		}
+		fn(&detection)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read detection file: %v", err)
+	}
+	return nil
+}
+
+// prune rewrites the file without detections older than the retention
+func (l *DetectionLog) prune() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cutoff := time.Now().Add(-l.retention)
+	var kept []byte
+	removed := 0
+	if err := l.scan(func(d *Detection) {
+		if d.Timestamp.Before(cutoff) {
+			removed++
+			return
+		}
+		line, _ := json.Marshal(d)
+		kept = append(append(kept, line...), '\n')
+	}); err != nil {
+		return err
+	}
+	if removed == 0 {
+		return nil
+	}
+
+	tmpPath := l.path + ".tmp"
+	if err := os.WriteFile(tmpPath, kept, 0644); err != nil {
+		return fmt.Errorf("failed to write detection file: %v", err)
+	}
+	if l.file != nil {
+		l.file.Close()
+	}
+	if err := os.Rename(tmpPath, l.path); err != nil {
+		return fmt.Errorf("failed to replace detection file: %v", err)
+	}
+	if l.file != nil {
+		file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to reopen detection file: %v", err)
+		}
+		l.file = file
+	}
+
+	logger.Info("speaker_detections_pruned", "removed", removed, "retention", l.retention)
+	return nil
+}
+
+// Query returns the enrolled speakers detected in each session matching q, ordered by
+// when the session's first detection occurred
+func (l *DetectionLog) Query(q *DetectionQuery) ([]*SessionDetections, error) {
+	sessions := make(map[string]*SessionDetections)
+	speakers := make(map[string]map[string]*SessionSpeaker)
+
+	l.mu.Lock()
+	err := l.scan(func(d *Detection) {
+		if !q.matches(d) {
+			return
+		}
+		result, exists := sessions[d.SessionID]
+		if !exists {
+			result = &SessionDetections{SessionID: d.SessionID, FirstSeen: d.Timestamp}
+			sessions[d.SessionID] = result
+			speakers[d.SessionID] = make(map[string]*SessionSpeaker)
+		}
+		speaker, exists := speakers[d.SessionID][d.SpeakerID]
+		if !exists {
+			speaker = &SessionSpeaker{SpeakerID: d.SpeakerID, FirstSeen: d.Timestamp}
+			speakers[d.SessionID][d.SpeakerID] = speaker
+			result.Speakers = append(result.Speakers, speaker)
+		}
+		// Report the most recent name in case the speaker was renamed
+		speaker.SpeakerName = d.SpeakerName
+		speaker.Segments++
+		speaker.SpeechSeconds += d.EndTime - d.StartTime
+		speaker.LastSeen = d.Timestamp
+	})
+	l.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*SessionDetections, 0, len(sessions))
+	for _, result := range sessions {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if !results[i].FirstSeen.Equal(results[j].FirstSeen) {
+			return results[i].FirstSeen.Before(results[j].FirstSeen)
+		}
+		return results[i].SessionID < results[j].SessionID
+	})
+	return results, nil
+}
@@ -0,0 +1,53 @@
+package speaker
+
+import (
+	"errors"
+	"fmt"
+
+	"asr_server/internal/logger"
+)
+
+// ErrInsufficientSpeech is returned when audio holds too little speech for a reliable embedding
+var ErrInsufficientSpeech = errors.New("insufficient speech")
+
+// SpeechTrimmer removes silence and non-speech from audio before embedding extraction
+type SpeechTrimmer interface {
+	// TrimSilence returns the speech in samples and its sample rate
+	TrimSilence(samples []float32, sampleRate int) ([]float32, int, error)
+}
+
+// SetSpeechTrimmer makes duration limits apply to detected speech rather than the whole clip.
+// It must be called before the manager serves requests.
+func (m *Manager) SetSpeechTrimmer(trimmer SpeechTrimmer) {
+	m.trimmer = trimmer
+}
+
+// prepareAudio trims silence, rejects audio with less than minSeconds of speech and cuts it
+// to the configured maximum
+func (m *Manager) prepareAudio(audioData []float32, sampleRate int, minSeconds float64, purpose string) ([]float32, int, error) {
+	if m.trimmer != nil {
+		speech, rate, err := m.trimmer.TrimSilence(audioData, sampleRate)
+		if err != nil {
+			// Fall back to the untrimmed clip rather than failing the request
+			logger.Warn("speaker_speech_trimming_failed", "error", err)
+		} else {
+			audioData, sampleRate = speech, rate
+		}
+	}
+
+	duration := float64(len(audioData)) / float64(sampleRate)
+	if duration < minSeconds {
+		what := "audio"
+		if m.trimmer != nil {
+			what = "speech after removing silence"
+		}
+		return nil, 0, fmt.Errorf("%w: %.1fs of %s, at least %.1fs is required for %s; provide a longer recording with continuous speech",
+			ErrInsufficientSpeech, duration, what, minSeconds, purpose)
+	}
+
+	if m.maxSpeechSeconds > 0 && duration > m.maxSpeechSeconds {
+		audioData = audioData[:int(m.maxSpeechSeconds*float64(sampleRate))]
+		logger.Info("speaker_audio_trimmed", "purpose", purpose, "duration", duration, "max_seconds", m.maxSpeechSeconds)
+	}
+	return audioData, sampleRate, nil
+}
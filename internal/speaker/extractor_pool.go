@@ -0,0 +1,124 @@
+package speaker
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"asr_server/internal/logger"
+
+	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
+)
+
+// DefaultExtractorPoolSize is the number of embedding extractors loaded when unset
+const DefaultExtractorPoolSize = 2
+
+// extractorPool holds a fixed number of embedding extractors so concurrent requests each
+// compute on their own instance. When all are busy, callers wait for one to be returned;
+// extractors load the full model, so no temporary instances are created.
+type extractorPool struct {
+	available chan *sherpa.SpeakerEmbeddingExtractor
+	instances []*sherpa.SpeakerEmbeddingExtractor
+	closeOnce sync.Once
+
+	// Statistics
+	totalAcquired int64
+	totalWaited   int64 // Acquisitions that found no free extractor
+	totalWaitNs   int64
+	active        int64
+}
+
+// newExtractorPool loads size extractors in parallel. It fails only if none can be created.
+func newExtractorPool(config *sherpa.SpeakerEmbeddingExtractorConfig, size int) (*extractorPool, error) {
+	if size <= 0 {
+		size = DefaultExtractorPoolSize
+	}
+	logger.Info("initializing_speaker_extractor_pool", "size", size)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	instances := make([]*sherpa.SpeakerEmbeddingExtractor, 0, size)
+	for i := 0; i < size; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			extractor := sherpa.NewSpeakerEmbeddingExtractor(config)
+			if extractor == nil {
+				logger.Warn("failed_to_create_speaker_extractor", "id", id)
+				return
+			}
+			mu.Lock()
+			instances = append(instances, extractor)
+			mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("failed to create speaker embedding extractor")
+	}
+
+	p := &extractorPool{
+		available: make(chan *sherpa.SpeakerEmbeddingExtractor, len(instances)),
+		instances: instances,
+	}
+	for _, extractor := range instances {
+		p.available <- extractor
+	}
+	logger.Info("speaker_extractor_pool_initialized", "success_count", len(instances), "target_size", size)
+	return p, nil
+}
+
+// dim returns the embedding dimension of the loaded model
+func (p *extractorPool) dim() int {
+	return p.instances[0].Dim()
+}
+
+// get takes an extractor, waiting until one is free
+func (p *extractorPool) get() *sherpa.SpeakerEmbeddingExtractor {
+	var extractor *sherpa.SpeakerEmbeddingExtractor
+	select {
+	case extractor = <-p.available:
+	default:
+		start := time.Now()
+		extractor = <-p.available
+		atomic.AddInt64(&p.totalWaited, 1)
+		atomic.AddInt64(&p.totalWaitNs, int64(time.Since(start)))
+	}
+	atomic.AddInt64(&p.totalAcquired, 1)
+	atomic.AddInt64(&p.active, 1)
+	return extractor
+}
+
+// put returns an extractor taken with get
+func (p *extractorPool) put(extractor *sherpa.SpeakerEmbeddingExtractor) {
+	atomic.AddInt64(&p.active, -1)
+	p.available <- extractor
+}
+
+// stats returns pool usage for the stats endpoints
+func (p *extractorPool) stats() map[string]interface{} {
+	waited := atomic.LoadInt64(&p.totalWaited)
+	avgWaitMs := float64(0)
+	if waited > 0 {
+		avgWaitMs = float64(atomic.LoadInt64(&p.totalWaitNs)) / float64(waited) / float64(time.Millisecond)
+	}
+	return map[string]interface{}{
+		"pool_size":       len(p.instances),
+		"available_count": len(p.available),
+		"active_count":    atomic.LoadInt64(&p.active),
+		"total_acquired":  atomic.LoadInt64(&p.totalAcquired),
+		"total_waited":    waited,
+		"avg_wait_ms":     avgWaitMs,
+	}
+}
+
+// close destroys every extractor. Callers must have returned all of them.
+func (p *extractorPool) close() {
+	p.closeOnce.Do(func() {
+		for _, extractor := range p.instances {
+			sherpa.DeleteSpeakerEmbeddingExtractor(extractor)
+		}
+	})
+}
@@ -0,0 +1,271 @@
+//go:build grpc
+
+package speaker
+
+import (
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// wireMessage is implemented by the hand-encoded messages of speaker.proto
+type wireMessage interface {
+	marshal() []byte
+	unmarshal(b []byte) error
+}
+
+// wireCodec is a gRPC codec for wireMessage values. It registers under the "proto"
+// name so clients generated from speaker.proto interoperate unchanged.
+type wireCodec struct{}
+
+func (wireCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(wireMessage)
+	if !ok {
+		return nil, fmt.Errorf("unsupported message type %T", v)
+	}
+	return msg.marshal(), nil
+}
+
+func (wireCodec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(wireMessage)
+	if !ok {
+		return fmt.Errorf("unsupported message type %T", v)
+	}
+	return msg.unmarshal(data)
+}
+
+func (wireCodec) Name() string {
+	return "proto"
+}
+
+// audioRequest is speaker.v1.AudioRequest
+type audioRequest struct {
+	speakerID   string
+	speakerName string
+	sampleRate  int
+	pcm         []byte
+}
+
+func (m *audioRequest) marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, m.speakerID)
+	b = appendString(b, 2, m.speakerName)
+	b = appendVarint(b, 3, uint64(m.sampleRate))
+	if len(m.pcm) > 0 {
+		b = protowire.AppendTag(b, 4, protowire.BytesType)
+		b = protowire.AppendBytes(b, m.pcm)
+	}
+	return b
+}
+
+func (m *audioRequest) unmarshal(b []byte) error {
+	*m = audioRequest{}
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			m.speakerID = v
+			return n
+		case num == 2 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			m.speakerName = v
+			return n
+		case num == 3 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			m.sampleRate = int(int32(v))
+			return n
+		case num == 4 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			m.pcm = v
+			return n
+		}
+		return protowire.ConsumeFieldValue(num, typ, b)
+	})
+}
+
+// registerResponse is speaker.v1.RegisterResponse
+type registerResponse struct {
+	speakerID   string
+	speakerName string
+}
+
+func (m *registerResponse) marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, m.speakerID)
+	b = appendString(b, 2, m.speakerName)
+	return b
+}
+
+func (m *registerResponse) unmarshal(b []byte) error {
+	return fmt.Errorf("registerResponse is server-to-client only")
+}
+
+// identifyResponse is speaker.v1.IdentifyResponse
+type identifyResponse struct {
+	*IdentifyResult
+}
+
+func (m *identifyResponse) marshal() []byte {
+	var b []byte
+	b = appendBool(b, 1, m.Identified)
+	b = appendString(b, 2, m.SpeakerID)
+	b = appendString(b, 3, m.SpeakerName)
+	b = appendFloat(b, 4, m.Confidence)
+	b = appendFloat(b, 5, m.Threshold)
+	return b
+}
+
+func (m *identifyResponse) unmarshal(b []byte) error {
+	return fmt.Errorf("identifyResponse is server-to-client only")
+}
+
+// verifyResponse is speaker.v1.VerifyResponse
+type verifyResponse struct {
+	*VerifyResult
+}
+
+func (m *verifyResponse) marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, m.SpeakerID)
+	b = appendString(b, 2, m.SpeakerName)
+	b = appendBool(b, 3, m.Verified)
+	b = appendFloat(b, 4, m.Confidence)
+	b = appendFloat(b, 5, m.Threshold)
+	if m.Liveness != nil {
+		var lb []byte
+		lb = appendFloat(lb, 1, m.Liveness.Score)
+		lb = appendFloat(lb, 2, m.Liveness.Threshold)
+		lb = appendBool(lb, 3, m.Liveness.Spoofed)
+
+		b = protowire.AppendTag(b, 6, protowire.BytesType)
+		b = protowire.AppendBytes(b, lb)
+	}
+	return b
+}
+
+func (m *verifyResponse) unmarshal(b []byte) error {
+	return fmt.Errorf("verifyResponse is server-to-client only")
+}
+
+// listRequest is speaker.v1.ListRequest
+type listRequest struct{}
+
+func (m *listRequest) marshal() []byte {
+	return nil
+}
+
+func (m *listRequest) unmarshal(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		return protowire.ConsumeFieldValue(num, typ, b)
+	})
+}
+
+// listResponse is speaker.v1.ListResponse
+type listResponse struct {
+	speakers []*SpeakerInfo
+}
+
+func (m *listResponse) marshal() []byte {
+	var b []byte
+	for _, s := range m.speakers {
+		var sb []byte
+		sb = appendString(sb, 1, s.ID)
+		sb = appendString(sb, 2, s.Name)
+		sb = appendVarint(sb, 3, uint64(s.SampleCount))
+		sb = appendVarint(sb, 4, uint64(s.CreatedAt.UnixMilli()))
+		sb = appendVarint(sb, 5, uint64(s.UpdatedAt.UnixMilli()))
+
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, sb)
+	}
+	return b
+}
+
+func (m *listResponse) unmarshal(b []byte) error {
+	return fmt.Errorf("listResponse is server-to-client only")
+}
+
+// deleteRequest is speaker.v1.DeleteRequest
+type deleteRequest struct {
+	speakerID string
+}
+
+func (m *deleteRequest) marshal() []byte {
+	return appendString(nil, 1, m.speakerID)
+}
+
+func (m *deleteRequest) unmarshal(b []byte) error {
+	*m = deleteRequest{}
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		if num == 1 && typ == protowire.BytesType {
+			v, n := protowire.ConsumeString(b)
+			m.speakerID = v
+			return n
+		}
+		return protowire.ConsumeFieldValue(num, typ, b)
+	})
+}
+
+// deleteResponse is speaker.v1.DeleteResponse
+type deleteResponse struct {
+	speakerID string
+}
+
+func (m *deleteResponse) marshal() []byte {
+	return appendString(nil, 1, m.speakerID)
+}
+
+func (m *deleteResponse) unmarshal(b []byte) error {
+	return fmt.Errorf("deleteResponse is server-to-client only")
+}
+
+// consumeFields walks the fields of an encoded message. consume returns the number of bytes
+// it read for the field value, or a negative protowire error code.
+func consumeFields(b []byte, consume func(num protowire.Number, typ protowire.Type, b []byte) int) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		n = consume(num, typ, b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func appendBool(b []byte, num protowire.Number, v bool) []byte {
+	if !v {
+		return b
+	}
+	return appendVarint(b, num, 1)
+}
+
+func appendFloat(b []byte, num protowire.Number, v float32) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.Fixed32Type)
+	return protowire.AppendFixed32(b, math.Float32bits(v))
+}
@@ -0,0 +1,257 @@
+//go:build grpc
+
+package speaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"asr_server/config"
+	"asr_server/internal/audio"
+	"asr_server/internal/logger"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// defaultGRPCSampleRate is assumed when an audio stream does not set sample_rate
+const defaultGRPCSampleRate = 16000
+
+// GRPCServer exposes the speaker manager as the speaker.v1.SpeakerService gRPC service.
+// All dependencies are explicitly injected via constructor.
+type GRPCServer struct {
+	cfg     *config.Config
+	manager *Manager
+	audit   *AuditLog // nil when auditing is disabled
+	server  *grpc.Server
+}
+
+// NewGRPCServer creates a new gRPC speaker server with explicit dependencies. audit may be nil.
+func NewGRPCServer(cfg *config.Config, manager *Manager, audit *AuditLog) (*GRPCServer, error) {
+	s := &GRPCServer{
+		cfg:     cfg,
+		manager: manager,
+		audit:   audit,
+		server:  grpc.NewServer(grpc.ForceServerCodec(wireCodec{})),
+	}
+	s.server.RegisterService(&speakerServiceDesc, s)
+	return s, nil
+}
+
+// Start binds the gRPC listener and begins serving in the background
+func (s *GRPCServer) Start() error {
+	listener, err := net.Listen("tcp", s.cfg.Speaker.GRPC.ListenAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for gRPC: %v", err)
+	}
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil {
+			logger.Error("speaker_grpc_server_error", "error", err)
+		}
+	}()
+
+	logger.Info("speaker_grpc_server_started", "listen_addr", listener.Addr().String())
+	return nil
+}
+
+// Stop waits for in-flight RPCs to finish and closes the listener
+func (s *GRPCServer) Stop() {
+	s.server.GracefulStop()
+}
+
+// speakerServiceDesc describes speaker.v1.SpeakerService from speaker.proto
+var speakerServiceDesc = grpc.ServiceDesc{
+	ServiceName: "speaker.v1.SpeakerService",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "List", Handler: listHandler},
+		{MethodName: "Delete", Handler: deleteHandler},
+	},
+	Streams: []grpc.StreamDesc{
+		{StreamName: "Register", Handler: registerHandler, ClientStreams: true},
+		{StreamName: "Identify", Handler: identifyHandler, ClientStreams: true},
+		{StreamName: "Verify", Handler: verifyHandler, ClientStreams: true},
+	},
+	Metadata: "speaker.proto",
+}
+
+func registerHandler(srv interface{}, stream grpc.ServerStream) error {
+	s := srv.(*GRPCServer)
+	first, samples, sampleRate, err := s.receiveAudio(stream)
+	if err != nil {
+		return err
+	}
+	if first.speakerID == "" {
+		return status.Error(codes.InvalidArgument, "speaker_id is required")
+	}
+	if first.speakerName == "" {
+		return status.Error(codes.InvalidArgument, "speaker_name is required")
+	}
+
+	if err := s.manager.RegisterSpeaker(first.speakerID, first.speakerName, nil, samples, sampleRate); err != nil {
+		if errors.Is(err, ErrInsufficientSpeech) {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		return status.Errorf(codes.Internal, "failed to register speaker: %v", err)
+	}
+	s.recordAudit(stream.Context(), AuditRegister, first.speakerID)
+
+	return stream.SendMsg(&registerResponse{speakerID: first.speakerID, speakerName: first.speakerName})
+}
+
+func identifyHandler(srv interface{}, stream grpc.ServerStream) error {
+	s := srv.(*GRPCServer)
+	_, samples, sampleRate, err := s.receiveAudio(stream)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.manager.IdentifySpeaker(samples, sampleRate)
+	if err != nil {
+		if errors.Is(err, ErrInsufficientSpeech) {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		return status.Errorf(codes.Internal, "failed to identify speaker: %v", err)
+	}
+
+	return stream.SendMsg(&identifyResponse{result})
+}
+
+func verifyHandler(srv interface{}, stream grpc.ServerStream) error {
+	s := srv.(*GRPCServer)
+	first, samples, sampleRate, err := s.receiveAudio(stream)
+	if err != nil {
+		return err
+	}
+	if first.speakerID == "" {
+		return status.Error(codes.InvalidArgument, "speaker_id is required")
+	}
+
+	result, err := s.manager.VerifySpeaker(first.speakerID, samples, sampleRate)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return status.Error(codes.NotFound, err.Error())
+		}
+		if errors.Is(err, ErrInsufficientSpeech) {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		return status.Errorf(codes.Internal, "failed to verify speaker: %v", err)
+	}
+
+	return stream.SendMsg(&verifyResponse{result})
+}
+
+func listHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := &listRequest{}
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return &listResponse{speakers: srv.(*GRPCServer).manager.GetAllSpeakers()}, nil
+	}
+	if interceptor == nil {
+		return handler(ctx, req)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/speaker.v1.SpeakerService/List"}
+	return interceptor(ctx, req, info, handler)
+}
+
+func deleteHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := &deleteRequest{}
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		speakerID := req.(*deleteRequest).speakerID
+		if speakerID == "" {
+			return nil, status.Error(codes.InvalidArgument, "speaker_id is required")
+		}
+		if err := srv.(*GRPCServer).manager.DeleteSpeaker(speakerID); err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				return nil, status.Error(codes.NotFound, err.Error())
+			}
+			return nil, status.Errorf(codes.Internal, "failed to delete speaker: %v", err)
+		}
+		srv.(*GRPCServer).recordAudit(ctx, AuditDelete, speakerID)
+		return &deleteResponse{speakerID: speakerID}, nil
+	}
+	if interceptor == nil {
+		return handler(ctx, req)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/speaker.v1.SpeakerService/Delete"}
+	return interceptor(ctx, req, info, handler)
+}
+
+// recordAudit records a change made by an RPC. The actor is taken from the x-api-key or
+// authorization bearer metadata, falling back to the peer address.
+func (s *GRPCServer) recordAudit(ctx context.Context, action, speakerID string) {
+	var apiKey string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("x-api-key"); len(values) > 0 {
+			apiKey = values[0]
+		} else if values := md.Get("authorization"); len(values) > 0 {
+			apiKey, _ = strings.CutPrefix(values[0], "Bearer ")
+		}
+	}
+	var remoteAddr string
+	if p, ok := peer.FromContext(ctx); ok {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			remoteAddr = host
+		} else {
+			remoteAddr = p.Addr.String()
+		}
+	}
+	s.audit.Record(AuditEntry{
+		Action:     action,
+		SpeakerID:  speakerID,
+		Actor:      RequestActor(apiKey, remoteAddr),
+		Source:     "grpc",
+		RemoteAddr: remoteAddr,
+	})
+}
+
+// receiveAudio reads an AudioRequest stream until the client closes it. It returns the first
+// message, which carries the request metadata, and the decoded samples.
+func (s *GRPCServer) receiveAudio(stream grpc.ServerStream) (*audioRequest, []float32, int, error) {
+	var first *audioRequest
+	var pcm []byte
+	sampleRate := defaultGRPCSampleRate
+	maxSeconds := s.cfg.Speaker.GRPC.MaxAudioSeconds
+
+	for {
+		req := &audioRequest{}
+		if err := stream.RecvMsg(req); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, nil, 0, err
+		}
+		if first == nil {
+			first = req
+			if req.sampleRate > 0 {
+				sampleRate = req.sampleRate
+			}
+		}
+
+		pcm = append(pcm, req.pcm...)
+		if maxSeconds > 0 && len(pcm) > maxSeconds*sampleRate*2 {
+			return nil, nil, 0, status.Errorf(codes.InvalidArgument, "audio exceeds maximum length of %d seconds", maxSeconds)
+		}
+	}
+
+	if first == nil || len(pcm) < 2 {
+		return nil, nil, 0, status.Error(codes.InvalidArgument, "audio is required")
+	}
+
+	return first, audio.PCM16ToFloat32(pcm), sampleRate, nil
+}
@@ -0,0 +1,25 @@
+//go:build !grpc
+
+package speaker
+
+import (
+	"fmt"
+
+	"asr_server/config"
+)
+
+// GRPCServer is unavailable without the "grpc" build tag
+type GRPCServer struct{}
+
+// NewGRPCServer always fails without the "grpc" build tag
+func NewGRPCServer(cfg *config.Config, manager *Manager, audit *AuditLog) (*GRPCServer, error) {
+	return nil, fmt.Errorf("gRPC support not compiled in (rebuild with -tags grpc)")
+}
+
+// Start is a no-op without the "grpc" build tag
+func (s *GRPCServer) Start() error {
+	return nil
+}
+
+// Stop is a no-op without the "grpc" build tag
+func (s *GRPCServer) Stop() {}
@@ -2,42 +2,90 @@ package speaker
 
 import (
 	"asr_server/config"
+	"asr_server/internal/audio"
+	"asr_server/internal/auth"
+	"asr_server/internal/middleware"
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-audio/wav"
+)
+
+// Base64 API limits
+const (
+	// MaxBase64RequestSize caps the JSON body, about 12 minutes of 16kHz PCM after Base64 encoding
+	MaxBase64RequestSize = 32 << 20
+	MinSampleRate        = 8000
+	MaxSampleRate        = 48000
+)
+
+// MaxImportRequestSize caps the body of an import request
+const MaxImportRequestSize = 256 << 20
+
+// List API limits
+const (
+	DefaultListPageSize = 100
+	MaxListPageSize     = 1000
+)
+
+// Search API limits
+const (
+	DefaultSearchTopK = 5
+	MaxSearchTopK     = 100
 )
 
 // Handler handles speaker recognition HTTP requests.
 // All dependencies are explicitly injected via constructor.
 type Handler struct {
-	manager *Manager
-	cfg     *config.Config
+	manager    *Manager
+	detections *DetectionLog // nil when speaker tracking is disabled
+	audit      *AuditLog     // nil when auditing is disabled
+	cfg        *config.Config
 }
 
-// NewHandler creates a new handler with explicit dependencies
-func NewHandler(manager *Manager, cfg *config.Config) *Handler {
+// NewHandler creates a new handler with explicit dependencies. detections and audit may be nil.
+func NewHandler(manager *Manager, detections *DetectionLog, audit *AuditLog, cfg *config.Config) *Handler {
 	return &Handler{
-		manager: manager,
-		cfg:     cfg,
+		manager:    manager,
+		detections: detections,
+		audit:      audit,
+		cfg:        cfg,
 	}
 }
 
-// RegisterRoutes registers routes
-func (h *Handler) RegisterRoutes(router *gin.Engine) {
-	speakerGroup := router.Group("/api/v1/speaker")
+// RegisterRoutes registers routes. Changes to the speaker database, and exporting its
+// voiceprints, require the speaker:write scope and the other routes speaker:read.
+func (h *Handler) RegisterRoutes(router gin.IRouter) {
+	upload := middleware.UploadLimit(h.cfg.Speaker.MaxUploadSize, h.cfg.Server.MultipartMemory)
+	read := router.Group("/api/v1/speaker", middleware.RequireScope(auth.ScopeSpeakerRead))
 	{
-		speakerGroup.POST("/register", h.RegisterSpeaker)
-		speakerGroup.POST("/identify", h.IdentifySpeaker)
-		speakerGroup.POST("/verify/:speaker_id", h.VerifySpeaker)
-		speakerGroup.GET("/list", h.GetAllSpeakers)
-		speakerGroup.DELETE("/:speaker_id", h.DeleteSpeaker)
-		speakerGroup.GET("/stats", h.GetStats)
-		speakerGroup.POST("/register_base64", h.RegisterSpeakerBase64)
-		speakerGroup.POST("/identify_base64", h.IdentifySpeakerBase64)
+		read.POST("/identify", upload, h.IdentifySpeaker)
+		read.POST("/search", upload, h.SearchSpeakers)
+		read.POST("/verify/:speaker_id", upload, h.VerifySpeaker)
+		read.GET("/list", h.GetAllSpeakers)
+		read.GET("/stats", h.GetStats)
+		read.GET("/detections", h.GetDetections)
+		read.POST("/identify_base64", h.IdentifySpeakerBase64)
+	}
+	write := router.Group("/api/v1/speaker", middleware.RequireScope(auth.ScopeSpeakerWrite))
+	{
+		write.POST("/register", upload, h.RegisterSpeaker)
+		write.PATCH("/:speaker_id", h.UpdateSpeaker)
+		write.DELETE("/:speaker_id", h.DeleteSpeaker)
+		write.GET("/export", h.ExportSpeakers)
+		write.POST("/import", h.ImportSpeakers)
+		write.POST("/register_base64", h.RegisterSpeakerBase64)
 	}
 }
 
@@ -77,14 +125,27 @@ func (h *Handler) RegisterSpeaker(c *gin.Context) {
 		return
 	}
 
-	err = h.manager.RegisterSpeaker(speakerID, speakerName, audioData, sampleRate)
+	var metadata json.RawMessage
+	if value := c.PostForm("metadata"); value != "" {
+		metadata = json.RawMessage(value)
+		if err := validateMetadata(metadata); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+	}
+
+	err = h.manager.RegisterSpeaker(speakerID, speakerName, metadata, audioData, sampleRate)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(audioErrorStatus(err), gin.H{
 			"error": fmt.Sprintf("failed to register speaker: %v", err),
 		})
 		return
 	}
 
+	h.recordAudit(c, AuditRegister, speakerID)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":      "Speaker registered successfully",
 		"speaker_id":   speakerID,
@@ -113,7 +174,7 @@ func (h *Handler) IdentifySpeaker(c *gin.Context) {
 
 	result, err := h.manager.IdentifySpeaker(audioData, sampleRate)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(audioErrorStatus(err), gin.H{
 			"error": fmt.Sprintf("failed to identify speaker: %v", err),
 		})
 		return
@@ -122,6 +183,65 @@ func (h *Handler) IdentifySpeaker(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// SearchSpeakers returns the closest speakers with their similarity scores. The optional
+// top_k and threshold form fields default to DefaultSearchTopK and the configured threshold.
+func (h *Handler) SearchSpeakers(c *gin.Context) {
+	topK := DefaultSearchTopK
+	if value := c.PostForm("top_k"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > MaxSearchTopK {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("top_k must be an integer between 1 and %d", MaxSearchTopK),
+			})
+			return
+		}
+		topK = n
+	}
+
+	threshold := h.manager.Threshold()
+	if value := c.PostForm("threshold"); value != "" {
+		t, err := strconv.ParseFloat(value, 32)
+		if err != nil || t < 0 || t > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "threshold must be a number between 0 and 1",
+			})
+			return
+		}
+		threshold = float32(t)
+	}
+
+	file, header, err := c.Request.FormFile("audio")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "audio file is required",
+		})
+		return
+	}
+	defer file.Close()
+
+	audioData, sampleRate, err := h.parseAudioFile(file, header)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to parse audio file: %v", err),
+		})
+		return
+	}
+
+	matches, err := h.manager.SearchSpeakers(audioData, sampleRate, topK, threshold)
+	if err != nil {
+		c.JSON(audioErrorStatus(err), gin.H{
+			"error": fmt.Sprintf("failed to search speakers: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"matches":   matches,
+		"top_k":     topK,
+		"threshold": threshold,
+	})
+}
+
 // VerifySpeaker verifies a speaker
 func (h *Handler) VerifySpeaker(c *gin.Context) {
 	speakerID := c.Param("speaker_id")
@@ -151,7 +271,7 @@ func (h *Handler) VerifySpeaker(c *gin.Context) {
 
 	result, err := h.manager.VerifySpeaker(speakerID, audioData, sampleRate)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(audioErrorStatus(err), gin.H{
 			"error": fmt.Sprintf("failed to verify speaker: %v", err),
 		})
 		return
@@ -160,15 +280,102 @@ func (h *Handler) VerifySpeaker(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
-// GetAllSpeakers returns all speakers
+// GetAllSpeakers returns one page of speakers. Query parameters: page (from 1), page_size,
+// q (substring of ID or name), sort (one of ValidSortFields), order (asc or desc) and
+// meta.<key>=<value> to filter on top-level metadata values.
 func (h *Handler) GetAllSpeakers(c *gin.Context) {
-	speakers := h.manager.GetAllSpeakers()
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "page must be a positive integer",
+		})
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(DefaultListPageSize)))
+	if err != nil || pageSize < 1 || pageSize > MaxListPageSize {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("page_size must be an integer between 1 and %d", MaxListPageSize),
+		})
+		return
+	}
+
+	query := ListQuery{
+		Search: c.Query("q"),
+		SortBy: c.DefaultQuery("sort", SortByID),
+		Offset: (page - 1) * pageSize,
+		Limit:  pageSize,
+	}
+	if !slices.Contains(ValidSortFields, query.SortBy) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("sort must be one of %v", ValidSortFields),
+		})
+		return
+	}
+	switch c.DefaultQuery("order", "asc") {
+	case "asc":
+	case "desc":
+		query.Desc = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "order must be asc or desc",
+		})
+		return
+	}
+	for key, values := range c.Request.URL.Query() {
+		if name, ok := strings.CutPrefix(key, "meta."); ok && name != "" {
+			if query.Metadata == nil {
+				query.Metadata = make(map[string]string)
+			}
+			query.Metadata[name] = values[0]
+		}
+	}
+
+	speakers, total := h.manager.ListSpeakers(query)
 	c.JSON(http.StatusOK, gin.H{
-		"speakers": speakers,
-		"total":    len(speakers),
+		"speakers":  speakers,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
 	})
 }
 
+// UpdateSpeaker changes a speaker's name or metadata
+func (h *Handler) UpdateSpeaker(c *gin.Context) {
+	var req struct {
+		SpeakerName *string         `json:"speaker_name"`
+		Metadata    json.RawMessage `json:"metadata"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if req.SpeakerName != nil && *req.SpeakerName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "speaker_name cannot be empty",
+		})
+		return
+	}
+
+	info, err := h.manager.UpdateSpeaker(c.Param("speaker_id"), req.SpeakerName, req.Metadata)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "metadata") {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	h.recordAudit(c, AuditUpdate, info.ID)
+
+	c.JSON(http.StatusOK, info)
+}
+
 // DeleteSpeaker deletes a speaker
 func (h *Handler) DeleteSpeaker(c *gin.Context) {
 	speakerID := c.Param("speaker_id")
@@ -193,6 +400,8 @@ func (h *Handler) DeleteSpeaker(c *gin.Context) {
 		return
 	}
 
+	h.recordAudit(c, AuditDelete, speakerID)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":    "Speaker deleted successfully",
 		"speaker_id": speakerID,
@@ -205,67 +414,242 @@ func (h *Handler) GetStats(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
-// parseAudioFile parses an audio file
-func (h *Handler) parseAudioFile(file multipart.File, header *multipart.FileHeader) ([]float32, int, error) {
-	filename := strings.ToLower(header.Filename)
-	if !strings.HasSuffix(filename, ".wav") {
-		return nil, 0, fmt.Errorf("only WAV files are supported")
+// GetDetections returns the enrolled speakers detected in each session. Query parameters:
+// from and to (RFC 3339) bound when segments were recognized, session_id (repeatable or
+// comma-separated) selects sessions and speaker_id selects one speaker. A time range or
+// session_id is required.
+func (h *Handler) GetDetections(c *gin.Context) {
+	if h.detections == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "speaker tracking is disabled, set speaker.tracking.enabled to record detections",
+		})
+		return
 	}
 
-	decoder := wav.NewDecoder(file)
-	if !decoder.IsValidFile() {
-		return nil, 0, fmt.Errorf("invalid WAV file")
+	var query DetectionQuery
+	for _, param := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &query.From}, {"to", &query.To}} {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("%s must be an RFC 3339 time such as 2006-01-02T15:04:05Z", param.name),
+			})
+			return
+		}
+		*param.dst = t
 	}
+	for _, value := range c.QueryArray("session_id") {
+		for _, id := range strings.Split(value, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				query.SessionIDs = append(query.SessionIDs, id)
+			}
+		}
+	}
+	query.SpeakerID = c.Query("speaker_id")
 
-	sampleRate := int(decoder.SampleRate)
-	numChannels := int(decoder.NumChans)
+	if query.From.IsZero() && query.To.IsZero() && len(query.SessionIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "from, to or session_id is required",
+		})
+		return
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && query.To.Before(query.From) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "to must not be before from",
+		})
+		return
+	}
+
+	sessions, err := h.detections.Query(&query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to query detections: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": sessions,
+		"total":    len(sessions),
+	})
+}
+
+// ExportSpeakers downloads all speakers with their embeddings, as one JSON archive or, with
+// format=ndjson, one speaker per line
+func (h *Handler) ExportSpeakers(c *gin.Context) {
+	archive := h.manager.ExportSpeakers()
+
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.Header("Content-Disposition", `attachment; filename="speakers.json"`)
+		c.JSON(http.StatusOK, archive)
+	case "ndjson":
+		c.Header("Content-Disposition", `attachment; filename="speakers.ndjson"`)
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Status(http.StatusOK)
+		encoder := json.NewEncoder(c.Writer)
+		for _, speaker := range archive.Speakers {
+			if err := encoder.Encode(speaker); err != nil {
+				return
+			}
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "format must be json or ndjson",
+		})
+	}
+}
 
-	if numChannels > 2 {
-		return nil, 0, fmt.Errorf("unsupported number of channels: %d", numChannels)
+// ImportSpeakers imports speakers from an export. The body is a JSON archive, or NDJSON when
+// format=ndjson or the Content-Type says so. Existing speakers are kept unless overwrite=true.
+func (h *Handler) ImportSpeakers(c *gin.Context) {
+	overwrite := c.Query("overwrite") == "true"
+	format := c.Query("format")
+	if format == "" {
+		format = "json"
+		if strings.Contains(c.ContentType(), "ndjson") {
+			format = "ndjson"
+		}
 	}
 
-	buffer, err := decoder.FullPCMBuffer()
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, MaxImportRequestSize)
+	var speakers []*SpeakerData
+	var err error
+	switch format {
+	case "json":
+		var archive Archive
+		err = json.NewDecoder(body).Decode(&archive)
+		speakers = archive.Speakers
+	case "ndjson":
+		speakers, err = decodeNDJSONSpeakers(body)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "format must be json or ndjson",
+		})
+		return
+	}
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to decode audio: %v", err)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": fmt.Sprintf("request exceeds maximum size of %d bytes", MaxImportRequestSize),
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid import data: %v", err),
+		})
+		return
 	}
 
-	// Convert to float32 format using config
-	samples := make([]float32, len(buffer.Data))
-	normalizeFactor := h.cfg.Audio.NormalizeFactor
-	for i, sample := range buffer.Data {
-		samples[i] = float32(sample) / normalizeFactor
+	result, err := h.manager.ImportSpeakers(speakers, overwrite)
+	if err != nil {
+		status := http.StatusBadRequest
+		if strings.Contains(err.Error(), "failed to") {
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{
+			"error": fmt.Sprintf("failed to import speakers: %v", err),
+		})
+		return
+	}
+	for _, speakerID := range result.ImportedIDs {
+		h.recordAudit(c, AuditImport, speakerID)
 	}
 
-	// Convert stereo to mono if needed
-	if numChannels == 2 {
-		monoSamples := make([]float32, len(samples)/2)
-		for i := 0; i < len(monoSamples); i++ {
-			monoSamples[i] = (samples[i*2] + samples[i*2+1]) / 2.0
+	c.JSON(http.StatusOK, result)
+}
+
+// decodeNDJSONSpeakers reads one speaker per non-empty line
+func decodeNDJSONSpeakers(r io.Reader) ([]*SpeakerData, error) {
+	var speakers []*SpeakerData
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), MaxImportRequestSize)
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var speaker SpeakerData
+		if err := json.Unmarshal(data, &speaker); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
 		}
-		samples = monoSamples
+		speakers = append(speakers, &speaker)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return speakers, nil
+}
+
+// parseAudioFile decodes an uploaded WAV, MP3, FLAC or Ogg Vorbis file into mono samples
+// at the configured sample rate
+func (h *Handler) parseAudioFile(file multipart.File, header *multipart.FileHeader) ([]float32, int, error) {
+	filename := strings.ToLower(header.Filename)
+	if !strings.HasSuffix(filename, ".wav") && !audio.IsCompressedFile(filename) {
+		return nil, 0, fmt.Errorf("only WAV, MP3, FLAC and Ogg Vorbis files are supported")
+	}
+
+	decoded, err := audio.DecodeFile(file, filename)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(decoded.Samples) == 0 {
+		return nil, 0, fmt.Errorf("audio file contains no samples")
 	}
 
-	return samples, sampleRate, nil
+	sampleRate := h.cfg.Audio.SampleRate
+	return audio.Resample(decoded.Mono(), decoded.SampleRate, sampleRate), sampleRate, nil
 }
 
 // RegisterSpeakerBase64 registers a speaker using Base64 encoded audio
 func (h *Handler) RegisterSpeakerBase64(c *gin.Context) {
 	var req struct {
-		SpeakerID   string `json:"speaker_id" binding:"required"`
-		SpeakerName string `json:"speaker_name" binding:"required"`
-		AudioData   string `json:"audio_data" binding:"required"`
-		SampleRate  int    `json:"sample_rate" binding:"required"`
+		SpeakerID   string          `json:"speaker_id" binding:"required"`
+		SpeakerName string          `json:"speaker_name" binding:"required"`
+		Metadata    json.RawMessage `json:"metadata"`
+		AudioData   string          `json:"audio_data" binding:"required"`
+		SampleRate  int             `json:"sample_rate"`
+	}
+	if !bindBase64Request(c, &req) {
+		return
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := validateMetadata(req.Metadata); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusNotImplemented, gin.H{
-		"error": "Base64 API not implemented yet",
+	audioData, sampleRate, err := decodeBase64Audio(req.AudioData, req.SampleRate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	err = h.manager.RegisterSpeaker(req.SpeakerID, req.SpeakerName, req.Metadata, audioData, sampleRate)
+	if err != nil {
+		c.JSON(audioErrorStatus(err), gin.H{
+			"error": fmt.Sprintf("failed to register speaker: %v", err),
+		})
+		return
+	}
+
+	h.recordAudit(c, AuditRegister, req.SpeakerID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Speaker registered successfully",
+		"speaker_id":   req.SpeakerID,
+		"speaker_name": req.SpeakerName,
 	})
 }
 
@@ -273,17 +657,114 @@ func (h *Handler) RegisterSpeakerBase64(c *gin.Context) {
 func (h *Handler) IdentifySpeakerBase64(c *gin.Context) {
 	var req struct {
 		AudioData  string `json:"audio_data" binding:"required"`
-		SampleRate int    `json:"sample_rate" binding:"required"`
+		SampleRate int    `json:"sample_rate"`
+	}
+	if !bindBase64Request(c, &req) {
+		return
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
+	audioData, sampleRate, err := decodeBase64Audio(req.AudioData, req.SampleRate)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusNotImplemented, gin.H{
-		"error": "Base64 API not implemented yet",
+	result, err := h.manager.IdentifySpeaker(audioData, sampleRate)
+	if err != nil {
+		c.JSON(audioErrorStatus(err), gin.H{
+			"error": fmt.Sprintf("failed to identify speaker: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// recordAudit records a change made by the request. The actor is the identity Authenticate
+// verified, that is the JWT subject or the HMAC key ID. Without one it is taken from the
+// X-API-Key header or a bearer token, falling back to the client IP.
+func (h *Handler) recordAudit(c *gin.Context, action, speakerID string) {
+	actor := c.GetString("auth_subject")
+	if actor == "" {
+		apiKey := c.GetHeader("X-API-Key")
+		if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && apiKey == "" {
+			apiKey = token
+		}
+		actor = RequestActor(apiKey, c.ClientIP())
+	}
+	h.audit.Record(AuditEntry{
+		Action:     action,
+		SpeakerID:  speakerID,
+		Actor:      actor,
+		Source:     "http",
+		RemoteAddr: c.ClientIP(),
+		RequestID:  c.GetString("request_id"),
 	})
 }
+
+// audioErrorStatus maps a manager error for submitted audio to an HTTP status
+func audioErrorStatus(err error) int {
+	if errors.Is(err, ErrInsufficientSpeech) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// bindBase64Request binds a JSON request body of at most MaxBase64RequestSize bytes and
+// writes an error response on failure
+func bindBase64Request(c *gin.Context, req interface{}) bool {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxBase64RequestSize)
+	if err := c.ShouldBindJSON(req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": fmt.Sprintf("request exceeds maximum size of %d bytes", MaxBase64RequestSize),
+			})
+			return false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+// decodeBase64Audio decodes Base64 encoded audio, optionally given as a data URL. WAV files
+// carry their own sample rate; anything else is taken as 16-bit little-endian mono PCM at
+// sampleRate.
+func decodeBase64Audio(data string, sampleRate int) ([]float32, int, error) {
+	if i := strings.Index(data, ";base64,"); strings.HasPrefix(data, "data:") && i >= 0 {
+		data = data[i+len(";base64,"):]
+	}
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, 0, fmt.Errorf("audio_data is not valid Base64: %v", err)
+	}
+
+	if audio.IsWAVHeader(raw) {
+		decoded, err := audio.DecodeWAV(bytes.NewReader(raw))
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to parse audio file: %v", err)
+		}
+		sampleRate = decoded.SampleRate
+		if sampleRate < MinSampleRate || sampleRate > MaxSampleRate {
+			return nil, 0, fmt.Errorf("unsupported WAV sample rate %d, must be between %d and %d", sampleRate, MinSampleRate, MaxSampleRate)
+		}
+		samples := decoded.Mono()
+		if len(samples) == 0 {
+			return nil, 0, fmt.Errorf("audio_data contains no samples")
+		}
+		return samples, sampleRate, nil
+	}
+
+	if sampleRate < MinSampleRate || sampleRate > MaxSampleRate {
+		return nil, 0, fmt.Errorf("sample_rate must be between %d and %d for PCM audio", MinSampleRate, MaxSampleRate)
+	}
+	if len(raw) < 2 || len(raw)%2 != 0 {
+		return nil, 0, fmt.Errorf("PCM audio_data must contain whole 16-bit samples")
+	}
+	return audio.PCM16ToFloat32(raw), sampleRate, nil
+}
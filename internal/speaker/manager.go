@@ -3,10 +3,10 @@ package speaker
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -17,12 +17,13 @@ import (
 
 // SpeakerData 声纹数据结构
 type SpeakerData struct {
-	ID          string      `json:"id"`
-	Name        string      `json:"name"`
-	Embeddings  [][]float32 `json:"embeddings"`
-	CreatedAt   time.Time   `json:"created_at"`
-	UpdatedAt   time.Time   `json:"updated_at"`
-	SampleCount int         `json:"sample_count"`
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Metadata    json.RawMessage `json:"metadata,omitempty"` // 任意 JSON 对象，由调用方定义
+	Embeddings  [][]float32     `json:"embeddings"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	SampleCount int             `json:"sample_count"`
 }
 
 // SpeakerDatabase 声纹数据库结构
@@ -34,14 +35,25 @@ type SpeakerDatabase struct {
 
 // Manager 声纹识别管理器
 type Manager struct {
-	extractor    *sherpa.SpeakerEmbeddingExtractor
+	extractors   *extractorPool
 	manager      *sherpa.SpeakerEmbeddingManager
 	database     *SpeakerDatabase
-	dbPath       string
+	store        Store
 	threshold    float32
 	embeddingDim int
 	mutex        sync.RWMutex
 	dataDir      string
+
+	stopSync chan struct{}
+	syncDone chan struct{}
+
+	spoofDetector  SpoofDetector
+	spoofThreshold float32
+
+	trimmer            SpeechTrimmer
+	minEnrollSeconds   float64
+	minIdentifySeconds float64
+	maxSpeechSeconds   float64
 }
 
 // Config 声纹识别配置
@@ -51,6 +63,19 @@ type Config struct {
 	Provider   string  `json:"provider"`
 	Threshold  float32 `json:"threshold"`
 	DataDir    string  `json:"data_dir"`
+	PoolSize   int     `json:"pool_size"` // 声纹特征提取器实例数
+	Storage    string  `json:"storage"`   // json、memory、sqlite、postgres 或 redis
+
+	PostgresDSN    string        `json:"postgres_dsn"`
+	RedisAddr      string        `json:"redis_addr"`
+	RedisPassword  string        `json:"redis_password"`
+	RedisDB        int           `json:"redis_db"`
+	RedisKeyPrefix string        `json:"redis_key_prefix"`
+	SyncInterval   time.Duration `json:"sync_interval"` // 共享存储的重新加载间隔，0 为不同步
+
+	MinEnrollSeconds   float64 `json:"min_enroll_seconds"`   // 注册所需最短语音时长
+	MinIdentifySeconds float64 `json:"min_identify_seconds"` // 识别/验证所需最短语音时长
+	MaxSpeechSeconds   float64 `json:"max_speech_seconds"`   // 超出部分截断，0 为不限制
 }
 
 // NewManager 创建声纹识别管理器
@@ -60,6 +85,29 @@ func NewManager(config *Config) (*Manager, error) {
 		return nil, fmt.Errorf("failed to create data directory: %v", err)
 	}
 
+	// 打开声纹存储
+	store, err := NewStore(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open speaker storage: %v", err)
+	}
+	database, err := store.Load()
+	persistent := config.Storage != "" && config.Storage != StorageJSON && config.Storage != StorageMemory
+	if err == nil && len(database.Speakers) == 0 && persistent {
+		// 首次切换到数据库存储时导入本地已有的 JSON 声纹库
+		jsonPath := filepath.Join(config.DataDir, "speaker.json")
+		if _, statErr := os.Stat(jsonPath); statErr == nil {
+			var imported int
+			if imported, err = importJSON(store, jsonPath); err == nil {
+				logger.Info("speaker_database_imported", "from", jsonPath, "speakers", imported)
+				database, err = store.Load()
+			}
+		}
+	}
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("failed to load speaker database: %v", err)
+	}
+
 	// 创建声纹特征提取器配置
 	extractorConfig := &sherpa.SpeakerEmbeddingExtractorConfig{
 		Model:      config.ModelPath,
@@ -68,40 +116,37 @@ func NewManager(config *Config) (*Manager, error) {
 		Provider:   config.Provider,
 	}
 
-	// 创建声纹特征提取器
-	extractor := sherpa.NewSpeakerEmbeddingExtractor(extractorConfig)
-	if extractor == nil {
-		return nil, fmt.Errorf("failed to create speaker embedding extractor")
+	// 创建声纹特征提取器池
+	extractors, err := newExtractorPool(extractorConfig, config.PoolSize)
+	if err != nil {
+		store.Close()
+		return nil, err
 	}
 
 	// 获取特征维度
-	dim := extractor.Dim()
+	dim := extractors.dim()
 	logger.Info("speaker_embedding_dimension", "dim", dim)
 
 	// 创建声纹管理器
 	embeddingManager := sherpa.NewSpeakerEmbeddingManager(dim)
 	if embeddingManager == nil {
-		sherpa.DeleteSpeakerEmbeddingExtractor(extractor)
+		extractors.close()
+		store.Close()
 		return nil, fmt.Errorf("failed to create speaker embedding manager")
 	}
 
 	manager := &Manager{
-		extractor:    extractor,
+		extractors:   extractors,
 		manager:      embeddingManager,
 		threshold:    config.Threshold,
 		embeddingDim: dim,
 		dataDir:      config.DataDir,
-		dbPath:       filepath.Join(config.DataDir, "speaker.json"),
-	}
+		database:     database,
+		store:        store,
 
-	// 加载现有数据库
-	if err := manager.loadDatabase(); err != nil {
-		logger.Warn("failed_to_load_speaker_database_using_defaults", "error", err)
-		manager.database = &SpeakerDatabase{
-			Speakers:  make(map[string]*SpeakerData),
-			Version:   "1.0.0",
-			UpdatedAt: time.Now(),
-		}
+		minEnrollSeconds:   config.MinEnrollSeconds,
+		minIdentifySeconds: config.MinIdentifySeconds,
+		maxSpeechSeconds:   config.MaxSpeechSeconds,
 	}
 
 	// 将数据库中的声纹加载到内存管理器
@@ -109,53 +154,36 @@ func NewManager(config *Config) (*Manager, error) {
 		logger.Warn("failed_to_load_speakers_to_memory", "error", err)
 	}
 
+	// 共享存储需定期同步其他实例的注册与删除
+	if SharedStorage(config.Storage) && config.SyncInterval > 0 {
+		manager.stopSync = make(chan struct{})
+		manager.syncDone = make(chan struct{})
+		go manager.syncLoop(config.SyncInterval)
+	}
+
 	return manager, nil
 }
 
 // Close 关闭管理器并释放资源
 func (m *Manager) Close() {
-	if m.extractor != nil {
-		sherpa.DeleteSpeakerEmbeddingExtractor(m.extractor)
+	if m.stopSync != nil {
+		close(m.stopSync)
+		<-m.syncDone
+	}
+	if m.extractors != nil {
+		m.extractors.close()
 	}
 	if m.manager != nil {
 		sherpa.DeleteSpeakerEmbeddingManager(m.manager)
 	}
-}
-
-// loadDatabase 从文件加载声纹数据库
-func (m *Manager) loadDatabase() error {
-	if _, err := os.Stat(m.dbPath); os.IsNotExist(err) {
-		return fmt.Errorf("database file does not exist")
-	}
-
-	data, err := ioutil.ReadFile(m.dbPath)
-	if err != nil {
-		return fmt.Errorf("failed to read database file: %v", err)
-	}
-
-	var db SpeakerDatabase
-	if err := json.Unmarshal(data, &db); err != nil {
-		return fmt.Errorf("failed to unmarshal database: %v", err)
+	if m.spoofDetector != nil {
+		m.spoofDetector.Close()
 	}
-
-	m.database = &db
-	return nil
-}
-
-// saveDatabase 保存声纹数据库到文件
-func (m *Manager) saveDatabase() error {
-	m.database.UpdatedAt = time.Now()
-
-	data, err := json.MarshalIndent(m.database, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal database: %v", err)
-	}
-
-	if err := ioutil.WriteFile(m.dbPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write database file: %v", err)
+	if m.store != nil {
+		if err := m.store.Close(); err != nil {
+			logger.Warn("failed_to_close_speaker_storage", "error", err)
+		}
 	}
-
-	return nil
 }
 
 // loadSpeakersToMemory 将数据库中的声纹加载到内存管理器
@@ -180,10 +208,69 @@ func (m *Manager) loadSpeakersToMemory() error {
 	return nil
 }
 
-// extractEmbedding 从音频数据提取声纹特征
+// syncLoop 定期从共享存储重新加载声纹库
+func (m *Manager) syncLoop(interval time.Duration) {
+	defer close(m.syncDone)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stopSync:
+			return
+		case <-ticker.C:
+			if err := m.reload(); err != nil {
+				logger.Warn("failed_to_sync_speaker_database", "error", err)
+			}
+		}
+	}
+}
+
+// reload 从存储重新加载声纹库，只重新注册有变化的说话人。
+// 加载期间本实例的新注册会在下一次同步时补齐。
+func (m *Manager) reload() error {
+	database, err := m.store.Load()
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for speakerID := range m.database.Speakers {
+		if _, exists := database.Speakers[speakerID]; !exists {
+			m.manager.Remove(speakerID)
+		}
+	}
+	changed := 0
+	for speakerID, speakerData := range database.Speakers {
+		current, exists := m.database.Speakers[speakerID]
+		if exists && current.SampleCount == speakerData.SampleCount && current.UpdatedAt.Equal(speakerData.UpdatedAt) {
+			continue
+		}
+		if m.manager.Contains(speakerID) {
+			m.manager.Remove(speakerID)
+		}
+		if len(speakerData.Embeddings) > 0 && !m.manager.RegisterV(speakerID, speakerData.Embeddings) {
+			logger.Warn("failed_to_register_speaker_to_memory", "speaker_id", speakerID)
+		}
+		changed++
+	}
+	m.database = database
+
+	if changed > 0 {
+		logger.Info("speaker_database_synced", "speakers", len(database.Speakers), "changed", changed)
+	}
+	return nil
+}
+
+// extractEmbedding 从音频数据提取声纹特征，使用池中的提取器，无需持有 m.mutex
 func (m *Manager) extractEmbedding(audioData []float32, sampleRate int) ([]float32, error) {
+	extractor := m.extractors.get()
+	defer m.extractors.put(extractor)
+
 	// 创建音频流
-	stream := m.extractor.CreateStream()
+	stream := extractor.CreateStream()
 	defer sherpa.DeleteOnlineStream(stream)
 
 	// 接受音频数据
@@ -191,12 +278,12 @@ func (m *Manager) extractEmbedding(audioData []float32, sampleRate int) ([]float
 	stream.InputFinished()
 
 	// 检查是否准备就绪
-	if !m.extractor.IsReady(stream) {
+	if !extractor.IsReady(stream) {
 		return nil, fmt.Errorf("insufficient audio data for embedding extraction")
 	}
 
 	// 提取特征
-	embedding := m.extractor.Compute(stream)
+	embedding := extractor.Compute(stream)
 	if len(embedding) == 0 {
 		return nil, fmt.Errorf("failed to extract embedding")
 	}
@@ -245,10 +332,17 @@ func cosineSimilarity(a, b []float32) float32 {
 	return similarity
 }
 
-// RegisterSpeaker 注册声纹
-func (m *Manager) RegisterSpeaker(speakerID, speakerName string, audioData []float32, sampleRate int) error {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
+// RegisterSpeaker 注册声纹，metadata 为空时保留已有元数据
+func (m *Manager) RegisterSpeaker(speakerID, speakerName string, metadata json.RawMessage, audioData []float32, sampleRate int) error {
+	if err := validateMetadata(metadata); err != nil {
+		return err
+	}
+
+	// 去除静音并检查语音时长
+	audioData, sampleRate, err := m.prepareAudio(audioData, sampleRate, m.minEnrollSeconds, "enrollment")
+	if err != nil {
+		return err
+	}
 
 	// 提取声纹特征
 	embedding, err := m.extractEmbedding(audioData, sampleRate)
@@ -256,6 +350,9 @@ func (m *Manager) RegisterSpeaker(speakerID, speakerName string, audioData []flo
 		return fmt.Errorf("failed to extract embedding: %v", err)
 	}
 
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
 	// 检查说话人是否已存在
 	speakerData, exists := m.database.Speakers[speakerID]
 	if !exists {
@@ -276,6 +373,9 @@ func (m *Manager) RegisterSpeaker(speakerID, speakerName string, audioData []flo
 	speakerData.UpdatedAt = time.Now()
 	speakerData.SampleCount++
 	speakerData.Name = speakerName // 更新名称
+	if len(metadata) > 0 {
+		speakerData.Metadata = metadata
+	}
 
 	// 注册到内存管理器
 	success := m.manager.RegisterV(speakerID, speakerData.Embeddings)
@@ -283,10 +383,11 @@ func (m *Manager) RegisterSpeaker(speakerID, speakerName string, audioData []flo
 		return fmt.Errorf("failed to register speaker to memory manager")
 	}
 
-	// 保存到文件
-	if err := m.saveDatabase(); err != nil {
+	// 持久化
+	if err := m.store.SaveSpeaker(speakerData, embedding); err != nil {
 		return fmt.Errorf("failed to save database: %v", err)
 	}
+	m.database.UpdatedAt = speakerData.UpdatedAt
 
 	logger.Info("speaker_registered", "speaker_id", speakerID, "name", speakerName, "samples", speakerData.SampleCount)
 	return nil
@@ -294,8 +395,11 @@ func (m *Manager) RegisterSpeaker(speakerID, speakerName string, audioData []flo
 
 // IdentifySpeaker 识别声纹（直接使用内存中的数据进行高效对比）
 func (m *Manager) IdentifySpeaker(audioData []float32, sampleRate int) (*IdentifyResult, error) {
-	m.mutex.RLock()
-	defer m.mutex.RUnlock()
+	// 去除静音并检查语音时长
+	audioData, sampleRate, err := m.prepareAudio(audioData, sampleRate, m.minIdentifySeconds, "identification")
+	if err != nil {
+		return nil, err
+	}
 
 	// 提取声纹特征
 	embedding, err := m.extractEmbedding(audioData, sampleRate)
@@ -303,6 +407,9 @@ func (m *Manager) IdentifySpeaker(audioData []float32, sampleRate int) (*Identif
 		return nil, fmt.Errorf("failed to extract embedding: %v", err)
 	}
 
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
 	// 在内存管理器中搜索最佳匹配（已加载的声纹数据直接内存对比）
 	speakerID := m.manager.Search(embedding, m.threshold)
 
@@ -331,26 +438,98 @@ func (m *Manager) IdentifySpeaker(audioData []float32, sampleRate int) (*Identif
 	return result, nil
 }
 
-// VerifySpeaker 验证声纹（直接使用内存中的数据进行高效对比）
-func (m *Manager) VerifySpeaker(speakerID string, audioData []float32, sampleRate int) (*VerifyResult, error) {
+// SearchSpeakers 返回与音频最相似的 topK 个说话人，按相似度降序，低于 threshold 的不返回
+func (m *Manager) SearchSpeakers(audioData []float32, sampleRate int, topK int, threshold float32) ([]*SearchMatch, error) {
+	// 去除静音并检查语音时长
+	audioData, sampleRate, err := m.prepareAudio(audioData, sampleRate, m.minIdentifySeconds, "identification")
+	if err != nil {
+		return nil, err
+	}
+
+	// 提取声纹特征
+	embedding, err := m.extractEmbedding(audioData, sampleRate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract embedding: %v", err)
+	}
+
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
-	// 检查说话人是否存在
-	speakerData, exists := m.database.Speakers[speakerID]
+	// 与每个说话人的全部样本比对
+	matches := make([]*SearchMatch, 0, len(m.database.Speakers))
+	for _, speakerData := range m.database.Speakers {
+		similarity := m.calculateSimilarity(embedding, speakerData.Embeddings)
+		if similarity < threshold {
+			continue
+		}
+		matches = append(matches, &SearchMatch{
+			SpeakerID:   speakerData.ID,
+			SpeakerName: speakerData.Name,
+			Similarity:  similarity,
+		})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Similarity != matches[j].Similarity {
+			return matches[i].Similarity > matches[j].Similarity
+		}
+		return matches[i].SpeakerID < matches[j].SpeakerID
+	})
+	if len(matches) > topK {
+		matches = matches[:topK]
+	}
+
+	return matches, nil
+}
+
+// Threshold 返回默认识别阈值
+func (m *Manager) Threshold() float32 {
+	return m.threshold
+}
+
+// VerifySpeaker 验证声纹（直接使用内存中的数据进行高效对比）
+func (m *Manager) VerifySpeaker(speakerID string, audioData []float32, sampleRate int) (*VerifyResult, error) {
+	// 检查说话人是否存在，避免为不存在的说话人提取特征
+	m.mutex.RLock()
+	_, exists := m.database.Speakers[speakerID]
+	m.mutex.RUnlock()
 	if !exists {
 		return nil, fmt.Errorf("speaker %s not found", speakerID)
 	}
 
+	// 去除静音并检查语音时长
+	audioData, sampleRate, err := m.prepareAudio(audioData, sampleRate, m.minIdentifySeconds, "verification")
+	if err != nil {
+		return nil, err
+	}
+
 	// 提取声纹特征
 	embedding, err := m.extractEmbedding(audioData, sampleRate)
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract embedding: %v", err)
 	}
 
+	// 活体检测：判定为合成或回放的音频不予通过
+	liveness, err := m.checkLiveness(audioData, sampleRate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check liveness: %v", err)
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	// 提取期间可能已被删除
+	speakerData, exists := m.database.Speakers[speakerID]
+	if !exists {
+		return nil, fmt.Errorf("speaker %s not found", speakerID)
+	}
+
 	// 计算精确的相似度分数
 	confidence := m.calculateSimilarity(embedding, speakerData.Embeddings)
 	verified := confidence >= m.threshold
+	if liveness != nil && liveness.Spoofed {
+		verified = false
+	}
 
 	result := &VerifyResult{
 		SpeakerID:   speakerID,
@@ -358,6 +537,7 @@ func (m *Manager) VerifySpeaker(speakerID string, audioData []float32, sampleRat
 		Verified:    verified,
 		Confidence:  confidence,
 		Threshold:   m.threshold,
+		Liveness:    liveness,
 	}
 
 	return result, nil
@@ -370,13 +550,7 @@ func (m *Manager) GetAllSpeakers() []*SpeakerInfo {
 
 	speakers := make([]*SpeakerInfo, 0, len(m.database.Speakers))
 	for _, speakerData := range m.database.Speakers {
-		speakers = append(speakers, &SpeakerInfo{
-			ID:          speakerData.ID,
-			Name:        speakerData.Name,
-			SampleCount: speakerData.SampleCount,
-			CreatedAt:   speakerData.CreatedAt,
-			UpdatedAt:   speakerData.UpdatedAt,
-		})
+		speakers = append(speakers, speakerData.info())
 	}
 
 	return speakers
@@ -398,25 +572,129 @@ func (m *Manager) DeleteSpeaker(speakerID string) error {
 	// 从内存管理器删除
 	m.manager.Remove(speakerID)
 
-	// 保存到文件
-	if err := m.saveDatabase(); err != nil {
+	// 持久化
+	if err := m.store.DeleteSpeaker(speakerID); err != nil {
 		return fmt.Errorf("failed to save database: %v", err)
 	}
+	m.database.UpdatedAt = time.Now()
 
 	logger.Info("speaker_deleted", "speaker_id", speakerID)
 	return nil
 }
 
+// ExportSpeakers 导出全部说话人（含声纹向量），按 ID 排序
+func (m *Manager) ExportSpeakers() *Archive {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	speakers := make([]*SpeakerData, 0, len(m.database.Speakers))
+	for _, speakerData := range m.database.Speakers {
+		// 拷贝结构体，避免导出过程中被注册修改
+		speaker := *speakerData
+		speakers = append(speakers, &speaker)
+	}
+	sort.Slice(speakers, func(i, j int) bool {
+		return speakers[i].ID < speakers[j].ID
+	})
+
+	return &Archive{
+		Version:      m.database.Version,
+		ExportedAt:   time.Now(),
+		EmbeddingDim: m.embeddingDim,
+		Speakers:     speakers,
+	}
+}
+
+// ImportSpeakers 导入说话人。已存在的说话人在 overwrite 为 true 时整体替换，否则跳过。
+// 任一说话人数据不合法时不导入任何数据。
+func (m *Manager) ImportSpeakers(speakers []*SpeakerData, overwrite bool) (*ImportResult, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// 先校验全部数据
+	seen := make(map[string]bool, len(speakers))
+	for i, speakerData := range speakers {
+		if speakerData == nil || speakerData.ID == "" {
+			return nil, fmt.Errorf("speaker %d: id is required", i)
+		}
+		if seen[speakerData.ID] {
+			return nil, fmt.Errorf("speaker %s: duplicate id", speakerData.ID)
+		}
+		seen[speakerData.ID] = true
+		if len(speakerData.Embeddings) == 0 {
+			return nil, fmt.Errorf("speaker %s: no embeddings", speakerData.ID)
+		}
+		if err := validateMetadata(speakerData.Metadata); err != nil {
+			return nil, fmt.Errorf("speaker %s: %v", speakerData.ID, err)
+		}
+		for _, embedding := range speakerData.Embeddings {
+			if len(embedding) != m.embeddingDim {
+				return nil, fmt.Errorf("speaker %s: embedding dimension %d does not match model dimension %d", speakerData.ID, len(embedding), m.embeddingDim)
+			}
+		}
+	}
+
+	result := &ImportResult{}
+	now := time.Now()
+	imported := make([]*SpeakerData, 0, len(speakers))
+	for _, speakerData := range speakers {
+		if _, exists := m.database.Speakers[speakerData.ID]; exists && !overwrite {
+			result.Skipped++
+			continue
+		}
+
+		speaker := &SpeakerData{
+			ID:          speakerData.ID,
+			Name:        speakerData.Name,
+			Metadata:    speakerData.Metadata,
+			Embeddings:  speakerData.Embeddings,
+			CreatedAt:   speakerData.CreatedAt,
+			UpdatedAt:   speakerData.UpdatedAt,
+			SampleCount: len(speakerData.Embeddings),
+		}
+		if speaker.CreatedAt.IsZero() {
+			speaker.CreatedAt = now
+		}
+		if speaker.UpdatedAt.IsZero() {
+			speaker.UpdatedAt = now
+		}
+
+		// 注册到内存管理器
+		if m.manager.Contains(speaker.ID) {
+			m.manager.Remove(speaker.ID)
+		}
+		if !m.manager.RegisterV(speaker.ID, speaker.Embeddings) {
+			return nil, fmt.Errorf("failed to register speaker %s to memory manager", speaker.ID)
+		}
+		m.database.Speakers[speaker.ID] = speaker
+		imported = append(imported, speaker)
+		result.ImportedIDs = append(result.ImportedIDs, speaker.ID)
+	}
+
+	// 持久化
+	if len(imported) > 0 {
+		if err := m.store.SaveSpeakers(imported); err != nil {
+			return nil, fmt.Errorf("failed to save database: %v", err)
+		}
+		m.database.UpdatedAt = now
+	}
+
+	result.Imported = len(imported)
+	logger.Info("speakers_imported", "imported", result.Imported, "skipped", result.Skipped, "overwrite", overwrite)
+	return result, nil
+}
+
 // GetStats 获取统计信息（用于主服务监控）
 func (m *Manager) GetStats() map[string]interface{} {
 	stats := m.GetDatabaseStats()
 	return map[string]interface{}{
-		"speaker_count": stats.TotalSpeakers,
-		"total_samples": stats.TotalSamples,
-		"embedding_dim": stats.EmbeddingDim,
-		"threshold":     stats.Threshold,
-		"version":       stats.Version,
-		"last_updated":  stats.UpdatedAt.Format(time.RFC3339),
+		"speaker_count":  stats.TotalSpeakers,
+		"total_samples":  stats.TotalSamples,
+		"embedding_dim":  stats.EmbeddingDim,
+		"threshold":      stats.Threshold,
+		"version":        stats.Version,
+		"last_updated":   stats.UpdatedAt.Format(time.RFC3339),
+		"extractor_pool": stats.ExtractorPool,
 	}
 }
 
@@ -437,6 +715,7 @@ func (m *Manager) GetDatabaseStats() *DatabaseStats {
 		Threshold:     m.threshold,
 		Version:       m.database.Version,
 		UpdatedAt:     m.database.UpdatedAt,
+		ExtractorPool: m.extractors.stats(),
 	}
 }
 
@@ -449,27 +728,50 @@ type IdentifyResult struct {
 	Threshold   float32 `json:"threshold"`
 }
 
-type VerifyResult struct {
+type SearchMatch struct {
 	SpeakerID   string  `json:"speaker_id"`
 	SpeakerName string  `json:"speaker_name"`
-	Verified    bool    `json:"verified"`
-	Confidence  float32 `json:"confidence"`
-	Threshold   float32 `json:"threshold"`
+	Similarity  float32 `json:"similarity"`
+}
+
+type VerifyResult struct {
+	SpeakerID   string          `json:"speaker_id"`
+	SpeakerName string          `json:"speaker_name"`
+	Verified    bool            `json:"verified"`
+	Confidence  float32         `json:"confidence"`
+	Threshold   float32         `json:"threshold"`
+	Liveness    *LivenessResult `json:"liveness,omitempty"` // 仅启用活体检测时返回
 }
 
 type SpeakerInfo struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	SampleCount int       `json:"sample_count"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Metadata    json.RawMessage `json:"metadata,omitempty"`
+	SampleCount int             `json:"sample_count"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// Archive 声纹库导出格式
+type Archive struct {
+	Version      string         `json:"version"`
+	ExportedAt   time.Time      `json:"exported_at"`
+	EmbeddingDim int            `json:"embedding_dim"`
+	Speakers     []*SpeakerData `json:"speakers"`
+}
+
+type ImportResult struct {
+	Imported    int      `json:"imported"`
+	Skipped     int      `json:"skipped"`
+	ImportedIDs []string `json:"-"` // 实际导入的说话人，用于审计
 }
 
 type DatabaseStats struct {
-	TotalSpeakers int       `json:"total_speakers"`
-	TotalSamples  int       `json:"total_samples"`
-	EmbeddingDim  int       `json:"embedding_dim"`
-	Threshold     float32   `json:"threshold"`
-	Version       string    `json:"version"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	TotalSpeakers int                    `json:"total_speakers"`
+	TotalSamples  int                    `json:"total_samples"`
+	EmbeddingDim  int                    `json:"embedding_dim"`
+	Threshold     float32                `json:"threshold"`
+	Version       string                 `json:"version"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+	ExtractorPool map[string]interface{} `json:"extractor_pool"`
 }
@@ -0,0 +1,58 @@
+//go:build postgres
+
+package speaker
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+var postgresDialect = sqlDialect{
+	name: "postgres",
+	migrations: []string{
+		`CREATE TABLE speakers (
+			id         TEXT PRIMARY KEY,
+			name       TEXT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL
+		);
+		CREATE TABLE embeddings (
+			id         BIGSERIAL PRIMARY KEY,
+			speaker_id TEXT NOT NULL REFERENCES speakers(id) ON DELETE CASCADE,
+			embedding  BYTEA NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL
+		);
+		CREATE INDEX embeddings_speaker_id ON embeddings(speaker_id);`,
+		`ALTER TABLE speakers ADD COLUMN metadata TEXT;`,
+		`CREATE TABLE speaker_audit (
+			id          BIGSERIAL PRIMARY KEY,
+			timestamp   TIMESTAMPTZ NOT NULL,
+			action      TEXT NOT NULL,
+			speaker_id  TEXT NOT NULL,
+			actor       TEXT NOT NULL,
+			source      TEXT NOT NULL,
+			remote_addr TEXT NOT NULL DEFAULT '',
+			request_id  TEXT NOT NULL DEFAULT ''
+		);
+		CREATE INDEX speaker_audit_speaker_id ON speaker_audit(speaker_id);`,
+	},
+	// Arbitrary application-wide key shared by every instance migrating the same database
+	lock:     "SELECT pg_advisory_xact_lock(724361)",
+	numbered: true,
+}
+
+// newPostgresStore connects to a PostgreSQL database shared by all instances
+func newPostgresStore(dsn string) (Store, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PostgreSQL database: %v", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to PostgreSQL: %v", err)
+	}
+
+	return newSQLStore(db, postgresDialect)
+}
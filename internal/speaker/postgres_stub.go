@@ -0,0 +1,10 @@
+//go:build !postgres
+
+package speaker
+
+import "fmt"
+
+// newPostgresStore always fails without the "postgres" build tag
+func newPostgresStore(dsn string) (Store, error) {
+	return nil, fmt.Errorf("PostgreSQL storage not compiled in (rebuild with -tags postgres)")
+}
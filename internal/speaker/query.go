@@ -0,0 +1,181 @@
+package speaker
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Fields ListSpeakers can sort by
+const (
+	SortByID          = "id"
+	SortByName        = "name"
+	SortByCreatedAt   = "created_at"
+	SortByUpdatedAt   = "updated_at"
+	SortBySampleCount = "sample_count"
+)
+
+// ValidSortFields lists the fields accepted by ListQuery.SortBy
+var ValidSortFields = []string{SortByID, SortByName, SortByCreatedAt, SortByUpdatedAt, SortBySampleCount}
+
+// ListQuery selects a page of speakers
+type ListQuery struct {
+	Search   string            // Case-insensitive substring of the ID or name
+	Metadata map[string]string // Top-level metadata values that must match exactly
+	SortBy   string            // One of ValidSortFields, defaults to id
+	Desc     bool
+	Offset   int
+	Limit    int // 0 returns every match after Offset
+}
+
+// info returns the speaker without its embeddings
+func (s *SpeakerData) info() *SpeakerInfo {
+	return &SpeakerInfo{
+		ID:          s.ID,
+		Name:        s.Name,
+		Metadata:    s.Metadata,
+		SampleCount: s.SampleCount,
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+	}
+}
+
+// ListSpeakers returns one page of the speakers matching query and the total number of matches
+func (m *Manager) ListSpeakers(query ListQuery) ([]*SpeakerInfo, int) {
+	m.mutex.RLock()
+	matches := make([]*SpeakerInfo, 0, len(m.database.Speakers))
+	search := strings.ToLower(query.Search)
+	for _, speakerData := range m.database.Speakers {
+		if search != "" && !strings.Contains(strings.ToLower(speakerData.ID), search) &&
+			!strings.Contains(strings.ToLower(speakerData.Name), search) {
+			continue
+		}
+		if !metadataMatches(speakerData.Metadata, query.Metadata) {
+			continue
+		}
+		matches = append(matches, speakerData.info())
+	}
+	m.mutex.RUnlock()
+
+	less := speakerLess(query.SortBy)
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if query.Desc {
+			a, b = b, a
+		}
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		// Ties keep a stable order across pages
+		return a.ID < b.ID
+	})
+
+	total := len(matches)
+	if query.Offset >= total {
+		return []*SpeakerInfo{}, total
+	}
+	matches = matches[query.Offset:]
+	if query.Limit > 0 && len(matches) > query.Limit {
+		matches = matches[:query.Limit]
+	}
+	return matches, total
+}
+
+// UpdateSpeaker changes a speaker's name and/or metadata without re-enrolling. A nil name
+// keeps the current one; empty metadata keeps it and JSON null clears it.
+func (m *Manager) UpdateSpeaker(speakerID string, name *string, metadata json.RawMessage) (*SpeakerInfo, error) {
+	clearMetadata := string(metadata) == "null"
+	if !clearMetadata {
+		if err := validateMetadata(metadata); err != nil {
+			return nil, err
+		}
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	speakerData, exists := m.database.Speakers[speakerID]
+	if !exists {
+		return nil, fmt.Errorf("speaker %s not found", speakerID)
+	}
+
+	updated := *speakerData
+	if name != nil {
+		updated.Name = *name
+	}
+	if clearMetadata {
+		updated.Metadata = nil
+	} else if len(metadata) > 0 {
+		updated.Metadata = metadata
+	}
+	updated.UpdatedAt = time.Now()
+
+	// The JSON store writes the database it loaded, so swap the record in before saving
+	m.database.Speakers[speakerID] = &updated
+	if err := m.store.SaveSpeakers([]*SpeakerData{&updated}); err != nil {
+		m.database.Speakers[speakerID] = speakerData
+		return nil, fmt.Errorf("failed to save database: %v", err)
+	}
+	m.database.UpdatedAt = updated.UpdatedAt
+
+	return updated.info(), nil
+}
+
+// validateMetadata accepts empty metadata or a JSON object
+func validateMetadata(metadata json.RawMessage) error {
+	if len(metadata) == 0 {
+		return nil
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(metadata, &object); err != nil || object == nil {
+		return fmt.Errorf("metadata must be a JSON object")
+	}
+	return nil
+}
+
+// metadataMatches reports whether every filter key has the given value at the top level of
+// metadata. Non-string values are compared in their JSON form, so {"age": 30} matches "30".
+func metadataMatches(metadata json.RawMessage, filters map[string]string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(metadata, &object); err != nil {
+		return false
+	}
+	for key, want := range filters {
+		raw, exists := object[key]
+		if !exists {
+			return false
+		}
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			value = string(raw)
+		}
+		if value != want {
+			return false
+		}
+	}
+	return true
+}
+
+// speakerLess orders speakers by one of ValidSortFields
+func speakerLess(sortBy string) func(a, b *SpeakerInfo) bool {
+	switch sortBy {
+	case SortByName:
+		return func(a, b *SpeakerInfo) bool { return a.Name < b.Name }
+	case SortByCreatedAt:
+		return func(a, b *SpeakerInfo) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	case SortByUpdatedAt:
+		return func(a, b *SpeakerInfo) bool { return a.UpdatedAt.Before(b.UpdatedAt) }
+	case SortBySampleCount:
+		return func(a, b *SpeakerInfo) bool { return a.SampleCount < b.SampleCount }
+	default:
+		return func(a, b *SpeakerInfo) bool { return a.ID < b.ID }
+	}
+}
@@ -0,0 +1,171 @@
+//go:build redis
+
+package speaker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisTimeout bounds every Redis round trip
+const redisTimeout = 10 * time.Second
+
+// redisStore keeps the speaker database in Redis:
+//
+//	<prefix>speakers           set of speaker IDs
+//	<prefix>speaker:<id>       hash with name, metadata, created_at and updated_at
+//	<prefix>embeddings:<id>    list of embeddings in enrollment order
+type redisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// newRedisStore connects to a Redis server shared by all instances
+func newRedisStore(config *Config) (Store, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     config.RedisAddr,
+		Password: config.RedisPassword,
+		DB:       config.RedisDB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %v", err)
+	}
+
+	return &redisStore{client: client, prefix: config.RedisKeyPrefix}, nil
+}
+
+func (s *redisStore) speakersKey() string {
+	return s.prefix + "speakers"
+}
+
+func (s *redisStore) speakerKey(speakerID string) string {
+	return s.prefix + "speaker:" + speakerID
+}
+
+func (s *redisStore) embeddingsKey(speakerID string) string {
+	return s.prefix + "embeddings:" + speakerID
+}
+
+func (s *redisStore) Load() (*SpeakerDatabase, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	ids, err := s.client.SMembers(ctx, s.speakersKey()).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list speakers: %v", err)
+	}
+
+	fields := make([]*redis.MapStringStringCmd, len(ids))
+	embeddings := make([]*redis.StringSliceCmd, len(ids))
+	if _, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			fields[i] = pipe.HGetAll(ctx, s.speakerKey(id))
+			embeddings[i] = pipe.LRange(ctx, s.embeddingsKey(id), 0, -1)
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to read speakers: %v", err)
+	}
+
+	database := newDatabase()
+	var lastUpdated time.Time
+	for i, id := range ids {
+		values := fields[i].Val()
+		if len(values) == 0 {
+			// Deleted between SMEMBERS and the pipeline
+			continue
+		}
+		speaker := &SpeakerData{ID: id, Name: values["name"], Embeddings: [][]float32{}}
+		if metadata := values["metadata"]; metadata != "" {
+			speaker.Metadata = json.RawMessage(metadata)
+		}
+		speaker.CreatedAt, _ = time.Parse(time.RFC3339Nano, values["created_at"])
+		speaker.UpdatedAt, _ = time.Parse(time.RFC3339Nano, values["updated_at"])
+		for _, blob := range embeddings[i].Val() {
+			speaker.Embeddings = append(speaker.Embeddings, decodeEmbedding([]byte(blob)))
+		}
+		speaker.SampleCount = len(speaker.Embeddings)
+		database.Speakers[id] = speaker
+		if speaker.UpdatedAt.After(lastUpdated) {
+			lastUpdated = speaker.UpdatedAt
+		}
+	}
+	if !lastUpdated.IsZero() {
+		database.UpdatedAt = lastUpdated
+	}
+
+	return database, nil
+}
+
+func (s *redisStore) SaveSpeaker(speaker *SpeakerData, embedding []float32) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	if _, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		key := s.speakerKey(speaker.ID)
+		pipe.SAdd(ctx, s.speakersKey(), speaker.ID)
+		pipe.HSetNX(ctx, key, "created_at", speaker.CreatedAt.Format(time.RFC3339Nano))
+		pipe.HSet(ctx, key, "name", speaker.Name, "metadata", string(speaker.Metadata), "updated_at", speaker.UpdatedAt.Format(time.RFC3339Nano))
+		pipe.RPush(ctx, s.embeddingsKey(speaker.ID), encodeEmbedding(embedding))
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to save speaker: %v", err)
+	}
+	return nil
+}
+
+func (s *redisStore) SaveSpeakers(speakers []*SpeakerData) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	if _, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, speaker := range speakers {
+			key := s.speakerKey(speaker.ID)
+			embeddingsKey := s.embeddingsKey(speaker.ID)
+			pipe.SAdd(ctx, s.speakersKey(), speaker.ID)
+			pipe.Del(ctx, key, embeddingsKey)
+			pipe.HSet(ctx, key,
+				"name", speaker.Name,
+				"metadata", string(speaker.Metadata),
+				"created_at", speaker.CreatedAt.Format(time.RFC3339Nano),
+				"updated_at", speaker.UpdatedAt.Format(time.RFC3339Nano))
+			if len(speaker.Embeddings) > 0 {
+				blobs := make([]interface{}, len(speaker.Embeddings))
+				for i, embedding := range speaker.Embeddings {
+					blobs[i] = encodeEmbedding(embedding)
+				}
+				pipe.RPush(ctx, embeddingsKey, blobs...)
+			}
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to save speakers: %v", err)
+	}
+	return nil
+}
+
+func (s *redisStore) DeleteSpeaker(speakerID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	if _, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SRem(ctx, s.speakersKey(), speakerID)
+		pipe.Del(ctx, s.speakerKey(speakerID), s.embeddingsKey(speakerID))
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to delete speaker: %v", err)
+	}
+	return nil
+}
+
+func (s *redisStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,10 @@
+//go:build !redis
+
+package speaker
+
+import "fmt"
+
+// newRedisStore always fails without the "redis" build tag
+func newRedisStore(config *Config) (Store, error) {
+	return nil, fmt.Errorf("Redis storage not compiled in (rebuild with -tags redis)")
+}
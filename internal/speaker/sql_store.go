@@ -0,0 +1,223 @@
+package speaker
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sqlDialect describes how a database/sql backend differs from the others
+type sqlDialect struct {
+	name string
+	// migrations are applied in order; a database records how many it has seen in
+	// schema_migrations, so new entries must only ever be appended
+	migrations []string
+	// lock, when set, runs first in the migration transaction so concurrent instances
+	// migrate one at a time
+	lock string
+	// numbered placeholders ($1, $2, ...) instead of ?
+	numbered bool
+}
+
+// sqlStore keeps speakers and their enrollment embeddings in two tables of a SQL database
+type sqlStore struct {
+	db      *sql.DB
+	dialect sqlDialect
+}
+
+// newSQLStore migrates an open database and wraps it as a store. The database is closed
+// if migration fails.
+func newSQLStore(db *sql.DB, dialect sqlDialect) (Store, error) {
+	store := &sqlStore{db: db, dialect: dialect}
+	if err := store.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// query rewrites ? placeholders for the dialect
+func (s *sqlStore) query(q string) string {
+	if !s.dialect.numbered {
+		return q
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range q {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// migrate applies the migrations the database has not seen yet in a single transaction
+func (s *sqlStore) migrate() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin %s migration: %v", s.dialect.name, err)
+	}
+	defer tx.Rollback()
+
+	if s.dialect.lock != "" {
+		if _, err := tx.Exec(s.dialect.lock); err != nil {
+			return fmt.Errorf("failed to lock %s schema: %v", s.dialect.name, err)
+		}
+	}
+	if _, err := tx.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)"); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %v", err)
+	}
+
+	var version int
+	if err := tx.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %v", err)
+	}
+	if version > len(s.dialect.migrations) {
+		return fmt.Errorf("speaker database schema version %d is newer than supported version %d", version, len(s.dialect.migrations))
+	}
+
+	for i := version; i < len(s.dialect.migrations); i++ {
+		if _, err := tx.Exec(s.dialect.migrations[i]); err != nil {
+			return fmt.Errorf("failed to apply %s migration %d: %v", s.dialect.name, i+1, err)
+		}
+		if _, err := tx.Exec(s.query("INSERT INTO schema_migrations (version) VALUES (?)"), i+1); err != nil {
+			return fmt.Errorf("failed to record %s migration %d: %v", s.dialect.name, i+1, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqlStore) Load() (*SpeakerDatabase, error) {
+	database := newDatabase()
+	var lastUpdated time.Time
+
+	rows, err := s.db.Query("SELECT id, name, metadata, created_at, updated_at FROM speakers")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query speakers: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		speaker := &SpeakerData{Embeddings: [][]float32{}}
+		var metadata sql.NullString
+		if err := rows.Scan(&speaker.ID, &speaker.Name, &metadata, &speaker.CreatedAt, &speaker.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to read speaker: %v", err)
+		}
+		if metadata.Valid {
+			speaker.Metadata = json.RawMessage(metadata.String)
+		}
+		database.Speakers[speaker.ID] = speaker
+		if speaker.UpdatedAt.After(lastUpdated) {
+			lastUpdated = speaker.UpdatedAt
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read speakers: %v", err)
+	}
+	if !lastUpdated.IsZero() {
+		database.UpdatedAt = lastUpdated
+	}
+
+	embeddings, err := s.db.Query("SELECT speaker_id, embedding FROM embeddings ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query embeddings: %v", err)
+	}
+	defer embeddings.Close()
+	for embeddings.Next() {
+		var speakerID string
+		var blob []byte
+		if err := embeddings.Scan(&speakerID, &blob); err != nil {
+			return nil, fmt.Errorf("failed to read embedding: %v", err)
+		}
+		if speaker, exists := database.Speakers[speakerID]; exists {
+			speaker.Embeddings = append(speaker.Embeddings, decodeEmbedding(blob))
+			speaker.SampleCount++
+		}
+	}
+	if err := embeddings.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read embeddings: %v", err)
+	}
+
+	return database, nil
+}
+
+func (s *sqlStore) SaveSpeaker(speaker *SpeakerData, embedding []float32) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(s.query(`INSERT INTO speakers (id, name, metadata, created_at, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET name = excluded.name, metadata = excluded.metadata, updated_at = excluded.updated_at`),
+		speaker.ID, speaker.Name, nullMetadata(speaker.Metadata), speaker.CreatedAt, speaker.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save speaker: %v", err)
+	}
+	if _, err := tx.Exec(s.query("INSERT INTO embeddings (speaker_id, embedding, created_at) VALUES (?, ?, ?)"),
+		speaker.ID, encodeEmbedding(embedding), time.Now()); err != nil {
+		return fmt.Errorf("failed to save embedding: %v", err)
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqlStore) SaveSpeakers(speakers []*SpeakerData) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, speaker := range speakers {
+		if _, err := tx.Exec(s.query("DELETE FROM embeddings WHERE speaker_id = ?"), speaker.ID); err != nil {
+			return fmt.Errorf("failed to replace speaker %s: %v", speaker.ID, err)
+		}
+		if _, err := tx.Exec(s.query(`INSERT INTO speakers (id, name, metadata, created_at, updated_at) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET name = excluded.name, metadata = excluded.metadata,
+				created_at = excluded.created_at, updated_at = excluded.updated_at`),
+			speaker.ID, speaker.Name, nullMetadata(speaker.Metadata), speaker.CreatedAt, speaker.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to save speaker %s: %v", speaker.ID, err)
+		}
+		for _, embedding := range speaker.Embeddings {
+			if _, err := tx.Exec(s.query("INSERT INTO embeddings (speaker_id, embedding, created_at) VALUES (?, ?, ?)"),
+				speaker.ID, encodeEmbedding(embedding), now); err != nil {
+				return fmt.Errorf("failed to save embedding of speaker %s: %v", speaker.ID, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqlStore) DeleteSpeaker(speakerID string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(s.query("DELETE FROM embeddings WHERE speaker_id = ?"), speakerID); err != nil {
+		return fmt.Errorf("failed to delete embeddings: %v", err)
+	}
+	if _, err := tx.Exec(s.query("DELETE FROM speakers WHERE id = ?"), speakerID); err != nil {
+		return fmt.Errorf("failed to delete speaker: %v", err)
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqlStore) Close() error {
+	return s.db.Close()
+}
+
+// nullMetadata stores absent metadata as NULL
+func nullMetadata(metadata json.RawMessage) sql.NullString {
+	return sql.NullString{String: string(metadata), Valid: len(metadata) > 0}
+}
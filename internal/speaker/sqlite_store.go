@@ -0,0 +1,57 @@
+//go:build sqlite
+
+package speaker
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+)
+
+var sqliteDialect = sqlDialect{
+	name: "sqlite",
+	migrations: []string{
+		`CREATE TABLE speakers (
+			id         TEXT PRIMARY KEY,
+			name       TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);
+		CREATE TABLE embeddings (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			speaker_id TEXT NOT NULL REFERENCES speakers(id) ON DELETE CASCADE,
+			embedding  BLOB NOT NULL,
+			created_at TIMESTAMP NOT NULL
+		);
+		CREATE INDEX embeddings_speaker_id ON embeddings(speaker_id);`,
+		`ALTER TABLE speakers ADD COLUMN metadata TEXT;`,
+		`CREATE TABLE speaker_audit (
+			id          INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp   TIMESTAMP NOT NULL,
+			action      TEXT NOT NULL,
+			speaker_id  TEXT NOT NULL,
+			actor       TEXT NOT NULL,
+			source      TEXT NOT NULL,
+			remote_addr TEXT NOT NULL DEFAULT '',
+			request_id  TEXT NOT NULL DEFAULT ''
+		);
+		CREATE INDEX speaker_audit_speaker_id ON speaker_audit(speaker_id);`,
+	},
+}
+
+// newSQLiteStore opens an embedded SQLite database file
+func newSQLiteStore(path string) (Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database: %v", err)
+	}
+	// A single connection serialises writers and keeps the pragma below in effect
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to configure SQLite database: %v", err)
+	}
+
+	return newSQLStore(db, sqliteDialect)
+}
@@ -0,0 +1,10 @@
+//go:build !sqlite
+
+package speaker
+
+import "fmt"
+
+// newSQLiteStore always fails without the "sqlite" build tag
+func newSQLiteStore(path string) (Store, error) {
+	return nil, fmt.Errorf("SQLite storage not compiled in (rebuild with -tags sqlite)")
+}
@@ -0,0 +1,192 @@
+package speaker
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"asr_server/internal/logger"
+)
+
+// Speaker storage backends
+const (
+	StorageJSON     = "json"
+	StorageMemory   = "memory"
+	StorageSQLite   = "sqlite"
+	StoragePostgres = "postgres"
+	StorageRedis    = "redis"
+)
+
+// SharedStorage reports whether a backend can be shared by several server instances, in
+// which case each manager periodically reloads it to see the others' changes
+func SharedStorage(storage string) bool {
+	return storage == StoragePostgres || storage == StorageRedis
+}
+
+// Store persists the speaker database. The manager keeps the whole database in memory and
+// calls the store under its lock after every change, so implementations need not be safe
+// for concurrent use.
+type Store interface {
+	// Load returns the stored database, or an empty one when nothing was stored yet
+	Load() (*SpeakerDatabase, error)
+	// SaveSpeaker stores a speaker's name and timestamps and appends one enrollment embedding
+	SaveSpeaker(speaker *SpeakerData, embedding []float32) error
+	// SaveSpeakers stores complete speakers with all of their embeddings in one batch,
+	// replacing any stored speaker with the same ID
+	SaveSpeakers(speakers []*SpeakerData) error
+	// DeleteSpeaker removes a speaker and all of its embeddings
+	DeleteSpeaker(speakerID string) error
+	Close() error
+}
+
+// NewStore opens the storage backend selected by config.Storage
+func NewStore(config *Config) (Store, error) {
+	switch config.Storage {
+	case "", StorageJSON:
+		return &jsonStore{path: filepath.Join(config.DataDir, "speaker.json")}, nil
+	case StorageMemory:
+		return memoryStore{}, nil
+	case StorageSQLite:
+		return newSQLiteStore(filepath.Join(config.DataDir, "speaker.db"))
+	case StoragePostgres:
+		return newPostgresStore(config.PostgresDSN)
+	case StorageRedis:
+		return newRedisStore(config)
+	default:
+		return nil, fmt.Errorf("unsupported speaker storage %q", config.Storage)
+	}
+}
+
+// newDatabase returns an empty speaker database
+func newDatabase() *SpeakerDatabase {
+	return &SpeakerDatabase{
+		Speakers:  make(map[string]*SpeakerData),
+		Version:   "1.0.0",
+		UpdatedAt: time.Now(),
+	}
+}
+
+// jsonStore keeps the database in a single JSON file that is rewritten on every change.
+// It writes the database it loaded, which the manager updates in place.
+type jsonStore struct {
+	path     string
+	database *SpeakerDatabase
+}
+
+func (s *jsonStore) Load() (*SpeakerDatabase, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		s.database = newDatabase()
+		return s.database, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database file: %v", err)
+	}
+
+	var db SpeakerDatabase
+	if err := json.Unmarshal(data, &db); err != nil {
+		// Start over rather than refuse to run; the file is replaced on the next change
+		logger.Warn("failed_to_load_speaker_database_using_defaults", "error", err)
+		s.database = newDatabase()
+		return s.database, nil
+	}
+	if db.Speakers == nil {
+		db.Speakers = make(map[string]*SpeakerData)
+	}
+	s.database = &db
+	return s.database, nil
+}
+
+func (s *jsonStore) SaveSpeaker(speaker *SpeakerData, embedding []float32) error {
+	return s.save()
+}
+
+func (s *jsonStore) SaveSpeakers(speakers []*SpeakerData) error {
+	return s.save()
+}
+
+func (s *jsonStore) DeleteSpeaker(speakerID string) error {
+	return s.save()
+}
+
+func (s *jsonStore) Close() error {
+	return nil
+}
+
+// save writes the whole database to the file
+func (s *jsonStore) save() error {
+	s.database.UpdatedAt = time.Now()
+
+	data, err := json.MarshalIndent(s.database, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal database: %v", err)
+	}
+
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write database file: %v", err)
+	}
+
+	return nil
+}
+
+// memoryStore keeps nothing; registered speakers are lost on restart
+type memoryStore struct{}
+
+func (memoryStore) Load() (*SpeakerDatabase, error) {
+	return newDatabase(), nil
+}
+
+func (memoryStore) SaveSpeaker(speaker *SpeakerData, embedding []float32) error {
+	return nil
+}
+
+func (memoryStore) SaveSpeakers(speakers []*SpeakerData) error {
+	return nil
+}
+
+func (memoryStore) DeleteSpeaker(speakerID string) error {
+	return nil
+}
+
+func (memoryStore) Close() error {
+	return nil
+}
+
+// importJSON copies the speakers of a JSON database file into an empty store, so switching
+// backends keeps existing enrollments. It returns the number of speakers imported.
+func importJSON(store Store, path string) (int, error) {
+	source := &jsonStore{path: path}
+	db, err := source.Load()
+	if err != nil {
+		return 0, err
+	}
+	speakers := make([]*SpeakerData, 0, len(db.Speakers))
+	for _, speaker := range db.Speakers {
+		speakers = append(speakers, speaker)
+	}
+	if err := store.SaveSpeakers(speakers); err != nil {
+		return 0, fmt.Errorf("failed to import speakers: %v", err)
+	}
+	return len(speakers), nil
+}
+
+// encodeEmbedding stores an embedding as little-endian float32 values
+func encodeEmbedding(embedding []float32) []byte {
+	blob := make([]byte, 4*len(embedding))
+	for i, v := range embedding {
+		binary.LittleEndian.PutUint32(blob[4*i:], math.Float32bits(v))
+	}
+	return blob
+}
+
+func decodeEmbedding(blob []byte) []float32 {
+	embedding := make([]float32, len(blob)/4)
+	for i := range embedding {
+		embedding[i] = math.Float32frombits(binary.LittleEndian.Uint32(blob[4*i:]))
+	}
+	return embedding
+}
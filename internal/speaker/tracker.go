@@ -0,0 +1,86 @@
+package speaker
+
+import (
+	"fmt"
+	"sync"
+
+	"asr_server/internal/postprocess"
+)
+
+// Tracker attributes the speech segments of one audio stream to speakers. Segments matching
+// a registered speaker are reported by ID and name. With diarization enabled, every segment is
+// also clustered online by voice similarity and labelled spk_1, spk_2, ... in order of first
+// appearance, so speakers that were never enrolled can be told apart within the stream.
+// It is safe for concurrent use.
+type Tracker struct {
+	manager     *Manager
+	threshold   float32 // Minimum similarity to join an existing cluster
+	maxSpeakers int     // Cluster limit, 0 disables diarization
+
+	mu        sync.Mutex
+	centroids [][]float32 // Mean embedding of each cluster
+	counts    []int       // Segments averaged into each centroid
+}
+
+// NewTracker creates a tracker for a new audio stream. Segments whose best similarity is
+// below threshold start a new cluster until maxSpeakers exist; later ones join the closest.
+// A maxSpeakers of 0 only reports registered speakers.
+func (m *Manager) NewTracker(threshold float32, maxSpeakers int) *Tracker {
+	return &Tracker{
+		manager:     m,
+		threshold:   threshold,
+		maxSpeakers: maxSpeakers,
+	}
+}
+
+// Identify returns the speaker of a speech segment
+func (t *Tracker) Identify(audioData []float32, sampleRate int) (postprocess.Speaker, error) {
+	m := t.manager
+	embedding, err := m.extractEmbedding(audioData, sampleRate)
+	if err != nil {
+		return postprocess.Speaker{}, fmt.Errorf("failed to extract embedding: %v", err)
+	}
+	m.mutex.RLock()
+	var speaker postprocess.Speaker
+	if speakerID := m.manager.Search(embedding, m.threshold); speakerID != "" {
+		speaker.ID = speakerID
+		if speakerData, exists := m.database.Speakers[speakerID]; exists {
+			speaker.Name = speakerData.Name
+		}
+	}
+	m.mutex.RUnlock()
+
+	if t.maxSpeakers > 0 {
+		speaker.Label = t.cluster(embedding)
+	}
+	return speaker, nil
+}
+
+// cluster assigns an embedding to the most similar cluster, or to a new one when none
+// reaches the threshold, and returns the cluster's label
+func (t *Tracker) cluster(embedding []float32) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	best, bestSimilarity := -1, float32(0)
+	for i, centroid := range t.centroids {
+		if similarity := cosineSimilarity(embedding, centroid); best < 0 || similarity > bestSimilarity {
+			best, bestSimilarity = i, similarity
+		}
+	}
+
+	if best < 0 || (bestSimilarity < t.threshold && len(t.centroids) < t.maxSpeakers) {
+		t.centroids = append(t.centroids, append([]float32(nil), embedding...))
+		t.counts = append(t.counts, 1)
+		return fmt.Sprintf("spk_%d", len(t.centroids))
+	}
+
+	// Fold the segment into the running mean so the cluster adapts to the speaker's voice
+	centroid := t.centroids[best]
+	t.counts[best]++
+	n := float32(t.counts[best])
+	for i := range centroid {
+		centroid[i] += (embedding[i] - centroid[i]) / n
+	}
+	return fmt.Sprintf("spk_%d", best+1)
+}
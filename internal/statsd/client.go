@@ -0,0 +1,186 @@
+// Package statsd pushes server metrics to a StatsD or DogStatsD agent over UDP
+package statsd
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"asr_server/config"
+	"asr_server/internal/logger"
+)
+
+// maxPacketSize keeps packets within the Ethernet MTU, so they are not fragmented
+const maxPacketSize = 1432
+
+// Client buffers metrics in the DogStatsD line format and sends them in packets. Tags are
+// appended as |#tag,tag, which Datadog, Telegraf and the Prometheus statsd_exporter accept.
+// Sending is best effort: a lost packet or an unreachable agent never blocks the caller.
+type Client struct {
+	conn   net.Conn
+	prefix string
+	tags   []string
+
+	mu       sync.Mutex
+	buf      []byte
+	onFlush  []func()
+	interval time.Duration
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewClient resolves the agent address and starts flushing every flush_interval seconds
+func NewClient(cfg config.StatsDConfig) (*Client, error) {
+	conn, err := net.Dial("udp", cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve StatsD address %s: %v", cfg.Addr, err)
+	}
+	interval := time.Duration(cfg.FlushInterval) * time.Second
+	if interval <= 0 {
+		interval = time.Duration(config.DefaultStatsDFlushInterval) * time.Second
+	}
+
+	c := &Client{
+		conn:     conn,
+		prefix:   cfg.Prefix,
+		tags:     cfg.Tags,
+		buf:      make([]byte, 0, maxPacketSize),
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+	c.wg.Add(1)
+	go c.run()
+	return c, nil
+}
+
+// OnFlush registers fn to run before every periodic flush, to report gauges sampled from
+// current statistics
+func (c *Client) OnFlush(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onFlush = append(c.onFlush, fn)
+}
+
+// Timing reports a duration in milliseconds
+func (c *Client) Timing(name string, d time.Duration, tags ...string) {
+	c.write(name, strconv.FormatFloat(float64(d.Microseconds())/1000, 'f', -1, 64), "ms", tags)
+}
+
+// Gauge reports the current value of a metric
+func (c *Client) Gauge(name string, value float64, tags ...string) {
+	c.write(name, strconv.FormatFloat(value, 'f', -1, 64), "g", tags)
+}
+
+// GaugeStats reports every numeric value of a statistics map, as returned by the GetStats
+// methods, as a gauge named after its path: {"pool": {"active": 3}} becomes name.pool.active.
+// Booleans are reported as 0 or 1; other values are skipped.
+func (c *Client) GaugeStats(name string, stats map[string]interface{}, tags ...string) {
+	keys := make([]string, 0, len(stats))
+	for key := range stats {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		metric := name + "." + key
+		switch v := stats[key].(type) {
+		case map[string]interface{}:
+			c.GaugeStats(metric, v, tags...)
+		case map[string]int:
+			for k, n := range v {
+				c.Gauge(metric+"."+k, float64(n), tags...)
+			}
+		case int:
+			c.Gauge(metric, float64(v), tags...)
+		case int32:
+			c.Gauge(metric, float64(v), tags...)
+		case int64:
+			c.Gauge(metric, float64(v), tags...)
+		case uint64:
+			c.Gauge(metric, float64(v), tags...)
+		case float64:
+			c.Gauge(metric, v, tags...)
+		case bool:
+			gauge := 0.0
+			if v {
+				gauge = 1
+			}
+			c.Gauge(metric, gauge, tags...)
+		}
+	}
+}
+
+// Close sends buffered metrics and stops flushing
+func (c *Client) Close() {
+	close(c.done)
+	c.wg.Wait()
+	c.flush()
+	c.conn.Close()
+}
+
+// write appends a metric line, sending the buffer first when the line does not fit
+func (c *Client) write(name, value, metricType string, tags []string) {
+	var line strings.Builder
+	line.WriteString(c.prefix)
+	line.WriteString(name)
+	line.WriteByte(':')
+	line.WriteString(value)
+	line.WriteByte('|')
+	line.WriteString(metricType)
+	if len(c.tags)+len(tags) > 0 {
+		line.WriteString("|#")
+		line.WriteString(strings.Join(append(append([]string(nil), c.tags...), tags...), ","))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.buf) > 0 && len(c.buf)+1+line.Len() > maxPacketSize {
+		c.send()
+	}
+	if len(c.buf) > 0 {
+		c.buf = append(c.buf, '\n')
+	}
+	c.buf = append(c.buf, line.String()...)
+}
+
+// flush sends the buffered metrics
+func (c *Client) flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.send()
+}
+
+// send writes the buffer as one packet; c.mu must be held
+func (c *Client) send() {
+	if len(c.buf) == 0 {
+		return
+	}
+	if _, err := c.conn.Write(c.buf); err != nil {
+		logger.Debug("statsd_send_failed", "error", err)
+	}
+	c.buf = c.buf[:0]
+}
+
+// run samples gauges and flushes at the configured interval
+func (c *Client) run() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.mu.Lock()
+			samplers := c.onFlush
+			c.mu.Unlock()
+			for _, fn := range samplers {
+				fn()
+			}
+			c.flush()
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package tcp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Frame types. Each frame is a 4-byte big-endian length, counting the type byte and
+// the payload, followed by a 1-byte type and the payload.
+const (
+	// FrameAudio carries 16-bit little-endian mono PCM at audio.sample_rate (client to server)
+	FrameAudio byte = 0x01
+	// FrameJSON carries a JSON control command (client to server) or a session message
+	// such as a recognition result (server to client)
+	FrameJSON byte = 0x02
+)
+
+// frameHeaderSize is the length prefix plus the type byte
+const frameHeaderSize = 5
+
+// readFrame reads one frame, rejecting frames whose payload exceeds maxSize
+func readFrame(r io.Reader, maxSize int) (byte, []byte, error) {
+	var header [frameHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+
+	length := binary.BigEndian.Uint32(header[:4])
+	if length == 0 {
+		return 0, nil, fmt.Errorf("invalid frame length 0")
+	}
+	size := int(length - 1)
+	if maxSize > 0 && size > maxSize {
+		return 0, nil, fmt.Errorf("frame of %d bytes exceeds maximum of %d", size, maxSize)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return header[4], payload, nil
+}
+
+// appendFrame appends an encoded frame to b
+func appendFrame(b []byte, frameType byte, payload []byte) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(payload)+1))
+	b = append(b, frameType)
+	return append(b, payload...)
+}
@@ -0,0 +1,198 @@
+package tcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"asr_server/config"
+	"asr_server/internal/logger"
+	"asr_server/internal/session"
+	"asr_server/internal/ws"
+)
+
+// writeTimeout bounds writing a frame to a slow client
+const writeTimeout = 5 * time.Second
+
+// Server accepts length-prefixed frame connections over raw TCP for embedded clients
+// that cannot perform a WebSocket handshake. Each connection is one session.
+// All dependencies are explicitly injected via constructor.
+type Server struct {
+	cfg            *config.Config
+	sessionManager *session.Manager
+
+	listener net.Listener
+	done     chan struct{}
+	wg       sync.WaitGroup
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+// NewServer creates a new TCP server with explicit dependencies
+func NewServer(cfg *config.Config, sessionManager *session.Manager) *Server {
+	return &Server{
+		cfg:            cfg,
+		sessionManager: sessionManager,
+		done:           make(chan struct{}),
+		conns:          make(map[net.Conn]struct{}),
+	}
+}
+
+// Start binds the TCP listener and begins accepting connections
+func (s *Server) Start() error {
+	addr := net.JoinHostPort(s.cfg.Server.Host, fmt.Sprint(s.cfg.TCP.Port))
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for TCP clients: %v", err)
+	}
+	s.listener = listener
+
+	s.wg.Add(1)
+	go s.acceptLoop()
+
+	logger.Info("tcp_server_started", "listen_addr", listener.Addr().String(), "max_connections", s.cfg.TCP.MaxConnections)
+	return nil
+}
+
+// Stop closes the listener and all client connections
+func (s *Server) Stop() {
+	close(s.done)
+	if s.listener != nil {
+		s.listener.Close()
+	}
+
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	logger.Info("tcp_server_stopped")
+}
+
+// acceptLoop accepts connections until the listener is closed
+func (s *Server) acceptLoop() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.done:
+				return
+			default:
+			}
+			logger.Warn("tcp_accept_error", "error", err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
+		if !s.track(conn) {
+			logger.Warn("tcp_connection_rejected", "remote", conn.RemoteAddr().String(), "reason", "max_connections")
+			conn.Close()
+			continue
+		}
+
+		s.wg.Add(1)
+		go s.serve(conn)
+	}
+}
+
+// track registers a connection, refusing it when the connection limit is reached
+func (s *Server) track(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if max := s.cfg.TCP.MaxConnections; max > 0 && len(s.conns) >= max {
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	return true
+}
+
+// serve runs a session for one client connection
+func (s *Server) serve(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	sessionID := ws.GenerateSessionID()
+	sess, err := s.sessionManager.CreateSession(sessionID, &frameConn{conn: conn})
+	if err != nil {
+		logger.Error("failed_to_create_session", "session_id", sessionID, "error", err)
+		return
+	}
+	sess.SetRemoteAddr(conn.RemoteAddr().String())
+	defer s.sessionManager.RemoveSession(sessionID)
+
+	remote := conn.RemoteAddr().String()
+	logger.Info("tcp_connection_established", "session_id", sessionID, "remote", remote)
+
+	idleTimeout := time.Duration(s.cfg.TCP.IdleTimeout) * time.Second
+	for {
+		if idleTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(idleTimeout))
+		}
+
+		frameType, payload, err := readFrame(conn, s.cfg.TCP.MaxFrameSize)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				logger.Warn("tcp_read_error", "session_id", sessionID, "error", err)
+			}
+			break
+		}
+
+		switch frameType {
+		case FrameAudio:
+			if len(payload) == 0 {
+				continue
+			}
+			if err := s.sessionManager.ProcessAudioData(sessionID, payload); err != nil {
+				logger.Error("failed_to_process_audio", "session_id", sessionID, "error", err)
+			}
+		case FrameJSON:
+			if err := s.sessionManager.HandleControl(sessionID, payload); err != nil {
+				logger.Warn("control_message_failed", "session_id", sessionID, "error", err)
+			}
+		default:
+			logger.Warn("tcp_unknown_frame_type", "session_id", sessionID, "type", frameType)
+		}
+	}
+
+	logger.Info("tcp_connection_closed", "session_id", sessionID, "remote", remote)
+}
+
+// frameConn writes session messages as JSON frames
+type frameConn struct {
+	conn net.Conn
+	mu   sync.Mutex
+}
+
+// WriteJSON implements session.Conn
+func (f *frameConn) WriteJSON(v interface{}) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	_, err = f.conn.Write(appendFrame(nil, FrameJSON, payload))
+	return err
+}
+
+// Close implements session.Conn
+func (f *frameConn) Close() error {
+	return f.conn.Close()
+}
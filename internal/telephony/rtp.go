@@ -0,0 +1,58 @@
+package telephony
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// RTP payload types for G.711 (RFC 3551)
+const (
+	payloadTypePCMU = 0
+	payloadTypePCMA = 8
+)
+
+// rtpHeaderSize is the fixed RTP header length without CSRCs or extensions
+const rtpHeaderSize = 12
+
+// rtpPacket is the subset of an RTP packet needed for audio ingest
+type rtpPacket struct {
+	payloadType    uint8
+	sequenceNumber uint16
+	timestamp      uint32
+	ssrc           uint32
+	payload        []byte
+}
+
+// parseRTP parses an RTP packet as defined in RFC 3550
+func parseRTP(data []byte) (*rtpPacket, error) {
+	if len(data) < rtpHeaderSize {
+		return nil, fmt.Errorf("packet too short: %d bytes", len(data))
+	}
+	if version := data[0] >> 6; version != 2 {
+		return nil, fmt.Errorf("unsupported RTP version: %d", version)
+	}
+
+	offset := rtpHeaderSize + int(data[0]&0x0f)*4
+	if data[0]&0x10 != 0 {
+		if len(data) < offset+4 {
+			return nil, fmt.Errorf("truncated header extension")
+		}
+		offset += 4 + int(binary.BigEndian.Uint16(data[offset+2:]))*4
+	}
+
+	end := len(data)
+	if data[0]&0x20 != 0 && end > 0 {
+		end -= int(data[end-1])
+	}
+	if offset > end {
+		return nil, fmt.Errorf("invalid header length")
+	}
+
+	return &rtpPacket{
+		payloadType:    data[1] & 0x7f,
+		sequenceNumber: binary.BigEndian.Uint16(data[2:]),
+		timestamp:      binary.BigEndian.Uint32(data[4:]),
+		ssrc:           binary.BigEndian.Uint32(data[8:]),
+		payload:        data[offset:end],
+	}, nil
+}
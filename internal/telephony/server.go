@@ -0,0 +1,218 @@
+package telephony
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"asr_server/config"
+	"asr_server/internal/audio"
+	"asr_server/internal/logger"
+	"asr_server/internal/session"
+	"asr_server/internal/ws"
+)
+
+// g711SampleRate is the fixed sample rate of G.711 audio
+const g711SampleRate = 8000
+
+// maxPacketSize bounds a single UDP datagram
+const maxPacketSize = 1500
+
+// call tracks a single inbound RTP stream identified by its SSRC
+type call struct {
+	sessionID string
+	lastSeen  time.Time
+	buffer    []float32
+}
+
+// Server receives G.711 RTP streams over UDP and transcribes each one as a session.
+// All dependencies are explicitly injected via constructor.
+type Server struct {
+	cfg            *config.Config
+	sessionManager *session.Manager
+
+	conn *net.UDPConn
+	done chan struct{}
+	wg   sync.WaitGroup
+
+	mu    sync.Mutex
+	calls map[uint32]*call
+}
+
+// NewServer creates a new telephony server with explicit dependencies
+func NewServer(cfg *config.Config, sessionManager *session.Manager) *Server {
+	return &Server{
+		cfg:            cfg,
+		sessionManager: sessionManager,
+		done:           make(chan struct{}),
+		calls:          make(map[uint32]*call),
+	}
+}
+
+// Start binds the RTP listener and begins receiving packets
+func (s *Server) Start() error {
+	addr, err := net.ResolveUDPAddr("udp", s.cfg.Telephony.ListenAddr)
+	if err != nil {
+		return fmt.Errorf("invalid telephony listen address: %v", err)
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for RTP: %v", err)
+	}
+	s.conn = conn
+
+	s.wg.Add(2)
+	go s.readLoop()
+	go s.reapLoop()
+
+	logger.Info("telephony_server_started", "listen_addr", conn.LocalAddr().String())
+	return nil
+}
+
+// Stop closes the listener and removes all active call sessions
+func (s *Server) Stop() {
+	close(s.done)
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.wg.Wait()
+
+	s.mu.Lock()
+	calls := s.calls
+	s.calls = make(map[uint32]*call)
+	s.mu.Unlock()
+
+	for _, c := range calls {
+		s.sessionManager.RemoveSession(c.sessionID)
+	}
+	logger.Info("telephony_server_stopped")
+}
+
+// readLoop receives datagrams until the listener is closed
+func (s *Server) readLoop() {
+	defer s.wg.Done()
+
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, remote, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-s.done:
+				return
+			default:
+			}
+			logger.Warn("telephony_read_error", "error", err)
+			continue
+		}
+
+		packet, err := parseRTP(buf[:n])
+		if err != nil {
+			logger.Debug("telephony_invalid_rtp", "remote", remote.String(), "error", err)
+			continue
+		}
+		s.handlePacket(packet, remote)
+	}
+}
+
+// handlePacket decodes a G.711 packet and forwards chunk-sized PCM to the call's session
+func (s *Server) handlePacket(packet *rtpPacket, remote *net.UDPAddr) {
+	var pcm []float32
+	switch packet.payloadType {
+	case payloadTypePCMU:
+		pcm = audio.DecodeMuLaw(packet.payload)
+	case payloadTypePCMA:
+		pcm = audio.DecodeALaw(packet.payload)
+	default:
+		logger.Debug("telephony_unsupported_payload_type", "ssrc", packet.ssrc, "payload_type", packet.payloadType)
+		return
+	}
+
+	c, err := s.getOrCreateCall(packet.ssrc, remote)
+	if err != nil {
+		logger.Error("telephony_call_setup_failed", "ssrc", packet.ssrc, "error", err)
+		return
+	}
+
+	c.buffer = append(c.buffer, audio.Resample(pcm, g711SampleRate, s.cfg.Audio.SampleRate)...)
+
+	chunkSize := s.cfg.Audio.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = config.DefaultChunkSize
+	}
+	if len(c.buffer) < chunkSize {
+		return
+	}
+	if err := s.sessionManager.ProcessAudioData(c.sessionID, audio.Float32ToPCM16(c.buffer)); err != nil {
+		logger.Error("failed_to_process_audio", "session_id", c.sessionID, "error", err)
+	}
+	c.buffer = c.buffer[:0]
+}
+
+// getOrCreateCall returns the call for ssrc, creating a session on the first packet
+func (s *Server) getOrCreateCall(ssrc uint32, remote *net.UDPAddr) (*call, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, exists := s.calls[ssrc]; exists {
+		c.lastSeen = time.Now()
+		return c, nil
+	}
+
+	sessionID := ws.GenerateSessionID()
+	if _, err := s.sessionManager.CreateSession(sessionID, &callConn{sessionID: sessionID, ssrc: ssrc}); err != nil {
+		return nil, err
+	}
+
+	c := &call{sessionID: sessionID, lastSeen: time.Now()}
+	s.calls[ssrc] = c
+	logger.Info("telephony_call_started", "session_id", sessionID, "ssrc", ssrc, "remote", remote.String())
+	return c, nil
+}
+
+// reapLoop ends calls that stopped sending RTP for longer than the idle timeout
+func (s *Server) reapLoop() {
+	defer s.wg.Done()
+
+	idleTimeout := time.Duration(s.cfg.Telephony.IdleTimeout) * time.Second
+	if idleTimeout <= 0 {
+		idleTimeout = time.Duration(config.DefaultTelephonyIdleTimeout) * time.Second
+	}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			now := time.Now()
+			s.mu.Lock()
+			for ssrc, c := range s.calls {
+				if now.Sub(c.lastSeen) > idleTimeout {
+					delete(s.calls, ssrc)
+					go s.sessionManager.RemoveSession(c.sessionID)
+					logger.Info("telephony_call_ended", "session_id", c.sessionID, "ssrc", ssrc)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// callConn logs recognition results for a call, since RTP has no return channel for text
+type callConn struct {
+	sessionID string
+	ssrc      uint32
+}
+
+// WriteJSON implements session.Conn
+func (c *callConn) WriteJSON(v interface{}) error {
+	logger.Info("telephony_result", "session_id", c.sessionID, "ssrc", c.ssrc, "message", v)
+	return nil
+}
+
+// Close implements session.Conn
+func (c *callConn) Close() error {
+	return nil
+}
@@ -0,0 +1,132 @@
+package transcribe
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Output formats for transcription results
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+	FormatSRT  = "srt"
+	FormatVTT  = "vtt"
+)
+
+// ValidFormats lists the supported output formats
+var ValidFormats = []string{FormatJSON, FormatText, FormatSRT, FormatVTT}
+
+// RequestFormat returns the output format requested via the "format" query or form parameter,
+// defaulting to JSON
+func RequestFormat(c *gin.Context) (string, error) {
+	format := c.Query("format")
+	if format == "" {
+		format = c.PostForm("format")
+	}
+	return parseFormat(format)
+}
+
+// parseFormat validates an output format name, defaulting to JSON
+func parseFormat(format string) (string, error) {
+	if format == "" {
+		return FormatJSON, nil
+	}
+
+	format = strings.ToLower(format)
+	if !slices.Contains(ValidFormats, format) {
+		return "", fmt.Errorf("unsupported format %q, must be one of %v", format, ValidFormats)
+	}
+	return format, nil
+}
+
+// WriteResult writes result to the response in the given format
+func WriteResult(c *gin.Context, format string, result *Result) {
+	switch format {
+	case FormatText:
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(result.PlainText()))
+	case FormatSRT:
+		c.Data(http.StatusOK, "application/x-subrip; charset=utf-8", []byte(result.SRT()))
+	case FormatVTT:
+		c.Data(http.StatusOK, "text/vtt; charset=utf-8", []byte(result.VTT()))
+	default:
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// voice names who spoke a segment: the registered speaker, the diarization label or, for
+// split channels, the channel. It is empty when none is known.
+func (r *Result) voice(segment Segment) string {
+	switch {
+	case segment.SpeakerName != "":
+		return segment.SpeakerName
+	case segment.Speaker != "":
+		return segment.Speaker
+	case r.Channels > 1:
+		return fmt.Sprintf("channel %d", segment.Channel)
+	}
+	return ""
+}
+
+// PlainText renders the transcript as text. Results with speakers or split channels get one
+// line per segment labelled with its voice.
+func (r *Result) PlainText() string {
+	labelled := slices.ContainsFunc(r.Segments, func(segment Segment) bool {
+		return r.voice(segment) != ""
+	})
+	if !labelled {
+		return r.Text + "\n"
+	}
+
+	var b strings.Builder
+	for _, segment := range r.Segments {
+		if voice := r.voice(segment); voice != "" {
+			fmt.Fprintf(&b, "[%s] %s\n", voice, segment.Text)
+		} else {
+			fmt.Fprintf(&b, "%s\n", segment.Text)
+		}
+	}
+	return b.String()
+}
+
+// SRT renders the segments as SubRip subtitles
+func (r *Result) SRT() string {
+	var b strings.Builder
+	for i, segment := range r.Segments {
+		text := segment.Text
+		if voice := r.voice(segment); voice != "" {
+			text = fmt.Sprintf("[%s] %s", voice, text)
+		}
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n",
+			i+1, formatCueTime(segment.Start, ","), formatCueTime(segment.End, ","), text)
+	}
+	return b.String()
+}
+
+// VTT renders the segments as WebVTT subtitles, using voice tags for speakers and split channels
+func (r *Result) VTT() string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for _, segment := range r.Segments {
+		text := segment.Text
+		if voice := r.voice(segment); voice != "" {
+			text = fmt.Sprintf("<v %s>%s", voice, text)
+		}
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n",
+			formatCueTime(segment.Start, "."), formatCueTime(segment.End, "."), text)
+	}
+	return b.String()
+}
+
+// formatCueTime formats seconds as HH:MM:SS<sep>mmm
+func formatCueTime(seconds float64, sep string) string {
+	ms := int64(math.Round(seconds * 1000))
+	if ms < 0 {
+		ms = 0
+	}
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
@@ -0,0 +1,327 @@
+package transcribe
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"asr_server/config"
+	"asr_server/internal/audio"
+	"asr_server/internal/logger"
+	"asr_server/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler handles file transcription HTTP requests.
+// All dependencies are explicitly injected via constructor.
+type Handler struct {
+	service    *Service
+	cfg        *config.Config
+	httpClient *http.Client
+}
+
+// NewHandler creates a new handler with explicit dependencies
+func NewHandler(service *Service, cfg *config.Config) *Handler {
+	return &Handler{
+		service:    service,
+		cfg:        cfg,
+		httpClient: newURLClient(&cfg.Transcription),
+	}
+}
+
+// RegisterRoutes registers routes
+func (h *Handler) RegisterRoutes(router gin.IRouter) {
+	apiGroup := router.Group("/api/v1")
+	upload := middleware.UploadLimit(h.cfg.Transcription.MaxFileSize, h.cfg.Server.MultipartMemory)
+	{
+		apiGroup.POST("/transcribe", upload, h.Transcribe)
+		apiGroup.POST("/transcribe/stream", upload, h.TranscribeStream)
+		apiGroup.POST("/transcribe_url", h.TranscribeURL)
+	}
+}
+
+// Transcribe transcribes an uploaded WAV file and returns the full transcript with segment timestamps,
+// or subtitles when format=srt|vtt is requested
+func (h *Handler) Transcribe(c *gin.Context) {
+	// Read the upload first so the multipart form is parsed under the size limit
+	channels, ok := h.readUpload(c)
+	if !ok {
+		return
+	}
+
+	format, err := RequestFormat(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	result, err := h.service.TranscribeAudio(c.Request.Context(), channels, nil)
+	if err != nil {
+		logger.Error("transcription_failed", "request_id", c.GetString("request_id"), "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to transcribe audio: %v", err),
+		})
+		return
+	}
+
+	WriteResult(c, format, result)
+}
+
+// TranscribeURL downloads a WAV file from a client-supplied URL and transcribes it
+func (h *Handler) TranscribeURL(c *gin.Context) {
+	var req struct {
+		URL           string `json:"url" binding:"required"`
+		Format        string `json:"format"`
+		SplitChannels bool   `json:"split_channels"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "url is required",
+		})
+		return
+	}
+
+	if req.Format == "" {
+		req.Format = c.Query("format")
+	}
+	format, err := parseFormat(req.Format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	file, err := fetchURL(c.Request.Context(), h.httpClient, &h.cfg.Transcription, req.URL)
+	if err != nil {
+		logger.Warn("audio_url_fetch_failed", "request_id", c.GetString("request_id"), "url", req.URL, "error", err)
+		status := http.StatusBadGateway
+		switch {
+		case errors.Is(err, errInvalidURL), errors.Is(err, errBlockedAddress):
+			status = http.StatusBadRequest
+		case errors.Is(err, errURLTooLarge):
+			status = http.StatusRequestEntityTooLarge
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	channels, err := DecodeAudio(file, urlFilename(req.URL), h.cfg.Audio.SampleRate, req.SplitChannels || RequestSplitChannels(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to parse audio file: %v", err),
+		})
+		return
+	}
+
+	result, err := h.service.TranscribeAudio(c.Request.Context(), channels, nil)
+	if err != nil {
+		logger.Error("transcription_failed", "request_id", c.GetString("request_id"), "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to transcribe audio: %v", err),
+		})
+		return
+	}
+
+	WriteResult(c, format, result)
+}
+
+// TranscribeStream transcribes an uploaded WAV file and streams each decoded segment as a Server-Sent Event
+func (h *Handler) TranscribeStream(c *gin.Context) {
+	channels, ok := h.readUpload(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable nginx response buffering
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	result, err := h.service.TranscribeAudio(c.Request.Context(), channels, func(segment Segment) error {
+		c.SSEvent("segment", segment)
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		logger.Error("transcription_failed", "request_id", c.GetString("request_id"), "error", err)
+		c.SSEvent("error", gin.H{
+			"error": fmt.Sprintf("failed to transcribe audio: %v", err),
+		})
+		c.Writer.Flush()
+		return
+	}
+
+	c.SSEvent("done", gin.H{
+		"text":     result.Text,
+		"duration": result.Duration,
+		"segments": len(result.Segments),
+	})
+	c.Writer.Flush()
+}
+
+// readUpload reads the "audio" form file, parsed under the size limit by UploadLimit, and
+// writes an error response on failure. It returns one sample slice per channel when
+// split_channels is set, otherwise a single mono slice.
+func (h *Handler) readUpload(c *gin.Context) ([][]float32, bool) {
+	file, header, err := c.Request.FormFile("audio")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "audio file is required",
+		})
+		return nil, false
+	}
+	defer file.Close()
+
+	channels, err := h.parseAudioFile(file, header, RequestSplitChannels(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to parse audio file: %v", err),
+		})
+		return nil, false
+	}
+
+	return channels, true
+}
+
+// parseAudioFile decodes an uploaded audio file at the configured sample rate
+func (h *Handler) parseAudioFile(file multipart.File, header *multipart.FileHeader, split bool) ([][]float32, error) {
+	if !IsSupportedFile(header.Filename) {
+		return nil, ErrUnsupportedFile
+	}
+	return DecodeAudio(file, header.Filename, h.cfg.Audio.SampleRate, split)
+}
+
+// RequestSplitChannels reports whether the "split_channels" query or form parameter is set
+func RequestSplitChannels(c *gin.Context) bool {
+	value := c.Query("split_channels")
+	if value == "" {
+		value = c.PostForm("split_channels")
+	}
+	split, _ := strconv.ParseBool(value)
+	return split
+}
+
+// ErrUnsupportedFile is returned for uploads whose extension is not a supported audio format
+var ErrUnsupportedFile = errors.New("only WAV, MP3, FLAC, Ogg Vorbis and raw G.711 (.ulaw, .alaw) files are supported")
+
+// Raw G.711 file extensions, decoded as headerless 8kHz mono audio
+var (
+	muLawExtensions = []string{".ulaw", ".mulaw", ".ul"}
+	aLawExtensions  = []string{".alaw", ".al"}
+)
+
+// g711SampleRate is the sample rate of raw G.711 files
+const g711SampleRate = 8000
+
+// IsSupportedFile reports whether filename has a supported audio file extension
+func IsSupportedFile(filename string) bool {
+	ext := strings.ToLower(path.Ext(filename))
+	return ext == ".wav" || audio.IsCompressedFile(filename) ||
+		slices.Contains(muLawExtensions, ext) || slices.Contains(aLawExtensions, ext)
+}
+
+// urlFilename returns the path of an audio URL, whose extension selects the decoder
+func urlFilename(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Path
+}
+
+// DecodeFile decodes an audio file into mono samples at sampleRate
+func DecodeFile(r io.ReadSeeker, filename string, sampleRate int) ([]float32, error) {
+	decoded, err := decodeFile(r, filename)
+	if err != nil {
+		return nil, err
+	}
+	return audio.Resample(decoded.Mono(), decoded.SampleRate, sampleRate), nil
+}
+
+// DecodeAudio decodes an audio file at sampleRate, into one slice per channel when split is set
+// and into a single mono slice otherwise. Raw G.711, MP3, FLAC and Ogg Vorbis files are
+// recognized by the extension of filename; anything else is decoded as WAV.
+func DecodeAudio(r io.ReadSeeker, filename string, sampleRate int, split bool) ([][]float32, error) {
+	if samples, ok, err := decodeRawG711(r, filename, sampleRate); ok {
+		if err != nil {
+			return nil, err
+		}
+		return [][]float32{samples}, nil
+	}
+	if split {
+		return DecodeChannels(r, filename, sampleRate)
+	}
+	samples, err := DecodeFile(r, filename, sampleRate)
+	if err != nil {
+		return nil, err
+	}
+	return [][]float32{samples}, nil
+}
+
+// DecodeChannels decodes an audio file into one sample slice per channel at sampleRate
+func DecodeChannels(r io.ReadSeeker, filename string, sampleRate int) ([][]float32, error) {
+	decoded, err := decodeFile(r, filename)
+	if err != nil {
+		return nil, err
+	}
+	if decoded.NumChannels > MaxChannels {
+		return nil, fmt.Errorf("audio file has %d channels, at most %d are supported", decoded.NumChannels, MaxChannels)
+	}
+
+	channels := make([][]float32, decoded.NumChannels)
+	for ch := range channels {
+		channels[ch] = audio.Resample(decoded.Channel(ch), decoded.SampleRate, sampleRate)
+	}
+	return channels, nil
+}
+
+// decodeRawG711 decodes a headerless μ-law or A-law file at sampleRate. It reports false when
+// filename does not have a raw G.711 extension.
+func decodeRawG711(r io.Reader, filename string, sampleRate int) ([]float32, bool, error) {
+	ext := strings.ToLower(path.Ext(filename))
+	var decode func([]byte) []float32
+	switch {
+	case slices.Contains(muLawExtensions, ext):
+		decode = audio.DecodeMuLaw
+	case slices.Contains(aLawExtensions, ext):
+		decode = audio.DecodeALaw
+	default:
+		return nil, false, nil
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to read audio: %v", err)
+	}
+	if len(data) == 0 {
+		return nil, true, fmt.Errorf("audio file contains no samples")
+	}
+	return audio.Resample(decode(data), g711SampleRate, sampleRate), true, nil
+}
+
+// decodeFile decodes an audio file and rejects files without samples
+func decodeFile(r io.ReadSeeker, filename string) (*audio.Audio, error) {
+	decoded, err := audio.DecodeFile(r, filename)
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded.Samples) == 0 {
+		return nil, fmt.Errorf("audio file contains no samples")
+	}
+	return decoded, nil
+}
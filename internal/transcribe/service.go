@@ -0,0 +1,437 @@
+package transcribe
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"asr_server/config"
+	"asr_server/internal/audio"
+	"asr_server/internal/logger"
+	"asr_server/internal/pool"
+	"asr_server/internal/postprocess"
+	"asr_server/internal/session"
+
+	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
+)
+
+// MaxChannels bounds the number of channels transcribed independently
+const MaxChannels = 8
+
+// Segment is a recognized speech segment with offsets relative to the start of the audio
+type Segment struct {
+	Index   int     `json:"index"`
+	Channel int     `json:"channel"`
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Text    string  `json:"text"`
+	// Tags reported by models such as SenseVoice
+	Language string   `json:"language,omitempty"`
+	Emotion  string   `json:"emotion,omitempty"`
+	Events   []string `json:"events,omitempty"`
+	// Diarization label and registered speaker, set when speaker recognition is enabled
+	Speaker     string `json:"speaker,omitempty"`
+	SpeakerID   string `json:"speaker_id,omitempty"`
+	SpeakerName string `json:"speaker_name,omitempty"`
+}
+
+// Result is the full transcript of an audio file
+type Result struct {
+	Text     string    `json:"text"`
+	Duration float64   `json:"duration"`
+	Channels int       `json:"channels,omitempty"` // Set when channels were transcribed separately
+	Segments []Segment `json:"segments"`
+}
+
+// speechSpan is a VAD speech region expressed in samples
+type speechSpan struct {
+	start   int
+	samples []float32
+}
+
+// RecognizerProvider lends out the shared recognizer, which may be replaced at runtime,
+// on a worker shared with streaming sessions
+type RecognizerProvider interface {
+	WithRecognizer(ctx context.Context, priority string, fn func(recognizer *sherpa.OfflineRecognizer)) error
+}
+
+// Service transcribes complete audio buffers using the shared recognizer and VAD pool.
+// All dependencies are explicitly injected via constructor.
+type Service struct {
+	cfg         *config.Config
+	recognizers RecognizerProvider
+	vadPool     pool.VADPoolInterface
+	rules       *postprocess.Rules
+	speakers    session.SpeakerTrackerFactory
+}
+
+// NewService creates a new transcription service with explicit dependencies
+func NewService(cfg *config.Config, recognizers RecognizerProvider, vadPool pool.VADPoolInterface) *Service {
+	return &Service{
+		cfg:         cfg,
+		recognizers: recognizers,
+		vadPool:     vadPool,
+	}
+}
+
+// Transcribe splits mono samples at the configured sample rate into speech segments and recognizes each one
+func (s *Service) Transcribe(ctx context.Context, samples []float32) (*Result, error) {
+	return s.TranscribeStream(ctx, samples, nil)
+}
+
+// TranscribeStream works like Transcribe but invokes onSegment as soon as each segment is decoded.
+// Returning an error from onSegment aborts the transcription.
+func (s *Service) TranscribeStream(ctx context.Context, samples []float32, onSegment func(Segment) error) (*Result, error) {
+	return s.transcribe(ctx, samples, 0, s.newSpeakerTracker(), onSegment)
+}
+
+// TranscribeAudio transcribes audio decoded by DecodeAudio: a single slice as mono audio,
+// several slices channel by channel
+func (s *Service) TranscribeAudio(ctx context.Context, channels [][]float32, onSegment func(Segment) error) (*Result, error) {
+	if len(channels) == 1 {
+		return s.TranscribeStream(ctx, channels[0], onSegment)
+	}
+	return s.TranscribeChannelsStream(ctx, channels, onSegment)
+}
+
+// TranscribeChannels transcribes each channel independently and merges the segments in time order,
+// tagging every segment with its channel index
+func (s *Service) TranscribeChannels(ctx context.Context, channels [][]float32) (*Result, error) {
+	return s.TranscribeChannelsStream(ctx, channels, nil)
+}
+
+// TranscribeChannelsStream works like TranscribeChannels but invokes onSegment as soon as each segment
+// is decoded. Channels are processed one after another, so streamed segments are grouped by channel
+// and indexed within their channel.
+func (s *Service) TranscribeChannelsStream(ctx context.Context, channels [][]float32, onSegment func(Segment) error) (*Result, error) {
+	merged := &Result{
+		Channels: len(channels),
+		Segments: make([]Segment, 0),
+	}
+	// Channels share a tracker so a speaker keeps one label across channels
+	speakers := s.newSpeakerTracker()
+	for ch, samples := range channels {
+		result, err := s.transcribe(ctx, samples, ch, speakers, onSegment)
+		if err != nil {
+			return nil, fmt.Errorf("channel %d: %w", ch, err)
+		}
+		merged.Duration = max(merged.Duration, result.Duration)
+		merged.Segments = append(merged.Segments, result.Segments...)
+	}
+
+	sort.SliceStable(merged.Segments, func(i, j int) bool {
+		return merged.Segments[i].Start < merged.Segments[j].Start
+	})
+	texts := make([]string, len(merged.Segments))
+	for i := range merged.Segments {
+		merged.Segments[i].Index = i
+		texts[i] = merged.Segments[i].Text
+	}
+	merged.Text = strings.Join(texts, " ")
+
+	return merged, nil
+}
+
+// transcribe runs VAD and recognition over the samples of one channel. speakers may be nil.
+func (s *Service) transcribe(ctx context.Context, samples []float32, channel int, speakers session.SpeakerTracker, onSegment func(Segment) error) (*Result, error) {
+	sampleRate := float64(s.cfg.Audio.SampleRate)
+	result := &Result{
+		Duration: float64(len(samples)) / sampleRate,
+		Segments: make([]Segment, 0),
+	}
+
+	texts := make([]string, 0)
+	emit := func(span speechSpan) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		transcript, err := s.decode(ctx, span.samples)
+		if err != nil {
+			return err
+		}
+		if transcript.Text == "" {
+			return nil
+		}
+
+		segment := Segment{
+			Index:    len(result.Segments),
+			Channel:  channel,
+			Start:    float64(span.start) / sampleRate,
+			End:      float64(span.start+len(span.samples)) / sampleRate,
+			Text:     transcript.Text,
+			Language: transcript.Language,
+			Emotion:  transcript.Emotion,
+			Events:   transcript.Events,
+		}
+		if speakers != nil {
+			speaker, err := speakers.Identify(span.samples, s.cfg.Audio.SampleRate)
+			if err != nil {
+				logger.Debug("speaker_attribution_failed", "channel", channel, "segment", segment.Index, "error", err)
+			}
+			segment.Speaker = speaker.Label
+			segment.SpeakerID = speaker.ID
+			segment.SpeakerName = speaker.Name
+		}
+		result.Segments = append(result.Segments, segment)
+		texts = append(texts, transcript.Text)
+
+		if onSegment != nil {
+			return onSegment(segment)
+		}
+		return nil
+	}
+
+	if err := s.segment(samples, emit); err != nil {
+		return nil, err
+	}
+	result.Text = strings.Join(texts, " ")
+
+	logger.Info("transcription_completed", "channel", channel, "duration", result.Duration, "segments", len(result.Segments))
+	return result, nil
+}
+
+// TrimSilence returns only the speech in samples as detected by the VAD, resampled to the
+// configured sample rate, and that rate
+func (s *Service) TrimSilence(samples []float32, sampleRate int) ([]float32, int, error) {
+	rate := s.cfg.Audio.SampleRate
+	if sampleRate != rate {
+		samples = audio.Resample(samples, sampleRate, rate)
+	}
+
+	speech := make([]float32, 0, len(samples))
+	err := s.segment(samples, func(span speechSpan) error {
+		speech = append(speech, span.samples...)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return speech, rate, nil
+}
+
+// segment runs a pooled VAD instance over samples at the configured sample rate and calls
+// emit for each speech span in order
+func (s *Service) segment(samples []float32, emit func(speechSpan) error) error {
+	if s.vadPool == nil {
+		return fmt.Errorf("VAD pool is not initialized")
+	}
+
+	vadInstance, err := s.vadPool.Get()
+	if err != nil {
+		return fmt.Errorf("failed to get VAD instance: %v", err)
+	}
+	defer s.vadPool.Put(vadInstance)
+
+	emit, flush := s.padSpans(samples, emit)
+	rules := session.NewEndpointRules(s.cfg, vadInstance.GetType())
+	switch instance := vadInstance.(type) {
+	case *pool.SileroVADInstance:
+		err = s.segmentSilero(instance, samples, rules, emit)
+	case *pool.TenVADInstance:
+		err = s.segmentTenVAD(instance, samples, rules, emit)
+	default:
+		return fmt.Errorf("unsupported VAD type: %s", vadInstance.GetType())
+	}
+	if err != nil {
+		return err
+	}
+	return flush()
+}
+
+// padSpans wraps emit to add the configured padding of audio around each span, as live
+// sessions do, without overlapping the neighbouring spans. Each span is held until the next
+// one is known; flush emits the last.
+func (s *Service) padSpans(samples []float32, emit func(speechSpan) error) (func(speechSpan) error, func() error) {
+	pre := s.cfg.VAD.PrePaddingMs * s.cfg.Audio.SampleRate / 1000
+	post := s.cfg.VAD.PostPaddingMs * s.cfg.Audio.SampleRate / 1000
+	if pre <= 0 && post <= 0 {
+		return emit, func() error { return nil }
+	}
+
+	var held *speechSpan
+	paddedEnd := 0
+	release := func(next int) error {
+		if held == nil {
+			return nil
+		}
+		span := *held
+		held = nil
+		start := max(span.start-pre, paddedEnd, 0)
+		end := span.start + len(span.samples)
+		end = max(min(end+post, next, len(samples)), end)
+		paddedEnd = end
+		return emit(speechSpan{start: start, samples: samples[start:end]})
+	}
+	padded := func(span speechSpan) error {
+		if err := release(span.start); err != nil {
+			return err
+		}
+		held = &span
+		return nil
+	}
+	return padded, func() error { return release(len(samples)) }
+}
+
+// decode runs offline recognition on a single speech segment at the priority carried by ctx
+func (s *Service) decode(ctx context.Context, samples []float32) (postprocess.Transcript, error) {
+	var result *sherpa.OfflineRecognizerResult
+	err := s.recognizers.WithRecognizer(ctx, priorityFrom(ctx), func(recognizer *sherpa.OfflineRecognizer) {
+		stream := sherpa.NewOfflineStream(recognizer)
+		defer sherpa.DeleteOfflineStream(stream)
+		stream.AcceptWaveform(s.cfg.Audio.SampleRate, samples)
+		recognizer.Decode(stream)
+		result = stream.GetResult()
+	})
+	if err != nil {
+		return postprocess.Transcript{}, err
+	}
+
+	if result == nil {
+		return postprocess.Transcript{}, fmt.Errorf("recognition failed")
+	}
+	transcript := postprocess.ParseResult(result.Text, result.Lang, result.Emotion, result.Event)
+	transcript.Text = s.rules.Apply(transcript.Text)
+	return transcript, nil
+}
+
+// SetSpeakerTrackerFactory attributes the segments of each transcription to speakers.
+// It must be called before the service transcribes audio.
+func (s *Service) SetSpeakerTrackerFactory(factory session.SpeakerTrackerFactory) {
+	s.speakers = factory
+}
+
+// newSpeakerTracker returns a tracker for one transcription, or nil when speaker recognition is disabled
+func (s *Service) newSpeakerTracker() session.SpeakerTracker {
+	if s.speakers == nil {
+		return nil
+	}
+	return s.speakers()
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a context that schedules the recognition of each segment at the given
+// session priority. Transcriptions run at normal priority by default.
+func WithPriority(ctx context.Context, priority string) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// priorityFrom returns the priority set by WithPriority
+func priorityFrom(ctx context.Context) string {
+	if priority, ok := ctx.Value(priorityKey{}).(string); ok {
+		return priority
+	}
+	return session.PriorityNormal
+}
+
+// SetRules sets the replacement rules applied to every segment.
+// It must be called before the service transcribes audio.
+func (s *Service) SetRules(rules *postprocess.Rules) {
+	s.rules = rules
+}
+
+// emitUtterance emits an utterance the VAD found at start in parts no longer than the maximum,
+// unless it has less speech than the minimum. speech is its length without trailing silence.
+func emitUtterance(rules session.EndpointRules, samples []float32, start, speech int, emit func(speechSpan) error) error {
+	if !rules.Accept(speech) {
+		return nil
+	}
+	for _, part := range rules.Split(samples) {
+		if err := emit(speechSpan{start: start, samples: part}); err != nil {
+			return err
+		}
+		start += len(part)
+	}
+	return nil
+}
+
+// segmentSilero feeds the whole buffer through Silero VAD and emits each detected segment,
+// applying the endpointing rules of live sessions
+func (s *Service) segmentSilero(instance *pool.SileroVADInstance, samples []float32, rules session.EndpointRules, emit func(speechSpan) error) error {
+	windowSize := s.cfg.VAD.SileroVAD.WindowSize
+	if windowSize <= 0 {
+		windowSize = config.DefaultWindowSize
+	}
+
+	drain := func() error {
+		for !instance.VAD.IsEmpty() {
+			segment := instance.VAD.Front()
+			instance.VAD.Pop()
+			if segment != nil && len(segment.Samples) > 0 {
+				if err := emitUtterance(rules, segment.Samples, segment.Start, len(segment.Samples), emit); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	for i := 0; i < len(samples); i += windowSize {
+		end := i + windowSize
+		if end > len(samples) {
+			end = len(samples)
+		}
+		instance.VAD.AcceptWaveform(samples[i:end])
+		if err := drain(); err != nil {
+			return err
+		}
+	}
+	instance.VAD.Flush()
+	return drain()
+}
+
+// segmentTenVAD runs TEN-VAD frame by frame and emits each utterance once maxSilenceFrames
+// of silence end it, applying the endpointing rules of live sessions
+func (s *Service) segmentTenVAD(instance *pool.TenVADInstance, samples []float32, rules session.EndpointRules, emit func(speechSpan) error) error {
+	hopSize := s.cfg.VAD.TenVAD.HopSize
+	maxSilenceFrames := s.cfg.VAD.TenVAD.MaxSilenceFrames
+
+	// The utterance in progress is samples[start:end], ending in silenceFrames of silence
+	start, end := 0, 0
+	silenceFrames := 0
+	inSpeech := false
+
+	flush := func() error {
+		inSpeech = false
+		speech := end - start - silenceFrames*hopSize
+		return emitUtterance(rules, samples[start:end], start, speech, emit)
+	}
+
+	int16Frame := make([]int16, hopSize)
+	for i := 0; i+hopSize <= len(samples); i += hopSize {
+		for j, f := range samples[i : i+hopSize] {
+			int16Frame[j] = int16(f * 32768)
+		}
+
+		_, flag, err := pool.GetInstance().ProcessAudio(instance.Handle, int16Frame)
+		if err != nil {
+			instance.MarkFailed(err)
+			return fmt.Errorf("TEN-VAD ProcessAudio error: %v", err)
+		}
+
+		if flag == 1 {
+			if !inSpeech {
+				inSpeech = true
+				start = i
+			}
+			end = i + hopSize
+			silenceFrames = 0
+		} else if inSpeech {
+			end = i + hopSize
+			silenceFrames++
+			if silenceFrames >= maxSilenceFrames {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if inSpeech {
+		return flush()
+	}
+
+	return nil
+}
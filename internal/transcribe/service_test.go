@@ -0,0 +1,56 @@
+package transcribe
+
+import (
+	"testing"
+
+	"asr_server/config"
+	"asr_server/internal/pool"
+	"asr_server/internal/session"
+)
+
+func TestEmitUtterance(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Audio.SampleRate = 16000
+	cfg.Session.MaxSegmentSeconds = 1
+	cfg.VAD.SileroVAD.MinSpeechDuration = 0.25
+	cfg.VAD.SileroVAD.MaxSpeechDuration = 0.5
+	cfg.VAD.TenVAD.HopSize = 256
+	cfg.VAD.TenVAD.MinSpeechFrames = 12
+
+	type span struct{ start, length int }
+	tests := []struct {
+		name    string
+		vadType string
+		samples int
+		speech  int
+		want    []span
+	}{
+		{"ten_vad short", pool.TEN_VAD_TYPE, 3000, 3000, nil},
+		{"ten_vad short with trailing silence", pool.TEN_VAD_TYPE, 4000, 3000, nil},
+		{"ten_vad", pool.TEN_VAD_TYPE, 4000, 3500, []span{{100, 4000}}},
+		{"ten_vad long", pool.TEN_VAD_TYPE, 40000, 39000, []span{{100, 16000}, {16100, 16000}, {32100, 8000}}},
+		{"silero short", pool.SILERO_TYPE, 3000, 3000, nil},
+		{"silero long", pool.SILERO_TYPE, 20000, 20000, []span{{100, 8000}, {8100, 8000}, {16100, 4000}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []span
+			err := emitUtterance(session.NewEndpointRules(cfg, tt.vadType), make([]float32, tt.samples), 100, tt.speech, func(s speechSpan) error {
+				got = append(got, span{s.start, len(s.samples)})
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("emitUtterance() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("emitUtterance() emitted %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("emitUtterance() emitted %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
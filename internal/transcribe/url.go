@@ -0,0 +1,121 @@
+package transcribe
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"asr_server/config"
+)
+
+// maxURLRedirects bounds the redirect chain followed when downloading audio
+const maxURLRedirects = 5
+
+// Errors returned by fetchURL, mapped to HTTP status codes by the handler
+var (
+	errInvalidURL     = errors.New("invalid audio URL")
+	errURLTooLarge    = errors.New("remote audio file is too large")
+	errBlockedAddress = errors.New("audio URL resolves to a private or local address")
+)
+
+// newURLClient creates the HTTP client used to download audio by URL.
+// Unless private URLs are allowed, connections to loopback, private and link-local addresses are
+// refused after DNS resolution, which also covers redirects. Environment proxies are bypassed so
+// the check applies to the real destination.
+func newURLClient(cfg *config.TranscriptionConfig) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if !cfg.AllowPrivateURLs {
+		dialer.Control = func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+				ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+				return errBlockedAddress
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   time.Duration(cfg.URLFetchTimeout) * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxURLRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxURLRedirects)
+			}
+			return checkURLScheme(req.URL, cfg.AllowedURLSchemes)
+		},
+	}
+}
+
+// checkURLScheme rejects URLs whose scheme is not allowlisted
+func checkURLScheme(u *url.URL, allowed []string) error {
+	if !slices.Contains(allowed, strings.ToLower(u.Scheme)) {
+		return fmt.Errorf("%w: scheme %q is not allowed", errInvalidURL, u.Scheme)
+	}
+	return nil
+}
+
+// fetchURL downloads rawURL into memory, enforcing the configured scheme allowlist and size limit
+func fetchURL(ctx context.Context, client *http.Client, cfg *config.TranscriptionConfig, rawURL string) (io.ReadSeeker, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("%w: %s", errInvalidURL, rawURL)
+	}
+	if err := checkURLScheme(u, cfg.AllowedURLSchemes); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidURL, err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if errors.Is(err, errBlockedAddress) || errors.Is(err, errInvalidURL) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to download audio: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download audio: remote server returned %s", resp.Status)
+	}
+
+	maxSize := cfg.MaxFileSize
+	if maxSize > 0 && resp.ContentLength > maxSize {
+		return nil, errURLTooLarge
+	}
+
+	body := io.Reader(resp.Body)
+	if maxSize > 0 {
+		body = io.LimitReader(resp.Body, maxSize+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download audio: %v", err)
+	}
+	if maxSize > 0 && int64(len(data)) > maxSize {
+		return nil, errURLTooLarge
+	}
+
+	return bytes.NewReader(data), nil
+}
@@ -0,0 +1,78 @@
+package ws
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"asr_server/internal/session"
+)
+
+// maxChannels bounds the number of interleaved channels accepted on one connection
+const maxChannels = 8
+
+// channelConn lets one session per channel share a connection. Messages are tagged with
+// the channel index and writes are serialized, since each session has its own sender.
+type channelConn struct {
+	session.Conn
+	mu      *sync.Mutex
+	channel int
+}
+
+// WriteJSON implements session.Conn
+func (c *channelConn) WriteJSON(v interface{}) error {
+	if msg, ok := v.(map[string]interface{}); ok {
+		tagged := make(map[string]interface{}, len(msg)+1)
+		for k, val := range msg {
+			tagged[k] = val
+		}
+		tagged["channel"] = c.channel
+		v = tagged
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Conn.WriteJSON(v)
+}
+
+// parseChannels reads the "channels" query parameter, defaulting to a single channel
+func parseChannels(query url.Values) (int, error) {
+	v := query.Get("channels")
+	if v == "" {
+		return 1, nil
+	}
+	channels, err := strconv.Atoi(v)
+	if err != nil || channels < 1 || channels > maxChannels {
+		return 0, fmt.Errorf("invalid channels %q, must be between 1 and %d", v, maxChannels)
+	}
+	return channels, nil
+}
+
+// channelSessionID returns the session ID used for a channel; channel 0 keeps the connection's ID
+func channelSessionID(sessionID string, channel int) string {
+	if channel == 0 {
+		return sessionID
+	}
+	return fmt.Sprintf("%s-ch%d", sessionID, channel)
+}
+
+// splitChannels deinterleaves 16-bit PCM into one buffer per channel.
+// A trailing partial frame is dropped.
+func splitChannels(data []byte, channels int) [][]byte {
+	frameSize := 2 * channels
+	frames := len(data) / frameSize
+
+	out := make([][]byte, channels)
+	for ch := range out {
+		out[ch] = make([]byte, frames*2)
+	}
+	for i := 0; i < frames; i++ {
+		frame := data[i*frameSize:]
+		for ch := range out {
+			out[ch][i*2] = frame[ch*2]
+			out[ch][i*2+1] = frame[ch*2+1]
+		}
+	}
+	return out
+}
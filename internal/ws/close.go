@@ -0,0 +1,82 @@
+package ws
+
+import (
+	"errors"
+	"time"
+
+	"asr_server/internal/session"
+
+	"github.com/gorilla/websocket"
+)
+
+// Close codes private to this server, in the 4000-4999 range reserved for applications.
+// They mirror the closest HTTP status so clients can tell failure modes apart.
+const (
+	// CloseIdleTimeout is sent when no message or pong arrived within the read window
+	CloseIdleTimeout = 4408
+	// CloseSessionTimeout is sent when a session saw no audio for longer than the session timeout
+	CloseSessionTimeout = 4410
+	// CloseSessionEvicted is sent when an administrator force-closed the session
+	CloseSessionEvicted = 4403
+	// CloseQuotaExceeded is sent when the session used up its duration or audio quota
+	CloseQuotaExceeded = 4429
+)
+
+// closeWithCode sends a close frame with code and reason, then closes the connection.
+// The frame is best effort: the peer may already be gone.
+func closeWithCode(conn *websocket.Conn, code int, reason string) error {
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason),
+		time.Now().Add(controlWriteWait))
+	return conn.Close()
+}
+
+// closeCreateFailed closes a connection whose session could not be created. Clients turned
+// away while the server drains are told it is going away, so they reconnect elsewhere.
+func closeCreateFailed(conn *websocket.Conn, err error) error {
+	if errors.Is(err, session.ErrDraining) {
+		return closeWithCode(conn, websocket.CloseGoingAway, "server shutting down")
+	}
+	return closeWithCode(conn, websocket.CloseInternalServerErr, "failed to create session")
+}
+
+// closeCodeFor maps the reason a session ended to a close code and reason text
+func closeCodeFor(reason session.CloseReason) (int, string) {
+	switch reason {
+	case session.CloseShutdown:
+		return websocket.CloseGoingAway, "server shutting down"
+	case session.CloseTimeout:
+		return CloseSessionTimeout, "session timed out"
+	case session.CloseByAdmin:
+		return CloseSessionEvicted, "session closed by administrator"
+	case session.CloseQuotaExceeded:
+		return CloseQuotaExceeded, "session quota exceeded"
+	default:
+		return websocket.CloseNormalClosure, ""
+	}
+}
+
+// wsConn is a JSON session connection that reports why it is closed
+type wsConn struct {
+	*websocket.Conn
+}
+
+// CloseWithReason implements session.ReasonCloser
+func (c *wsConn) CloseWithReason(reason session.CloseReason) error {
+	code, text := closeCodeFor(reason)
+	return closeWithCode(c.Conn, code, text)
+}
+
+// CloseWithReason implements session.ReasonCloser
+func (p *protobufConn) CloseWithReason(reason session.CloseReason) error {
+	code, text := closeCodeFor(reason)
+	return closeWithCode(p.Conn, code, text)
+}
+
+// CloseWithReason implements session.ReasonCloser. Every channel's session closes the shared
+// connection; only the first close frame reaches the client.
+func (c *channelConn) CloseWithReason(reason session.CloseReason) error {
+	if rc, ok := c.Conn.(session.ReasonCloser); ok {
+		return rc.CloseWithReason(reason)
+	}
+	return c.Conn.Close()
+}
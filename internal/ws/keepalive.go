@@ -0,0 +1,88 @@
+package ws
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"asr_server/internal/logger"
+
+	"github.com/gorilla/websocket"
+)
+
+// controlWriteWait bounds writing a ping or pong control frame
+const controlWriteWait = 5 * time.Second
+
+// readWindow returns how long a connection may stay silent before it is considered dead.
+// With keepalive enabled a healthy peer answers every ping, so the window is one ping
+// interval plus the pong timeout; otherwise read_timeout applies.
+func (h *Handler) readWindow() time.Duration {
+	wsConfig := h.cfg.Server.WebSocket
+	if wsConfig.PingInterval > 0 {
+		return time.Duration(wsConfig.PingInterval+wsConfig.PongTimeout) * time.Second
+	}
+	return time.Duration(wsConfig.ReadTimeout) * time.Second
+}
+
+// extendDeadline pushes the read deadline forward by the read window
+func (h *Handler) extendDeadline(conn *websocket.Conn) {
+	if window := h.readWindow(); window > 0 {
+		conn.SetReadDeadline(time.Now().Add(window))
+	}
+}
+
+// startKeepalive answers client pings, sends server pings at the configured interval and
+// treats pongs as activity for every session on the connection. The returned function stops
+// the ping loop.
+func (h *Handler) startKeepalive(conn *websocket.Conn, sessionIDs []string) func() {
+	sessionID := sessionIDs[0]
+	touch := func() {
+		for _, id := range sessionIDs {
+			h.sessionManager.Touch(id)
+		}
+	}
+
+	conn.SetPingHandler(func(data string) error {
+		h.extendDeadline(conn)
+		touch()
+		err := conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(controlWriteWait))
+		if errors.Is(err, websocket.ErrCloseSent) {
+			return nil
+		}
+		return err
+	})
+	conn.SetPongHandler(func(string) error {
+		h.extendDeadline(conn)
+		touch()
+		return nil
+	})
+
+	interval := time.Duration(h.cfg.Server.WebSocket.PingInterval) * time.Second
+	if interval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(controlWriteWait)); err != nil {
+					logger.Debug("websocket_ping_failed", "session_id", sessionID, "error", err)
+					return
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// isTimeout reports whether a read error was caused by the read deadline expiring
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
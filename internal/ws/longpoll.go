@@ -0,0 +1,249 @@
+package ws
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"asr_server/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Long-polling limits
+const (
+	defaultPollTimeout = 25 * time.Second
+	maxPollTimeout     = 60 * time.Second
+	// maxPendingMessages bounds results buffered for a client that stopped polling
+	maxPendingMessages = 1000
+	// pollReadSize is how much of an audio upload is read before it is passed to the session
+	pollReadSize = 32 * 1024
+)
+
+// pollConn buffers session messages until the client fetches them with a long-poll request
+type pollConn struct {
+	mu       sync.Mutex
+	messages []interface{}
+	notify   chan struct{}
+	done     chan struct{}
+	closed   bool
+}
+
+func newPollConn() *pollConn {
+	return &pollConn{
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+}
+
+// WriteJSON implements session.Conn
+func (p *pollConn) WriteJSON(v interface{}) error {
+	p.mu.Lock()
+	if len(p.messages) >= maxPendingMessages {
+		p.messages = p.messages[1:]
+	}
+	p.messages = append(p.messages, v)
+	p.mu.Unlock()
+
+	select {
+	case p.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Close implements session.Conn and releases any waiting poll
+func (p *pollConn) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.closed {
+		p.closed = true
+		close(p.done)
+	}
+	return nil
+}
+
+// take returns the buffered messages, waiting up to timeout for the first one
+func (p *pollConn) take(r *http.Request, timeout time.Duration) []interface{} {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		p.mu.Lock()
+		if len(p.messages) > 0 || p.closed {
+			messages := p.messages
+			p.messages = nil
+			p.mu.Unlock()
+			return messages
+		}
+		p.mu.Unlock()
+
+		select {
+		case <-p.notify:
+		case <-p.done:
+		case <-timer.C:
+			return nil
+		case <-r.Context().Done():
+			return nil
+		}
+	}
+}
+
+// RegisterPollRoutes registers the HTTP long-polling fallback for clients that cannot open WebSockets
+func (h *Handler) RegisterPollRoutes(router gin.IRouter) {
+	streamGroup := router.Group("/stream")
+	{
+		streamGroup.POST("", h.CreatePollStream)
+		streamGroup.POST("/:token/audio", h.PollAudio)
+		streamGroup.POST("/:token/control", h.PollControl)
+		streamGroup.GET("/:token/results", h.PollResults)
+		streamGroup.DELETE("/:token", h.ClosePollStream)
+	}
+}
+
+// CreatePollStream creates a session and returns the token used by the other /stream endpoints.
+// It accepts the same query parameters as /ws.
+func (h *Handler) CreatePollStream(c *gin.Context) {
+	opts, err := parseQueryOptions(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token := GenerateSessionID()
+	s, err := h.sessionManager.CreateSession(token, newPollConn())
+	if err != nil {
+		logger.Error("failed_to_create_session", "session_id", token, "error", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	s.SetRemoteAddr(c.ClientIP())
+	s.SetAPIKey(requestAPIKey(c.Request))
+	if err := h.sessionManager.Configure(token, opts); err != nil {
+		logger.Warn("failed_to_apply_stream_options", "session_id", token, "error", err)
+	}
+
+	logger.Info("poll_stream_created", "session_id", token)
+	c.Header("Location", "/stream/"+token)
+	c.JSON(http.StatusCreated, gin.H{
+		"token":      token,
+		"session_id": token,
+	})
+}
+
+// PollAudio feeds the request body as 16-bit PCM into the session. The body is processed as it
+// arrives, so a single chunked upload can carry a whole live stream.
+func (h *Handler) PollAudio(c *gin.Context) {
+	token := c.Param("token")
+	if _, ok := h.pollSession(c, token); !ok {
+		return
+	}
+
+	// Extend the read deadline per chunk, as for WebSocket messages, so a long-running
+	// upload is not cut off by the server read timeout
+	rc := http.NewResponseController(c.Writer)
+	buf := make([]byte, pollReadSize)
+	total := 0
+	for {
+		if window := h.readWindow(); window > 0 {
+			rc.SetReadDeadline(time.Now().Add(window))
+		}
+		n, err := io.ReadFull(c.Request.Body, buf)
+		if n > 0 {
+			h.sessionManager.ThrottleAudio(token, n)
+			// Keep whole 16-bit samples; an odd trailing byte is dropped
+			if err := h.sessionManager.ProcessAudioData(token, buf[:n&^1]); err != nil {
+				logger.Error("failed_to_process_audio", "session_id", token, "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			total += n
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			logger.Warn("poll_audio_read_error", "session_id", token, "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read audio"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"bytes": total})
+}
+
+// PollControl applies a JSON control command, the same as a /ws text message
+func (h *Handler) PollControl(c *gin.Context) {
+	token := c.Param("token")
+	if _, ok := h.pollSession(c, token); !ok {
+		return
+	}
+
+	body := io.Reader(c.Request.Body)
+	if maxSize := h.cfg.Server.WebSocket.MaxMessageSize; maxSize > 0 {
+		body = io.LimitReader(body, int64(maxSize))
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read control message"})
+		return
+	}
+	if err := h.sessionManager.HandleControl(token, data); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The ack is delivered through the results endpoint like any other message
+	c.Status(http.StatusAccepted)
+}
+
+// PollResults returns pending messages, waiting up to ?timeout= seconds for one to arrive
+func (h *Handler) PollResults(c *gin.Context) {
+	token := c.Param("token")
+	pc, ok := h.pollSession(c, token)
+	if !ok {
+		return
+	}
+	h.sessionManager.Touch(token)
+
+	timeout := defaultPollTimeout
+	if v := c.Query("timeout"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid timeout"})
+			return
+		}
+		timeout = min(time.Duration(seconds)*time.Second, maxPollTimeout)
+	}
+
+	messages := pc.take(c.Request, timeout)
+	if messages == nil {
+		messages = []interface{}{}
+	}
+	c.JSON(http.StatusOK, gin.H{"messages": messages})
+}
+
+// ClosePollStream removes the session
+func (h *Handler) ClosePollStream(c *gin.Context) {
+	token := c.Param("token")
+	if _, ok := h.pollSession(c, token); !ok {
+		return
+	}
+	h.sessionManager.RemoveSession(token)
+	logger.Info("poll_stream_closed", "session_id", token)
+	c.Status(http.StatusNoContent)
+}
+
+// pollSession looks up the long-polling session for token and writes 404 if there is none
+func (h *Handler) pollSession(c *gin.Context, token string) (*pollConn, bool) {
+	if sess, exists := h.sessionManager.GetSession(token); exists {
+		if pc, ok := sess.Conn.(*pollConn); ok {
+			return pc, true
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "stream not found"})
+	return nil, false
+}
@@ -0,0 +1,66 @@
+package ws
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"asr_server/config"
+	"asr_server/internal/logger"
+)
+
+// makeOriginChecker creates an origin checker based on configuration. The configuration is
+// read on every handshake so hot-reloaded origin lists apply to new connections.
+func makeOriginChecker(cfg *config.Config) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		wsConfig := cfg.Server.WebSocket
+
+		// If AllowAllOrigins is true, allow all (for development)
+		if wsConfig.AllowAllOrigins {
+			return true
+		}
+
+		origin := r.Header.Get("Origin")
+
+		// If no origin header, allow (non-browser client)
+		if origin == "" {
+			return true
+		}
+
+		if originAllowed(origin, wsConfig.AllowedOrigins) {
+			return true
+		}
+
+		logger.Warn("websocket_origin_rejected", "origin", origin, "allowed_origins", wsConfig.AllowedOrigins)
+		return false
+	}
+}
+
+// originAllowed reports whether origin matches one of the allowed patterns. Patterns are
+// compared case-insensitively and "*" matches any run of characters other than "/", so
+// "https://*.example.com" allows every subdomain and "http://localhost:*" any port.
+// A lone "*" allows every origin.
+func originAllowed(origin string, allowed []string) bool {
+	origin = strings.ToLower(origin)
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(strings.TrimSuffix(pattern, "/"))
+		if pattern == "*" || pattern == origin {
+			return true
+		}
+		if matched, err := path.Match(pattern, origin); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// handshakeError replies to a failed WebSocket handshake. Unlike the upgrader's default
+// response it says which origin was rejected, the only failure reported as 403.
+func handshakeError(w http.ResponseWriter, r *http.Request, status int, reason error) {
+	if status == http.StatusForbidden {
+		reason = fmt.Errorf("origin %q is not allowed", r.Header.Get("Origin"))
+	}
+	w.Header().Set("Sec-Websocket-Version", "13")
+	http.Error(w, reason.Error(), status)
+}
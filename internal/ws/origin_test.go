@@ -0,0 +1,36 @@
+package ws
+
+import "testing"
+
+func TestOriginAllowed(t *testing.T) {
+	allowed := []string{"https://app.example.com/", "https://*.example.org", "http://localhost:*"}
+
+	tests := []struct {
+		name    string
+		origin  string
+		allowed []string
+		want    bool
+	}{
+		{"exact", "https://app.example.com", allowed, true},
+		{"case insensitive", "HTTPS://App.Example.com", allowed, true},
+		{"subdomain wildcard", "https://api.example.org", allowed, true},
+		{"port wildcard", "http://localhost:3000", allowed, true},
+		{"allow all", "https://anything.test", []string{"*"}, true},
+		{"no patterns", "https://app.example.com", nil, false},
+		{"other host", "https://evil.com", allowed, false},
+		{"other scheme", "http://app.example.com", allowed, false},
+		{"other port", "https://app.example.com:8443", allowed, false},
+		{"suffix trick", "https://app.example.com.evil.com", allowed, false},
+		{"wildcard does not cross slash", "https://evil.com/.example.org", allowed, false},
+		{"nested subdomain", "https://a.b.example.org", allowed, true},
+		{"wildcard requires subdomain", "https://example.org", allowed, false},
+		{"localhost without port", "http://localhost", allowed, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := originAllowed(tt.origin, tt.allowed); got != tt.want {
+				t.Errorf("originAllowed(%q, %v) = %v, want %v", tt.origin, tt.allowed, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package ws
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ProtobufSubprotocol selects the binary protobuf protocol described in asr.proto
+const ProtobufSubprotocol = "asr.protobuf"
+
+// OpusSubprotocol selects Opus-encoded binary audio frames, one packet per frame,
+// with JSON text messages as usual
+const OpusSubprotocol = "asr.opus"
+
+// Field numbers from asr.proto
+const (
+	audioFramePCMField = 1
+
+	serverMessageTypeField      = 1
+	serverMessageTextField      = 2
+	serverMessageTimestampField = 3
+	serverMessageSessionIDField = 4
+	serverMessageMessageField   = 5
+	serverMessageChannelField   = 6
+	serverMessageStartTimeField = 7
+	serverMessageEndTimeField   = 8
+	serverMessageSeqField       = 9
+)
+
+// protobufConn encodes session messages as ServerMessage protobufs
+type protobufConn struct {
+	*websocket.Conn
+}
+
+// WriteJSON implements session.Conn. Despite the interface name, messages are written
+// as binary protobuf frames.
+func (p *protobufConn) WriteJSON(v interface{}) error {
+	data, err := encodeServerMessage(v)
+	if err != nil {
+		return err
+	}
+	return p.Conn.WriteMessage(websocket.BinaryMessage, data)
+}
+
+// encodeServerMessage encodes a session message map as a ServerMessage
+func encodeServerMessage(v interface{}) ([]byte, error) {
+	msg, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unsupported message type %T", v)
+	}
+
+	var b []byte
+	appendString := func(num protowire.Number, key string) {
+		if s, ok := msg[key].(string); ok && s != "" {
+			b = protowire.AppendTag(b, num, protowire.BytesType)
+			b = protowire.AppendString(b, s)
+		}
+	}
+
+	appendString(serverMessageTypeField, "type")
+	appendString(serverMessageTextField, "text")
+	if ts, ok := msg["timestamp"].(int64); ok && ts != 0 {
+		b = protowire.AppendTag(b, serverMessageTimestampField, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(ts))
+	}
+	appendString(serverMessageSessionIDField, "session_id")
+	appendString(serverMessageMessageField, "message")
+	if ch, ok := msg["channel"].(int); ok && ch != 0 {
+		b = protowire.AppendTag(b, serverMessageChannelField, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(ch))
+	}
+	appendDouble := func(num protowire.Number, key string) {
+		if f, ok := msg[key].(float64); ok && f != 0 {
+			b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+			b = protowire.AppendFixed64(b, math.Float64bits(f))
+		}
+	}
+	appendDouble(serverMessageStartTimeField, "start_time")
+	appendDouble(serverMessageEndTimeField, "end_time")
+	if seq, ok := msg["seq"].(uint64); ok && seq != 0 {
+		b = protowire.AppendTag(b, serverMessageSeqField, protowire.VarintType)
+		b = protowire.AppendVarint(b, seq)
+	}
+
+	return b, nil
+}
+
+// decodeAudioFrame extracts the PCM payload from an AudioFrame
+func decodeAudioFrame(b []byte) ([]byte, error) {
+	var pcm []byte
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		if num == audioFramePCMField && typ == protowire.BytesType {
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			pcm = v
+			b = b[n:]
+			continue
+		}
+
+		// Skip unknown fields for forward compatibility
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return pcm, nil
+}
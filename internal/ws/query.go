@@ -0,0 +1,121 @@
+package ws
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"asr_server/internal/session"
+)
+
+// parseQueryOptions reads per-connection session options from the /ws query string,
+// e.g. /ws?sample_rate=8000&language=en&model=en&vad=ten_vad&vad_threshold=0.6&vad_probs=true&encoding=opus&hotwords=foo,bar&hotwords_score=2&channels=2&channel_select=left&translate=true
+func parseQueryOptions(query url.Values) (session.Options, error) {
+	var opts session.Options
+
+	if v := query.Get("sample_rate"); v != "" {
+		rate, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid sample_rate %q", v)
+		}
+		opts.SampleRate = rate
+	}
+	opts.Language = query.Get("language")
+	opts.Model = query.Get("model")
+	opts.VAD = query.Get("vad")
+	opts.Encoding = query.Get("encoding")
+	opts.ChannelSelect = query.Get("channel_select")
+	opts.Framing = query.Get("framing")
+	opts.Priority = query.Get("priority")
+
+	if v := query.Get("audio_level_interval"); v != "" {
+		interval, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid audio_level_interval %q", v)
+		}
+		opts.AudioLevelInterval = interval
+	}
+
+	if v := query.Get("channels"); v != "" {
+		channels, err := strconv.Atoi(v)
+		if err != nil || channels < 1 {
+			return opts, fmt.Errorf("invalid channels %q", v)
+		}
+		opts.Channels = channels
+	}
+
+	if v := query.Get("hotwords"); v != "" {
+		for _, word := range strings.Split(v, ",") {
+			if word = strings.TrimSpace(word); word != "" {
+				opts.Hotwords = append(opts.Hotwords, word)
+			}
+		}
+	}
+
+	if v := query.Get("hotwords_score"); v != "" {
+		score, err := strconv.ParseFloat(v, 32)
+		if err != nil {
+			return opts, fmt.Errorf("invalid hotwords_score %q", v)
+		}
+		opts.HotwordsScore = float32(score)
+	}
+
+	for key, value := range map[string]*float32{
+		"vad_threshold":   &opts.VADThreshold,
+		"vad_min_silence": &opts.VADMinSilence,
+		"vad_min_speech":  &opts.VADMinSpeech,
+		"vad_max_speech":  &opts.VADMaxSpeech,
+	} {
+		if v := query.Get(key); v != "" {
+			f, err := strconv.ParseFloat(v, 32)
+			if err != nil {
+				return opts, fmt.Errorf("invalid %s %q", key, v)
+			}
+			*value = float32(f)
+		}
+	}
+
+	if v := query.Get("translate"); v != "" {
+		translate, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid translate %q", v)
+		}
+		opts.Translate = translate
+	}
+
+	if v := query.Get("vad_probs"); v != "" {
+		probs, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid vad_probs %q", v)
+		}
+		opts.VADProbs = probs
+	}
+
+	if v := query.Get("acks"); v != "" {
+		acks, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid acks %q", v)
+		}
+		opts.Acks = acks
+	}
+
+	if err := opts.Validate(); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// requestAPIKey returns the API key a client presented, which selects its session quota.
+// Browsers cannot set headers on WebSocket handshakes, so the api_key query parameter is
+// accepted as well as the X-API-Key and Authorization: Bearer headers.
+func requestAPIKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return key
+	}
+	return r.URL.Query().Get("api_key")
+}
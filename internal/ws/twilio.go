@@ -0,0 +1,149 @@
+package ws
+
+import (
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	"asr_server/config"
+	"asr_server/internal/audio"
+	"asr_server/internal/logger"
+	"asr_server/internal/session"
+
+	"github.com/gorilla/websocket"
+)
+
+// twilioSampleRate is the sample rate of Twilio Media Streams audio (8 kHz μ-law)
+const twilioSampleRate = 8000
+
+// twilioMessage is the JSON envelope used by Twilio Media Streams
+type twilioMessage struct {
+	Event     string `json:"event"`
+	StreamSid string `json:"streamSid"`
+	Start     *struct {
+		CallSid     string `json:"callSid"`
+		MediaFormat struct {
+			Encoding   string `json:"encoding"`
+			SampleRate int    `json:"sampleRate"`
+			Channels   int    `json:"channels"`
+		} `json:"mediaFormat"`
+	} `json:"start,omitempty"`
+	Media *struct {
+		Track   string `json:"track"`
+		Payload string `json:"payload"`
+	} `json:"media,omitempty"`
+}
+
+// twilioConn logs recognition results for a Twilio stream.
+// Twilio only accepts media/mark/clear messages back, so results are not written to the socket.
+type twilioConn struct {
+	conn      *websocket.Conn
+	streamSid string
+}
+
+// WriteJSON implements session.Conn
+func (t *twilioConn) WriteJSON(v interface{}) error {
+	logger.Info("twilio_result", "session_id", t.streamSid, "message", v)
+	return nil
+}
+
+// Close implements session.Conn
+func (t *twilioConn) Close() error {
+	return t.conn.Close()
+}
+
+// CloseWithReason implements session.ReasonCloser
+func (t *twilioConn) CloseWithReason(reason session.CloseReason) error {
+	code, text := closeCodeFor(reason)
+	return closeWithCode(t.conn, code, text)
+}
+
+// HandleTwilio handles Twilio Media Streams connections.
+// The streamSid from the "start" event is used as the session ID.
+func (h *Handler) HandleTwilio(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Error("websocket_upgrade_failed", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	wsConfig := h.cfg.Server.WebSocket
+	chunkSize := h.cfg.Audio.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = config.DefaultChunkSize
+	}
+
+	var sessionID string
+	var buffer []float32
+	defer func() {
+		if sessionID != "" {
+			h.sessionManager.RemoveSession(sessionID)
+			logger.Info("twilio_stream_closed", "session_id", sessionID)
+		}
+	}()
+
+	for {
+		if wsConfig.ReadTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(time.Duration(wsConfig.ReadTimeout) * time.Second))
+		}
+
+		var msg twilioMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			logger.Warn("websocket_read_error", "session_id", sessionID)
+			return
+		}
+
+		switch msg.Event {
+		case "connected":
+			// Protocol handshake, nothing to do
+		case "start":
+			if sessionID != "" {
+				logger.Warn("twilio_duplicate_start", "session_id", sessionID)
+				continue
+			}
+			if msg.StreamSid == "" {
+				logger.Warn("twilio_missing_stream_sid")
+				return
+			}
+			s, err := h.sessionManager.CreateSession(msg.StreamSid, &twilioConn{conn: conn, streamSid: msg.StreamSid})
+			if err != nil {
+				logger.Error("failed_to_create_session", "session_id", msg.StreamSid, "error", err)
+				closeCreateFailed(conn, err)
+				return
+			}
+			s.SetRemoteAddr(r.RemoteAddr)
+			s.SetAPIKey(requestAPIKey(r))
+			sessionID = msg.StreamSid
+			callSid := ""
+			if msg.Start != nil {
+				callSid = msg.Start.CallSid
+			}
+			logger.Info("twilio_stream_started", "session_id", sessionID, "call_sid", callSid)
+		case "media":
+			if sessionID == "" || msg.Media == nil {
+				continue
+			}
+			payload, err := base64.StdEncoding.DecodeString(msg.Media.Payload)
+			if err != nil {
+				logger.Warn("twilio_invalid_payload", "session_id", sessionID, "error", err)
+				continue
+			}
+			buffer = append(buffer, audio.Resample(audio.DecodeMuLaw(payload), twilioSampleRate, h.cfg.Audio.SampleRate)...)
+			if len(buffer) < chunkSize {
+				continue
+			}
+			if err := h.sessionManager.ProcessAudioData(sessionID, audio.Float32ToPCM16(buffer)); err != nil {
+				logger.Error("failed_to_process_audio", "session_id", sessionID, "error", err)
+			}
+			buffer = buffer[:0]
+		case "stop":
+			if sessionID != "" && len(buffer) > 0 {
+				if err := h.sessionManager.ProcessAudioData(sessionID, audio.Float32ToPCM16(buffer)); err != nil {
+					logger.Error("failed_to_process_audio", "session_id", sessionID, "error", err)
+				}
+			}
+			return
+		}
+	}
+}
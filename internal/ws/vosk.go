@@ -0,0 +1,193 @@
+package ws
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"asr_server/internal/logger"
+	"asr_server/internal/session"
+
+	"github.com/gorilla/websocket"
+)
+
+// voskRequest is a text message in the vosk-server protocol
+type voskRequest struct {
+	Config *struct {
+		SampleRate float64 `json:"sample_rate"`
+	} `json:"config,omitempty"`
+	EOF int `json:"eof"`
+}
+
+// voskConn collects final results for the vosk handler, which replies to every client message itself.
+// Results are buffered rather than written directly because vosk clients expect exactly one reply per request.
+type voskConn struct {
+	conn *websocket.Conn
+
+	mu      sync.Mutex
+	results []string
+	partial string
+	flushed chan struct{}
+}
+
+// WriteJSON implements session.Conn
+func (v *voskConn) WriteJSON(msg interface{}) error {
+	m, ok := msg.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	switch m["type"] {
+	case "partial":
+		if text, ok := m["text"].(string); ok {
+			v.mu.Lock()
+			v.partial = text
+			v.mu.Unlock()
+		}
+	case "final":
+		if text, ok := m["text"].(string); ok {
+			v.mu.Lock()
+			v.results = append(v.results, text)
+			v.partial = ""
+			v.mu.Unlock()
+		}
+	case "flushed":
+		select {
+		case v.flushed <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Close implements session.Conn
+func (v *voskConn) Close() error {
+	return v.conn.Close()
+}
+
+// CloseWithReason implements session.ReasonCloser
+func (v *voskConn) CloseWithReason(reason session.CloseReason) error {
+	code, text := closeCodeFor(reason)
+	return closeWithCode(v.conn, code, text)
+}
+
+// currentPartial returns the latest partial hypothesis of a streaming recognizer
+func (v *voskConn) currentPartial() string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.partial
+}
+
+// takeResults returns and clears the buffered final results
+func (v *voskConn) takeResults() string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	text := strings.Join(v.results, " ")
+	v.results = nil
+	return text
+}
+
+// HandleVosk handles connections speaking the vosk-server WebSocket protocol:
+// an optional {"config": {...}} message, binary PCM chunks each answered with
+// {"partial": ...} or {"text": ...}, and {"eof": 1} answered with the final result.
+func (h *Handler) HandleVosk(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Error("websocket_upgrade_failed", "error", err)
+		return
+	}
+
+	wsConfig := h.cfg.Server.WebSocket
+	sessionID := GenerateSessionID()
+	vc := &voskConn{conn: conn, flushed: make(chan struct{}, 1)}
+
+	s, err := h.sessionManager.CreateSession(sessionID, vc)
+	if err != nil {
+		logger.Error("failed_to_create_session", "session_id", sessionID, "error", err)
+		closeCreateFailed(conn, err)
+		return
+	}
+	s.SetRemoteAddr(r.RemoteAddr)
+	s.SetAPIKey(requestAPIKey(r))
+	defer func() {
+		h.sessionManager.RemoveSession(sessionID)
+		logger.Info("vosk_connection_closed", "session_id", sessionID)
+	}()
+
+	logger.Info("vosk_connection_established", "session_id", sessionID)
+
+	for {
+		if wsConfig.ReadTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(time.Duration(wsConfig.ReadTimeout) * time.Second))
+		}
+
+		messageType, message, err := conn.ReadMessage()
+		if err != nil {
+			logger.Warn("websocket_read_error", "session_id", sessionID)
+			return
+		}
+		if wsConfig.MaxMessageSize > 0 && len(message) > wsConfig.MaxMessageSize {
+			logger.Warn("websocket_message_too_large", "session_id", sessionID, "size", len(message))
+			return
+		}
+
+		if messageType == websocket.TextMessage {
+			var req voskRequest
+			if err := json.Unmarshal(message, &req); err != nil {
+				logger.Warn("vosk_invalid_message", "session_id", sessionID, "error", err)
+				return
+			}
+			if req.Config != nil {
+				if req.Config.SampleRate > 0 {
+					if err := h.sessionManager.Configure(sessionID, session.Options{SampleRate: int(req.Config.SampleRate)}); err != nil {
+						logger.Warn("vosk_invalid_config", "session_id", sessionID, "error", err)
+						return
+					}
+				}
+				continue
+			}
+			if req.EOF != 0 {
+				h.finishVosk(sessionID, vc)
+				return
+			}
+			continue
+		}
+
+		if len(message) == 0 {
+			continue
+		}
+		h.sessionManager.ThrottleAudio(sessionID, len(message))
+		if err := h.sessionManager.ProcessAudioData(sessionID, message); err != nil {
+			logger.Error("failed_to_process_audio", "session_id", sessionID, "error", err)
+		}
+
+		var reply interface{} = map[string]string{"partial": vc.currentPartial()}
+		if text := vc.takeResults(); text != "" {
+			reply = map[string]string{"text": text}
+		}
+		if err := conn.WriteJSON(reply); err != nil {
+			logger.Warn("websocket_write_error", "session_id", sessionID, "error", err)
+			return
+		}
+	}
+}
+
+// finishVosk recognizes the remaining audio and sends the final result
+func (h *Handler) finishVosk(sessionID string, vc *voskConn) {
+	if err := h.sessionManager.FlushSession(sessionID); err != nil {
+		logger.Warn("vosk_flush_failed", "session_id", sessionID, "error", err)
+	} else {
+		select {
+		case <-vc.flushed:
+		case <-time.After(time.Duration(h.cfg.Response.Timeout) * time.Second):
+			logger.Warn("vosk_flush_timeout", "session_id", sessionID)
+		}
+	}
+
+	if err := vc.conn.WriteJSON(map[string]string{"text": vc.takeResults()}); err != nil {
+		logger.Warn("websocket_write_error", "session_id", sessionID, "error", err)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"net/http"
+	"slices"
+	"sync"
 	"time"
 
 	"asr_server/config"
@@ -31,42 +33,15 @@ func NewHandler(cfg *config.Config, sessionManager *session.Manager, globalRecog
 		globalRecognizer: globalRecognizer,
 		upgrader: websocket.Upgrader{
 			CheckOrigin:       makeOriginChecker(cfg),
+			Error:             handshakeError,
 			ReadBufferSize:    cfg.Server.WebSocket.ReadBufferSize,
 			WriteBufferSize:   cfg.Server.WebSocket.WriteBufferSize,
 			EnableCompression: cfg.Server.WebSocket.EnableCompression,
+			Subprotocols:      []string{ProtobufSubprotocol, OpusSubprotocol},
 		},
 	}
 }
 
-// makeOriginChecker creates an origin checker based on configuration
-func makeOriginChecker(cfg *config.Config) func(r *http.Request) bool {
-	return func(r *http.Request) bool {
-		wsConfig := cfg.Server.WebSocket
-
-		// If AllowAllOrigins is true, allow all (for development)
-		if wsConfig.AllowAllOrigins {
-			return true
-		}
-
-		origin := r.Header.Get("Origin")
-
-		// If no origin header, allow (same-origin request)
-		if origin == "" {
-			return true
-		}
-
-		// Check against allowed origins
-		for _, allowed := range wsConfig.AllowedOrigins {
-			if origin == allowed {
-				return true
-			}
-		}
-
-		logger.Warn("websocket_origin_rejected", "origin", origin, "allowed_origins", wsConfig.AllowedOrigins)
-		return false
-	}
-}
-
 // GenerateSessionID generates a unique session ID
 func GenerateSessionID() string {
 	bytes := make([]byte, 16)
@@ -76,43 +51,164 @@ func GenerateSessionID() string {
 
 // HandleWebSocket handles WebSocket connections
 func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
+	if h.sessionManager.Draining() {
+		http.Error(w, session.ErrDraining.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	// Per-connection options are rejected before the upgrade so clients get a plain HTTP error
+	opts, err := parseQueryOptions(r.URL.Query())
+	if err != nil {
+		logger.Warn("invalid_websocket_options", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	channels, err := parseChannels(r.URL.Query())
+	if err != nil {
+		logger.Warn("invalid_websocket_options", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// With channel_select the channels are mixed or selected within a single session;
+	// otherwise each channel is transcribed by its own session
+	if opts.ChannelSelect != "" {
+		channels = 1
+	} else {
+		opts.Channels = 0
+	}
+
+	// A client reconnecting with a resume token continues its previous sessions, keeping
+	// their options and channel layout
+	var resumed []*session.Session
+	if token := r.URL.Query().Get("resume_token"); token != "" {
+		if resumed, err = h.sessionManager.ResumeSessions(token); err != nil {
+			logger.Warn("websocket_resume_rejected", "error", err)
+			http.Error(w, err.Error(), http.StatusGone)
+			return
+		}
+		channels = len(resumed)
+	}
+
+	// Interleaved channels are split as 16-bit samples, which compressed frames cannot be
+	if resumed == nil && channels > 1 && opts.Encoding != "" && opts.Encoding != session.EncodingPCM16 && opts.Encoding != session.EncodingS16LE {
+		http.Error(w, "channels > 1 requires pcm16 encoding", http.StatusBadRequest)
+		return
+	}
+
 	conn, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		logger.Error("websocket_upgrade_failed", "error", err)
 		return
 	}
 
+	// The opus subprotocol selects Opus audio frames with JSON text messages
+	if conn.Subprotocol() == OpusSubprotocol {
+		if channels > 1 {
+			logger.Warn("invalid_websocket_options", "error", "opus subprotocol with multiple channels")
+			closeWithCode(conn, websocket.ClosePolicyViolation, "channels > 1 requires pcm16 encoding")
+			return
+		}
+		if resumed == nil {
+			opts.Encoding = session.EncodingOpus
+		}
+	}
+
 	wsConfig := h.cfg.Server.WebSocket
 
-	if wsConfig.ReadTimeout > 0 {
-		conn.SetReadDeadline(time.Now().Add(time.Duration(wsConfig.ReadTimeout) * time.Second))
-	}
+	h.extendDeadline(conn)
 
 	sessionID := GenerateSessionID()
 
-	// Create session
-	sess, err := h.sessionManager.CreateSession(sessionID, conn)
-	if err != nil {
-		logger.Error("failed_to_create_session", "session_id", sessionID, "error", err)
-		conn.Close()
-		return
+	// Clients that negotiated the protobuf subprotocol exchange binary frames instead of JSON
+	useProtobuf := conn.Subprotocol() == ProtobufSubprotocol
+	var sessionConn session.Conn = &wsConn{Conn: conn}
+	if useProtobuf {
+		sessionConn = &protobufConn{Conn: conn}
+	}
+
+	// One session per channel; with a single channel the session uses the connection as is
+	sessionIDs := make([]string, channels)
+	chConns := make([]session.Conn, channels)
+	var writeMu sync.Mutex
+	for ch := range chConns {
+		chConns[ch] = sessionConn
+		if channels > 1 {
+			chConns[ch] = &channelConn{Conn: sessionConn, mu: &writeMu, channel: ch}
+		}
+	}
+
+	var sess *session.Session
+	var resumeToken string
+	if resumed != nil {
+		sessionID = resumed[0].ID
+		sess = resumed[0]
+		for ch, s := range resumed {
+			sessionIDs[ch] = s.ID
+		}
+		resumeToken = r.URL.Query().Get("resume_token")
+	} else {
+		for ch := range sessionIDs {
+			sessionIDs[ch] = channelSessionID(sessionID, ch)
+			s, err := h.sessionManager.CreateSession(sessionIDs[ch], chConns[ch])
+			if err != nil {
+				logger.Error("failed_to_create_session", "session_id", sessionIDs[ch], "error", err)
+				for _, id := range sessionIDs[:ch] {
+					h.sessionManager.RemoveSession(id)
+				}
+				closeCreateFailed(conn, err)
+				return
+			}
+			s.SetRemoteAddr(r.RemoteAddr)
+			s.SetAPIKey(requestAPIKey(r))
+			if ch == 0 {
+				sess = s
+			}
+
+			if err := h.sessionManager.Configure(sessionIDs[ch], opts); err != nil {
+				logger.Warn("failed_to_apply_websocket_options", "session_id", sessionIDs[ch], "error", err)
+				h.queueError(s, sessionIDs[ch], err)
+			}
+		}
+		resumeToken = h.sessionManager.EnableResume(sessionIDs)
 	}
 
+	// Resumable sessions are detached rather than removed when the connection drops
 	defer func() {
-		h.sessionManager.RemoveSession(sessionID)
+		for ch, id := range sessionIDs {
+			h.sessionManager.ReleaseSession(id, chConns[ch])
+		}
 		logger.Info("websocket_connection_closed", "session_id", sessionID)
 	}()
 
-	logger.Info("websocket_connection_established", "session_id", sessionID)
+	logger.Info("websocket_connection_established", "session_id", sessionID, "protobuf", useProtobuf, "encoding", opts.Encoding, "channels", channels, "resumed", resumed != nil)
+
+	stopKeepalive := h.startKeepalive(conn, sessionIDs)
+	defer stopKeepalive()
 
 	// Send connection confirmation
-	if sess != nil {
+	confirmation := map[string]interface{}{
+		"type":       "connection",
+		"message":    "WebSocket connected, ready for audio",
+		"session_id": sessionID,
+		"channels":   channels,
+	}
+	if resumeToken != "" {
+		confirmation["resume_token"] = resumeToken
+		confirmation["resume_grace_period"] = h.cfg.Session.ResumeGracePeriod
+	}
+	if resumed != nil {
+		// Written before attaching so it precedes the results buffered while disconnected
+		confirmation["message"] = "WebSocket reconnected, session resumed"
+		confirmation["resumed"] = true
+		if err := chConns[0].WriteJSON(confirmation); err != nil {
+			logger.Warn("failed_to_send_message", "session_id", sessionID, "error", err)
+		}
+		for ch, s := range resumed {
+			s.Attach(chConns[ch])
+		}
+	} else {
 		select {
-		case sess.SendQueue <- map[string]interface{}{
-			"type":       "connection",
-			"message":    "WebSocket connected, ready for audio",
-			"session_id": sessionID,
-		}:
+		case sess.SendQueue <- confirmation:
 		default:
 			logger.Warn("session_send_queue_full", "session_id", sessionID, "action", "dropped_confirmation")
 		}
@@ -120,42 +216,111 @@ func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 
 	// Process messages
 	for {
-		_, message, err := conn.ReadMessage()
+		if h.cfg.Session.BackpressurePauseReading {
+			h.waitForCapacity(conn, sessionIDs)
+		}
+
+		messageType, message, err := conn.ReadMessage()
 		if err != nil {
-			logger.Warn("websocket_read_error", "session_id", sessionID)
+			if isTimeout(err) {
+				logger.Warn("websocket_read_timeout", "session_id", sessionID, "window", h.readWindow().String())
+				closeWithCode(conn, CloseIdleTimeout, "idle timeout")
+			} else {
+				logger.Warn("websocket_read_error", "session_id", sessionID)
+			}
 			break
 		}
 
 		// Refresh read timeout on each message
-		if wsConfig.ReadTimeout > 0 {
-			conn.SetReadDeadline(time.Now().Add(time.Duration(wsConfig.ReadTimeout) * time.Second))
-		}
+		h.extendDeadline(conn)
 
 		// Check message size
 		if wsConfig.MaxMessageSize > 0 && len(message) > wsConfig.MaxMessageSize {
 			logger.Warn("websocket_message_too_large", "session_id", sessionID, "size", len(message))
+			closeWithCode(conn, websocket.ClosePolicyViolation, "message too large")
 			break
 		}
 
-		// Process audio data
-		if len(message) > 0 {
-			if err := h.sessionManager.ProcessAudioData(sessionID, message); err != nil {
-				logger.Error("failed_to_process_audio", "session_id", sessionID, "error", err)
-				if sess != nil {
-					select {
-					case sess.SendQueue <- map[string]interface{}{
-						"type":    "error",
-						"message": err.Error(),
-					}:
-					default:
-						logger.Warn("session_send_queue_full", "session_id", sessionID, "action", "dropped_error_message")
-					}
+		// Text frames carry JSON control commands, binary frames carry audio
+		// Control commands apply to every channel
+		if messageType == websocket.TextMessage {
+			for _, id := range sessionIDs {
+				if err := h.sessionManager.HandleControl(id, message); err != nil {
+					logger.Warn("control_message_failed", "session_id", id, "error", err)
+					h.queueError(sess, sessionID, err)
 				}
 			}
+			continue
+		}
+
+		if useProtobuf {
+			if message, err = decodeAudioFrame(message); err != nil {
+				logger.Warn("invalid_protobuf_frame", "session_id", sessionID, "error", err)
+				continue
+			}
+		}
+
+		if len(message) == 0 {
+			continue
+		}
+
+		// Hold off reading while the connection exceeds its audio rate; the wait may outlast
+		// the read window
+		if h.sessionManager.ThrottleAudio(sessionID, len(message)) {
+			h.extendDeadline(conn)
+		}
+
+		// Process audio data, deinterleaving multi-channel PCM into each channel's session
+		chunks := [][]byte{message}
+		if channels > 1 {
+			chunks = splitChannels(message, channels)
+		}
+		for ch, chunk := range chunks {
+			if err := h.sessionManager.ProcessAudioData(sessionIDs[ch], chunk); err != nil {
+				logger.Error("failed_to_process_audio", "session_id", sessionIDs[ch], "error", err)
+				h.queueError(sess, sessionID, err)
+			}
 		}
 	}
 }
 
+// Reading pauses for at most maxBackpressurePause per backpressure episode, so a stalled
+// recognizer cannot hold a connection open indefinitely
+const (
+	maxBackpressurePause     = 10 * time.Second
+	backpressurePollInterval = 50 * time.Millisecond
+)
+
+// waitForCapacity stops reading from the client while any of its sessions is under
+// backpressure, so TCP flow control slows the sender down
+func (h *Handler) waitForCapacity(conn *websocket.Conn, sessionIDs []string) {
+	deadline := time.Now().Add(maxBackpressurePause)
+	paused := false
+	for time.Now().Before(deadline) && slices.ContainsFunc(sessionIDs, h.sessionManager.Backpressured) {
+		paused = true
+		time.Sleep(backpressurePollInterval)
+	}
+	if paused {
+		// Pongs are not processed while reading is paused
+		h.extendDeadline(conn)
+	}
+}
+
+// queueError sends an error message to the client
+func (h *Handler) queueError(sess *session.Session, sessionID string, err error) {
+	if sess == nil {
+		return
+	}
+	select {
+	case sess.SendQueue <- map[string]interface{}{
+		"type":    "error",
+		"message": err.Error(),
+	}:
+	default:
+		logger.Warn("session_send_queue_full", "session_id", sessionID, "action", "dropped_error_message")
+	}
+}
+
 // ServeHTTP implements http.Handler interface
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.HandleWebSocket(w, r)
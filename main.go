@@ -2,25 +2,46 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"asr_server/config"
 	"asr_server/internal/bootstrap"
 	"asr_server/internal/logger"
+	"asr_server/internal/redact"
 	"asr_server/internal/router"
 )
 
 func main() {
+	validate := flag.Bool("validate", false, "validate the configuration and model files, then exit without starting the server")
+	schema := flag.Bool("schema", false, "print the JSON Schema of the configuration file and exit")
+	flag.Parse()
+	// "asr_server validate" and "asr_server schema" are the same as the flags
+	switch flag.Arg(0) {
+	case "validate":
+		*validate = true
+	case "schema":
+		*schema = true
+	}
+	if *schema {
+		os.Exit(printSchema())
+	}
+
 	// Load configuration - returns immutable config instance
 	// Support CONFIG_FILE environment variable for flexible config loading
 	configFile := os.Getenv("CONFIG_FILE")
 	if configFile == "" {
-		configFile = "config.json"
+		configFile = config.DefaultConfigPath()
 	}
 
 	cfg, err := config.Load(configFile)
@@ -29,9 +50,17 @@ func main() {
 		fmt.Fprintf(os.Stderr, "[ERROR] Failed to load configuration: %v\n", err)
 		os.Exit(1)
 	}
+	// Name the etcd or Consul key instead when CONFIG_SOURCE selects one
+	if remote, _ := config.RemoteSourceFromEnv(); remote != nil {
+		configFile = remote.String()
+	}
+	if *validate {
+		os.Exit(validateFiles(cfg, configFile))
+	}
 
 	// Initialize logger
 	lcfg := cfg.Logging
+	redact.Configure(lcfg.Redaction.Rules())
 	logger.InitFromConfig(
 		lcfg.Level,
 		lcfg.Format,
@@ -41,6 +70,14 @@ func main() {
 		lcfg.MaxBackups,
 		lcfg.MaxAge,
 		lcfg.Compress,
+		logger.OTLPOptions{
+			Endpoint:           lcfg.OTLP.Endpoint,
+			Headers:            lcfg.OTLP.Headers,
+			ResourceAttributes: lcfg.OTLP.ResourceAttributes,
+			BatchSize:          lcfg.OTLP.BatchSize,
+			FlushInterval:      time.Duration(lcfg.OTLP.FlushInterval) * time.Second,
+			MaxRetries:         lcfg.OTLP.MaxRetries,
+		},
 	)
 	logger.Info("configuration_loaded", "config", cfg.ToSafeMap())
 
@@ -55,16 +92,42 @@ func main() {
 	r := router.NewRouter(deps)
 
 	// Create HTTP server
+	// WriteTimeout is left unset so WebSocket and SSE streams are not cut off
 	server := &http.Server{
-		Addr:        cfg.Addr(),
-		Handler:     deps.RateLimiter.Middleware(r),
-		ReadTimeout: time.Duration(cfg.Server.ReadTimeout) * time.Second,
+		Addr:              cfg.Addr(),
+		Handler:           deps.RateLimiter.Middleware(r),
+		ReadTimeout:       time.Duration(cfg.Server.ReadTimeout) * time.Second,
+		ReadHeaderTimeout: time.Duration(cfg.Server.ReadHeaderTimeout) * time.Second,
+		IdleTimeout:       time.Duration(cfg.Server.IdleTimeout) * time.Second,
+	}
+	configureHTTP2(server, &cfg.Server.HTTP2)
+	if cfg.Server.TLS.Enabled() {
+		server.TLSConfig, err = newTLSConfig(&cfg.Server.TLS)
+		if err != nil {
+			logger.Error("failed_to_configure_tls", "error", err)
+			os.Exit(1)
+		}
 	}
 
-	// Graceful shutdown
+	// Optional listener redirecting plaintext HTTP to HTTPS
+	var redirectServer *http.Server
+	if port := cfg.Server.TLS.RedirectHTTPPort; port != 0 {
+		redirectServer = newRedirectServer(cfg, port)
+		go func() {
+			if err := redirectServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("https_redirect_server_error", "error", err)
+			}
+		}()
+		logger.Info("https_redirect_started", "addr", redirectServer.Addr)
+	}
+
+	// Graceful shutdown. Serving returns as soon as server.Shutdown is called, so main
+	// waits on done for the drain and cleanup below to finish before exiting.
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	done := make(chan struct{})
 	go func() {
+		defer close(done)
 		<-quit
 		logger.Info("shutting_down_server")
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -72,23 +135,183 @@ func main() {
 		if err := server.Shutdown(ctx); err != nil {
 			logger.Error("server_forced_to_shutdown", "error", err)
 		}
+		if redirectServer != nil {
+			redirectServer.Shutdown(ctx)
+		}
+
+		// WebSocket connections are hijacked and not tracked by server.Shutdown; let their
+		// sessions finish in-flight recognition before connections are closed
+		deps.SessionManager.Drain(time.Duration(cfg.Session.DrainTimeout) * time.Second)
 
+		if deps.SpeakerGRPCServer != nil {
+			deps.SpeakerGRPCServer.Stop()
+		}
+		if deps.TelephonyServer != nil {
+			deps.TelephonyServer.Stop()
+		}
+		if deps.TCPServer != nil {
+			deps.TCPServer.Stop()
+		}
+		if deps.MQTTBridge != nil {
+			deps.MQTTBridge.Stop()
+		}
+		deps.SessionManager.Shutdown()
+		if deps.JobsManager != nil {
+			deps.JobsManager.Shutdown()
+		}
+		if deps.KafkaPublisher != nil {
+			if err := deps.KafkaPublisher.Close(); err != nil {
+				logger.Error("kafka_publisher_close_failed", "error", err)
+			}
+		}
+		if deps.NATSPublisher != nil {
+			deps.NATSPublisher.Close()
+		}
+		if deps.StatsD != nil {
+			deps.StatsD.Close()
+		}
+		if deps.SpeakerDetections != nil {
+			if err := deps.SpeakerDetections.Close(); err != nil {
+				logger.Error("speaker_detections_close_failed", "error", err)
+			}
+		}
+		if deps.SpeakerAudit != nil {
+			if err := deps.SpeakerAudit.Close(); err != nil {
+				logger.Error("speaker_audit_close_failed", "error", err)
+			}
+		}
+		if deps.TextRules != nil {
+			deps.TextRules.Stop()
+		}
+
+		logger.Info("server_shutdown_complete")
 		// Ensure logs are flushed
 		if err := logger.Close(); err != nil {
 			fmt.Fprintf(os.Stderr, "Error closing logger: %v\n", err)
 		}
-		logger.Info("server_shutdown_complete")
 	}()
 
 	// Log startup information
+	wsScheme, httpScheme := "ws", "http"
+	if cfg.Server.TLS.Enabled() {
+		wsScheme, httpScheme = "wss", "https"
+	}
 	logger.Info("server_started",
 		"addr", cfg.Addr(),
-		"websocket", fmt.Sprintf("ws://%s/ws", cfg.Addr()),
-		"health", fmt.Sprintf("http://%s/health", cfg.Addr()),
+		"websocket", fmt.Sprintf("%s://%s/ws", wsScheme, cfg.Addr()),
+		"health", fmt.Sprintf("%s://%s/health", httpScheme, cfg.Addr()),
+		"tls", cfg.Server.TLS.Enabled(),
+		"mtls", cfg.Server.TLS.ClientCAFile != "",
+		"http2", cfg.Server.HTTP2.Enabled,
+		"h2c", cfg.Server.HTTP2.H2C,
 	)
 
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	if cfg.Server.TLS.Enabled() {
+		// Certificates are loaded into server.TLSConfig by newTLSConfig
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
 		logger.Error("server_error", "error", err)
 		os.Exit(1)
 	}
+	<-done
+}
+
+// configureHTTP2 sets the protocols served by server. HTTP/2 is negotiated via ALPN over TLS;
+// h2c additionally accepts prior-knowledge HTTP/2 over plaintext, e.g. behind a load balancer.
+// HTTP/1.1 always stays enabled because WebSocket upgrades require it.
+func configureHTTP2(server *http.Server, cfg *config.HTTP2Config) {
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(cfg.Enabled)
+	protocols.SetUnencryptedHTTP2(cfg.Enabled && cfg.H2C)
+	server.Protocols = &protocols
+
+	if cfg.Enabled {
+		server.HTTP2 = &http.HTTP2Config{
+			MaxConcurrentStreams: cfg.MaxConcurrentStreams,
+		}
+	}
+}
+
+// newTLSConfig loads the server certificate and, when client_ca_file is set, the CAs that
+// client certificates are verified against (mTLS).
+func newTLSConfig(cfg *config.TLSConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %v", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if cfg.MinVersion == "1.3" {
+		tlsConfig.MinVersion = tls.VersionTLS13
+	}
+
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in client CA file %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		if cfg.RequireClientCert {
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return tlsConfig, nil
+}
+
+// newRedirectServer creates a plaintext server on port that permanently redirects every
+// request to the same host and path on the HTTPS port.
+func newRedirectServer(cfg *config.Config, port int) *http.Server {
+	httpsPort := strconv.Itoa(cfg.Server.Port)
+	return &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", cfg.Server.Host, port),
+		ReadHeaderTimeout: time.Duration(cfg.Server.ReadHeaderTimeout) * time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host, _, err := net.SplitHostPort(r.Host)
+			if err != nil {
+				host = r.Host // No port in the Host header
+			}
+			if httpsPort != "443" {
+				host = net.JoinHostPort(host, httpsPort)
+			}
+			http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+		}),
+	}
+}
+
+// validateFiles reports files the configuration refers to that are missing or unreadable
+// and returns the process exit code, 1 if there are any
+func validateFiles(cfg *config.Config, configFile string) int {
+	errs := cfg.CheckFiles()
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "[ERROR] %v\n", err)
+	}
+	if len(errs) > 0 {
+		fmt.Fprintf(os.Stderr, "[ERROR] Configuration %s is invalid: %d file problem(s)\n", configFile, len(errs))
+		return 1
+	}
+	fmt.Printf("[INFO] Configuration %s is valid\n", configFile)
+	return 0
+}
+
+// printSchema writes the JSON Schema of the configuration file to stdout and returns the
+// process exit code
+func printSchema() int {
+	out, err := json.MarshalIndent(config.Schema(), "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[ERROR] Failed to generate schema: %v\n", err)
+		return 1
+	}
+	fmt.Println(string(out))
+	return 0
 }